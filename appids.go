@@ -0,0 +1,86 @@
+package hive
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// Tez reports the YARN application a query runs in, and each DAG it
+// submits, in the operation log, e.g.
+//
+//	Submitted dag to TezSession, sessionName=HIVE-4b1c..., applicationId=application_1700000000000_0042, dagId=dag_1700000000000_0042_3, dagName=...
+//	Status: Running (Executing on YARN cluster with App id application_1700000000000_0042)
+var (
+	tezAppIDPattern = regexp.MustCompile(`(?:App id |applicationId=)(application_\d+_\d+)`)
+	tezDAGIDPattern = regexp.MustCompile(`\b(dag_\d+_\d+_\d+)\b`)
+)
+
+// ApplicationIDs returns the YARN application and Tez DAG IDs found in
+// the operation log, in the order they first appear; a query may run
+// several DAGs in one application. It returns none for queries that
+// didn't run on Tez, such as MapReduce jobs or fetch-only queries.
+//
+// The IDs are only logged when operation logging is enabled on the
+// server, with hive.server2.logging.operation.enabled, and the log
+// level includes Tez's progress lines, as the default EXECUTION level
+// does. RowSets from QueryWithLogs are searched in the lines collected
+// so far and whatever the server has added since; others read the log
+// from the start.
+func (r *rowSet) ApplicationIDs(ctx context.Context) ([]string, error) {
+	if err := r.waitStarted(ctx); err != nil {
+		return nil, err
+	}
+	var lines []string
+	if r.collectLogs {
+		for r.collectLogs {
+			if r.fetchLogs() == 0 {
+				break
+			}
+		}
+		lines = r.logs
+	} else {
+		var err error
+		if lines, err = r.readLog(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	var ids []string
+	seen := map[string]bool{}
+	for _, line := range lines {
+		for _, pattern := range []*regexp.Regexp{tezAppIDPattern, tezDAGIDPattern} {
+			for _, m := range pattern.FindAllStringSubmatch(line, -1) {
+				if !seen[m[1]] {
+					seen[m[1]] = true
+					ids = append(ids, m[1])
+				}
+			}
+		}
+	}
+	return ids, nil
+}
+
+// readLog reads the whole operation log, rewinding it first.
+func (r *rowSet) readLog(ctx context.Context) ([]string, error) {
+	var lines []string
+	orientation := inf.TFetchOrientation_FETCH_FIRST
+	for {
+		fetchReq := r.fetchRequest(orientation, r.batchSize(), FetchLogs)
+		resp, err := r.thrift.FetchResults(ctx, fetchReq)
+		if err != nil {
+			return nil, fmt.Errorf("Error fetching operation log: %v", err)
+		}
+		if !isSuccessStatus(resp.Status) {
+			return nil, operationStatusError("Fetching operation log failed", resp.Status)
+		}
+		batch := logLines(resp.GetResults())
+		if len(batch) == 0 {
+			return lines, nil
+		}
+		lines = append(lines, batch...)
+		orientation = inf.TFetchOrientation_FETCH_NEXT
+	}
+}
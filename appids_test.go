@@ -0,0 +1,73 @@
+package hive
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestApplicationIDs(t *testing.T) {
+	tezLog := []string{
+		"INFO  : Compiling command(queryId=hive_20240101_1): SELECT count(*) FROM t",
+		"INFO  : Submitted dag to TezSession, sessionName=HIVE-4b1c, applicationId=application_1700000000000_0042, dagId=dag_1700000000000_0042_3, dagName=SELECT count(*) FROM t (Stage-1)",
+		"INFO  : Status: Running (Executing on YARN cluster with App id application_1700000000000_0042)",
+		"INFO  : Map 1: 0/1	Reducer 2: 0/1",
+	}
+	mrLog := []string{
+		"INFO  : Starting Job = job_1700000000000_0043, Tracking URL = http://rm:8088/proxy/application_1700000000000_0043/",
+	}
+	want := []string{"application_1700000000000_0042", "dag_1700000000000_0042_3"}
+	ctx := context.Background()
+
+	cases := []struct {
+		name string
+		logs []string
+		want []string
+	}{
+		{"tez", tezLog, want},
+		{"mapreduce", mrLog, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := newFakeService()
+			svc.logs = tc.logs
+			conn := connectFake(t, svc, testOptions())
+
+			rs, err := conn.Query("SELECT count(*) FROM t")
+			if err != nil {
+				t.Fatalf("Query error: %v", err)
+			}
+			ids, err := rs.ApplicationIDs(ctx)
+			if err != nil {
+				t.Fatalf("ApplicationIDs error: %v", err)
+			}
+			if !reflect.DeepEqual(ids, tc.want) {
+				t.Errorf("expected %v, got %v", tc.want, ids)
+			}
+		})
+	}
+
+	t.Run("collected logs", func(t *testing.T) {
+		svc := newFakeService()
+		svc.logs = tezLog
+		conn := connectFake(t, svc, testOptions())
+
+		rs, err := conn.QueryWithLogs(ctx, "SELECT count(*) FROM t")
+		if err != nil {
+			t.Fatalf("QueryWithLogs error: %v", err)
+		}
+		if _, err := rs.Wait(); err != nil {
+			t.Fatalf("Wait error: %v", err)
+		}
+		ids, err := rs.ApplicationIDs(ctx)
+		if err != nil {
+			t.Fatalf("ApplicationIDs error: %v", err)
+		}
+		if !reflect.DeepEqual(ids, want) {
+			t.Errorf("expected %v, got %v", want, ids)
+		}
+		if len(rs.Logs()) != len(tezLog) {
+			t.Errorf("expected the collected log to be kept, got %v", rs.Logs())
+		}
+	})
+}
@@ -0,0 +1,58 @@
+package hive
+
+import (
+	"context"
+	"strings"
+)
+
+// audit calls Options.AuditHook for a statement the server accepted.
+func (c *Connection) audit(ctx context.Context, stmt, operationID string) {
+	if c.options.AuditHook == nil {
+		return
+	}
+	if c.options.AuditRedactLiterals {
+		stmt = RedactLiterals(stmt)
+	}
+	c.options.AuditHook(ctx, stmt, operationID)
+}
+
+// RedactLiterals replaces the string and numeric literals in sql with
+// "?", e.g. for logging statements whose arguments were interpolated
+// by ExecBatch. Comments, identifiers and keywords are kept.
+func RedactLiterals(sql string) string {
+	var b strings.Builder
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"':
+			end, _ := quotedEnd(sql, i)
+			b.WriteByte('?')
+			i = end
+		case c == '`':
+			end, _ := quotedEnd(sql, i)
+			b.WriteString(sql[i:end])
+			i = end
+		case c == '-' && strings.HasPrefix(sql[i:], "--"), c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			end := len(sql) - len(skipSpaceAndComments(sql[i:]))
+			b.WriteString(sql[i:end])
+			i = end
+		case !isWordBoundary(sql, i):
+			word, _ := leadingWord(sql[i:])
+			if c >= '0' && c <= '9' {
+				// Take in a decimal point and fraction, as in 1.5.
+				if end := i + len(word); end+1 < len(sql) && sql[end] == '.' && !isWordBoundary(sql, end+1) {
+					next, _ := leadingWord(sql[end+1:])
+					word = sql[i : end+1+len(next)]
+				}
+				b.WriteByte('?')
+			} else {
+				b.WriteString(word)
+			}
+			i += len(word)
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,78 @@
+package hive
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestAuditHook(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	options := testOptions()
+	var (
+		audited []string
+		ids     = map[string]bool{}
+	)
+	options.AuditHook = func(ctx context.Context, sql, operationID string) {
+		audited = append(audited, sql)
+		ids[operationID] = true
+	}
+	conn := connectFake(t, svc, options)
+	audited, sent := nil, len(svc.executed())
+
+	if _, err := conn.Exec("DROP TABLE t"); err != nil {
+		t.Fatalf("Exec error: %v", err)
+	}
+	rs, err := conn.Query("SELECT 1")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	rs.Close(ctx)
+	if _, err := conn.ExecBatch(ctx, "INSERT INTO t VALUES (?, ?)", [][]interface{}{{1, "a"}}); err != nil {
+		t.Fatalf("ExecBatch error: %v", err)
+	}
+	if err := conn.ExecScript(ctx, "DROP TABLE a; DROP TABLE b"); err != nil {
+		t.Fatalf("ExecScript error: %v", err)
+	}
+
+	want := svc.executed()[sent:]
+	if len(want) != 5 || !reflect.DeepEqual(audited, want) {
+		t.Errorf("expected the statements sent, %q, got %q", want, audited)
+	}
+	if len(ids) < len(audited) || ids[""] {
+		t.Errorf("expected a distinct operation ID per statement, got %v", ids)
+	}
+}
+
+func TestAuditRedactLiterals(t *testing.T) {
+	svc := newFakeService()
+	options := testOptions()
+	options.AuditRedactLiterals = true
+	var audited string
+	options.AuditHook = func(ctx context.Context, sql, operationID string) { audited = sql }
+	conn := connectFake(t, svc, options)
+
+	if _, err := conn.Exec("INSERT INTO t VALUES (1, 'secret')"); err != nil {
+		t.Fatalf("Exec error: %v", err)
+	}
+	if want := "INSERT INTO t VALUES (?, ?)"; audited != want {
+		t.Errorf("expected %q, got %q", want, audited)
+	}
+	if got := svc.executed(); got[len(got)-1] != "INSERT INTO t VALUES (1, 'secret')" {
+		t.Errorf("expected the statement sent unredacted, got %q", got[len(got)-1])
+	}
+}
+
+func TestRedactLiterals(t *testing.T) {
+	for _, tc := range []struct{ in, want string }{
+		{"SELECT * FROM t WHERE id = 42 AND name = 'it\\'s'", "SELECT * FROM t WHERE id = ? AND name = ?"},
+		{`SELECT col1, "x\"y", -1.5 FROM t2`, "SELECT col1, ?, -? FROM t2"},
+		{"SELECT `a 'b'` FROM t -- 'note' 3\nLIMIT 10", "SELECT `a 'b'` FROM t -- 'note' 3\nLIMIT ?"},
+		{"SELECT a /* 'x' */ FROM db.t", "SELECT a /* 'x' */ FROM db.t"},
+	} {
+		if got := RedactLiterals(tc.in); got != tc.want {
+			t.Errorf("RedactLiterals(%q): expected %q, got %q", tc.in, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/apache/thrift/lib/go/thrift"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// ExecBatch executes an INSERT ... VALUES statement with one "?"
+// placeholder per value, once for every parameter set in rows. The sets
+// are expanded client-side into multi-row INSERT ... VALUES (...),(...)
+// statements, each kept under the connection's message size limit.
+//
+// Hive doesn't report affected rows for plain INSERTs, so the returned
+// count is the number of rows sent in successfully executed statements.
+func (c *Connection) ExecBatch(ctx context.Context, query string, rows [][]interface{}) (int64, error) {
+	var n int64
+	if queued, err := c.inStatementScope(ctx, func(ctx context.Context) error {
+		var err error
+		n, err = c.ExecBatch(ctx, query, rows)
+		return err
+	}); queued {
+		return n, err
+	}
+
+	prefix, tuple, err := splitValues(query)
+	if err != nil {
+		return 0, err
+	}
+	if err := c.ensureSession(ctx); err != nil {
+		return 0, err
+	}
+
+	limit := statementByteLimit(c.options)
+
+	var (
+		inserted int64
+		pending  int64
+		stmt     strings.Builder
+	)
+	flush := func() error {
+		if pending == 0 {
+			return nil
+		}
+		executeReq := inf.NewTExecuteStatementReq()
+		executeReq.Statement = stmt.String()
+		if _, err := c.executeStatement(ctx, executeReq); err != nil {
+			return err
+		}
+		inserted += pending
+		pending = 0
+		stmt.Reset()
+		return nil
+	}
+
+	for i, row := range rows {
+		values, err := bindParams(tuple, row)
+		if err != nil {
+			return inserted, fmt.Errorf("Row %d: %v", i, err)
+		}
+
+		if len(prefix)+1+len(values) > limit {
+			return inserted, fmt.Errorf("%w: row %d alone exceeds the %d byte limit", ErrStatementTooLarge, i, limit)
+		}
+		if pending > 0 && stmt.Len()+1+len(values) > limit {
+			if err := flush(); err != nil {
+				return inserted, err
+			}
+		}
+
+		if pending == 0 {
+			stmt.WriteString(prefix)
+			stmt.WriteByte(' ')
+		} else {
+			stmt.WriteByte(',')
+		}
+		stmt.WriteString(values)
+		pending++
+	}
+
+	if err := flush(); err != nil {
+		return inserted, err
+	}
+	return inserted, nil
+}
+
+// splitValues splits an INSERT ... VALUES (...) statement into the part
+// up to and including the VALUES keyword and the row tuple that follows.
+func splitValues(query string) (prefix, tuple string, err error) {
+	idx := -1
+	upper := strings.ToUpper(query)
+	for i := 0; i < len(query); i++ {
+		switch query[i] {
+		case '\'', '"', '`':
+			end, _ := quotedEnd(query, i)
+			i = end - 1
+		case 'v', 'V':
+			if strings.HasPrefix(upper[i:], "VALUES") && isWordBoundary(query, i-1) && isWordBoundary(query, i+6) {
+				idx = i
+			}
+		}
+	}
+
+	if idx < 0 {
+		return "", "", errors.New("ExecBatch requires an INSERT ... VALUES (...) statement")
+	}
+
+	prefix = strings.TrimSpace(query[:idx+len("VALUES")])
+	tuple = strings.TrimSpace(query[idx+len("VALUES"):])
+	if !strings.HasPrefix(tuple, "(") || !strings.HasSuffix(tuple, ")") {
+		return "", "", errors.New("ExecBatch requires a single parenthesised row after VALUES")
+	}
+	return prefix, tuple, nil
+}
+
+func isWordBoundary(s string, i int) bool {
+	if i < 0 || i >= len(s) {
+		return true
+	}
+	c := s[i]
+	return !(c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z')
+}
+
+// ErrStatementTooLarge is returned, before anything is sent, for a
+// statement longer than Options.MaxStatementBytes.
+var ErrStatementTooLarge = errors.New("hive: statement exceeds the maximum statement size")
+
+// statementByteLimit is the largest statement the client will send:
+// Options.MaxStatementBytes if set, otherwise nine tenths of the thrift
+// message size, leaving headroom for the rest of the request.
+func statementByteLimit(options Options) int {
+	if options.MaxStatementBytes > 0 {
+		return options.MaxStatementBytes
+	}
+	size := int(options.MaxMessageSize)
+	if size <= 0 {
+		size = thrift.DEFAULT_MAX_MESSAGE_SIZE
+	}
+	return size / 10 * 9
+}
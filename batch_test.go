@@ -0,0 +1,126 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestMaxStatementBytes(t *testing.T) {
+	svc := newFakeService()
+	options := testOptions()
+	options.MaxStatementBytes = 64
+	conn := connectFake(t, svc, options)
+
+	_, err := conn.Query("SELECT * FROM t WHERE id IN (" + strings.Repeat("1,", 40) + "1)")
+	if !errors.Is(err, ErrStatementTooLarge) {
+		t.Fatalf("expected ErrStatementTooLarge, got %v", err)
+	}
+	if n := len(svc.executed()); n != 0 {
+		t.Errorf("expected nothing sent, got %d statements", n)
+	}
+}
+
+func TestExecBatch(t *testing.T) {
+	svc := newFakeService()
+	conn := connectFake(t, svc, testOptions())
+
+	rows := [][]interface{}{{1, "it's", nil}, {2, `a\b`, true}}
+	n, err := conn.ExecBatch(context.Background(), "INSERT INTO t (id, name, flag) VALUES (?, ?, ?)", rows)
+	if err != nil {
+		t.Fatalf("ExecBatch error: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("expected 2 rows inserted, got %d", n)
+	}
+	want := []string{`INSERT INTO t (id, name, flag) VALUES (1, 'it\'s', NULL),(2, 'a\\b', TRUE)`}
+	if got := svc.executed(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestExecBatchRejectsArguments(t *testing.T) {
+	svc := newFakeService()
+	conn := connectFake(t, svc, testOptions())
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		query string
+		rows  [][]interface{}
+	}{
+		{"UPDATE t SET x = ?", [][]interface{}{{1}}},
+		{"INSERT INTO t SELECT ?", [][]interface{}{{1}}},
+		{"INSERT INTO t VALUES (?, ?)", [][]interface{}{{1}}},
+		{"INSERT INTO t VALUES (?)", [][]interface{}{{1, 2}}},
+		{"INSERT INTO t VALUES (?)", [][]interface{}{{struct{}{}}}},
+	} {
+		if _, err := conn.ExecBatch(ctx, tc.query, tc.rows); err == nil {
+			t.Errorf("ExecBatch(%q, %v): expected an error", tc.query, tc.rows)
+		}
+	}
+	if got := svc.executed(); len(got) != 0 {
+		t.Errorf("expected nothing sent, got %q", got)
+	}
+}
+
+func TestExecBatchCountsSentRows(t *testing.T) {
+	svc := newFakeService()
+	svc.onExecute = func(req *inf.TExecuteStatementReq) (*inf.TExecuteStatementResp, error) {
+		if strings.Contains(req.Statement, "(3)") {
+			return &inf.TExecuteStatementResp{Status: errorStatus("Permission denied")}, nil
+		}
+		svc.mu.Lock()
+		defer svc.mu.Unlock()
+		return &inf.TExecuteStatementResp{Status: okStatus(), OperationHandle: svc.newOperation(req.Statement)}, nil
+	}
+	options := testOptions()
+	options.MaxStatementBytes = len("INSERT INTO t VALUES (1),(2)")
+	conn := connectFake(t, svc, options)
+
+	n, err := conn.ExecBatch(context.Background(), "INSERT INTO t VALUES (?)", [][]interface{}{{1}, {2}, {3}, {4}})
+	if err == nil {
+		t.Fatal("expected the failed statement to be reported")
+	}
+	if n != 2 {
+		t.Errorf("expected the 2 rows of the statement that succeeded, got %d", n)
+	}
+	want := []string{"INSERT INTO t VALUES (1),(2)", "INSERT INTO t VALUES (3),(4)"}
+	if got := svc.executed(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestExecBatchChunksToMaxStatementBytes(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	options := testOptions()
+	options.MaxStatementBytes = 64
+	conn := connectFake(t, svc, options)
+
+	rows := [][]interface{}{{1, "a"}, {2, "b"}, {3, "c"}, {4, "d"}, {5, "e"}}
+	n, err := conn.ExecBatch(ctx, "INSERT INTO t VALUES (?, ?)", rows)
+	if err != nil {
+		t.Fatalf("ExecBatch error: %v", err)
+	}
+	if n != int64(len(rows)) {
+		t.Errorf("expected %d rows inserted, got %d", len(rows), n)
+	}
+	stmts := svc.executed()
+	if len(stmts) < 2 {
+		t.Errorf("expected the rows split over several statements, got %q", stmts)
+	}
+	for _, stmt := range stmts {
+		if len(stmt) > options.MaxStatementBytes {
+			t.Errorf("statement of %d bytes exceeds the limit: %q", len(stmt), stmt)
+		}
+	}
+
+	_, err = conn.ExecBatch(ctx, "INSERT INTO t VALUES (?)", [][]interface{}{{strings.Repeat("x", 100)}})
+	if !errors.Is(err, ErrStatementTooLarge) {
+		t.Errorf("expected ErrStatementTooLarge for an oversized row, got %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package hive
+
+import (
+	"fmt"
+	"log"
+)
+
+// defaultBatchSize is DefaultOptions' BatchSize, used in place of an
+// unset one.
+const defaultBatchSize = 10000
+
+// defaultMinBatchSize is the size of the first adaptive fetch when
+// Options.MinBatchSize is unset.
+const defaultMinBatchSize = 100
+
+// withDefaultBatchSize fills in an unset or negative BatchSize, which
+// would otherwise ask for no rows and so never get any, e.g. when Options
+// were built from scratch rather than from DefaultOptions.
+func withDefaultBatchSize(options Options) Options {
+	if options.BatchSize <= 0 {
+		log.Printf("BatchSize %d is not positive; using %d\n", options.BatchSize, defaultBatchSize)
+		options.BatchSize = defaultBatchSize
+	}
+	return options
+}
+
+// batchSize returns the MaxRows for the next fetch.
+func (r *rowSet) batchSize() int64 {
+	if r.fetchSize > 0 {
+		return r.fetchSize
+	}
+	if r.options.TargetBatchBytes <= 0 {
+		return r.options.BatchSize
+	}
+	if r.nextBatch > 0 {
+		return r.nextBatch
+	}
+	return r.clampBatchSize(r.minBatchSize())
+}
+
+// SetFetchSize sets the MaxRows of the fetches that follow, from the next
+// one on, e.g. to shrink batches of rows found wider than expected. It
+// overrides Options.BatchSize and the adaptive sizing of
+// Options.TargetBatchBytes for the rest of the RowSet.
+func (r *rowSet) SetFetchSize(n int64) error {
+	if n <= 0 {
+		return fmt.Errorf("Invalid fetch size %d: must be positive", n)
+	}
+	r.fetchSize = n
+	return nil
+}
+
+// adaptBatchSize sizes the next fetch from the average row width seen so
+// far, aiming for Options.TargetBatchBytes per batch.
+func (r *rowSet) adaptBatchSize() {
+	if r.options.TargetBatchBytes <= 0 || r.stats.Rows == 0 {
+		return
+	}
+	width := r.stats.Bytes / r.stats.Rows
+	if width < 1 {
+		width = 1
+	}
+	r.nextBatch = r.clampBatchSize(r.options.TargetBatchBytes / width)
+}
+
+func (r *rowSet) minBatchSize() int64 {
+	if r.options.MinBatchSize > 0 {
+		return r.options.MinBatchSize
+	}
+	return defaultMinBatchSize
+}
+
+func (r *rowSet) clampBatchSize(n int64) int64 {
+	max := r.options.MaxBatchSize
+	if max <= 0 {
+		max = r.options.BatchSize
+	}
+	if min := r.minBatchSize(); n < min {
+		n = min
+	}
+	if max > 0 && n > max {
+		n = max
+	}
+	return n
+}
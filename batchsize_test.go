@@ -0,0 +1,128 @@
+package hive
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAdaptiveBatchSize(t *testing.T) {
+	svc := newFakeService()
+	narrow := strings.Repeat("n", 96)
+	wide := strings.Repeat("w", 1996)
+	svc.batches = append(svc.batches, stringBatch(narrow, narrow), stringBatch(wide, wide))
+
+	options := testOptions()
+	options.TargetBatchBytes = 1000
+	options.MinBatchSize = 2
+	options.MaxBatchSize = 50
+	conn := connectFake(t, svc, options)
+
+	rs, err := conn.Query("SELECT s FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	for rs.Next() {
+	}
+	if err := rs.Err(); err != nil {
+		t.Fatalf("Next error: %v", err)
+	}
+
+	// 100 bytes a row fits 10 rows; once the wide rows push the average
+	// past the target, the size bottoms out at MinBatchSize.
+	want := []int64{2, 10, 2}
+	if len(svc.fetches) != len(want) {
+		t.Fatalf("expected %d fetches, got %d", len(want), len(svc.fetches))
+	}
+	for i, req := range svc.fetches {
+		if req.MaxRows != want[i] {
+			t.Errorf("fetch %d: expected MaxRows %d, got %d", i, want[i], req.MaxRows)
+		}
+	}
+}
+
+func TestFixedBatchSize(t *testing.T) {
+	svc := newFakeService()
+	svc.batches = append(svc.batches, stringBatch("a", "b"))
+	options := testOptions()
+	options.BatchSize = 7
+	conn := connectFake(t, svc, options)
+
+	rs, err := conn.Query("SELECT s FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	for rs.Next() {
+	}
+	for i, req := range svc.fetches {
+		if req.MaxRows != 7 {
+			t.Errorf("fetch %d: expected MaxRows 7, got %d", i, req.MaxRows)
+		}
+	}
+}
+
+func TestSetFetchSize(t *testing.T) {
+	svc := newFakeService()
+	svc.batches = append(svc.batches, stringBatch("a", "b"), stringBatch("c"))
+	options := testOptions()
+	options.BatchSize = 7
+	conn := connectFake(t, svc, options)
+
+	rs, err := conn.Query("SELECT s FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	if err := rs.SetFetchSize(0); err == nil {
+		t.Error("expected a zero fetch size to be rejected")
+	}
+	rs.Next()
+	if err := rs.SetFetchSize(3); err != nil {
+		t.Fatalf("SetFetchSize error: %v", err)
+	}
+	for rs.Next() {
+	}
+	if err := rs.Err(); err != nil {
+		t.Fatalf("Next error: %v", err)
+	}
+
+	want := []int64{7, 3, 3}
+	if len(svc.fetches) != len(want) {
+		t.Fatalf("expected %d fetches, got %d", len(want), len(svc.fetches))
+	}
+	for i, req := range svc.fetches {
+		if req.MaxRows != want[i] {
+			t.Errorf("fetch %d: expected MaxRows %d, got %d", i, want[i], req.MaxRows)
+		}
+	}
+}
+
+func TestZeroBatchSize(t *testing.T) {
+	for _, size := range []int64{0, -1} {
+		svc := newFakeService()
+		svc.batches = append(svc.batches, stringBatch("a", "b"))
+
+		options := testOptions()
+		options.BatchSize = size
+		if err := options.Validate(); err != nil {
+			t.Fatalf("BatchSize %d: Validate error: %v", size, err)
+		}
+		conn := connectFake(t, svc, options)
+
+		rs, err := conn.Query("SELECT s FROM t")
+		if err != nil {
+			t.Fatalf("BatchSize %d: Query error: %v", size, err)
+		}
+		var rows int
+		for rs.Next() {
+			rows++
+		}
+		if err := rs.Err(); err != nil {
+			t.Fatalf("BatchSize %d: Next error: %v", size, err)
+		}
+		if rows != 2 {
+			t.Errorf("BatchSize %d: expected 2 rows, got %d", size, rows)
+		}
+		if len(svc.fetches) == 0 || svc.fetches[0].MaxRows != defaultBatchSize {
+			t.Errorf("BatchSize %d: expected fetches of %d rows, got %v", size, defaultBatchSize, svc.fetches)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package hive
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// cboKey switches Hive's cost-based optimizer on; it defaults to true
+// from Hive 0.14.
+const cboKey = "hive.cbo.enable"
+
+// A CBONode is one operator of the cost-based optimizer's plan, such as
+// "HiveTableScan" or "HiveJoin".
+type CBONode struct {
+	Operator string
+	// Attributes are the operator's arguments as printed, e.g.
+	// "condition=[>($0, 10)]".
+	Attributes string
+	// RowCount is the optimizer's estimate of the rows the operator
+	// returns, or -1 if the plan doesn't give one.
+	RowCount float64
+	// Cost is the cumulative cost of the operator and its inputs, or nil
+	// if the plan doesn't give it.
+	Cost     *CBOCost
+	Children []*CBONode
+}
+
+// A CBOCost is a cumulative cost estimate, in Calcite's units.
+type CBOCost struct {
+	Rows, CPU, IO float64
+}
+
+// A CBOResult is the optimizer's plan of a query, see ExplainCBO.
+type CBOResult struct {
+	// Plan is the parsed plan, rooted at its final operator; it is nil
+	// when the server returned none.
+	Plan *CBONode
+	// Text is the output as returned, one entry per row.
+	Text []string
+	// Note says why there is no plan, e.g. that the optimizer is off.
+	Note string
+}
+
+// ExplainCBO returns the plan query gets from the cost-based optimizer,
+// from EXPLAIN CBO COST, with the row and cost estimates of each
+// operator parsed. EXPLAIN CBO needs Hive 4, where servers that reject
+// COST are asked for a plain EXPLAIN CBO, which has no estimates; older
+// servers fail with their parse error. The optimizer must be on, with
+// hive.cbo.enable; when it is off, or gives the query up, e.g. for a
+// construct it doesn't support, the result has a Note instead of a
+// Plan. Estimates need table and column statistics, as gathered by
+// ANALYZE TABLE ... COMPUTE STATISTICS FOR COLUMNS.
+func (c *Connection) ExplainCBO(ctx context.Context, query string) (*CBOResult, error) {
+	query = strings.TrimRight(strings.TrimSpace(query), ";")
+	lines, err := c.explainLines(ctx, "EXPLAIN CBO COST "+query)
+	if err != nil {
+		var plainErr error
+		if lines, plainErr = c.explainLines(ctx, "EXPLAIN CBO "+query); plainErr != nil {
+			return nil, err
+		}
+	}
+
+	result := &CBOResult{Text: lines, Plan: parseCBOPlan(lines)}
+	if result.Plan == nil {
+		if value, defined, err := c.readConf(ctx, cboKey); err == nil && defined && value == "false" {
+			result.Note = "The cost-based optimizer is not enabled (" + cboKey + "=false), so there is no CBO plan"
+		} else {
+			result.Note = "The cost-based optimizer returned no plan for this query"
+		}
+	}
+	return result, nil
+}
+
+// cboLinePattern matches an operator line of a CBO plan, as in
+//
+//	HiveFilter(condition=[>($0, 10)]): rowcount = 166.66, cumulative cost = {500.0 rows, 0.0 cpu, 0.0 io}, id = 12
+//
+// where everything from the colon on appears only with COST.
+var cboLinePattern = regexp.MustCompile(`^(\s*)(\w+)(?:\((.*?)\))?(?::\s*rowcount = ([^,]+), cumulative cost = \{([^}]*)\}(?:, id = \d+)?)?\s*$`)
+
+// cboCostPattern matches a cumulative cost, as in
+// "500.0 rows, 0.0 cpu, 0.0 io".
+var cboCostPattern = regexp.MustCompile(`^(\S+) rows, (\S+) cpu, (\S+) io$`)
+
+// parseCBOPlan parses the operator lines following "CBO PLAN:", which
+// nest by indentation, into a tree.
+func parseCBOPlan(lines []string) *CBONode {
+	var (
+		root   *CBONode
+		parent []*CBONode
+		indent []int
+		inPlan bool
+	)
+	for _, text := range strings.Split(strings.Join(lines, "\n"), "\n") {
+		if !inPlan {
+			inPlan = strings.TrimSpace(text) == "CBO PLAN:"
+			continue
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		m := cboLinePattern.FindStringSubmatch(text)
+		if m == nil {
+			continue
+		}
+		node := &CBONode{Operator: m[2], Attributes: m[3], RowCount: -1}
+		if n, err := strconv.ParseFloat(strings.TrimSpace(m[4]), 64); err == nil {
+			node.RowCount = n
+		}
+		node.Cost = parseCBOCost(m[5])
+
+		depth := len(m[1])
+		for len(indent) > 0 && indent[len(indent)-1] >= depth {
+			parent, indent = parent[:len(parent)-1], indent[:len(indent)-1]
+		}
+		switch {
+		case len(parent) > 0:
+			p := parent[len(parent)-1]
+			p.Children = append(p.Children, node)
+		case root == nil:
+			root = node
+		default:
+			// A second top-level operator: not a plan this parses.
+			return root
+		}
+		parent, indent = append(parent, node), append(indent, depth)
+	}
+	return root
+}
+
+func parseCBOCost(s string) *CBOCost {
+	m := cboCostPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return nil
+	}
+	var cost CBOCost
+	for i, f := range []*float64{&cost.Rows, &cost.CPU, &cost.IO} {
+		n, err := strconv.ParseFloat(m[i+1], 64)
+		if err != nil {
+			return nil
+		}
+		*f = n
+	}
+	return &cost
+}
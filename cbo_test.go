@@ -0,0 +1,79 @@
+package hive
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// explainCBO is EXPLAIN CBO COST output from Hive 4.
+var explainCBO = []string{
+	"CBO PLAN:",
+	"HiveProject(id=[$0], name=[$2]): rowcount = 166.66666666666666, cumulative cost = {1166.6666666666665 rows, 0.0 cpu, 0.0 io}, id = 40",
+	"  HiveJoin(condition=[=($0, $1)], joinType=[inner], algorithm=[none], cost=[not available]): rowcount = 166.66666666666666, cumulative cost = {1000.0 rows, 0.0 cpu, 0.0 io}, id = 38",
+	"    HiveFilter(condition=[>($0, 10)]): rowcount = 166.66666666666666, cumulative cost = {500.0 rows, 0.0 cpu, 0.0 io}, id = 35",
+	"      HiveTableScan(table=[[default, t]], table:alias=[t]): rowcount = 500.0, cumulative cost = {0.0 rows, 0.0 cpu, 0.0 io}, id = 0",
+	"    HiveProject(id=[$0], name=[$1]): rowcount = 500.0, cumulative cost = {0.0 rows, 0.0 cpu, 0.0 io}, id = 37",
+	"      HiveTableScan(table=[[default, u]], table:alias=[u]): rowcount = 500.0, cumulative cost = {0.0 rows, 0.0 cpu, 0.0 io}, id = 1",
+	"",
+}
+
+func TestExplainCBO(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("Explain", inf.TTypeId_STRING_TYPE, 1)}
+	query := "SELECT t.id, u.name FROM t JOIN u ON t.id = u.id WHERE t.id > 10"
+	svc.results = map[string][]*inf.TRowSet{"EXPLAIN CBO COST " + query: {stringBatch(explainCBO...)}}
+	conn := connectFake(t, svc, testOptions())
+
+	result, err := conn.ExplainCBO(context.Background(), query+";")
+	if err != nil {
+		t.Fatalf("ExplainCBO error: %v", err)
+	}
+	if result.Note != "" || !reflect.DeepEqual(result.Text, explainCBO) {
+		t.Errorf("expected the raw output and no note, got %q, %q", result.Text, result.Note)
+	}
+
+	project := result.Plan
+	if project == nil || project.Operator != "HiveProject" || project.Attributes != "id=[$0], name=[$2]" {
+		t.Fatalf("expected the plan rooted at the final HiveProject, got %+v", project)
+	}
+	if project.RowCount != 166.66666666666666 || !reflect.DeepEqual(project.Cost, &CBOCost{Rows: 1166.6666666666665}) {
+		t.Errorf("expected the root's estimates, got %v, %+v", project.RowCount, project.Cost)
+	}
+	if len(project.Children) != 1 || project.Children[0].Operator != "HiveJoin" {
+		t.Fatalf("expected a join under the project, got %+v", project.Children)
+	}
+	join := project.Children[0]
+	var inputs []string
+	for _, child := range join.Children {
+		inputs = append(inputs, child.Operator)
+	}
+	if want := []string{"HiveFilter", "HiveProject"}; !reflect.DeepEqual(inputs, want) {
+		t.Fatalf("expected join inputs %q, got %q", want, inputs)
+	}
+	scan := join.Children[0].Children[0]
+	if scan.Operator != "HiveTableScan" || scan.RowCount != 500 || !strings.Contains(scan.Attributes, "table:alias=[t]") {
+		t.Errorf("expected a scan of t estimated at 500 rows, got %+v", scan)
+	}
+}
+
+func TestExplainCBODisabled(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("Explain", inf.TTypeId_STRING_TYPE, 1)}
+	svc.results = map[string][]*inf.TRowSet{
+		"EXPLAIN CBO COST SELECT 1": {stringBatch("CBO PLAN:", "")},
+		"SET hive.cbo.enable":       {stringBatch("hive.cbo.enable=false")},
+	}
+	conn := connectFake(t, svc, testOptions())
+
+	result, err := conn.ExplainCBO(context.Background(), "SELECT 1")
+	if err != nil {
+		t.Fatalf("ExplainCBO error: %v", err)
+	}
+	if result.Plan != nil || !strings.Contains(result.Note, "hive.cbo.enable=false") {
+		t.Errorf("expected a note about the disabled optimizer, got %+v", result)
+	}
+}
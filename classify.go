@@ -0,0 +1,174 @@
+package hive
+
+import (
+	"strings"
+)
+
+// StatementKind is the intent of a statement, as told by ClassifyStatement.
+type StatementKind int
+
+const (
+	// StatementUnknown covers session commands like SET and USE, and
+	// anything not recognised.
+	StatementUnknown StatementKind = iota
+	// StatementRead is a query that only reads: SELECT, SHOW, DESCRIBE
+	// and EXPLAIN.
+	StatementRead
+	// StatementWrite changes table data: INSERT, UPDATE, DELETE, MERGE,
+	// LOAD, TRUNCATE, IMPORT and EXPORT.
+	StatementWrite
+	// StatementDDL changes the metastore: CREATE, DROP, ALTER, MSCK,
+	// ANALYZE, GRANT and REVOKE.
+	StatementDDL
+)
+
+func (k StatementKind) String() string {
+	switch k {
+	case StatementRead:
+		return "Read"
+	case StatementWrite:
+		return "Write"
+	case StatementDDL:
+		return "DDL"
+	default:
+		return "Unknown"
+	}
+}
+
+var statementKinds = map[string]StatementKind{
+	"SELECT":   StatementRead,
+	"SHOW":     StatementRead,
+	"DESCRIBE": StatementRead,
+	"DESC":     StatementRead,
+	"EXPLAIN":  StatementRead,
+	"VALUES":   StatementRead,
+	"INSERT":   StatementWrite,
+	"UPDATE":   StatementWrite,
+	"DELETE":   StatementWrite,
+	"MERGE":    StatementWrite,
+	"LOAD":     StatementWrite,
+	"TRUNCATE": StatementWrite,
+	"IMPORT":   StatementWrite,
+	"EXPORT":   StatementWrite,
+	"CREATE":   StatementDDL,
+	"DROP":     StatementDDL,
+	"ALTER":    StatementDDL,
+	"MSCK":     StatementDDL,
+	"ANALYZE":  StatementDDL,
+	"GRANT":    StatementDDL,
+	"REVOKE":   StatementDDL,
+}
+
+// ClassifyStatement tells the intent of a statement from its leading
+// keyword, skipping comments and opening parentheses. For WITH, the
+// statement following the common table expressions decides, so
+// "WITH x AS (...) INSERT ..." is a write. It is meant for callers
+// routing between read and write servers, not for validating SQL, and
+// the client itself only uses it to pick the queries Preview may append
+// a LIMIT to, the statements WithSQLComment comments and the DDL that
+// clears the metadata cache. Nothing in the client retries statements,
+// so it makes no claim about which are safe to run twice.
+func ClassifyStatement(sql string) StatementKind {
+	s := skipSpaceAndComments(sql)
+	for strings.HasPrefix(s, "(") {
+		s = skipSpaceAndComments(s[1:])
+	}
+
+	keyword, rest := leadingWord(s)
+	keyword = strings.ToUpper(keyword)
+	if keyword == "WITH" {
+		return ClassifyStatement(skipCTEs(rest))
+	}
+	return statementKinds[keyword]
+}
+
+// skipCTEs returns what follows the common table expressions at the
+// start of s, the text after a WITH keyword.
+func skipCTEs(s string) string {
+	for {
+		s = skipSpaceAndComments(s)
+		// name [(columns)] AS (query)
+		_, s = leadingWord(s)
+		s = skipSpaceAndComments(s)
+		if strings.HasPrefix(s, "(") {
+			s = skipParens(s)
+			s = skipSpaceAndComments(s)
+		}
+		as, rest := leadingWord(s)
+		if !strings.EqualFold(as, "AS") {
+			return s
+		}
+		s = skipParens(skipSpaceAndComments(rest))
+		s = skipSpaceAndComments(s)
+		if !strings.HasPrefix(s, ",") {
+			return s
+		}
+		s = s[1:]
+	}
+}
+
+// skipParens returns what follows the parenthesised group at the start
+// of s, or s unchanged if it doesn't start with one. Quoted sections and
+// comments inside the group are skipped whole.
+func skipParens(s string) string {
+	if !strings.HasPrefix(s, "(") {
+		return s
+	}
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\'' || c == '"' || c == '`':
+			end, _ := quotedEnd(s, i)
+			i = end - 1
+		case c == '-' && strings.HasPrefix(s[i:], "--"), c == '/' && strings.HasPrefix(s[i:], "/*"):
+			rest := skipSpaceAndComments(s[i:])
+			i = len(s) - len(rest) - 1
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return s[i+1:]
+			}
+		}
+	}
+	return ""
+}
+
+// skipSpaceAndComments trims leading whitespace, "--" and "/* */"
+// comments from s.
+func skipSpaceAndComments(s string) string {
+	for {
+		s = strings.TrimLeft(s, " \t\r\n")
+		switch {
+		case strings.HasPrefix(s, "--"):
+			end := strings.IndexByte(s, '\n')
+			if end < 0 {
+				return ""
+			}
+			s = s[end+1:]
+		case strings.HasPrefix(s, "/*"):
+			end := strings.Index(s[2:], "*/")
+			if end < 0 {
+				return ""
+			}
+			s = s[end+4:]
+		default:
+			return s
+		}
+	}
+}
+
+// leadingWord splits off the identifier characters at the start of s,
+// including a backtick-quoted name.
+func leadingWord(s string) (word, rest string) {
+	if strings.HasPrefix(s, "`") {
+		end, _ := quotedEnd(s, 0)
+		return s[:end], s[end:]
+	}
+	i := 0
+	for i < len(s) && !isWordBoundary(s, i) {
+		i++
+	}
+	return s[:i], s[i:]
+}
@@ -0,0 +1,32 @@
+package hive
+
+import "testing"
+
+func TestClassifyStatement(t *testing.T) {
+	cases := map[string]StatementKind{
+		"SELECT * FROM t":                                      StatementRead,
+		"select 1":                                             StatementRead,
+		"(SELECT 1) UNION ALL (SELECT 2)":                      StatementRead,
+		"SHOW TABLES":                                          StatementRead,
+		"INSERT INTO t VALUES (1)":                             StatementWrite,
+		"INSERT OVERWRITE TABLE t SELECT * FROM s":             StatementWrite,
+		"CREATE TABLE t (id INT)":                              StatementDDL,
+		"DROP TABLE IF EXISTS t":                               StatementDDL,
+		"SET hive.exec.parallel=true":                          StatementUnknown,
+		"USE sales":                                            StatementUnknown,
+		"WITH x AS (SELECT 1) SELECT * FROM x":                 StatementRead,
+		"WITH x AS (SELECT ')') INSERT INTO t SELECT * FROM x": StatementWrite,
+		"WITH a AS (SELECT 1), `b c` AS (SELECT (2)) INSERT INTO t SELECT 1": StatementWrite,
+		"-- nightly load\nINSERT INTO t SELECT 1":                            StatementWrite,
+		"/* report */ SELECT 1":                                              StatementRead,
+		"/* a */ -- b\n  /* c */ CREATE VIEW v AS SELECT 1":                  StatementDDL,
+		"":              StatementUnknown,
+		"-- only":       StatementUnknown,
+		"FROM t SELECT": StatementUnknown,
+	}
+	for sql, want := range cases {
+		if got := ClassifyStatement(sql); got != want {
+			t.Errorf("ClassifyStatement(%q): expected %v, got %v", sql, want, got)
+		}
+	}
+}
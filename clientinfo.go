@@ -0,0 +1,178 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/apache/thrift/lib/go/thrift"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// ErrClientInfoUnsupported is returned by SetClientInfo when the session
+// was negotiated below protocol V11.
+var ErrClientInfoUnsupported = errors.New("hive: server can't set client info; SetClientInfo needs protocol V11")
+
+// protocolV11 is HIVE_CLI_SERVICE_PROTOCOL_V11, which added SetClientInfo
+// and postdates the IDL inf was generated from.
+const protocolV11 inf.TProtocolVersion = 10
+
+// SetClientInfo updates client attributes of the open session, such as
+// ApplicationName, without reopening it. It needs a session negotiated
+// at protocol V11 or later (Hive 4); on older sessions it returns
+// ErrClientInfoUnsupported without calling the server.
+func (c *Connection) SetClientInfo(ctx context.Context, info map[string]string) error {
+	if err := c.ensureSession(ctx); err != nil {
+		return err
+	}
+	if !c.isOpen() {
+		return errors.New("Session is closed")
+	}
+	if c.protocol < protocolV11 {
+		return ErrClientInfoUnsupported
+	}
+
+	args := setClientInfoArgs{Req: &setClientInfoReq{SessionHandle: c.session, Configuration: info}}
+	var result setClientInfoResult
+	if _, err := c.calls.Call(ctx, "SetClientInfo", &args, &result); err != nil {
+		return fmt.Errorf("Error in SetClientInfo: %v", err)
+	}
+	if result.Success == nil {
+		return errors.New("SetClientInfo returned no result")
+	}
+	if !isSuccessStatus(result.Success.Status) {
+		return fmt.Errorf("SetClientInfo failed: %s", result.Success.Status.String())
+	}
+	return nil
+}
+
+// The SetClientInfo call postdates the IDL inf was generated from, so its
+// structs are written out here:
+//
+//	struct TSetClientInfoReq {
+//	  1: required TSessionHandle sessionHandle
+//	  2: optional map<string, string> configuration
+//	}
+//	struct TSetClientInfoResp { 1: required TStatus status }
+type setClientInfoReq struct {
+	SessionHandle *inf.TSessionHandle
+	Configuration map[string]string
+}
+
+type setClientInfoResp struct {
+	Status *inf.TStatus
+}
+
+type setClientInfoArgs struct {
+	Req *setClientInfoReq
+}
+
+type setClientInfoResult struct {
+	Success *setClientInfoResp
+}
+
+func (p *setClientInfoReq) Write(ctx context.Context, oprot thrift.TProtocol) error {
+	return writeStruct(ctx, oprot, "TSetClientInfoReq", func() error {
+		if err := writeStructField(ctx, oprot, "sessionHandle", 1, p.SessionHandle); err != nil {
+			return err
+		}
+		if p.Configuration == nil {
+			return nil
+		}
+		return writeField(ctx, oprot, "configuration", thrift.MAP, 2, func() error {
+			if err := oprot.WriteMapBegin(ctx, thrift.STRING, thrift.STRING, len(p.Configuration)); err != nil {
+				return err
+			}
+			for k, v := range p.Configuration {
+				if err := oprot.WriteString(ctx, k); err != nil {
+					return err
+				}
+				if err := oprot.WriteString(ctx, v); err != nil {
+					return err
+				}
+			}
+			return oprot.WriteMapEnd(ctx)
+		})
+	})
+}
+
+func (p *setClientInfoReq) Read(ctx context.Context, iprot thrift.TProtocol) error {
+	return readStruct(ctx, iprot, func(id int16, typ thrift.TType) (bool, error) {
+		switch {
+		case id == 1 && typ == thrift.STRUCT:
+			p.SessionHandle = inf.NewTSessionHandle()
+			return true, p.SessionHandle.Read(ctx, iprot)
+		case id == 2 && typ == thrift.MAP:
+			_, _, size, err := iprot.ReadMapBegin(ctx)
+			if err != nil {
+				return true, err
+			}
+			p.Configuration = make(map[string]string, size)
+			for i := 0; i < size; i++ {
+				k, err := iprot.ReadString(ctx)
+				if err != nil {
+					return true, err
+				}
+				v, err := iprot.ReadString(ctx)
+				if err != nil {
+					return true, err
+				}
+				p.Configuration[k] = v
+			}
+			return true, iprot.ReadMapEnd(ctx)
+		}
+		return false, nil
+	})
+}
+
+func (p *setClientInfoResp) Write(ctx context.Context, oprot thrift.TProtocol) error {
+	return writeStruct(ctx, oprot, "TSetClientInfoResp", func() error {
+		return writeStructField(ctx, oprot, "status", 1, p.Status)
+	})
+}
+
+func (p *setClientInfoResp) Read(ctx context.Context, iprot thrift.TProtocol) error {
+	return readStruct(ctx, iprot, func(id int16, typ thrift.TType) (bool, error) {
+		if id != 1 || typ != thrift.STRUCT {
+			return false, nil
+		}
+		p.Status = inf.NewTStatus()
+		return true, p.Status.Read(ctx, iprot)
+	})
+}
+
+func (p *setClientInfoArgs) Write(ctx context.Context, oprot thrift.TProtocol) error {
+	return writeStruct(ctx, oprot, "SetClientInfo_args", func() error {
+		return writeStructField(ctx, oprot, "req", 1, p.Req)
+	})
+}
+
+func (p *setClientInfoArgs) Read(ctx context.Context, iprot thrift.TProtocol) error {
+	return readStruct(ctx, iprot, func(id int16, typ thrift.TType) (bool, error) {
+		if id != 1 || typ != thrift.STRUCT {
+			return false, nil
+		}
+		p.Req = &setClientInfoReq{}
+		return true, p.Req.Read(ctx, iprot)
+	})
+}
+
+func (p *setClientInfoResult) Write(ctx context.Context, oprot thrift.TProtocol) error {
+	return writeStruct(ctx, oprot, "SetClientInfo_result", func() error {
+		if p.Success == nil {
+			return nil
+		}
+		return writeStructField(ctx, oprot, "success", 0, p.Success)
+	})
+}
+
+func (p *setClientInfoResult) Read(ctx context.Context, iprot thrift.TProtocol) error {
+	return readStruct(ctx, iprot, func(id int16, typ thrift.TType) (bool, error) {
+		if id != 0 || typ != thrift.STRUCT {
+			return false, nil
+		}
+		p.Success = &setClientInfoResp{}
+		return true, p.Success.Read(ctx, iprot)
+	})
+}
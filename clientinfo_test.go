@@ -0,0 +1,54 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestSetClientInfo(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	var got []map[string]string
+	svc.onSetClientInfo = func(info map[string]string) *inf.TStatus {
+		got = append(got, info)
+		return okStatus()
+	}
+	conn := connectFake(t, svc, testOptions())
+	// As if the session had been negotiated by a Hive 4 server.
+	conn.protocol = protocolV11
+
+	if err := conn.SetClientInfo(ctx, map[string]string{"ApplicationName": "nightly-etl"}); err != nil {
+		t.Fatalf("SetClientInfo error: %v", err)
+	}
+	if len(got) != 1 || got[0]["ApplicationName"] != "nightly-etl" {
+		t.Errorf("unexpected client info sent %v", got)
+	}
+
+	svc.onSetClientInfo = func(map[string]string) *inf.TStatus {
+		return errorStatus("Invalid SessionHandle")
+	}
+	if err := conn.SetClientInfo(ctx, map[string]string{"ApplicationName": "x"}); err == nil {
+		t.Error("expected an error for a failed status")
+	}
+}
+
+func TestSetClientInfoOldProtocol(t *testing.T) {
+	svc := newFakeService()
+	called := false
+	svc.onSetClientInfo = func(map[string]string) *inf.TStatus {
+		called = true
+		return okStatus()
+	}
+	conn := connectFake(t, svc, testOptions())
+
+	err := conn.SetClientInfo(context.Background(), map[string]string{"ApplicationName": "x"})
+	if !errors.Is(err, ErrClientInfoUnsupported) {
+		t.Errorf("expected ErrClientInfoUnsupported, got %v", err)
+	}
+	if called {
+		t.Error("expected no SetClientInfo call below protocol V11")
+	}
+}
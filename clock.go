@@ -0,0 +1,23 @@
+package hive
+
+import "time"
+
+// clock abstracts the passage of time for the polling and retry loops,
+// so tests can drive them without real sleeps.
+type clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// clock returns the clock configured for tests, or the real clock.
+func (o Options) clock() clock {
+	if o.testClock != nil {
+		return o.testClock
+	}
+	return realClock{}
+}
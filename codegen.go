@@ -0,0 +1,255 @@
+package hive
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// Import paths returned by GoTypeFor.
+const (
+	decimalImport = "github.com/shopspring/decimal"
+	hiveImport    = "github.com/jasonlabz/hive"
+)
+
+// GoTypeFor returns the Go type a code generator should declare for a
+// column, together with the import path the type needs, or "" if it
+// needs none. The mapping is stable:
+//   - BOOLEAN: bool, or sql.NullBool
+//   - TINYINT: int8, or *int8
+//   - SMALLINT, INT, BIGINT: int16, int32, int64, or sql.NullInt16,
+//     sql.NullInt32, sql.NullInt64
+//   - FLOAT, DOUBLE: float64, or sql.NullFloat64
+//   - STRING, VARCHAR, CHAR: string, or sql.NullString
+//   - BINARY: []byte, NULL being nil
+//   - DECIMAL: decimal.Decimal, or decimal.NullDecimal, from
+//     github.com/shopspring/decimal
+//   - DATE, TIMESTAMP: time.Time, or sql.NullTime
+//   - INTERVAL_YEAR_MONTH, INTERVAL_DAY_TIME: hive.Interval, or
+//     *hive.Interval
+//   - ARRAY<T>: []T and MAP<K,V>: map[K]V, with elements, keys and
+//     values in their NOT NULL form and NULL being nil
+//   - STRUCT: map[string]interface{}; UNIONTYPE and anything else:
+//     interface{}
+//
+// The second form is used unless col.NotNull is set. The element types
+// of ARRAY and MAP columns come from col.ComplexType; without it they
+// map to []interface{} and map[string]interface{}. Where a MAP needs two
+// packages, e.g. MAP<DATE,DECIMAL>, the value's is returned.
+func GoTypeFor(col Column) (goType, importPath string) {
+	if col.ComplexType != "" {
+		if goType, importPath, err := goTypeForSignature(col.ComplexType); err == nil {
+			return goType, importPath
+		}
+	}
+	switch col.TypeID {
+	case inf.TTypeId_ARRAY_TYPE:
+		return "[]interface{}", ""
+	case inf.TTypeId_MAP_TYPE, inf.TTypeId_STRUCT_TYPE:
+		return "map[string]interface{}", ""
+	}
+	return primitiveGoType(col.TypeID, !col.NotNull)
+}
+
+// primitiveGoType maps a primitive type, see GoTypeFor.
+func primitiveGoType(id inf.TTypeId, nullable bool) (goType, importPath string) {
+	pick := func(notNull, null, path string) (string, string) {
+		if nullable {
+			if strings.HasPrefix(null, "sql.") {
+				return null, "database/sql"
+			}
+			return null, path
+		}
+		return notNull, path
+	}
+	switch id {
+	case inf.TTypeId_BOOLEAN_TYPE:
+		return pick("bool", "sql.NullBool", "")
+	case inf.TTypeId_TINYINT_TYPE:
+		return pick("int8", "*int8", "")
+	case inf.TTypeId_SMALLINT_TYPE:
+		return pick("int16", "sql.NullInt16", "")
+	case inf.TTypeId_INT_TYPE:
+		return pick("int32", "sql.NullInt32", "")
+	case inf.TTypeId_BIGINT_TYPE:
+		return pick("int64", "sql.NullInt64", "")
+	case inf.TTypeId_FLOAT_TYPE, inf.TTypeId_DOUBLE_TYPE:
+		return pick("float64", "sql.NullFloat64", "")
+	case inf.TTypeId_STRING_TYPE, inf.TTypeId_VARCHAR_TYPE, inf.TTypeId_CHAR_TYPE:
+		return pick("string", "sql.NullString", "")
+	case inf.TTypeId_BINARY_TYPE:
+		return "[]byte", ""
+	case inf.TTypeId_DECIMAL_TYPE:
+		return pick("decimal.Decimal", "decimal.NullDecimal", decimalImport)
+	case inf.TTypeId_DATE_TYPE, inf.TTypeId_TIMESTAMP_TYPE:
+		return pick("time.Time", "sql.NullTime", "time")
+	case inf.TTypeId_INTERVAL_YEAR_MONTH_TYPE, inf.TTypeId_INTERVAL_DAY_TIME_TYPE:
+		return pick("hive.Interval", "*hive.Interval", hiveImport)
+	}
+	return "interface{}", ""
+}
+
+// typeIDs looks up primitive types by their lower-case names, as used in
+// type signatures.
+var typeIDs = func() map[string]inf.TTypeId {
+	ids := map[string]inf.TTypeId{"integer": inf.TTypeId_INT_TYPE}
+	for id, name := range inf.TYPE_NAMES {
+		ids[strings.ToLower(name)] = id
+	}
+	return ids
+}()
+
+// goTypeForSignature maps a type in Hive's syntax, e.g.
+// "map<string,array<int>>", in its NOT NULL form.
+func goTypeForSignature(sig string) (goType, importPath string, err error) {
+	p := &signatureParser{s: strings.ToLower(sig)}
+	goType, importPath, err = p.goType()
+	if err == nil && strings.TrimSpace(p.s[p.pos:]) != "" {
+		err = fmt.Errorf("Invalid type %q", sig)
+	}
+	return goType, importPath, err
+}
+
+type signatureParser struct {
+	s   string
+	pos int
+}
+
+func (p *signatureParser) skipSpace() {
+	for p.pos < len(p.s) && p.s[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *signatureParser) consume(c byte) bool {
+	p.skipSpace()
+	if p.pos < len(p.s) && p.s[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// skipNested skips the rest of a parenthesised or angle-bracketed list
+// whose opening delimiter has been consumed.
+func (p *signatureParser) skipNested(open, close byte) error {
+	for depth := 1; p.pos < len(p.s); p.pos++ {
+		switch p.s[p.pos] {
+		case open:
+			depth++
+		case close:
+			if depth--; depth == 0 {
+				p.pos++
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("Invalid type %q: unbalanced %c", p.s, open)
+}
+
+func (p *signatureParser) goType() (string, string, error) {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.s) && (p.s[p.pos] == '_' || p.s[p.pos] >= 'a' && p.s[p.pos] <= 'z') {
+		p.pos++
+	}
+	name := p.s[start:p.pos]
+	if p.consume('(') {
+		// Qualifiers such as decimal(10,2) don't change the Go type.
+		if err := p.skipNested('(', ')'); err != nil {
+			return "", "", err
+		}
+	}
+
+	switch name {
+	case "array":
+		if !p.consume('<') {
+			return "", "", fmt.Errorf("Invalid type %q: expected '<' after array", p.s)
+		}
+		elem, path, err := p.goType()
+		if err != nil {
+			return "", "", err
+		}
+		if !p.consume('>') {
+			return "", "", fmt.Errorf("Invalid type %q: expected '>'", p.s)
+		}
+		return "[]" + elem, path, nil
+	case "map":
+		if !p.consume('<') {
+			return "", "", fmt.Errorf("Invalid type %q: expected '<' after map", p.s)
+		}
+		key, keyPath, err := p.goType()
+		if err != nil {
+			return "", "", err
+		}
+		if !p.consume(',') {
+			return "", "", fmt.Errorf("Invalid type %q: expected ','", p.s)
+		}
+		value, path, err := p.goType()
+		if err != nil {
+			return "", "", err
+		}
+		if !p.consume('>') {
+			return "", "", fmt.Errorf("Invalid type %q: expected '>'", p.s)
+		}
+		if path == "" {
+			path = keyPath
+		}
+		return "map[" + key + "]" + value, path, nil
+	case "struct", "uniontype":
+		if !p.consume('<') {
+			return "", "", fmt.Errorf("Invalid type %q: expected '<' after %s", p.s, name)
+		}
+		if err := p.skipNested('<', '>'); err != nil {
+			return "", "", err
+		}
+		if name == "struct" {
+			return "map[string]interface{}", "", nil
+		}
+		return "interface{}", "", nil
+	}
+
+	id, ok := typeIDs[name]
+	if !ok {
+		return "", "", fmt.Errorf("Invalid type %q: unknown type %q", p.s, name)
+	}
+	goType, path := primitiveGoType(id, false)
+	return goType, path, nil
+}
+
+// typeSignature renders the type at index i of a type descriptor in
+// Hive's syntax. The wire format doesn't keep the order of STRUCT
+// fields, so they are rendered by name.
+func typeSignature(types []*inf.TTypeEntry, i inf.TTypeEntryPtr, depth int) string {
+	if i < 0 || int(i) >= len(types) || depth > len(types) {
+		return "string"
+	}
+	entry := types[i]
+	fields := func(m map[string]inf.TTypeEntryPtr) string {
+		names := make([]string, 0, len(m))
+		for name := range m {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for j, name := range names {
+			names[j] = name + ":" + typeSignature(types, m[name], depth+1)
+		}
+		return strings.Join(names, ",")
+	}
+	switch {
+	case entry.IsSetArrayEntry():
+		return "array<" + typeSignature(types, entry.GetArrayEntry().GetObjectTypePtr(), depth+1) + ">"
+	case entry.IsSetMapEntry():
+		m := entry.GetMapEntry()
+		return "map<" + typeSignature(types, m.GetKeyTypePtr(), depth+1) + "," + typeSignature(types, m.GetValueTypePtr(), depth+1) + ">"
+	case entry.IsSetStructEntry():
+		return "struct<" + fields(entry.GetStructEntry().GetNameToTypePtr()) + ">"
+	case entry.IsSetUnionEntry():
+		return "uniontype<" + fields(entry.GetUnionEntry().GetNameToTypePtr()) + ">"
+	case entry.IsSetPrimitiveEntry():
+		return strings.ToLower(typeName(entry.GetPrimitiveEntry().GetType()))
+	}
+	return "string"
+}
@@ -0,0 +1,87 @@
+package hive
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestGoTypeFor(t *testing.T) {
+	cases := []struct {
+		col      Column
+		goType   string
+		imported string
+	}{
+		{Column{TypeID: inf.TTypeId_BOOLEAN_TYPE, NotNull: true}, "bool", ""},
+		{Column{TypeID: inf.TTypeId_BOOLEAN_TYPE}, "sql.NullBool", "database/sql"},
+		{Column{TypeID: inf.TTypeId_TINYINT_TYPE, NotNull: true}, "int8", ""},
+		{Column{TypeID: inf.TTypeId_TINYINT_TYPE}, "*int8", ""},
+		{Column{TypeID: inf.TTypeId_SMALLINT_TYPE, NotNull: true}, "int16", ""},
+		{Column{TypeID: inf.TTypeId_SMALLINT_TYPE}, "sql.NullInt16", "database/sql"},
+		{Column{TypeID: inf.TTypeId_INT_TYPE, NotNull: true}, "int32", ""},
+		{Column{TypeID: inf.TTypeId_INT_TYPE}, "sql.NullInt32", "database/sql"},
+		{Column{TypeID: inf.TTypeId_BIGINT_TYPE, NotNull: true}, "int64", ""},
+		{Column{TypeID: inf.TTypeId_BIGINT_TYPE}, "sql.NullInt64", "database/sql"},
+		{Column{TypeID: inf.TTypeId_FLOAT_TYPE, NotNull: true}, "float64", ""},
+		{Column{TypeID: inf.TTypeId_DOUBLE_TYPE}, "sql.NullFloat64", "database/sql"},
+		{Column{TypeID: inf.TTypeId_STRING_TYPE, NotNull: true}, "string", ""},
+		{Column{TypeID: inf.TTypeId_VARCHAR_TYPE, Length: 10}, "sql.NullString", "database/sql"},
+		{Column{TypeID: inf.TTypeId_CHAR_TYPE}, "sql.NullString", "database/sql"},
+		{Column{TypeID: inf.TTypeId_BINARY_TYPE}, "[]byte", ""},
+		{Column{TypeID: inf.TTypeId_BINARY_TYPE, NotNull: true}, "[]byte", ""},
+		{Column{TypeID: inf.TTypeId_DECIMAL_TYPE, NotNull: true}, "decimal.Decimal", decimalImport},
+		{Column{TypeID: inf.TTypeId_DECIMAL_TYPE}, "decimal.NullDecimal", decimalImport},
+		{Column{TypeID: inf.TTypeId_TIMESTAMP_TYPE, NotNull: true}, "time.Time", "time"},
+		{Column{TypeID: inf.TTypeId_DATE_TYPE}, "sql.NullTime", "database/sql"},
+		{Column{TypeID: inf.TTypeId_INTERVAL_DAY_TIME_TYPE, NotNull: true}, "hive.Interval", hiveImport},
+		{Column{TypeID: inf.TTypeId_INTERVAL_YEAR_MONTH_TYPE}, "*hive.Interval", hiveImport},
+		{Column{TypeID: inf.TTypeId_NULL_TYPE}, "interface{}", ""},
+		{Column{TypeID: inf.TTypeId_ARRAY_TYPE, ComplexType: "array<string>"}, "[]string", ""},
+		{Column{TypeID: inf.TTypeId_ARRAY_TYPE, ComplexType: "ARRAY<DECIMAL(10,2)>"}, "[]decimal.Decimal", decimalImport},
+		{Column{TypeID: inf.TTypeId_ARRAY_TYPE, ComplexType: "array<array<bigint>>"}, "[][]int64", ""},
+		{Column{TypeID: inf.TTypeId_MAP_TYPE, ComplexType: "map<string,array<int>>"}, "map[string][]int32", ""},
+		{Column{TypeID: inf.TTypeId_MAP_TYPE, ComplexType: "map<date, decimal(18,4)>"}, "map[time.Time]decimal.Decimal", decimalImport},
+		{Column{TypeID: inf.TTypeId_MAP_TYPE, ComplexType: "map<integer,timestamp>"}, "map[int32]time.Time", "time"},
+		{Column{TypeID: inf.TTypeId_ARRAY_TYPE, ComplexType: "array<struct<a:int,b:map<string,int>>>"}, "[]map[string]interface{}", ""},
+		{Column{TypeID: inf.TTypeId_STRUCT_TYPE, ComplexType: "struct<a:int>"}, "map[string]interface{}", ""},
+		{Column{TypeID: inf.TTypeId_UNION_TYPE, ComplexType: "uniontype<int,string>"}, "interface{}", ""},
+		{Column{TypeID: inf.TTypeId_ARRAY_TYPE}, "[]interface{}", ""},
+		{Column{TypeID: inf.TTypeId_MAP_TYPE}, "map[string]interface{}", ""},
+		{Column{TypeID: inf.TTypeId_ARRAY_TYPE, ComplexType: "array<widget>"}, "[]interface{}", ""},
+		{Column{TypeID: inf.TTypeId_MAP_TYPE, ComplexType: "map<string,int"}, "map[string]interface{}", ""},
+	}
+	for _, tc := range cases {
+		goType, imported := GoTypeFor(tc.col)
+		if goType != tc.goType || imported != tc.imported {
+			t.Errorf("%+v: expected %s (%q), got %s (%q)", tc.col, tc.goType, tc.imported, goType, imported)
+		}
+	}
+}
+
+func TestSchemaComplexType(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{
+		{ColumnName: "groups", Position: 1, TypeDesc: &inf.TTypeDesc{Types: mapStringIntArray}},
+		columnDesc("id", inf.TTypeId_INT_TYPE, 2),
+	}
+	conn := connectFake(t, svc, testOptions())
+
+	rs, err := conn.Query("SELECT groups, id FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	schema, err := rs.Schema(context.Background())
+	if err != nil {
+		t.Fatalf("Schema error: %v", err)
+	}
+	if got := schema[0].ComplexType; got != "map<string,array<int>>" {
+		t.Errorf("expected map<string,array<int>>, got %q", got)
+	}
+	if got := schema[1].ComplexType; got != "" {
+		t.Errorf("expected no complex type for INT, got %q", got)
+	}
+	if goType, _ := GoTypeFor(schema[0]); goType != "map[string][]int32" {
+		t.Errorf("expected map[string][]int32, got %s", goType)
+	}
+}
@@ -0,0 +1,137 @@
+package hive
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// A ColumnOption changes how FetchColumn and the Query* column helpers
+// collect values.
+type ColumnOption func(*columnOptions)
+
+type columnOptions struct {
+	skipNulls bool
+}
+
+// SkipNulls drops NULL cells from the result instead of failing.
+func SkipNulls() ColumnOption {
+	return func(o *columnOptions) {
+		o.skipNulls = true
+	}
+}
+
+// FetchColumn reads the remaining rows of rs and returns column index
+// as a []T. Values of a different type are converted where Go allows it,
+// e.g. INT into int64; anything can be collected as a string. A NULL
+// cell is an error unless SkipNulls is given.
+func FetchColumn[T any](rs RowSet, index int, opts ...ColumnOption) ([]T, error) {
+	var o columnOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	columns := rs.Columns()
+	if index < 0 || index >= len(columns) {
+		return nil, fmt.Errorf("Column index %d out of range for %d columns", index, len(columns))
+	}
+
+	row := make([]interface{}, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range row {
+		dest[i] = &row[i]
+	}
+
+	var values []T
+	for n := 0; rs.Next(); n++ {
+		if err := rs.Scan(dest...); err != nil {
+			return values, err
+		}
+		if row[index] == nil {
+			if o.skipNulls {
+				continue
+			}
+			return values, fmt.Errorf("Row %d: column %s is NULL", n, columns[index])
+		}
+		v, err := convertValue[T](row[index])
+		if err != nil {
+			return values, fmt.Errorf("Row %d: column %s: %v", n, columns[index], err)
+		}
+		values = append(values, v)
+	}
+	return values, rs.Err()
+}
+
+// QueryStrings runs query and returns its first column as strings.
+func (c *Connection) QueryStrings(ctx context.Context, query string, opts ...ColumnOption) ([]string, error) {
+	return queryColumn[string](ctx, c, query, opts)
+}
+
+// QueryInts runs query and returns its first column as int64s.
+func (c *Connection) QueryInts(ctx context.Context, query string, opts ...ColumnOption) ([]int64, error) {
+	return queryColumn[int64](ctx, c, query, opts)
+}
+
+func queryColumn[T any](ctx context.Context, c *Connection, query string, opts []ColumnOption) ([]T, error) {
+	executeReq := inf.NewTExecuteStatementReq()
+	executeReq.Statement = query
+	executeReq.RunAsync = true
+
+	rs, err := c.submit(ctx, executeReq)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close(ctx)
+
+	if err := rs.waitForSuccess(ctx); err != nil {
+		return nil, err
+	}
+	return FetchColumn[T](rs, 0, opts...)
+}
+
+// convertValue converts a cell value to T.
+func convertValue[T any](v interface{}) (T, error) {
+	var zero T
+	if t, ok := v.(T); ok {
+		return t, nil
+	}
+	converted, err := convertTo(v, reflect.TypeOf(&zero).Elem())
+	if err != nil {
+		return zero, err
+	}
+	return converted.Interface().(T), nil
+}
+
+// convertTo converts a cell value to target: anything to a string, and
+// numbers to other numeric types.
+func convertTo(v interface{}, target reflect.Type) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Type().AssignableTo(target) {
+		return rv, nil
+	}
+	if target.Kind() == reflect.String {
+		var s string
+		if b, ok := v.([]byte); ok {
+			s = string(b)
+		} else {
+			s = fmt.Sprint(v)
+		}
+		return reflect.ValueOf(s).Convert(target), nil
+	}
+	if isNumeric(rv.Kind()) && isNumeric(target.Kind()) {
+		return rv.Convert(target), nil
+	}
+	return reflect.Value{}, fmt.Errorf("Can't convert %T to %v", v, target)
+}
+
+func isNumeric(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
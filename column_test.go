@@ -0,0 +1,70 @@
+package hive
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestQueryInts(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("id", inf.TTypeId_INT_TYPE, 1)}
+	svc.batches = []*inf.TRowSet{{Columns: []*inf.TColumn{
+		// Row 1 is NULL.
+		{I32Val: &inf.TI32Column{Values: []int32{7, 0, 9}, Nulls: []byte{0x02}}},
+	}}}
+	conn := connectFake(t, svc, testOptions())
+
+	if _, err := conn.QueryInts(ctx, "SELECT id FROM t"); err == nil {
+		t.Error("expected an error for the NULL cell")
+	}
+
+	ids, err := conn.QueryInts(ctx, "SELECT id FROM t", SkipNulls())
+	if err != nil {
+		t.Fatalf("QueryInts error: %v", err)
+	}
+	if expected := []int64{7, 9}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("expected %v, got %v", expected, ids)
+	}
+
+	strs, err := conn.QueryStrings(ctx, "SELECT id FROM t", SkipNulls())
+	if err != nil {
+		t.Fatalf("QueryStrings error: %v", err)
+	}
+	if expected := []string{"7", "9"}; !reflect.DeepEqual(strs, expected) {
+		t.Errorf("expected %v, got %v", expected, strs)
+	}
+
+	if conn.InFlight() != 0 {
+		t.Errorf("expected the helpers to close their operations")
+	}
+}
+
+func TestScanNull(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("name", inf.TTypeId_STRING_TYPE, 1)}
+	svc.batches = []*inf.TRowSet{{Columns: []*inf.TColumn{
+		{StringVal: &inf.TStringColumn{Values: []string{""}, Nulls: []byte{0x01}}},
+	}}}
+	conn := connectFake(t, svc, testOptions())
+
+	rs, err := conn.Query("SELECT name FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	if !rs.Next() {
+		t.Fatal("expected a row")
+	}
+
+	s := "unchanged"
+	if err := rs.Scan(&s); err != nil || s != "" {
+		t.Errorf("expected NULL to scan as the empty string, got %q, %v", s, err)
+	}
+	var v interface{} = "unchanged"
+	if err := rs.Scan(&v); err != nil || v != nil {
+		t.Errorf("expected NULL to scan as nil, got %v, %v", v, err)
+	}
+}
@@ -0,0 +1,175 @@
+package hive
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// NextBatch fetches the next batch of the result without converting it
+// to rows, for consumers that read whole columns with ColumnInt64,
+// ColumnString and the like instead of boxing every cell as Next and
+// NextValues do. Summing a BIGINT column of a million rows that way
+// takes a few hundred times less time than with NextValues and doesn't
+// allocate, see BenchmarkColumnar; what is left is the cost of the
+// fetches themselves.
+//
+// NextBatch returns false at the end of the result or on error; check
+// Err afterwards. Empty batches are skipped. Next and NextValues must
+// not be mixed with NextBatch on the same RowSet.
+func (r *rowSet) NextBatch(ctx context.Context) bool {
+	if r.err != nil {
+		return false
+	}
+	if r.options.RowInterceptor != nil {
+		r.err = errRawBatchIntercepted
+		return false
+	}
+	if err := r.waitForSuccess(ctx); err != nil {
+		r.err = err
+		return false
+	}
+	for {
+		if ctx.Err() != nil {
+			r.err = r.abandon(ctx)
+			return false
+		}
+		results, ok := r.fetchRaw(ctx)
+		if !ok {
+			if r.err == nil {
+				r.finish()
+			}
+			r.rowSet = nil
+			return false
+		}
+		if rowSetLength(results) > 0 {
+			r.rowSet = results
+			r.resultSet = nil
+			return true
+		}
+	}
+}
+
+// currentColumn returns column i of the batch from NextBatch.
+func (r *rowSet) currentColumn(i int) (*inf.TColumn, error) {
+	if r.rowSet == nil {
+		return nil, fmt.Errorf("No batch fetched")
+	}
+	cols := r.rowSet.GetColumns()
+	if len(cols) == 0 && len(r.rowSet.GetRows()) > 0 {
+		return nil, fmt.Errorf("Batch is row-based, see Options.PreferColumnarResults")
+	}
+	if i < 0 || i >= len(cols) {
+		return nil, fmt.Errorf("Column %d out of range for %d columns", i, len(cols))
+	}
+	return cols[i], nil
+}
+
+// ColumnNulls returns the null bitmap of column i of the current batch,
+// one bit per row, least significant bit first; see BatchColumn.Nulls.
+// Values of NULL cells in the typed vectors are zero.
+func (r *rowSet) ColumnNulls(i int) ([]byte, error) {
+	col, err := r.currentColumn(i)
+	if err != nil {
+		return nil, err
+	}
+	return columnNulls(col), nil
+}
+
+// ColumnInt64 returns column i of the current batch, which must hold
+// TINYINT, SMALLINT, INT or BIGINT values. BIGINT vectors are returned
+// as decoded, without copying; narrower ones are widened into a new
+// slice.
+func (r *rowSet) ColumnInt64(i int) ([]int64, error) {
+	col, err := r.currentColumn(i)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case col.IsSetI64Val():
+		return col.GetI64Val().GetValues(), nil
+	case col.IsSetI32Val():
+		return widenInts(col.GetI32Val().GetValues()), nil
+	case col.IsSetI16Val():
+		return widenInts(col.GetI16Val().GetValues()), nil
+	case col.IsSetByteVal():
+		// TINYINTs travel as bytes holding two's complement values.
+		values := col.GetByteVal().GetValues()
+		wide := make([]int64, len(values))
+		for j, v := range values {
+			wide[j] = int64(int8(v))
+		}
+		return wide, nil
+	}
+	return nil, columnTypeError(r, i, "an integer")
+}
+
+// ColumnFloat64 returns column i of the current batch, which must hold
+// FLOAT or DOUBLE values; both are sent as doubles.
+func (r *rowSet) ColumnFloat64(i int) ([]float64, error) {
+	col, err := r.currentColumn(i)
+	if err != nil {
+		return nil, err
+	}
+	if !col.IsSetDoubleVal() {
+		return nil, columnTypeError(r, i, "a floating point")
+	}
+	return col.GetDoubleVal().GetValues(), nil
+}
+
+// ColumnString returns column i of the current batch, which must be
+// sent as strings: STRING, VARCHAR and CHAR, and DECIMAL, DATE,
+// TIMESTAMP and complex types in their text form.
+func (r *rowSet) ColumnString(i int) ([]string, error) {
+	col, err := r.currentColumn(i)
+	if err != nil {
+		return nil, err
+	}
+	if !col.IsSetStringVal() {
+		return nil, columnTypeError(r, i, "a string")
+	}
+	return col.GetStringVal().GetValues(), nil
+}
+
+// ColumnBool returns column i of the current batch, which must be a
+// BOOLEAN column.
+func (r *rowSet) ColumnBool(i int) ([]bool, error) {
+	col, err := r.currentColumn(i)
+	if err != nil {
+		return nil, err
+	}
+	if !col.IsSetBoolVal() {
+		return nil, columnTypeError(r, i, "a boolean")
+	}
+	return col.GetBoolVal().GetValues(), nil
+}
+
+// ColumnBytes returns column i of the current batch, which must be a
+// BINARY column.
+func (r *rowSet) ColumnBytes(i int) ([][]byte, error) {
+	col, err := r.currentColumn(i)
+	if err != nil {
+		return nil, err
+	}
+	if !col.IsSetBinaryVal() {
+		return nil, columnTypeError(r, i, "a binary")
+	}
+	return col.GetBinaryVal().GetValues(), nil
+}
+
+func columnTypeError(r *rowSet, i int, want string) error {
+	name := fmt.Sprintf("%d", i)
+	if i < len(r.columns) {
+		name = r.columns[i].GetColumnName()
+	}
+	return fmt.Errorf("Column %s is not %s column", name, want)
+}
+
+func widenInts[T int16 | int32](values []T) []int64 {
+	wide := make([]int64, len(values))
+	for i, v := range values {
+		wide[i] = int64(v)
+	}
+	return wide
+}
@@ -0,0 +1,138 @@
+package hive
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestNextBatchColumns(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{
+		columnDesc("id", inf.TTypeId_INT_TYPE, 1),
+		columnDesc("name", inf.TTypeId_STRING_TYPE, 2),
+		columnDesc("tiny", inf.TTypeId_TINYINT_TYPE, 3),
+	}
+	svc.batches = []*inf.TRowSet{
+		{Columns: []*inf.TColumn{
+			{I32Val: &inf.TI32Column{Values: []int32{1, 0, 3}, Nulls: []byte{0x02}}},
+			{StringVal: &inf.TStringColumn{Values: []string{"a", "b", "c"}, Nulls: []byte{}}},
+			{ByteVal: &inf.TByteColumn{Values: []byte{0xff, 0x7f, 0}, Nulls: []byte{}}},
+		}},
+		{Columns: []*inf.TColumn{
+			{I32Val: &inf.TI32Column{Values: []int32{4}, Nulls: []byte{}}},
+			{StringVal: &inf.TStringColumn{Values: []string{"d"}, Nulls: []byte{}}},
+			{ByteVal: &inf.TByteColumn{Values: []byte{1}, Nulls: []byte{}}},
+		}},
+	}
+	conn := connectFake(t, svc, testOptions())
+	rs, err := conn.Query("SELECT id, name, tiny FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	if _, err := rs.ColumnInt64(0); err == nil {
+		t.Error("expected an error before the first batch")
+	}
+
+	var ids []int64
+	var names []string
+	batches := 0
+	for rs.NextBatch(ctx) {
+		batches++
+		id, err := rs.ColumnInt64(0)
+		if err != nil {
+			t.Fatalf("ColumnInt64 error: %v", err)
+		}
+		name, err := rs.ColumnString(1)
+		if err != nil {
+			t.Fatalf("ColumnString error: %v", err)
+		}
+		ids, names = append(ids, id...), append(names, name...)
+		if batches == 1 {
+			nulls, _ := rs.ColumnNulls(0)
+			if !isNull(nulls, 1) || isNull(nulls, 0) {
+				t.Errorf("expected row 1 of id to be NULL, bitmap %v", nulls)
+			}
+			if tiny, err := rs.ColumnInt64(2); err != nil || !reflect.DeepEqual(tiny, []int64{-1, 127, 0}) {
+				t.Errorf("expected signed TINYINTs, got %v, %v", tiny, err)
+			}
+			if _, err := rs.ColumnString(0); err == nil {
+				t.Error("expected an error reading an INT column as strings")
+			}
+			if _, err := rs.ColumnBool(5); err == nil {
+				t.Error("expected an error for a column out of range")
+			}
+		}
+	}
+	if err := rs.Err(); err != nil {
+		t.Fatalf("NextBatch error: %v", err)
+	}
+	if batches != 2 || !reflect.DeepEqual(ids, []int64{1, 0, 3, 4}) || !reflect.DeepEqual(names, []string{"a", "b", "c", "d"}) {
+		t.Errorf("expected 2 batches of ids [1 0 3 4] and names [a b c d], got %d, %v and %v", batches, ids, names)
+	}
+}
+
+// BenchmarkColumnar compares summing a million-row BIGINT column through
+// NextValues with reading it with ColumnInt64, on an already fetched
+// batch so that only decoding is measured.
+func BenchmarkColumnar(b *testing.B) {
+	const rows = 1000000
+	ids := make([]int64, rows)
+	names := make([]string, rows)
+	for i := range ids {
+		ids[i] = int64(i)
+		names[i] = "name-" + strconv.Itoa(i)
+	}
+	batch := &inf.TRowSet{Columns: []*inf.TColumn{
+		{I64Val: &inf.TI64Column{Values: ids, Nulls: []byte{}}},
+		{StringVal: &inf.TStringColumn{Values: names, Nulls: []byte{}}},
+	}}
+	schema := []*inf.TColumnDesc{
+		columnDesc("id", inf.TTypeId_BIGINT_TYPE, 1),
+		columnDesc("name", inf.TTypeId_STRING_TYPE, 2),
+	}
+	want := int64(rows) * (rows - 1) / 2
+	ctx := context.Background()
+
+	b.Run("NextValues", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			r := &rowSet{operation: &inf.TOperationHandle{OperationId: &inf.THandleIdentifier{}}, options: testOptions(), columns: schema, ready: true}
+			if err := r.decodeBatch(batch); err != nil {
+				b.Fatalf("decodeBatch error: %v", err)
+			}
+			var sum int64
+			for {
+				values, err := r.NextValues(ctx)
+				if err != nil {
+					break
+				}
+				sum += values[0].(int64)
+			}
+			if sum != want {
+				b.Fatalf("expected sum %d, got %d", want, sum)
+			}
+		}
+	})
+	b.Run("ColumnInt64", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			r := &rowSet{options: testOptions(), columns: schema, ready: true, rowSet: batch}
+			values, err := r.ColumnInt64(0)
+			if err != nil {
+				b.Fatalf("ColumnInt64 error: %v", err)
+			}
+			var sum int64
+			for _, v := range values {
+				sum += v
+			}
+			if sum != want {
+				b.Fatalf("expected sum %d, got %d", want, sum)
+			}
+		}
+	})
+}
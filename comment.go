@@ -0,0 +1,44 @@
+package hive
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+type sqlCommentKey struct{}
+
+// WithSQLComment returns a context under which statements are sent with
+// comment in front of them, as in "/* job=etl run=42 */ INSERT ...", for
+// lineage tools such as OpenLineage or DataHub that read job and run IDs
+// from the SQL the cluster runs. The comment becomes part of the
+// statement, so hooks, logs and the audit trail on the server see it,
+// as do StatementSubmitted events and Options.AuditHook.
+//
+// A comment containing "*/", which would end it early and let the rest
+// through as SQL, fails the statement, as does one with control
+// characters other than tabs and newlines. Session commands such as SET
+// and USE, which ClassifyStatement doesn't recognise, are sent without
+// the comment: HiveServer2 only tells them apart by their first word.
+func WithSQLComment(ctx context.Context, comment string) context.Context {
+	return context.WithValue(ctx, sqlCommentKey{}, comment)
+}
+
+// commentStatement puts the comment of WithSQLComment in front of stmt.
+func commentStatement(ctx context.Context, stmt string) (string, error) {
+	comment, ok := ctx.Value(sqlCommentKey{}).(string)
+	if !ok || ClassifyStatement(stmt) == StatementUnknown {
+		return stmt, nil
+	}
+	if strings.Contains(comment, "*/") {
+		return "", fmt.Errorf("Invalid SQL comment %q: must not contain */", comment)
+	}
+	for _, r := range comment {
+		if r < ' ' && r != '\t' && r != '\n' || r == 0x7f {
+			return "", fmt.Errorf("Invalid SQL comment %q: must not contain control characters", comment)
+		}
+	}
+	// The spaces keep a comment ending in "*" from closing early, and
+	// one starting with "+" from being read as an optimizer hint.
+	return "/* " + comment + " */ " + stmt, nil
+}
@@ -0,0 +1,65 @@
+package hive
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestWithSQLComment(t *testing.T) {
+	svc := newFakeService()
+	svc.results = map[string][]*inf.TRowSet{
+		"SET mapreduce.job.queuename": {stringBatch("mapreduce.job.queuename=default")},
+		"SET tez.queue.name":          {stringBatch("tez.queue.name=default")},
+	}
+	conn := connectFake(t, svc, testOptions())
+	ctx := WithSQLComment(context.Background(), "job=etl run=42*")
+
+	rs, err := conn.QueryContext(WithResourceQueue(ctx, "etl"), "SELECT * FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	rs.Close(ctx)
+	if _, _, err := conn.ExecCount(ctx, "USE db"); err != nil {
+		t.Fatalf("Exec error: %v", err)
+	}
+
+	expected := []string{
+		"SET mapreduce.job.queuename",
+		"SET mapreduce.job.queuename=etl",
+		"SET tez.queue.name",
+		"SET tez.queue.name=etl",
+		"/* job=etl run=42* */ SELECT * FROM t",
+		"SET tez.queue.name=default",
+		"SET mapreduce.job.queuename=default",
+		"USE db",
+	}
+	if got := svc.executed(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected statements\n%q\ngot\n%q", expected, got)
+	}
+}
+
+func TestWithSQLCommentRejectsEscapes(t *testing.T) {
+	svc := newFakeService()
+	conn := connectFake(t, svc, testOptions())
+	sent := len(svc.executed())
+
+	for _, comment := range []string{
+		"run=1 */ DROP TABLE t; /*",
+		"run=1*/",
+		"run=1\x00",
+		"run=1\r",
+	} {
+		ctx := WithSQLComment(context.Background(), comment)
+		_, err := conn.QueryContext(ctx, "SELECT * FROM t")
+		if err == nil || !strings.Contains(err.Error(), "Invalid SQL comment") {
+			t.Errorf("expected %q to be rejected, got %v", comment, err)
+		}
+	}
+	if got := svc.executed()[sent:]; len(got) != 0 {
+		t.Errorf("expected nothing sent, got %q", got)
+	}
+}
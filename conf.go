@@ -0,0 +1,53 @@
+package hive
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// GetConf reads the session's effective value of a configuration key
+// with SET <key>, which reports settings made in the session, through
+// Options.SessionConf or SET, as well as the server's defaults. ok is
+// false if the key isn't set at all.
+func (c *Connection) GetConf(ctx context.Context, key string) (value string, ok bool, err error) {
+	if key == "" || strings.ContainsAny(key, "= \t\r\n;") {
+		return "", false, fmt.Errorf("Invalid configuration key %q", key)
+	}
+	return c.readConf(ctx, key)
+}
+
+// AllConf reads the session's whole configuration with SET -v: every
+// Hive and Hadoop setting, and the server's environment and system
+// properties, keyed as env:NAME and system:name. Settings the server
+// hides, such as passwords listed in hive.conf.hidden.list, are left out
+// by the server.
+func (c *Connection) AllConf(ctx context.Context) (map[string]string, error) {
+	executeReq := inf.NewTExecuteStatementReq()
+	executeReq.Statement = "SET -v"
+	rs, err := c.submit(ctx, executeReq)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close(ctx)
+
+	conf := map[string]string{}
+	for {
+		values, err := rs.NextValues(ctx)
+		if err == io.EOF {
+			return conf, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Error reading configuration: %v", err)
+		}
+		if len(values) == 0 || values[0] == nil {
+			continue
+		}
+		if key, value, ok := strings.Cut(fmt.Sprint(values[0]), "="); ok && key != "" {
+			conf[key] = value
+		}
+	}
+}
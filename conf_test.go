@@ -0,0 +1,65 @@
+package hive
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestGetConf(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("set", inf.TTypeId_STRING_TYPE, 1)}
+	svc.results = map[string][]*inf.TRowSet{
+		"SET hive.execution.engine": {stringBatch("hive.execution.engine=tez")},
+		"SET no.such.key":           {stringBatch("no.such.key is undefined")},
+	}
+	conn := connectFake(t, svc, testOptions())
+
+	if value, ok, err := conn.GetConf(ctx, "hive.execution.engine"); err != nil || !ok || value != "tez" {
+		t.Errorf("expected tez, got %q (ok %v, err %v)", value, ok, err)
+	}
+	if value, ok, err := conn.GetConf(ctx, "no.such.key"); err != nil || ok {
+		t.Errorf("expected an unset key, got %q (ok %v, err %v)", value, ok, err)
+	}
+	before := len(svc.executed())
+	if _, _, err := conn.GetConf(ctx, "a=b"); err == nil {
+		t.Error("expected an error for a key that would set a value")
+	}
+	if len(svc.executed()) != before {
+		t.Error("expected no statement for an invalid key")
+	}
+}
+
+func TestAllConf(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("set", inf.TTypeId_STRING_TYPE, 1)}
+	svc.results = map[string][]*inf.TRowSet{
+		"SET -v": {
+			stringBatch("hive.execution.engine=tez", "hive.exec.parallel=true"),
+			stringBatch("env:HOME=/home/hive", "mapreduce.job.name=", "system:java.version=1.8.0_392"),
+		},
+	}
+	conn := connectFake(t, svc, testOptions())
+
+	conf, err := conn.AllConf(context.Background())
+	if err != nil {
+		t.Fatalf("AllConf error: %v", err)
+	}
+	want := map[string]string{
+		"hive.execution.engine": "tez",
+		"hive.exec.parallel":    "true",
+		"env:HOME":              "/home/hive",
+		"mapreduce.job.name":    "",
+		"system:java.version":   "1.8.0_392",
+	}
+	if len(conf) != len(want) {
+		t.Errorf("expected %d settings, got %v", len(want), conf)
+	}
+	for k, v := range want {
+		if got, ok := conf[k]; !ok || got != v {
+			t.Errorf("expected %s=%q, got %q (present %v)", k, v, got, ok)
+		}
+	}
+}
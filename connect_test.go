@@ -0,0 +1,391 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestConnectSelectsDatabase(t *testing.T) {
+	options := testOptions()
+	options.Database = "sales"
+
+	connects := map[string]func(hostPort string) (*Connection, error){
+		"Connect": func(hostPort string) (*Connection, error) {
+			return Connect(hostPort, options)
+		},
+		"ConnectWithUser": func(hostPort string) (*Connection, error) {
+			return ConnectWithUser(hostPort, "user", "secret", options)
+		},
+		"ConnectWithUserContext": func(hostPort string) (*Connection, error) {
+			return ConnectWithUserContext(context.Background(), hostPort, "user", "secret", options)
+		},
+	}
+
+	for name, connect := range connects {
+		t.Run(name, func(t *testing.T) {
+			svc := newFakeService()
+			conn, err := connect(startFakeServer(t, svc))
+			if err != nil {
+				t.Fatalf("%s error: %v", name, err)
+			}
+			defer conn.Close()
+
+			if db := svc.sessions[0].Configuration["use:database"]; db != "sales" {
+				t.Errorf("expected use:database in the session configuration, got %q", db)
+			}
+			if statements := svc.executed(); len(statements) != 0 {
+				t.Errorf("expected no USE statement on a V7 server, got %q", statements)
+			}
+		})
+	}
+}
+
+func TestConnectSelectsDatabaseOnOldServer(t *testing.T) {
+	options := testOptions()
+	options.Database = "sales"
+
+	svc := newFakeService()
+	svc.protocol = inf.TProtocolVersion_HIVE_CLI_SERVICE_PROTOCOL_V5
+	conn := connectFake(t, svc, options)
+	defer conn.Close()
+
+	statements := svc.executed()
+	if len(statements) != 1 || statements[0] != "USE `sales`" {
+		t.Errorf("expected a single USE statement, got %q", statements)
+	}
+	if info, _ := conn.SessionInfo(context.Background()); info.Database != "sales" {
+		t.Errorf("expected the database to be tracked, got %q", info.Database)
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	if err := DefaultOptions.Validate(); err != nil {
+		t.Fatalf("DefaultOptions should be valid: %v", err)
+	}
+
+	invalid := map[string]func(o *Options){
+		"negative poll interval":  func(o *Options) { o.PollIntervalSeconds = -1 },
+		"frame over message size": func(o *Options) { o.MaxMessageSize = 1024; o.MaxFrameSize = 2048 },
+		"negative retries":        func(o *Options) { o.ConnectRetries = -1 },
+		"nanosecond timeout":      func(o *Options) { o.SocketTimeout = 5000 },
+		"negative timeout":        func(o *Options) { o.ConnectTimeout = -time.Second },
+		"password without user":   func(o *Options) { o.Password = "secret" },
+		"min over max batch size": func(o *Options) { o.MinBatchSize = 100; o.MaxBatchSize = 10 },
+		"anonymous with user":     func(o *Options) { o.Anonymous = true; o.Username = "ann" },
+		"timestamp mode with location": func(o *Options) {
+			o.TimestampMode = AssumeUTC
+			o.Location = time.UTC
+		},
+		"nil timestamp location": func(o *Options) { o.TimestampMode = AssumeLocation(nil) },
+	}
+
+	for name, mutate := range invalid {
+		t.Run(name, func(t *testing.T) {
+			options := DefaultOptions
+			mutate(&options)
+			if err := options.Validate(); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+
+	// Zero timeouts mean no timeout.
+	options := DefaultOptions
+	options.ConnectTimeout = 0
+	options.SocketTimeout = 0
+	options.FetchTimeout = 0
+	options.OperationIdleTimeout = 0
+	options.QueryDefaults.Timeout = 0
+	if err := options.Validate(); err != nil {
+		t.Errorf("expected zero timeouts to be accepted: %v", err)
+	}
+}
+
+func TestConnectValidatesOptions(t *testing.T) {
+	options := testOptions()
+	options.PollIntervalSeconds = -1
+
+	svc := newFakeService()
+	if _, err := ConnectContext(context.Background(), startFakeServer(t, svc), options); err == nil {
+		t.Fatal("expected Connect to reject the options")
+	}
+	if len(svc.sessions) != 0 {
+		t.Errorf("expected no session to be opened, got %d", len(svc.sessions))
+	}
+}
+
+func TestConnectClientFactory(t *testing.T) {
+	called := false
+	options := testOptions()
+	options.ClientFactory = func(transport thrift.TTransport, pf thrift.TProtocolFactory) *inf.TCLIServiceClient {
+		called = true
+		return inf.NewTCLIServiceClientFactory(transport, pf)
+	}
+
+	conn := connectFake(t, newFakeService(), options)
+	defer conn.Close()
+	if !called {
+		t.Error("expected Connect to use the ClientFactory")
+	}
+}
+
+func TestIsSuccessStatus(t *testing.T) {
+	status := func(code inf.TStatusCode) *inf.TStatus {
+		return &inf.TStatus{StatusCode: code}
+	}
+
+	cases := []struct {
+		name    string
+		status  *inf.TStatus
+		success bool
+		running bool
+	}{
+		{"nil", nil, false, false},
+		{"error", status(inf.TStatusCode_ERROR_STATUS), false, false},
+		{"invalid handle", status(inf.TStatusCode_INVALID_HANDLE_STATUS), false, false},
+		{"still executing", status(inf.TStatusCode_STILL_EXECUTING_STATUS), false, true},
+		{"success", status(inf.TStatusCode_SUCCESS_STATUS), true, false},
+		{"success with info", status(inf.TStatusCode_SUCCESS_WITH_INFO_STATUS), true, false},
+	}
+
+	for _, c := range cases {
+		if got := isSuccessStatus(c.status); got != c.success {
+			t.Errorf("%s: isSuccessStatus = %v, expected %v", c.name, got, c.success)
+		}
+		if got := isStillExecuting(c.status); got != c.running {
+			t.Errorf("%s: isStillExecuting = %v, expected %v", c.name, got, c.running)
+		}
+	}
+}
+
+func TestOpenSessionCredentials(t *testing.T) {
+	ctx := context.Background()
+	str := func(s string) *string { return &s }
+	cases := []struct {
+		name     string
+		connect  func(addr string, options Options) (*Connection, error)
+		username *string
+		password *string
+	}{
+		{"no user", func(addr string, options Options) (*Connection, error) {
+			return ConnectContext(ctx, addr, options)
+		}, nil, nil},
+		{"anonymous", func(addr string, options Options) (*Connection, error) {
+			options.Anonymous = true
+			return ConnectContext(ctx, addr, options)
+		}, str(""), str("")},
+		{"with user", func(addr string, options Options) (*Connection, error) {
+			return ConnectWithUserContext(ctx, addr, "ann", "pw", options)
+		}, str("ann"), str("pw")},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := newFakeService()
+			conn, err := tc.connect(startFakeServer(t, svc), testOptions())
+			if err != nil {
+				t.Fatalf("Connect error: %v", err)
+			}
+			defer conn.Close()
+
+			req := svc.sessions[0]
+			if !reflect.DeepEqual(req.Username, tc.username) || !reflect.DeepEqual(req.Password, tc.password) {
+				t.Errorf("expected username %v and password %v, got %v and %v",
+					tc.username, tc.password, req.Username, req.Password)
+			}
+		})
+	}
+}
+
+func TestConnectCancelDuringOpenSession(t *testing.T) {
+	svc := newFakeService()
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	svc.onOpenSession = func(*inf.TOpenSessionReq) (*inf.TOpenSessionResp, error) {
+		close(entered)
+		<-release
+		return nil, errors.New("released")
+	}
+	addr := startFakeServer(t, svc)
+	baseline := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-entered
+		cancel()
+	}()
+
+	start := time.Now()
+	conn, err := ConnectContext(ctx, addr, testOptions())
+	if conn != nil || !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v, %v", conn, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected connect to return on cancel, took %v", elapsed)
+	}
+
+	// Once the handler returns, the server sees the closed socket and
+	// everything started for the connection winds down.
+	close(release)
+	waitFor(t, func() bool { return runtime.NumGoroutine() <= baseline })
+}
+
+func TestCloseContextHonorsDeadline(t *testing.T) {
+	svc := newFakeService()
+	release := make(chan struct{})
+	defer close(release)
+	svc.onCloseSession = func(*inf.TCloseSessionReq) (*inf.TCloseSessionResp, error) {
+		<-release
+		return &inf.TCloseSessionResp{Status: okStatus()}, nil
+	}
+	conn := connectFake(t, svc, testOptions())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := conn.CloseContext(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a deadline error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected CloseContext to return at the deadline, took %v", elapsed)
+	}
+	if err := conn.Close(); err != nil {
+		t.Errorf("expected Close after CloseContext to be a no-op, got %v", err)
+	}
+}
+
+func TestCloseOperationsHonorDeadline(t *testing.T) {
+	svc := newFakeService()
+	release := make(chan struct{})
+	defer close(release)
+	svc.onCancel = func(*inf.TCancelOperationReq) (*inf.TCancelOperationResp, error) {
+		<-release
+		return &inf.TCancelOperationResp{Status: okStatus()}, nil
+	}
+	svc.onClose = func(*inf.TCloseOperationReq) (*inf.TCloseOperationResp, error) {
+		<-release
+		return &inf.TCloseOperationResp{Status: okStatus()}, nil
+	}
+	svc.onCloseSession = func(*inf.TCloseSessionReq) (*inf.TCloseSessionResp, error) {
+		<-release
+		return &inf.TCloseSessionResp{Status: okStatus()}, nil
+	}
+	conn := connectFake(t, svc, testOptions())
+	rs, err := conn.Query("SELECT * FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+
+	// One deadline for the whole shutdown: the operation, still running
+	// as far as the client knows, is cancelled and closed, then the
+	// session.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := rs.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a deadline error from RowSet.Close, got %v", err)
+	}
+	if err := conn.CloseContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a deadline error from CloseContext, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the shutdown to end at the deadline, took %v", elapsed)
+	}
+	if err := rs.Close(context.Background()); err != nil {
+		t.Errorf("expected closing the RowSet again to be a no-op, got %v", err)
+	}
+}
+
+func TestCloseOperationWithEndedContext(t *testing.T) {
+	svc := newFakeService()
+	conn := connectFake(t, svc, testOptions())
+	rs, err := conn.Query("SELECT * FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := rs.Close(ctx); err != nil {
+		t.Errorf("expected Close with an ended ctx to succeed, got %v", err)
+	}
+	if _, err := conn.Exec("SELECT 1"); err != nil {
+		t.Errorf("expected the connection to stay usable, got %v", err)
+	}
+}
+
+func TestLazyConnect(t *testing.T) {
+	svc := newFakeService()
+	var attempts int
+	svc.onOpenSession = func(*inf.TOpenSessionReq) (*inf.TOpenSessionResp, error) {
+		attempts++
+		if attempts == 1 {
+			return &inf.TOpenSessionResp{Status: errorStatus("HiveServer2 is starting")}, nil
+		}
+		return &inf.TOpenSessionResp{
+			Status:                okStatus(),
+			ServerProtocolVersion: inf.TProtocolVersion_HIVE_CLI_SERVICE_PROTOCOL_V6,
+			SessionHandle: &inf.TSessionHandle{SessionId: &inf.THandleIdentifier{
+				GUID: make([]byte, 16), Secret: make([]byte, 16),
+			}},
+		}, nil
+	}
+	options := testOptions()
+	options.LazyConnect = true
+	conn := connectFake(t, svc, options)
+	if len(svc.sessions) != 0 {
+		t.Fatalf("expected no session before first use, got %d", len(svc.sessions))
+	}
+
+	if _, err := conn.Exec("SET a=1"); err == nil || !strings.Contains(err.Error(), "OpenSession failed") {
+		t.Fatalf("expected the OpenSession error on first use, got %v", err)
+	}
+	if _, err := conn.Exec("SET a=1"); err != nil {
+		t.Fatalf("expected the second use to open the session, got %v", err)
+	}
+	if _, err := conn.Exec("SET b=2"); err != nil {
+		t.Fatalf("Exec error: %v", err)
+	}
+	if len(svc.sessions) != 2 {
+		t.Errorf("expected 2 OpenSession calls, got %d", len(svc.sessions))
+	}
+
+	conn.Close()
+	if _, err := conn.Exec("SET c=3"); err == nil {
+		t.Error("expected Exec after Close to fail")
+	}
+	if len(svc.sessions) != 2 {
+		t.Errorf("expected Close to stop reopening, got %d OpenSession calls", len(svc.sessions))
+	}
+}
+
+func TestLazyConnectUnreachable(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen error: %v", err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	options := testOptions()
+	options.LazyConnect = true
+	conn, err := ConnectContext(context.Background(), addr, options)
+	if err != nil {
+		t.Fatalf("expected a lazy Connect to succeed, got %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Query("SELECT 1"); err == nil {
+		t.Error("expected the first Query to report the connection error")
+	}
+}
@@ -7,6 +7,10 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/apache/thrift/lib/go/thrift"
@@ -26,164 +30,986 @@ type Options struct {
 	Database           string
 	MaxMessageSize     int32
 	MaxFrameSize       int32
-	ConnectTimeout     time.Duration
-	SocketTimeout      time.Duration
-	TLSConfig          *tls.Config
 	TBinaryStrictRead  *bool
 	TBinaryStrictWrite *bool
 	THeaderProtocolID  *thrift.THeaderProtocolID
+
+	// ConnectTimeout bounds dialling the server, TLS handshake included,
+	// and SocketTimeout each read and write on the connection. Zero means
+	// no timeout.
+	ConnectTimeout time.Duration
+	SocketTimeout  time.Duration
+
+	// TLSConfig, if set, makes connections use TLS. A Connection resumes
+	// its first TLS session when it dials the server again, as for a
+	// lazy reopen or a cancel over a connection of its own, which saves
+	// most of a full handshake. To let separate Connections resume each
+	// other's sessions, set TLSConfig.ClientSessionCache, e.g. to
+	// tls.NewLRUClientSessionCache(0), and share the config between them;
+	// a config with SessionTicketsDisabled never resumes.
+	//
+	// A resumed session isn't verified again: the certificate checked
+	// when it was first established is taken on trust until the session
+	// expires, so a certificate revoked in the meantime goes unnoticed,
+	// and whatever holds the cache holds the session keys. Only share
+	// a cache between configs trusting the same servers.
+	// TLSPinnedCertSHA256 is checked on resumed sessions too.
+	TLSConfig *tls.Config
+
+	// DialContext, if set, opens the network connection in place of
+	// thrift's own socket, e.g. to go through a proxy or tunnel;
+	// ConnectTimeout bounds each call. TLSConfig, if any, is applied on
+	// top. Every read and write on the connection is then bounded by
+	// SocketTimeout through the connection's deadlines, and a connection
+	// that ignores deadlines is closed shortly after one passes, so a
+	// half-open connection fails within SocketTimeout however long the
+	// call's context allows.
+	DialContext func(ctx context.Context, network, addr string) (net.Conn, error)
+
+	// FetchTimeout, if positive, bounds each FetchResults call of a
+	// query's RowSet. A fetch the server hasn't answered in time fails with
+	// ErrFetchTimeout, and the operation is cancelled over a separate
+	// short-lived connection, since HiveServer2 only reads a connection's
+	// next call once the current one is answered. The stalled reply is
+	// then read and discarded so the Connection stays usable. Unlike
+	// SocketTimeout, which applies to every read, it can be set below the
+	// time a slow but progressing fetch takes as long as each batch
+	// arrives within it.
+	FetchTimeout time.Duration
+
+	// OperationIdleTimeout, if positive, cancels and closes the RowSets
+	// of a Connection that go that long without being polled or fetched
+	// from, so operations a consumer forgot to close don't hold server
+	// resources until the session ends. The RowSet's next use then fails
+	// with ErrOperationIdleTimeout. There is no background goroutine,
+	// since calls on a Connection must not run concurrently: idle
+	// RowSets are closed when the next statement is submitted on the
+	// Connection, or when they are next used. An async query is idle
+	// while nobody polls it, so a caller that leaves one running longer
+	// than this before calling Poll or Wait must set a longer timeout.
+	// Wait keeps the query in use as long as the timeout is longer than
+	// PollIntervalSeconds.
+	OperationIdleTimeout time.Duration
+
+	// QueryDefaults holds the per-query settings Connection.QueryWith
+	// uses where its own QueryOptions leave them unset.
+	QueryDefaults QueryOptions
+
+	// TLSPinnedCertSHA256, if set, lists the hex SHA-256 fingerprints of
+	// the leaf certificates the server may present, e.g. as printed by
+	// openssl x509 -fingerprint -sha256. Connections presenting any other
+	// certificate are rejected. The check is made on top of TLSConfig's
+	// normal verification, so a self-signed certificate also needs
+	// RootCAs or InsecureSkipVerify. Setting it enables TLS even without
+	// TLSConfig.
+	TLSPinnedCertSHA256 []string
+
+	// MaxConcurrentOperations, if positive, caps the number of open
+	// RowSets on a Connection. Further queries block until one is closed.
+	MaxConcurrentOperations int
+
+	// ClientFactory, if set, builds the thrift client in place of
+	// inf.NewTCLIServiceClientFactory, e.g. to wrap the protocol for a
+	// gateway that multiplexes services.
+	ClientFactory func(transport thrift.TTransport, pf thrift.TProtocolFactory) *inf.TCLIServiceClient
+
+	// Middleware wraps every thrift call the Connection makes, the first
+	// outermost, e.g. with LoggingMiddleware, MetricsMiddleware or
+	// RetryMiddleware, or one's own RPCMiddleware. It can't be combined
+	// with ClientFactory, whose client is wrapped with WrapClient
+	// instead.
+	Middleware []RPCMiddleware
+
+	// SpoolDir, if set, enables spooling to disk: once a RowSet has
+	// fetched SpoolThresholdRows rows, the rest of the result is read from
+	// the server in one go into a temporary file in SpoolDir and served
+	// from there. This frees the server from a slow consumer without
+	// holding the whole result in memory.
+	SpoolDir           string
+	SpoolThresholdRows int64
+
+	// SessionConf is sent as the OpenSession configuration, e.g.
+	// {"set:hiveconf:hive.exec.parallel": "true"}.
+	SessionConf map[string]string
+
+	// Anonymous makes ConnectContext send an explicit empty username and
+	// password in OpenSession, for servers set up for anonymous access
+	// that reject a request without them. By default ConnectContext omits
+	// both fields, and ConnectWithUserContext always sends the ones given.
+	// This is independent of AuthMechanism, which governs the transport.
+	Anonymous bool
+
+	// AuthMechanism selects how the transport authenticates: AuthNoSASL
+	// (the default), AuthPlain or AuthLDAP.
+	AuthMechanism string
+
+	// RequireQOP, if set, is the weakest SASL quality of protection
+	// accepted: QOPAuth, QOPAuthInt or QOPAuthConf. A weaker negotiation,
+	// or none with AuthNoSASL, fails with ErrInsufficientQOP. The PLAIN
+	// mechanism behind AuthPlain and AuthLDAP only ever negotiates
+	// QOPAuth, so confidentiality there has to come from TLSConfig.
+	RequireQOP string
+
+	// ConnectRetries is the number of additional attempts made to open
+	// the socket when the server refuses the connection, e.g. during a
+	// rolling restart. ConnectRetryBackoff is the pause between attempts.
+	ConnectRetries      int
+	ConnectRetryBackoff time.Duration
+	// RetryUnreachable extends the dial retries to errors other than a
+	// refused connection, such as no route to host or DNS failures.
+	RetryUnreachable bool
+
+	// TypeMapper, if set, converts every fetched cell before it is
+	// returned. See DefaultTypeMapper for the default representation.
+	TypeMapper TypeMapper
+
+	// DecodeMaps makes RowSets decode MAP columns instead of returning
+	// the server's text rendering. Maps with STRING, VARCHAR or CHAR keys
+	// become map[string]interface{}; maps with other keys, such as
+	// MAP<INT,STRING>, become []MapEntry in server order with keys of the
+	// key type. Nested values are decoded by type too: ARRAYs as
+	// []interface{}, STRUCTs as map[string]interface{} and primitives as
+	// by DefaultTypeMapper. A TypeMapper sees the decoded value.
+	DecodeMaps bool
+
+	// InvalidUTF8 says what to do with string cells that aren't valid
+	// UTF-8: UTF8Keep, the default, passes them on as sent, UTF8Replace
+	// replaces the bad bytes with U+FFFD and UTF8Error fails the fetch.
+	// It applies before DecodeMaps and TypeMapper.
+	InvalidUTF8 UTF8Policy
+
+	// RowInterceptor, if set, is called with every row of a fetched batch
+	// as soon as it is decoded, after DecodeMaps and TypeMapper, and may
+	// change the row's cells in place, e.g. to set sensitive columns to
+	// nil or replace them with a hash. Only the changed row is kept: Next,
+	// Scan, NextValues, spool files and Tail all see it, and the raw
+	// batch is dropped. NextBatch and StreamBatches, which hand out the
+	// raw batch, fail instead. It runs on the fetch path, once per row,
+	// so it should be fast; schema must not be changed.
+	RowInterceptor func(schema []Column, row []interface{})
+
+	// AuditHook, if set, is called with every statement the server
+	// accepts, as sent, and the operation ID it was given, right after
+	// ExecuteStatement returns and before the call that sent it returns.
+	// It sees the final text: Query, Exec, ExecBatch, ExecScript and the
+	// session's own statements such as USE alike, after QualifyTables.
+	// ExecBatch's statements carry their arguments as literals; set
+	// AuditRedactLiterals to log them with every string and numeric
+	// literal replaced by "?" instead, see RedactLiterals. Unlike Events,
+	// the hook is never skipped, so it should return quickly.
+	AuditHook           func(ctx context.Context, finalSQL string, operationID string)
+	AuditRedactLiterals bool
+
+	// Events, if set, receives lifecycle events for sessions and
+	// statements. Sends never block: events are dropped when the channel
+	// is full.
+	Events chan<- Event
+
+	// MaxStatementBytes caps the length of a statement. Longer ones fail
+	// with ErrStatementTooLarge before being sent, and ExecBatch splits
+	// its inserts to fit. It defaults to nine tenths of MaxMessageSize.
+	MaxStatementBytes int
+
+	// AllowMultiStatement passes statements holding several, separated
+	// by semicolons, to the server as they are. By default they fail
+	// with ErrMultipleStatements, since HiveServer2 runs one statement
+	// per call; set it for servers and proxies that accept more. Either
+	// way a single trailing semicolon is dropped.
+	AllowMultiStatement bool
+
+	// PreferColumnarResults, if set to false, asks for row-based results
+	// by opening the session at protocol V5, the last version before
+	// columnar results. Columnar results are smaller on the wire and
+	// cheaper to decode, see BenchmarkDecodeColumnar and
+	// BenchmarkDecodeRows; row-based ones are mainly useful against
+	// proxies that mishandle columnar payloads. Unset or true keeps the
+	// columnar default. Either way NextValues and Scan decode whatever
+	// format the server sends.
+	PreferColumnarResults *bool
+
+	// OnFeatureDowngrade, if set, is called each time a session opens
+	// with the features the client asks for that the server can't
+	// provide, such as FeatureAsync from a server below protocol V2.
+	// Those requests are downgraded silently otherwise: the server
+	// ignores them and queries still run, without the feature. It isn't
+	// called when nothing is downgraded.
+	OnFeatureDowngrade func(features []string)
+
+	// MaxResultRows, if positive, caps the rows QueryAll holds in memory:
+	// a larger result fails with ErrTooManyRows.
+	MaxResultRows int64
+
+	// MaxFetchBatches, if positive, caps the FetchResults calls a RowSet
+	// makes for its result, against queries that trickle out tiny batches
+	// without end: the next fetch fails with ErrTooManyBatches instead,
+	// and the operation is cancelled. Zero means no limit.
+	MaxFetchBatches int64
+
+	// TrackIntegrity makes RowSets keep a row count and checksum of the
+	// rows they return, reported by RowSet.Integrity.
+	TrackIntegrity bool
+
+	// Location, if set, is the zone NextValues decodes TIMESTAMP and DATE
+	// values in. Otherwise the zone found by Connection.ServerTimeZone is
+	// used, or UTC if it hasn't been looked up.
+	Location *time.Location
+
+	// TimestampMode, if set, makes the zone of decoded TIMESTAMP and DATE
+	// values explicit instead of depending on whether
+	// Connection.ServerTimeZone has been called: AssumeUTC,
+	// AssumeServerTZ or AssumeLocation(loc). It replaces Location.
+	TimestampMode TimestampMode
+
+	// TargetBatchBytes, if positive, makes fetches adaptive: the first
+	// batch asks for MinBatchSize rows, and later ones for as many rows as
+	// fit in TargetBatchBytes at the row width seen so far, clamped to
+	// [MinBatchSize, MaxBatchSize]. MinBatchSize defaults to 100 and
+	// MaxBatchSize to BatchSize. BatchSize is used as is when
+	// TargetBatchBytes is zero.
+	TargetBatchBytes int64
+	MinBatchSize     int64
+	MaxBatchSize     int64
+
+	// QualifyTables makes the Connection prefix unqualified table names
+	// in FROM and JOIN clauses and INSERT targets with Database before
+	// sending a statement, so that a multi-tenant service can't reach
+	// another database by accident after a stray USE. The rewrite is done
+	// by QualifyTableNames, see there for its limits; a statement it gets
+	// wrong can be sent as written under WithoutTableQualification. It
+	// requires Database.
+	QualifyTables bool
+
+	// InteractiveGuard, for notebook-style tools, stops accidental full
+	// scans: a single SELECT, or WITH ... SELECT, without a LIMIT outside
+	// parentheses gets "LIMIT InteractiveLimit" appended, or is rejected
+	// with ErrUnboundedQuery if InteractiveLimit is zero. Statements it
+	// can't tell are plain reads, such as multi-statement scripts, are
+	// sent as written. A query can opt out under WithUnboundedQuery.
+	InteractiveGuard bool
+	InteractiveLimit int64
+
+	// CoalesceSessionConf makes WithSession, and WithResourceQueue which
+	// builds on it, skip settings the session is known to have already:
+	// those in SessionConf or set by a SET key=value statement run
+	// through the Connection, as reported by SessionInfo. Only changed
+	// keys are SET, and their prior values are taken from what is known
+	// rather than read back with SET <key>. Settings changed in ways the
+	// client can't see, e.g. inside a script file run by the server, make
+	// the known values stale.
+	CoalesceSessionConf bool
+
+	// MetadataCacheTTL, if positive, makes GetSchemas, GetTables and
+	// GetColumns cache their results for that long, keyed by their
+	// arguments. DDL statements run through the same Connection clear the
+	// cache; changes made elsewhere, by other connections or directly in
+	// the metastore, are only seen once the entries expire or
+	// ClearMetadataCache is called.
+	MetadataCacheTTL time.Duration
+
+	// LazyConnect makes the Connect variants return without dialling.
+	// The transport and session are opened by the first call that needs
+	// them, such as Query or Exec, which then reports any connection
+	// error; a failed open is retried by the next call. This lets a
+	// service start while Hive is down, at the cost of finding a bad
+	// address or credentials only on first use. Options are still
+	// validated up front.
+	LazyConnect bool
+
+	// testClock replaces the real clock in tests.
+	testClock clock
 }
 
 var (
 	DefaultOptions = Options{
 		PollIntervalSeconds: 5,
-		BatchSize:           10000,
-		ConnectTimeout:      5000,
-		SocketTimeout:       5000,
+		BatchSize:           defaultBatchSize,
+		ConnectTimeout:      5 * time.Second,
+		SocketTimeout:       60 * time.Second,
 	}
 )
 
+// Validate reports the first invalid value or combination in o. It is
+// called by the Connect variants before anything is dialled.
+//
+// The rules are:
+//   - PollIntervalSeconds, MaxMessageSize, MaxFrameSize,
+//     ConnectRetries, ConnectRetryBackoff, MaxConcurrentOperations,
+//     MetadataCacheTTL, SpoolThresholdRows, TargetBatchBytes,
+//     MinBatchSize, MaxBatchSize, MaxStatementBytes, MaxResultRows,
+//     MaxFetchBatches and QueryDefaults.FetchSize may not be negative.
+//   - MaxFrameSize may not exceed MaxMessageSize when both are set.
+//   - MinBatchSize may not exceed MaxBatchSize when both are set.
+//   - BatchSize 0 or below is accepted, and replaced when connecting by
+//     DefaultOptions' 10000 with a logged warning.
+//   - ConnectTimeout, SocketTimeout, FetchTimeout,
+//     OperationIdleTimeout and QueryDefaults.Timeout may not be
+//     negative. Zero is accepted rather than rejected, since each of
+//     them takes it to mean no timeout; a positive value under a
+//     millisecond is rejected as a unit mistake (a plain integer is
+//     nanoseconds, not milliseconds).
+//   - Password requires Username.
+//   - Anonymous excludes Username.
+//   - Middleware excludes ClientFactory.
+//   - InvalidUTF8 must be UTF8Keep, UTF8Replace or UTF8Error.
+//   - TimestampMode excludes Location, and AssumeLocation needs a
+//     non-nil location.
+//   - QualifyTables requires Database.
+//   - InteractiveLimit may not be negative, and requires
+//     InteractiveGuard.
+//   - THeaderProtocolID, if set, must name a known protocol.
+//   - TLSPinnedCertSHA256 entries must be hex SHA-256 digests.
+//   - RequireQOP must be empty, QOPAuth, QOPAuthInt or QOPAuthConf.
+//   - AuthMechanism must be empty, AuthNoSASL, AuthPlain or AuthLDAP.
+//     AuthLDAP additionally needs a username and password, checked when
+//     connecting since ConnectWithUser passes them separately.
+func (o Options) Validate() error {
+	switch {
+	case o.PollIntervalSeconds < 0:
+		return fmt.Errorf("Invalid PollIntervalSeconds %d: must not be negative", o.PollIntervalSeconds)
+	case o.MaxMessageSize < 0:
+		return fmt.Errorf("Invalid MaxMessageSize %d: must not be negative", o.MaxMessageSize)
+	case o.MaxFrameSize < 0:
+		return fmt.Errorf("Invalid MaxFrameSize %d: must not be negative", o.MaxFrameSize)
+	case o.MaxMessageSize > 0 && o.MaxFrameSize > o.MaxMessageSize:
+		return fmt.Errorf("Invalid MaxFrameSize %d: exceeds MaxMessageSize %d", o.MaxFrameSize, o.MaxMessageSize)
+	case o.SpoolThresholdRows < 0:
+		return fmt.Errorf("Invalid SpoolThresholdRows %d: must not be negative", o.SpoolThresholdRows)
+	case o.ConnectRetries < 0:
+		return fmt.Errorf("Invalid ConnectRetries %d: must not be negative", o.ConnectRetries)
+	case o.MaxConcurrentOperations < 0:
+		return fmt.Errorf("Invalid MaxConcurrentOperations %d: must not be negative", o.MaxConcurrentOperations)
+	case o.ConnectRetryBackoff < 0:
+		return fmt.Errorf("Invalid ConnectRetryBackoff %v: must not be negative", o.ConnectRetryBackoff)
+	case o.MetadataCacheTTL < 0:
+		return fmt.Errorf("Invalid MetadataCacheTTL %v: must not be negative", o.MetadataCacheTTL)
+	case o.MaxStatementBytes < 0:
+		return fmt.Errorf("Invalid MaxStatementBytes %d: must not be negative", o.MaxStatementBytes)
+	case o.MaxResultRows < 0:
+		return fmt.Errorf("Invalid MaxResultRows %d: must not be negative", o.MaxResultRows)
+	case o.MaxFetchBatches < 0:
+		return fmt.Errorf("Invalid MaxFetchBatches %d: must not be negative", o.MaxFetchBatches)
+	case o.QueryDefaults.FetchSize < 0:
+		return fmt.Errorf("Invalid QueryDefaults.FetchSize %d: must not be negative", o.QueryDefaults.FetchSize)
+	case o.TargetBatchBytes < 0:
+		return fmt.Errorf("Invalid TargetBatchBytes %d: must not be negative", o.TargetBatchBytes)
+	case o.MinBatchSize < 0:
+		return fmt.Errorf("Invalid MinBatchSize %d: must not be negative", o.MinBatchSize)
+	case o.MaxBatchSize < 0:
+		return fmt.Errorf("Invalid MaxBatchSize %d: must not be negative", o.MaxBatchSize)
+	case o.MaxBatchSize > 0 && o.MinBatchSize > o.MaxBatchSize:
+		return fmt.Errorf("Invalid MinBatchSize %d: exceeds MaxBatchSize %d", o.MinBatchSize, o.MaxBatchSize)
+	case o.Password != "" && o.Username == "":
+		return errors.New("Invalid options: Password is set without Username")
+	case o.Anonymous && o.Username != "":
+		return errors.New("Invalid options: Anonymous is set with Username")
+	case o.InvalidUTF8 < UTF8Keep || o.InvalidUTF8 > UTF8Error:
+		return fmt.Errorf("Invalid InvalidUTF8 %d: must be UTF8Keep, UTF8Replace or UTF8Error", o.InvalidUTF8)
+	case len(o.Middleware) > 0 && o.ClientFactory != nil:
+		return errors.New("Invalid options: Middleware is set with ClientFactory; wrap the factory's client with WrapClient")
+	case o.TimestampMode.kind != timestampUnset && o.Location != nil:
+		return errors.New("Invalid options: TimestampMode is set with Location, which it replaces")
+	case o.TimestampMode.kind == timestampLocation && o.TimestampMode.loc == nil:
+		return errors.New("Invalid options: TimestampMode AssumeLocation has a nil location")
+	case o.QualifyTables && o.Database == "":
+		return errors.New("Invalid options: QualifyTables is set without Database")
+	case o.InteractiveLimit < 0:
+		return fmt.Errorf("Invalid InteractiveLimit %d: must not be negative", o.InteractiveLimit)
+	case o.InteractiveLimit > 0 && !o.InteractiveGuard:
+		return errors.New("Invalid options: InteractiveLimit is set without InteractiveGuard")
+	}
+
+	switch o.authMechanism() {
+	case AuthNoSASL, AuthPlain, AuthLDAP:
+	default:
+		return fmt.Errorf("Invalid AuthMechanism %q", o.AuthMechanism)
+	}
+	if o.RequireQOP != "" && qopRank(o.RequireQOP) == 0 {
+		return fmt.Errorf("Invalid RequireQOP %q", o.RequireQOP)
+	}
+
+	if err := validateTimeout("ConnectTimeout", o.ConnectTimeout); err != nil {
+		return err
+	}
+	if err := validateTimeout("SocketTimeout", o.SocketTimeout); err != nil {
+		return err
+	}
+	if err := validateTimeout("FetchTimeout", o.FetchTimeout); err != nil {
+		return err
+	}
+	if err := validateTimeout("OperationIdleTimeout", o.OperationIdleTimeout); err != nil {
+		return err
+	}
+	if err := validateTimeout("QueryDefaults.Timeout", o.QueryDefaults.Timeout); err != nil {
+		return err
+	}
+
+	if o.THeaderProtocolID != nil {
+		if err := o.THeaderProtocolID.Validate(); err != nil {
+			return fmt.Errorf("Invalid THeaderProtocolID: %v", err)
+		}
+	}
+	for _, pin := range o.TLSPinnedCertSHA256 {
+		if _, err := parsePin(pin); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateTimeout(name string, d time.Duration) error {
+	if d < 0 {
+		return fmt.Errorf("Invalid %s %v: must not be negative", name, d)
+	}
+	if d > 0 && d < time.Millisecond {
+		return fmt.Errorf("Invalid %s %v: under a millisecond, probably a unit mistake", name, d)
+	}
+	return nil
+}
+
 type Connection struct {
-	thrift  *inf.TCLIServiceClient
-	session *inf.TSessionHandle
-	options Options
+	thrift   *inf.TCLIServiceClient
+	session  *inf.TSessionHandle
+	options  Options
+	protocol inf.TProtocolVersion
+	// calls issues requests the generated client doesn't know about.
+	calls     thrift.TClient
+	transport thrift.TTransport
+	socket    socketTransport
+	// protocolFactory reads replies outside the generated client.
+	protocolFactory thrift.TProtocolFactory
+	qop             string
+	// tlsSessions lets the Connection's later dials resume its first TLS
+	// session, see Options.TLSConfig.
+	tlsSessions tls.ClientSessionCache
+
+	// hostPort and the credentials are kept for a lazy open.
+	hostPort           string
+	username, password *string
+	// openMu serializes the lazy open; closed stops it once Close is
+	// called.
+	openMu sync.Mutex
+	closed bool
+
+	mu          sync.Mutex
+	database    string
+	sessionConf map[string]string
+	nameStyle   *NameStyle
+	operations  map[*rowSet]struct{}
+	slots       chan struct{}
+	location    *time.Location
+	metadata    map[string]metadataEntry
+	warnings    []string
+	remoteAddr  string
+	// keyReset caches whether the server clears a single setting with
+	// RESET <key>, see WithSession.
+	keyReset *bool
+	// setup holds the statements of AddJar and CreateTemporaryFunction,
+	// run again in a new session.
+	setup []string
 }
 
+// Connect opens a session without credentials.
+//
+// Deprecated: Use ConnectContext.
 func Connect(hostPort string, options Options) (*Connection, error) {
-	tc := &thrift.TConfiguration{
-		MaxMessageSize:     options.MaxMessageSize,
-		MaxFrameSize:       options.MaxFrameSize,
-		ConnectTimeout:     options.ConnectTimeout,
-		SocketTimeout:      options.SocketTimeout,
-		TLSConfig:          options.TLSConfig,
-		TBinaryStrictRead:  options.TBinaryStrictRead,
-		TBinaryStrictWrite: options.TBinaryStrictWrite,
-		THeaderProtocolID:  options.THeaderProtocolID,
+	return ConnectContext(context.Background(), hostPort, options)
+}
+
+// ConnectContext opens a session like Connect. The context bounds the
+// dial retries and the OpenSession call. Set Options.Anonymous to send
+// an explicit empty username instead of none.
+func ConnectContext(ctx context.Context, hostPort string, options Options) (*Connection, error) {
+	if options.Anonymous {
+		var empty string
+		return connect(ctx, hostPort, &empty, &empty, options)
 	}
-	transport := thrift.NewTSocketConf(hostPort, tc)
+	return connect(ctx, hostPort, nil, nil, options)
+}
+
+// ConnectWithUser opens a session with the given credentials.
+//
+// Deprecated: Use ConnectWithUserContext.
+func ConnectWithUser(hostPort, username, password string, options Options) (*Connection, error) {
+	return ConnectWithUserContext(context.Background(), hostPort, username, password, options)
+}
+
+// ConnectWithUserContext opens a session like ConnectWithUser. The
+// context bounds the dial retries and the OpenSession call.
+func ConnectWithUserContext(ctx context.Context, hostPort, username, password string, options Options) (*Connection, error) {
+	return connect(ctx, hostPort, &username, &password, options)
+}
 
-	if err := transport.Open(); err != nil {
+// connect is the shared implementation of the Connect variants. A nil
+// username or password is omitted from the OpenSession request.
+func connect(ctx context.Context, hostPort string, username, password *string, options Options) (*Connection, error) {
+	if err := options.Validate(); err != nil {
 		return nil, err
 	}
+	options = withDefaultBatchSize(options)
+	warnMessageSize(options)
 
-	if transport == nil {
-		return nil, errors.New("nil thrift transport")
+	if options.authMechanism() != AuthNoSASL {
+		if _, _, err := saslCredentials(username, password, options); err != nil {
+			return nil, err
+		}
 	}
 
-	/*
-		NB: hive 0.13's default is a TSaslProtocol, but
-		there isn't a golang implementation in apache thrift as
-		of this writing.
-	*/
-	protocol := thrift.NewTBinaryProtocolFactoryConf(tc)
-	client := inf.NewTCLIServiceClientFactory(transport, protocol)
-	s := inf.NewTOpenSessionReq()
-	s.ClientProtocol = 6
-	session, err := client.OpenSession(context.Background(), s)
-	if err != nil {
+	conn := &Connection{
+		options:     options,
+		hostPort:    hostPort,
+		username:    username,
+		password:    password,
+		sessionConf: map[string]string{},
+		operations:  map[*rowSet]struct{}{},
+		tlsSessions: tls.NewLRUClientSessionCache(0),
+	}
+	if options.MaxConcurrentOperations > 0 {
+		conn.slots = make(chan struct{}, options.MaxConcurrentOperations)
+	}
+	for k, v := range options.SessionConf {
+		conn.sessionConf[k] = v
+	}
+	if options.LazyConnect {
+		return conn, nil
+	}
+	if err := conn.open(ctx); err != nil {
 		return nil, err
 	}
+	return conn, nil
+}
 
-	return &Connection{client, session.SessionHandle, options}, nil
+// dialed is a transport opened by dial.
+type dialed struct {
+	// socket is the TCP or TLS socket under transport.
+	socket    socketTransport
+	transport thrift.TTransport
+	protocol  thrift.TProtocolFactory
+	client    *inf.TCLIServiceClient
+	qop       string
+	// stop ends the closing of socket on cancellation, reporting false
+	// if it has already happened.
+	stop func() bool
 }
 
-func ConnectWithUser(hostPort, username, password string, options Options) (*Connection, error) {
+// socketTransport is implemented by thrift's TSocket and TSSLSocket.
+type socketTransport interface {
+	thrift.TTransport
+	SetSocketTimeout(timeout time.Duration) error
+}
+
+// dial opens a transport to c.hostPort, negotiating SASL if configured,
+// and builds a client on it. The thrift calls made on it don't watch
+// ctx, so until stop is called cancelling ctx closes the socket to
+// unblock them.
+func (c *Connection) dial(ctx context.Context) (*dialed, error) {
+	options := c.options
+	tlsConfig, err := options.tlsConfig(c.tlsSessions)
+	if err != nil {
+		return nil, err
+	}
 	tc := &thrift.TConfiguration{
 		MaxMessageSize:     options.MaxMessageSize,
 		MaxFrameSize:       options.MaxFrameSize,
 		ConnectTimeout:     options.ConnectTimeout,
 		SocketTimeout:      options.SocketTimeout,
-		TLSConfig:          options.TLSConfig,
+		TLSConfig:          tlsConfig,
 		TBinaryStrictRead:  options.TBinaryStrictRead,
 		TBinaryStrictWrite: options.TBinaryStrictWrite,
 		THeaderProtocolID:  options.THeaderProtocolID,
 	}
-	transport := thrift.NewTSocketConf(hostPort, tc)
-	if err := transport.Open(); err != nil {
+	var user, pass string
+	if options.authMechanism() != AuthNoSASL {
+		// Checked by connect already.
+		user, pass, _ = saslCredentials(c.username, c.password, options)
+	}
+
+	socket, err := dialSocket(ctx, c.hostPort, tc, options)
+	if err != nil {
 		return nil, err
 	}
+	stop := context.AfterFunc(ctx, func() { socket.Close() })
 
-	if transport == nil {
-		return nil, errors.New("nil thrift transport")
+	var (
+		transport thrift.TTransport = socket
+		qop       string
+	)
+	if options.authMechanism() != AuthNoSASL {
+		var err error
+		if qop, err = saslHandshake(ctx, socket, options, user, pass); err != nil {
+			stop()
+			socket.Close()
+			err = cancelledConnectError(ctx, err)
+			options.emit(ErrorOccurred{Err: err})
+			return nil, err
+		}
+		transport = thrift.NewTFramedTransportConf(socket, tc)
+	}
+	if err := checkQOP(qop, options); err != nil {
+		stop()
+		socket.Close()
+		options.emit(ErrorOccurred{Err: err})
+		return nil, err
 	}
 
-	/*
-		NB: hive 0.13's default is a TSaslProtocol, but
-		there isn't a golang implementation in apache thrift as
-		of this writing.
-	*/
 	protocol := thrift.NewTBinaryProtocolFactoryConf(tc)
-	client := inf.NewTCLIServiceClientFactory(transport, protocol)
+	newClient := func(t thrift.TTransport, f thrift.TProtocolFactory) *inf.TCLIServiceClient {
+		return inf.NewTCLIServiceClient(WrapClient(thrift.NewTStandardClient(f.GetProtocol(t), f.GetProtocol(t)), options.Middleware...))
+	}
+	if options.ClientFactory != nil {
+		newClient = options.ClientFactory
+	}
+	client := newClient(transport, protocol)
+	if client == nil {
+		stop()
+		transport.Close()
+		return nil, errors.New("ClientFactory returned a nil client")
+	}
+	return &dialed{socket: socket, transport: transport, protocol: protocol, client: client, qop: qop, stop: stop}, nil
+}
+
+// open dials c.hostPort and opens the session.
+func (c *Connection) open(ctx context.Context) error {
+	options := c.options
+	hostPort, username, password := c.hostPort, c.username, c.password
+
+	d, err := c.dial(ctx)
+	if err != nil {
+		return err
+	}
+	stop := d.stop
+	defer stop()
+	client, transport, protocol := d.client, d.transport, d.protocol
+
 	s := inf.NewTOpenSessionReq()
-	s.ClientProtocol = 6
-	s.Username = &username
-	s.Password = &password
-	session, err := client.OpenSession(context.Background(), s)
+	s.ClientProtocol = inf.TProtocolVersion_HIVE_CLI_SERVICE_PROTOCOL_V7
+	if options.PreferColumnarResults != nil && !*options.PreferColumnarResults {
+		s.ClientProtocol = inf.TProtocolVersion_HIVE_CLI_SERVICE_PROTOCOL_V5
+	}
+	s.Username = username
+	s.Password = password
+	s.Configuration = options.SessionConf
+	if options.Database != "" {
+		s.Configuration = make(map[string]string, len(options.SessionConf)+1)
+		for k, v := range options.SessionConf {
+			s.Configuration[k] = v
+		}
+		s.Configuration[useDatabaseKey] = options.Database
+	}
+	session, err := client.OpenSession(ctx, s)
 	if err != nil {
-		return nil, err
+		transport.Close()
+		err = cancelledConnectError(ctx, err)
+		options.emit(ErrorOccurred{Err: err})
+		return err
+	}
+
+	if !isSuccessStatus(session.Status) {
+		transport.Close()
+		err := fmt.Errorf("OpenSession failed: %s", session.Status.String())
+		options.emit(ErrorOccurred{Err: err})
+		return err
+	}
+
+	c.thrift = client
+	c.session = session.SessionHandle
+	c.protocol = session.ServerProtocolVersion
+	c.calls = WrapClient(thrift.NewTStandardClient(protocol.GetProtocol(transport), protocol.GetProtocol(transport)), options.Middleware...)
+	c.transport = transport
+	c.socket = d.socket
+	c.protocolFactory = protocol
+	c.qop = d.qop
+	c.mu.Lock()
+	c.remoteAddr = socketRemoteAddr(d.socket)
+	c.mu.Unlock()
+	c.setWarnings(session.Status)
+	options.emit(SessionOpened{HostPort: hostPort, ProtocolVersion: session.ServerProtocolVersion})
+	if options.OnFeatureDowngrade != nil {
+		if features := c.featureDowngrades(ctx); len(features) > 0 {
+			options.OnFeatureDowngrade(features)
+		}
+	}
+
+	// Servers from protocol V6 on apply use:database while opening the
+	// session; older ones need a USE statement.
+	if options.Database != "" && session.ServerProtocolVersion >= inf.TProtocolVersion_HIVE_CLI_SERVICE_PROTOCOL_V6 {
+		c.database = options.Database
+	} else if options.Database != "" {
+		executeReq := inf.NewTExecuteStatementReq()
+		executeReq.Statement = "USE " + quoteIdentifier(options.Database)
+		if _, err := c.executeStatement(ctx, executeReq); err != nil {
+			closeReq := inf.NewTCloseSessionReq()
+			closeReq.SessionHandle = c.session
+			client.CloseSession(ctx, closeReq)
+			c.session = nil
+			transport.Close()
+			return cancelledConnectError(ctx, fmt.Errorf("Error selecting database %s: %v", options.Database, err))
+		}
+	}
+	if err := c.replaySetup(ctx); err != nil {
+		closeReq := inf.NewTCloseSessionReq()
+		closeReq.SessionHandle = c.session
+		client.CloseSession(ctx, closeReq)
+		c.session = nil
+		transport.Close()
+		return cancelledConnectError(ctx, err)
+	}
+	if options.TimestampMode.kind == timestampServer {
+		// Look the zone up afresh: the session's may differ from the last.
+		c.mu.Lock()
+		c.location = nil
+		c.mu.Unlock()
+		if _, err := c.ServerTimeZone(ctx); err != nil {
+			closeReq := inf.NewTCloseSessionReq()
+			closeReq.SessionHandle = c.session
+			client.CloseSession(ctx, closeReq)
+			c.session = nil
+			transport.Close()
+			return cancelledConnectError(ctx, fmt.Errorf("Error reading the server time zone for AssumeServerTZ: %v", err))
+		}
+	}
+
+	if !stop() {
+		// ctx was cancelled after the last call returned; the socket is
+		// already closed.
+		c.session = nil
+		return ctx.Err()
+	}
+	return nil
+}
+
+// setWarnings keeps and logs the info messages of a SUCCESS_WITH_INFO
+// OpenSession status, e.g. about deprecated configuration.
+func (c *Connection) setWarnings(status *inf.TStatus) {
+	var warnings []string
+	if status.GetStatusCode() == inf.TStatusCode_SUCCESS_WITH_INFO_STATUS {
+		warnings = append(warnings, status.GetInfoMessages()...)
+		if msg := status.GetErrorMessage(); msg != "" && len(warnings) == 0 {
+			warnings = append(warnings, msg)
+		}
+	}
+	for _, w := range warnings {
+		log.Printf("OpenSession warning: %s\n", w)
+	}
+	c.mu.Lock()
+	c.warnings = warnings
+	c.mu.Unlock()
+}
+
+// RemoteAddr returns the address of the server the session is open on,
+// as resolved when connecting, e.g. to tell which HiveServer2 instance
+// behind a load balancer served a query. It is "" until the session is
+// open.
+func (c *Connection) RemoteAddr() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.remoteAddr
+}
+
+// Warnings returns the warnings the server sent when the session was
+// opened, or nil if there were none.
+func (c *Connection) Warnings() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.warnings...)
+}
+
+// cancelledConnectError reports ctx's error in place of err when the
+// failure came from the socket being closed on cancellation.
+func cancelledConnectError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
 	}
+	return err
+}
 
-	return &Connection{client, session.SessionHandle, options}, nil
+// ensureSession opens the session of a LazyConnect connection if that
+// hasn't happened yet.
+func (c *Connection) ensureSession(ctx context.Context) error {
+	if !c.options.LazyConnect {
+		return nil
+	}
+	c.openMu.Lock()
+	defer c.openMu.Unlock()
+	switch {
+	case c.closed:
+		return errors.New("Session is closed")
+	case c.session != nil:
+		return nil
+	}
+	return c.open(ctx)
 }
 
 func (c *Connection) isOpen() bool {
 	return c.session != nil
 }
 
+// defaultCloseTimeout bounds the CloseSession call made by Close.
+const defaultCloseTimeout = 5 * time.Second
+
 // Close Closes an open hive session. After using this, the
-// connection is invalid for other use.
+// connection is invalid for other use. It gives the server
+// defaultCloseTimeout to respond; use CloseContext to choose.
 func (c *Connection) Close() error {
-	if c.isOpen() {
-		closeReq := inf.NewTCloseSessionReq()
-		closeReq.SessionHandle = c.session
-		resp, err := c.thrift.CloseSession(context.Background(), closeReq)
-		if err != nil {
-			return fmt.Errorf("Error closing session: ", resp, err)
-		}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultCloseTimeout)
+	defer cancel()
+	return c.CloseContext(ctx)
+}
 
-		c.session = nil
+// CloseContext closes the session like Close and then the transport. If
+// ctx ends before the server answers, the transport is closed anyway
+// and the context's error is returned. RowSet.Close bounds its cancel
+// and close calls by its ctx the same way, so a shutdown can close the
+// operations still open and then the connection under one deadline.
+func (c *Connection) CloseContext(ctx context.Context) error {
+	c.openMu.Lock()
+	c.closed = true
+	c.openMu.Unlock()
+	if !c.isOpen() {
+		return nil
+	}
+
+	stop := c.dropOnDone(ctx)
+	defer stop()
+
+	closeReq := inf.NewTCloseSessionReq()
+	closeReq.SessionHandle = c.session
+	resp, err := c.thrift.CloseSession(ctx, closeReq)
+	if err != nil && ctx.Err() == nil {
+		return fmt.Errorf("Error closing session: %+v, %v", resp, err)
+	}
+
+	c.session = nil
+
+	// The server closes the session's operations along with it.
+	c.mu.Lock()
+	for rs := range c.operations {
+		c.release(rs)
 	}
+	c.mu.Unlock()
 
+	c.transport.Close()
+	if err != nil {
+		return fmt.Errorf("Error closing session: %w", ctx.Err())
+	}
 	return nil
 }
 
+// dropOnDone closes the transport if ctx ends before stop is called, so
+// cleanup calls the server doesn't answer return by ctx's deadline
+// rather than the socket timeout. The connection is unusable after that.
+func (c *Connection) dropOnDone(ctx context.Context) (stop func() bool) {
+	return context.AfterFunc(ctx, func() { c.transport.Close() })
+}
+
 // Query Issue a query on an open connection, returning a RowSet, which
-// can be later used to query the operation's status.
+// can be later used to query the operation's status. For statements the
+// server reports as having no result set, such as DDL, the RowSet is
+// empty: Next returns false and Err nil once the statement completes.
 func (c *Connection) Query(query string) (RowSet, error) {
+	return c.QueryContext(context.Background(), query)
+}
+
+// QueryWithLogs issues a query asynchronously and collects the
+// operation log while waiting for it to complete. The collected lines
+// are available from Logs once Wait (or the first Next) returns.
+func (c *Connection) QueryWithLogs(ctx context.Context, query string) (LogRowSet, error) {
 	executeReq := inf.NewTExecuteStatementReq()
-	executeReq.SessionHandle = c.session
 	executeReq.Statement = query
+	executeReq.RunAsync = true
 
-	resp, err := c.thrift.ExecuteStatement(context.Background(), executeReq)
+	rs, err := c.submit(ctx, executeReq)
 	if err != nil {
-		return nil, fmt.Errorf("Error in ExecuteStatement: %+v, %v", resp, err)
+		return nil, err
 	}
+	rs.collectLogs = true
+	return rs, nil
+}
 
-	if !isSuccessStatus(resp.Status) {
-		return nil, fmt.Errorf("Error from server: %s", resp.Status.String())
+// Reattach returns a RowSet for an operation serialized with
+// RowSet.Handle, possibly by another process. The operation can be
+// polled and fetched from any session on the same server, but only as
+// long as the session that submitted it is still open: closing that
+// session closes its operations.
+func (c *Connection) Reattach(ctx context.Context, handle []byte) (RowSet, error) {
+	operation, err := deserializeOp(ctx, handle)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid operation handle: %v", err)
 	}
 
-	return newRowSet(c.thrift, resp.OperationHandle, c.options), nil
+	if err := c.ensureSession(ctx); err != nil {
+		return nil, err
+	}
+	if err := c.acquire(ctx); err != nil {
+		return nil, err
+	}
+	return c.track(operation), nil
 }
 
 func (c *Connection) Exec(query string) (*inf.TExecuteStatementResp, error) {
-	executeReq := inf.NewTExecuteStatementReq()
+	return c.execContext(context.Background(), query)
+}
+
+// executeStatement submits executeReq on the connection's session and
+// checks the response status.
+func (c *Connection) executeStatement(ctx context.Context, executeReq *inf.TExecuteStatementReq) (*inf.TExecuteStatementResp, error) {
+	stmt, err := singleStatement(executeReq.Statement, c.options.AllowMultiStatement)
+	if err != nil {
+		return nil, err
+	}
+	if stmt, err = c.guardStatement(ctx, c.qualifyStatement(ctx, stmt)); err != nil {
+		return nil, err
+	}
+	if stmt, err = commentStatement(ctx, stmt); err != nil {
+		return nil, err
+	}
+	executeReq.Statement = stmt
+	if limit := statementByteLimit(c.options); len(executeReq.Statement) > limit {
+		return nil, fmt.Errorf("%w: %d bytes, limit %d", ErrStatementTooLarge, len(executeReq.Statement), limit)
+	}
 	executeReq.SessionHandle = c.session
-	executeReq.Statement = query
 
-	resp, err := c.thrift.ExecuteStatement(context.Background(), executeReq)
+	resp, err := c.thrift.ExecuteStatement(ctx, executeReq)
 	if err != nil {
-		return nil, fmt.Errorf("Error in ExecuteStatement: %+v, %v", resp, err)
+		err = fmt.Errorf("Error in ExecuteStatement: %+v, %v", resp, err)
+		c.options.emit(ErrorOccurred{Err: err})
+		return nil, err
 	}
 
-	if !isSuccessStatus(resp.Status) {
-		return nil, fmt.Errorf("Error from server: %s", resp.Status.String())
+	if !isSuccessStatus(resp.Status) && !(executeReq.RunAsync && isStillExecuting(resp.Status)) {
+		err := fmt.Errorf("Error from server: %s", resp.Status.String())
+		c.options.emit(statusErrorEvent("", err, resp.Status))
+		return nil, err
 	}
 
-	return resp, err
+	c.options.emit(StatementSubmitted{OperationID: operationID(resp.OperationHandle), SQL: executeReq.Statement})
+	c.audit(ctx, executeReq.Statement, operationID(resp.OperationHandle))
+	c.trackStatement(executeReq.Statement)
+	return resp, nil
 }
 
+// useDatabaseKey is the OpenSession configuration key that selects the
+// initial database.
+const useDatabaseKey = "use:database"
+
+// quoteIdentifier quotes name with backticks so reserved words and
+// unusual characters are accepted as identifiers.
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// isSuccessStatus reports whether a response status is a success. A nil
+// status, from a malformed response, is treated as a failure.
 func isSuccessStatus(p *inf.TStatus) bool {
+	if p == nil {
+		return false
+	}
 	status := p.GetStatusCode()
 	return status == inf.TStatusCode_SUCCESS_STATUS || status == inf.TStatusCode_SUCCESS_WITH_INFO_STATUS
 }
+
+// isStillExecuting reports whether a status says the call was accepted
+// but the operation hasn't completed yet, which async operations may
+// return instead of a success.
+func isStillExecuting(p *inf.TStatus) bool {
+	return p != nil && p.GetStatusCode() == inf.TStatusCode_STILL_EXECUTING_STATUS
+}
@@ -0,0 +1,139 @@
+package hive
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CopyOptions configures CopyTo.
+type CopyOptions struct {
+	// Columns names the target columns in result order. It defaults to
+	// the result's column names, without any "table." prefix Hive adds.
+	Columns []string
+	// TxRows is the number of rows inserted per transaction, 1000 if
+	// unset.
+	TxRows int
+	// Placeholder renders the n-th bind parameter, counting from 1. It
+	// defaults to "?"; use DollarPlaceholder for PostgreSQL.
+	Placeholder func(n int) string
+}
+
+// DollarPlaceholder renders PostgreSQL style $n placeholders.
+func DollarPlaceholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+const defaultCopyTxRows = 1000
+
+// CopyTo inserts the remaining rows of rs into targetTable of db, a
+// connection to another database. Rows are read with NextValues and
+// bound to a prepared INSERT, committing every TxRows rows. On error the
+// open transaction is rolled back and the rows committed so far are
+// returned with the error. Table and column names are used verbatim.
+func CopyTo(ctx context.Context, rs RowSet, db *sql.DB, targetTable string, opts CopyOptions) (int64, error) {
+	if targetTable == "" {
+		return 0, errors.New("CopyTo requires a target table")
+	}
+
+	columns := opts.Columns
+	if columns == nil {
+		schema, err := rs.Schema(ctx)
+		if err != nil {
+			return 0, err
+		}
+		for _, col := range schema {
+			name := col.Name
+			if i := strings.LastIndexByte(name, '.'); i >= 0 {
+				name = name[i+1:]
+			}
+			columns = append(columns, name)
+		}
+	}
+	if len(columns) == 0 {
+		return 0, errors.New("CopyTo found no columns to insert")
+	}
+
+	placeholder := opts.Placeholder
+	if placeholder == nil {
+		placeholder = func(int) string { return "?" }
+	}
+	params := make([]string, len(columns))
+	for i := range params {
+		params[i] = placeholder(i + 1)
+	}
+	insert := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		targetTable, strings.Join(columns, ", "), strings.Join(params, ", "))
+
+	txRows := opts.TxRows
+	if txRows <= 0 {
+		txRows = defaultCopyTxRows
+	}
+
+	var copied int64
+	for {
+		n, err := copyTx(ctx, rs, db, insert, len(columns), txRows)
+		copied += n
+		if err == io.EOF {
+			return copied, nil
+		}
+		if err != nil {
+			return copied, err
+		}
+	}
+}
+
+// copyTx inserts up to txRows rows in one transaction and returns the
+// number committed. It returns io.EOF, with the final rows committed,
+// once rs is exhausted.
+func copyTx(ctx context.Context, rs RowSet, db *sql.DB, insert string, width, txRows int) (int64, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("Error starting transaction: %v", err)
+	}
+	stmt, err := tx.PrepareContext(ctx, insert)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("Error preparing %q: %v", insert, err)
+	}
+	defer stmt.Close()
+
+	var (
+		n   int64
+		end error
+	)
+	for n < int64(txRows) {
+		values, err := rs.NextValues(ctx)
+		if err == io.EOF {
+			end = io.EOF
+			break
+		}
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		if len(values) != width {
+			tx.Rollback()
+			return 0, fmt.Errorf("Row has %d values for %d target columns", len(values), width)
+		}
+
+		args := make([]interface{}, len(values))
+		for i, v := range values {
+			args[i] = v
+		}
+		if _, err := stmt.ExecContext(ctx, args...); err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("Error inserting row: %v", err)
+		}
+		n++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("Error committing transaction: %v", err)
+	}
+	return n, end
+}
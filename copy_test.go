@@ -0,0 +1,147 @@
+package hive
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// recordingDriver is a database/sql driver that records committed
+// inserts, failing any insert of the value "fail".
+type recordingDriver struct {
+	mu        sync.Mutex
+	queries   []string
+	committed [][]driver.Value
+	rollbacks int
+}
+
+func (d *recordingDriver) Open(string) (driver.Conn, error) { return &recordingConn{d: d}, nil }
+
+type recordingConn struct {
+	d       *recordingDriver
+	pending [][]driver.Value
+}
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) {
+	c.d.mu.Lock()
+	c.d.queries = append(c.d.queries, query)
+	c.d.mu.Unlock()
+	return &recordingStmt{c: c}, nil
+}
+
+func (c *recordingConn) Close() error              { return nil }
+func (c *recordingConn) Begin() (driver.Tx, error) { return c, nil }
+
+func (c *recordingConn) Commit() error {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	c.d.committed = append(c.d.committed, c.pending...)
+	c.pending = nil
+	return nil
+}
+
+func (c *recordingConn) Rollback() error {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	c.d.rollbacks++
+	c.pending = nil
+	return nil
+}
+
+type recordingStmt struct {
+	c *recordingConn
+}
+
+func (s *recordingStmt) Close() error  { return nil }
+func (s *recordingStmt) NumInput() int { return -1 }
+
+func (s *recordingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	for _, v := range args {
+		if v == "fail" {
+			return nil, errors.New("constraint violation")
+		}
+	}
+	s.c.pending = append(s.c.pending, args)
+	return driver.RowsAffected(1), nil
+}
+
+func (s *recordingStmt) Query([]driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+func openRecordingDB(t *testing.T) (*sql.DB, *recordingDriver) {
+	d := &recordingDriver{}
+	db := sql.OpenDB(connector{d})
+	t.Cleanup(func() { db.Close() })
+	return db, d
+}
+
+type connector struct{ d *recordingDriver }
+
+func (c connector) Connect(context.Context) (driver.Conn, error) { return c.d.Open("") }
+func (c connector) Driver() driver.Driver                        { return c.d }
+
+func TestCopyTo(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{
+		columnDesc("t.name", inf.TTypeId_STRING_TYPE, 1),
+		columnDesc("t.age", inf.TTypeId_INT_TYPE, 2),
+	}
+	svc.batches = append(svc.batches, &inf.TRowSet{Columns: []*inf.TColumn{
+		{StringVal: &inf.TStringColumn{Values: []string{"ann", "bob", "cy"}, Nulls: []byte{}}},
+		{I32Val: &inf.TI32Column{Values: []int32{31, 42, 0}, Nulls: []byte{0x04}}},
+	}})
+	conn := connectFake(t, svc, testOptions())
+	db, d := openRecordingDB(t)
+
+	rs, err := conn.Query("SELECT name, age FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	n, err := CopyTo(ctx, rs, db, "people", CopyOptions{TxRows: 2, Placeholder: DollarPlaceholder})
+	if err != nil {
+		t.Fatalf("CopyTo error: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("expected 3 rows copied, got %d", n)
+	}
+
+	if want := "INSERT INTO people (name, age) VALUES ($1, $2)"; d.queries[0] != want {
+		t.Errorf("expected %q, got %q", want, d.queries[0])
+	}
+	want := [][]driver.Value{{"ann", int64(31)}, {"bob", int64(42)}, {"cy", nil}}
+	if !reflect.DeepEqual(d.committed, want) {
+		t.Errorf("expected %v committed, got %v", want, d.committed)
+	}
+}
+
+func TestCopyToRollsBack(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("s", inf.TTypeId_STRING_TYPE, 1)}
+	svc.batches = append(svc.batches, stringBatch("a", "b", "c", "fail", "e"))
+	conn := connectFake(t, svc, testOptions())
+	db, d := openRecordingDB(t)
+
+	rs, err := conn.Query("SELECT s FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	n, err := CopyTo(ctx, rs, db, "letters", CopyOptions{TxRows: 2})
+	if err == nil {
+		t.Fatal("expected the failing insert to be reported")
+	}
+	if n != 2 || len(d.committed) != 2 {
+		t.Errorf("expected only the first transaction committed, got %d rows (%d recorded)", n, len(d.committed))
+	}
+	if d.rollbacks != 1 {
+		t.Errorf("expected one rollback, got %d", d.rollbacks)
+	}
+}
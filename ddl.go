@@ -0,0 +1,104 @@
+package hive
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// createTablePattern matches the head of a CREATE TABLE statement up to
+// the table name, which is captured, as is an IF NOT EXISTS clause.
+var createTablePattern = regexp.MustCompile("(?is)^(\\s*CREATE\\s+(?:(?:TEMPORARY|EXTERNAL|TRANSACTIONAL|MANAGED)\\s+)*TABLE\\s+)(IF\\s+NOT\\s+EXISTS\\s+)?((?:`(?:[^`]|``)+`|\\w+)(?:\\s*\\.\\s*(?:`(?:[^`]|``)+`|\\w+))?)")
+
+// CreateTableIfNotExists runs a CREATE TABLE statement, adding IF NOT
+// EXISTS after TABLE unless ddl has it, and reports whether the table
+// was created. HiveServer2 answers both outcomes alike, so the table is
+// looked up with SHOW TABLES first; a table created by another client in
+// between is reported as created by this call.
+func (c *Connection) CreateTableIfNotExists(ctx context.Context, ddl string) (created bool, err error) {
+	m := createTablePattern.FindStringSubmatchIndex(ddl)
+	if m == nil {
+		return false, fmt.Errorf("Not a CREATE TABLE statement: %q", truncateCell(ddl, 80))
+	}
+	database, table := splitTableName(ddl[m[6]:m[7]])
+	if m[4] < 0 {
+		ddl = ddl[:m[3]] + "IF NOT EXISTS " + ddl[m[3]:]
+	}
+
+	exists, err := c.tableExists(ctx, database, table)
+	if err != nil {
+		return false, err
+	}
+	if _, err := c.execContext(ctx, ddl); err != nil {
+		return false, err
+	}
+	if !exists {
+		c.ClearMetadataCache()
+	}
+	return !exists, nil
+}
+
+// DropTableIfExists drops table, a plain name optionally qualified as
+// db.table, with DROP TABLE IF EXISTS and reports whether it existed,
+// looked up with SHOW TABLES first like CreateTableIfNotExists does.
+func (c *Connection) DropTableIfExists(ctx context.Context, table string) (dropped bool, err error) {
+	database, name, _ := strings.Cut(table, ".")
+	if name == "" {
+		database, name = "", table
+	}
+	if !isIdentifier(name) || database != "" && !isIdentifier(database) {
+		return false, fmt.Errorf("Invalid table name %q", table)
+	}
+
+	exists, err := c.tableExists(ctx, database, name)
+	if err != nil {
+		return false, err
+	}
+	if _, err := c.execContext(ctx, "DROP TABLE IF EXISTS "+quoteTableName(table)); err != nil {
+		return false, err
+	}
+	if exists {
+		c.ClearMetadataCache()
+	}
+	return exists, nil
+}
+
+// tableExists looks table up in database, or in the current database if
+// that is empty. Hive keeps names in lower case and compares them so.
+func (c *Connection) tableExists(ctx context.Context, database, table string) (bool, error) {
+	stmt := "SHOW TABLES"
+	if database != "" {
+		stmt += " IN " + quoteIdentifier(database)
+	}
+	// LIKE takes * and | as wildcards, which plain names don't contain;
+	// the results are compared in full regardless.
+	stmt += " LIKE " + quoteString(strings.ToLower(table))
+
+	tables, err := c.QueryStrings(ctx, stmt)
+	if err != nil {
+		return false, fmt.Errorf("Error looking up table %s: %v", table, err)
+	}
+	for _, t := range tables {
+		if strings.EqualFold(t, table) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// splitTableName splits a possibly qualified, possibly quoted table name
+// as written in a statement into its unquoted parts.
+func splitTableName(name string) (database, table string) {
+	for i := 0; i < len(name); i++ {
+		if name[i] == '`' {
+			end, _ := quotedEnd(name, i)
+			i = end - 1
+			continue
+		}
+		if name[i] == '.' {
+			return unquoteIdentifier(strings.TrimSpace(name[:i])), unquoteIdentifier(strings.TrimSpace(name[i+1:]))
+		}
+	}
+	return "", unquoteIdentifier(name)
+}
@@ -0,0 +1,101 @@
+package hive
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func ddlService(existing ...string) *fakeService {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("tab_name", inf.TTypeId_STRING_TYPE, 1)}
+	svc.results = map[string][]*inf.TRowSet{}
+	for _, name := range existing {
+		svc.results["SHOW TABLES IN `sales` LIKE '"+name+"'"] = []*inf.TRowSet{stringBatch(name)}
+	}
+	return svc
+}
+
+func TestCreateTableIfNotExists(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		existing []string
+		ddl      string
+		want     string
+		created  bool
+	}{
+		{
+			name:    "created",
+			ddl:     "CREATE TABLE sales.orders (id INT) STORED AS ORC",
+			want:    "CREATE TABLE IF NOT EXISTS sales.orders (id INT) STORED AS ORC",
+			created: true,
+		},
+		{
+			name:     "exists",
+			existing: []string{"orders"},
+			ddl:      "create external table `sales`.`Orders` (id INT) LOCATION '/data/orders'",
+			want:     "create external table IF NOT EXISTS `sales`.`Orders` (id INT) LOCATION '/data/orders'",
+		},
+		{
+			name:     "clause kept",
+			existing: []string{"orders"},
+			ddl:      "CREATE TABLE IF NOT EXISTS sales.orders (id INT)",
+			want:     "CREATE TABLE IF NOT EXISTS sales.orders (id INT)",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := ddlService(tc.existing...)
+			conn := connectFake(t, svc, testOptions())
+
+			created, err := conn.CreateTableIfNotExists(context.Background(), tc.ddl)
+			if err != nil {
+				t.Fatalf("CreateTableIfNotExists error: %v", err)
+			}
+			if created != tc.created {
+				t.Errorf("expected created %v, got %v", tc.created, created)
+			}
+			got := svc.executed()
+			if len(got) != 2 || got[1] != tc.want {
+				t.Errorf("expected %q run after the lookup, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestCreateTableIfNotExistsInvalid(t *testing.T) {
+	conn := connectFake(t, ddlService(), testOptions())
+	for _, ddl := range []string{"CREATE VIEW v AS SELECT 1", "DROP TABLE t", "CREATE TABLE"} {
+		if _, err := conn.CreateTableIfNotExists(context.Background(), ddl); err == nil {
+			t.Errorf("%q: expected an error", ddl)
+		}
+	}
+}
+
+func TestDropTableIfExists(t *testing.T) {
+	svc := ddlService("orders")
+	conn := connectFake(t, svc, testOptions())
+	ctx := context.Background()
+
+	dropped, err := conn.DropTableIfExists(ctx, "sales.orders")
+	if err != nil || !dropped {
+		t.Errorf("expected the table dropped, got %v (err %v)", dropped, err)
+	}
+	dropped, err = conn.DropTableIfExists(ctx, "sales.returns")
+	if err != nil || dropped {
+		t.Errorf("expected nothing dropped, got %v (err %v)", dropped, err)
+	}
+	want := []string{
+		"SHOW TABLES IN `sales` LIKE 'orders'", "DROP TABLE IF EXISTS `sales`.`orders`",
+		"SHOW TABLES IN `sales` LIKE 'returns'", "DROP TABLE IF EXISTS `sales`.`returns`",
+	}
+	if got := svc.executed(); len(got) != len(want) || got[1] != want[1] || got[2] != want[2] || got[3] != want[3] {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	for _, table := range []string{"orders; DROP TABLE x", "a.b.c", "", "`x`"} {
+		if _, err := conn.DropTableIfExists(ctx, table); err == nil {
+			t.Errorf("%q: expected an invalid name error", table)
+		}
+	}
+}
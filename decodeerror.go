@@ -0,0 +1,44 @@
+package hive
+
+import "fmt"
+
+// A DecodeError reports a cell that couldn't be converted, pinpointing
+// where it is in the result, e.g.
+//
+//	Row 12345, column amount (DECIMAL(10,2)): cannot scan string into *int
+type DecodeError struct {
+	// Row is the index of the row within the batch it was fetched in.
+	Row int
+	// Column and Type are the column's name and its declared type, as
+	// from Column.DatabaseTypeName.
+	Column string
+	Type   string
+	// Value is the cell as decoded from the wire, nil for NULL.
+	Value interface{}
+	Err   error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("Row %d, column %s (%s): %v", e.Row, e.Column, e.Type, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// decodeError wraps err, from converting val, the cell of column i of
+// the current row.
+func (r *rowSet) decodeError(i int, val interface{}, err error) error {
+	return r.cellError(r.offset-1, i, val, err)
+}
+
+// cellError wraps err, from converting val, the cell of column i of row
+// row of the current batch.
+func (r *rowSet) cellError(row, i int, val interface{}, err error) error {
+	e := &DecodeError{Row: row, Column: fmt.Sprintf("%d", i), Value: val, Err: err}
+	if i < len(r.columns) {
+		col := newColumn(r.columns[i])
+		e.Column, e.Type = col.Name, col.DatabaseTypeName()
+	}
+	return e
+}
@@ -0,0 +1,48 @@
+package hive
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestScanDecodeError(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{
+		columnDesc("id", inf.TTypeId_INT_TYPE, 1),
+		qualifiedDesc("amount", inf.TTypeId_DECIMAL_TYPE, 2, map[string]int32{inf.PRECISION: 10, inf.SCALE: 2}),
+	}
+	svc.batches = []*inf.TRowSet{{Columns: []*inf.TColumn{
+		{I32Val: &inf.TI32Column{Values: []int32{1, 2}, Nulls: []byte{}}},
+		{StringVal: &inf.TStringColumn{Values: []string{"1.50", "2.25"}, Nulls: []byte{}}},
+	}}}
+	conn := connectFake(t, svc, testOptions())
+
+	rs, err := conn.Query("SELECT id, amount FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	rs.Next()
+	if !rs.Next() {
+		t.Fatal("expected a second row")
+	}
+
+	var id, amount int
+	err = rs.Scan(&id, &amount)
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("expected a DecodeError, got %v", err)
+	}
+	if decodeErr.Row != 1 || decodeErr.Column != "amount" || decodeErr.Type != "DECIMAL(10,2)" || decodeErr.Value != "2.25" {
+		t.Errorf("unexpected error fields %+v", decodeErr)
+	}
+	if want := "Row 1, column amount (DECIMAL(10,2)): cannot scan string into *int"; err.Error() != want {
+		t.Errorf("expected %q, got %q", want, err.Error())
+	}
+
+	var s string
+	if err := rs.Scan(&id, &s); err != nil || id != 2 || s != "2.25" {
+		t.Errorf("expected the row to scan into matching types, got %d, %q, %v", id, s, err)
+	}
+}
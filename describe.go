@@ -0,0 +1,151 @@
+package hive
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// TableColumn is a column of a table as listed by DESCRIBE.
+type TableColumn struct {
+	Name    string
+	Type    string
+	Comment string
+}
+
+// TableDescription is the parsed output of DESCRIBE FORMATTED.
+type TableDescription struct {
+	Columns       []TableColumn
+	PartitionKeys []TableColumn
+
+	Database  string
+	Owner     string
+	Location  string
+	TableType string
+
+	// StorageFormat is TEXTFILE, ORC, PARQUET, AVRO, SEQUENCEFILE or
+	// RCFILE as derived from InputFormat, or empty if it isn't one of
+	// those.
+	StorageFormat string
+	InputFormat   string
+	OutputFormat  string
+	SerDe         string
+
+	// Rows is the unparsed output, three cells per row, for anything the
+	// parser doesn't pick out.
+	Rows [][]string
+}
+
+// DescribeTable runs DESCRIBE FORMATTED on table and parses the result.
+// The layout differs slightly between Hive versions, so parsing is best
+// effort: fields that aren't found are left empty, and Rows keeps the
+// complete output.
+func (c *Connection) DescribeTable(ctx context.Context, table string) (*TableDescription, error) {
+	executeReq := inf.NewTExecuteStatementReq()
+	executeReq.Statement = "DESCRIBE FORMATTED " + quoteTableName(table)
+	rs, err := c.submit(ctx, executeReq)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close(ctx)
+
+	var rows [][]string
+	for {
+		values, err := rs.NextValues(ctx)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Error describing %s: %v", table, err)
+		}
+		row := make([]string, 3)
+		for i := 0; i < len(values) && i < len(row); i++ {
+			if values[i] != nil {
+				row[i] = strings.TrimSpace(fmt.Sprint(values[i]))
+			}
+		}
+		rows = append(rows, row)
+	}
+	return parseTableDescription(rows), nil
+}
+
+// parseTableDescription picks the known sections and labels out of
+// DESCRIBE FORMATTED rows.
+func parseTableDescription(rows [][]string) *TableDescription {
+	d := &TableDescription{Rows: rows}
+	section := "columns"
+	for _, row := range rows {
+		name, value := row[0], row[1]
+		switch {
+		case name == "" && value == "":
+			continue
+		case strings.HasPrefix(name, "#"):
+			switch strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "#"))) {
+			case "partition information":
+				section = "partitions"
+			case "detailed table information", "storage information":
+				section = "details"
+			}
+			continue
+		}
+
+		switch section {
+		case "columns":
+			d.Columns = append(d.Columns, TableColumn{Name: name, Type: value, Comment: row[2]})
+		case "partitions":
+			d.PartitionKeys = append(d.PartitionKeys, TableColumn{Name: name, Type: value, Comment: row[2]})
+		case "details":
+			switch strings.TrimSuffix(name, ":") {
+			case "Database":
+				d.Database = value
+			case "Owner", "OwnerName":
+				d.Owner = value
+			case "Location":
+				d.Location = value
+			case "Table Type":
+				d.TableType = value
+			case "InputFormat":
+				d.InputFormat = value
+			case "OutputFormat":
+				d.OutputFormat = value
+			case "SerDe Library":
+				d.SerDe = value
+			}
+		}
+	}
+	d.StorageFormat = storageFormat(d.InputFormat)
+	return d
+}
+
+var inputFormats = []struct {
+	marker, format string
+}{
+	{"OrcInputFormat", "ORC"},
+	{"MapredParquetInputFormat", "PARQUET"},
+	{"AvroContainerInputFormat", "AVRO"},
+	{"SequenceFileInputFormat", "SEQUENCEFILE"},
+	{"RCFileInputFormat", "RCFILE"},
+	{"TextInputFormat", "TEXTFILE"},
+}
+
+func storageFormat(inputFormat string) string {
+	for _, f := range inputFormats {
+		if strings.HasSuffix(inputFormat, f.marker) {
+			return f.format
+		}
+	}
+	return ""
+}
+
+// ShowPartitions returns the partitions of table as listed by SHOW
+// PARTITIONS, e.g. "dt=2024-01-01/country=US".
+func (c *Connection) ShowPartitions(ctx context.Context, table string) ([]string, error) {
+	specs, err := c.QueryStrings(ctx, "SHOW PARTITIONS "+quoteTableName(table))
+	if err != nil {
+		return nil, fmt.Errorf("Error listing partitions of %s: %v", table, err)
+	}
+	return specs, nil
+}
@@ -0,0 +1,96 @@
+package hive
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func describeBatch(rows ...[3]string) *inf.TRowSet {
+	cols := make([][]string, 3)
+	for _, row := range rows {
+		for i, v := range row {
+			cols[i] = append(cols[i], v)
+		}
+	}
+	rs := &inf.TRowSet{}
+	for _, values := range cols {
+		rs.Columns = append(rs.Columns, &inf.TColumn{StringVal: &inf.TStringColumn{Values: values, Nulls: []byte{}}})
+	}
+	return rs
+}
+
+func TestDescribeTable(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{
+		columnDesc("col_name", inf.TTypeId_STRING_TYPE, 1),
+		columnDesc("data_type", inf.TTypeId_STRING_TYPE, 2),
+		columnDesc("comment", inf.TTypeId_STRING_TYPE, 3),
+	}
+	svc.results = map[string][]*inf.TRowSet{"DESCRIBE FORMATTED `sales`.`orders`": {describeBatch(
+		[3]string{"# col_name            ", "data_type           ", "comment             "},
+		[3]string{"", "", ""},
+		[3]string{"id                  ", "bigint              ", "order id            "},
+		[3]string{"amount              ", "decimal(18,4)       ", ""},
+		[3]string{"", "", ""},
+		[3]string{"# Partition Information", "", ""},
+		[3]string{"# col_name            ", "data_type           ", "comment             "},
+		[3]string{"dt                  ", "string              ", ""},
+		[3]string{"", "", ""},
+		[3]string{"# Detailed Table Information", "", ""},
+		[3]string{"Database:           ", "sales               ", ""},
+		[3]string{"Owner:              ", "etl                 ", ""},
+		[3]string{"Location:           ", "hdfs://nn/warehouse/sales.db/orders", ""},
+		[3]string{"Table Type:         ", "MANAGED_TABLE       ", ""},
+		[3]string{"Table Parameters:", "", ""},
+		[3]string{"", "numFiles            ", "3                   "},
+		[3]string{"", "", ""},
+		[3]string{"# Storage Information", "", ""},
+		[3]string{"SerDe Library:      ", "org.apache.hadoop.hive.ql.io.orc.OrcSerde", ""},
+		[3]string{"InputFormat:        ", "org.apache.hadoop.hive.ql.io.orc.OrcInputFormat", ""},
+		[3]string{"OutputFormat:       ", "org.apache.hadoop.hive.ql.io.orc.OrcOutputFormat", ""},
+	)}}
+	conn := connectFake(t, svc, testOptions())
+
+	d, err := conn.DescribeTable(context.Background(), "sales.orders")
+	if err != nil {
+		t.Fatalf("DescribeTable error: %v", err)
+	}
+
+	wantColumns := []TableColumn{{"id", "bigint", "order id"}, {"amount", "decimal(18,4)", ""}}
+	if !reflect.DeepEqual(d.Columns, wantColumns) {
+		t.Errorf("expected columns %v, got %v", wantColumns, d.Columns)
+	}
+	if want := []TableColumn{{"dt", "string", ""}}; !reflect.DeepEqual(d.PartitionKeys, want) {
+		t.Errorf("expected partition keys %v, got %v", want, d.PartitionKeys)
+	}
+	if d.Database != "sales" || d.Owner != "etl" || d.TableType != "MANAGED_TABLE" ||
+		d.Location != "hdfs://nn/warehouse/sales.db/orders" {
+		t.Errorf("unexpected table details %+v", d)
+	}
+	if d.StorageFormat != "ORC" || d.SerDe != "org.apache.hadoop.hive.ql.io.orc.OrcSerde" {
+		t.Errorf("unexpected storage details %q, %q", d.StorageFormat, d.SerDe)
+	}
+	if len(d.Rows) != 21 {
+		t.Errorf("expected the 21 raw rows, got %d", len(d.Rows))
+	}
+}
+
+func TestShowPartitions(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("partition", inf.TTypeId_STRING_TYPE, 1)}
+	svc.results = map[string][]*inf.TRowSet{
+		"SHOW PARTITIONS `events`": {stringBatch("dt=2024-01-01", "dt=2024-01-02")},
+	}
+	conn := connectFake(t, svc, testOptions())
+
+	got, err := conn.ShowPartitions(context.Background(), "events")
+	if err != nil {
+		t.Fatalf("ShowPartitions error: %v", err)
+	}
+	if want := []string{"dt=2024-01-01", "dt=2024-01-02"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,159 @@
+package hive
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+)
+
+// openTransport opens the transport, retrying failed dials up to
+// options.ConnectRetries times with options.ConnectRetryBackoff between
+// attempts. Only refused connections are retried unless
+// options.RetryUnreachable is set.
+func openTransport(ctx context.Context, transport thrift.TTransport, options Options) error {
+	return retryDial(ctx, options, transport.Open)
+}
+
+// retryDial calls dial with the retry policy of openTransport.
+func retryDial(ctx context.Context, options Options, dial func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := dial()
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= options.ConnectRetries || !isRetryableDialError(err, options) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-options.clock().After(options.ConnectRetryBackoff):
+		}
+	}
+}
+
+func isRetryableDialError(err error, options Options) bool {
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	return options.RetryUnreachable
+}
+
+// dialSocket opens the socket to hostPort, through Options.DialContext
+// if it is set.
+func dialSocket(ctx context.Context, hostPort string, tc *thrift.TConfiguration, options Options) (socketTransport, error) {
+	if options.DialContext == nil {
+		var socket socketTransport = thrift.NewTSocketConf(hostPort, tc)
+		if tc.TLSConfig != nil {
+			socket = thrift.NewTSSLSocketConf(hostPort, tc)
+		}
+		if err := openTransport(ctx, socket, options); err != nil {
+			return nil, err
+		}
+		return socket, nil
+	}
+
+	var conn net.Conn
+	err := retryDial(ctx, options, func() error {
+		dialCtx := ctx
+		if options.ConnectTimeout > 0 {
+			var cancel context.CancelFunc
+			dialCtx, cancel = context.WithTimeout(ctx, options.ConnectTimeout)
+			defer cancel()
+		}
+		var err error
+		conn, err = options.DialContext(dialCtx, "tcp", hostPort)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if tc.TLSConfig != nil {
+		config := tc.TLSConfig
+		if config.ServerName == "" {
+			config = config.Clone()
+			config.ServerName, _, _ = net.SplitHostPort(hostPort)
+		}
+		tlsConn := tls.Client(conn, config)
+		handshakeCtx := ctx
+		if options.ConnectTimeout > 0 {
+			var cancel context.CancelFunc
+			handshakeCtx, cancel = context.WithTimeout(ctx, options.ConnectTimeout)
+			defer cancel()
+		}
+		if err := tlsConn.HandshakeContext(handshakeCtx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+	return thrift.NewTSocketFromConnConf(&deadlineConn{Conn: conn, conf: tc}, tc), nil
+}
+
+// socketRemoteAddr returns the peer address of an open socket, or "" if
+// it isn't known.
+func socketRemoteAddr(socket socketTransport) string {
+	s, ok := socket.(interface{ Conn() net.Conn })
+	if !ok || s.Conn() == nil || s.Conn().RemoteAddr() == nil {
+		return ""
+	}
+	return s.Conn().RemoteAddr().String()
+}
+
+// deadlineGrace is how long after a missed deadline deadlineConn closes
+// a connection that doesn't enforce deadlines itself.
+const deadlineGrace = time.Second
+
+// deadlineConn bounds every read and write of a dialled net.Conn by the
+// socket timeout. Thrift retries reads that time out while the call's
+// context has time left, so a timeout is reported as a plain error that
+// ends the call. Connections whose SetDeadline methods do nothing, as
+// with some tunnels, are closed shortly after the deadline instead.
+type deadlineConn struct {
+	net.Conn
+	// conf holds the socket timeout, which TSocket.SetSocketTimeout
+	// changes in place.
+	conf *thrift.TConfiguration
+}
+
+func (c *deadlineConn) Read(b []byte) (int, error) {
+	return c.bounded("read", c.Conn.SetReadDeadline, c.Conn.Read, b)
+}
+
+func (c *deadlineConn) Write(b []byte) (int, error) {
+	return c.bounded("write", c.Conn.SetWriteDeadline, c.Conn.Write, b)
+}
+
+func (c *deadlineConn) bounded(op string, setDeadline func(time.Time) error, do func([]byte) (int, error), b []byte) (int, error) {
+	timeout := c.conf.GetSocketTimeout()
+	if timeout <= 0 {
+		return do(b)
+	}
+	setDeadline(time.Now().Add(timeout))
+	var expired atomic.Bool
+	watchdog := time.AfterFunc(timeout+deadlineGrace, func() {
+		expired.Store(true)
+		c.Conn.Close()
+	})
+	n, err := do(b)
+	watchdog.Stop()
+	if err != nil && (expired.Load() || isTimeout(err)) {
+		err = fmt.Errorf("Socket %s timed out after %v", op, timeout)
+	}
+	return n, err
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, os.ErrDeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout()
+}
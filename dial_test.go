@@ -0,0 +1,196 @@
+package hive
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+)
+
+// freeAddr returns a loopback address that nothing is listening on.
+func freeAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen error: %v", err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	return addr
+}
+
+func TestOpenTransportRetriesUntilListening(t *testing.T) {
+	addr := freeAddr(t)
+
+	listening := make(chan net.Listener, 1)
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			t.Errorf("delayed Listen error: %v", err)
+			close(listening)
+			return
+		}
+		listening <- l
+	}()
+	defer func() {
+		if l, ok := <-listening; ok {
+			l.Close()
+		}
+	}()
+
+	options := DefaultOptions
+	options.ConnectRetries = 50
+	options.ConnectRetryBackoff = 20 * time.Millisecond
+	transport := thrift.NewTSocketConf(addr, &thrift.TConfiguration{ConnectTimeout: time.Second})
+
+	if err := openTransport(context.Background(), transport, options); err != nil {
+		t.Fatalf("openTransport error: %v", err)
+	}
+	transport.Close()
+}
+
+func TestOpenTransportWithoutRetries(t *testing.T) {
+	options := DefaultOptions
+	transport := thrift.NewTSocketConf(freeAddr(t), &thrift.TConfiguration{ConnectTimeout: time.Second})
+
+	if err := openTransport(context.Background(), transport, options); err == nil {
+		t.Fatal("expected a refused connection")
+	}
+}
+
+func TestOpenTransportHonorsContext(t *testing.T) {
+	options := DefaultOptions
+	options.ConnectRetries = 1000
+	options.ConnectRetryBackoff = time.Hour
+	transport := thrift.NewTSocketConf(freeAddr(t), &thrift.TConfiguration{ConnectTimeout: time.Second})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := openTransport(ctx, transport, options); err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestOpenTransportBacksOffOnClock(t *testing.T) {
+	clock := newFakeClock()
+	options := DefaultOptions
+	options.ConnectRetries = 3
+	options.ConnectRetryBackoff = time.Hour
+	options.testClock = clock
+	transport := thrift.NewTSocketConf(freeAddr(t), &thrift.TConfiguration{ConnectTimeout: time.Second})
+
+	if err := openTransport(context.Background(), transport, options); err == nil {
+		t.Fatal("expected a refused connection")
+	}
+
+	if slept := clock.slept(); len(slept) != 3 {
+		t.Errorf("expected 3 backoffs, got %v", slept)
+	}
+}
+
+// ignoreDeadlines is a connection whose deadlines do nothing, like some
+// tunnels.
+type ignoreDeadlines struct {
+	net.Conn
+}
+
+func (ignoreDeadlines) SetDeadline(time.Time) error      { return nil }
+func (ignoreDeadlines) SetReadDeadline(time.Time) error  { return nil }
+func (ignoreDeadlines) SetWriteDeadline(time.Time) error { return nil }
+
+func TestDialContextDeadlines(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		wrap  func(net.Conn) net.Conn
+		limit time.Duration
+	}{
+		{"deadlines", func(c net.Conn) net.Conn { return c }, time.Second},
+		{"ignored deadlines", func(c net.Conn) net.Conn { return ignoreDeadlines{c} }, time.Second + 2*deadlineGrace},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client, server := net.Pipe()
+			defer server.Close()
+			// The server reads requests but never responds.
+			go io.Copy(io.Discard, server)
+
+			options := testOptions()
+			options.SocketTimeout = 100 * time.Millisecond
+			options.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return tc.wrap(client), nil
+			}
+			// A distant deadline would keep thrift retrying timed out reads.
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+
+			start := time.Now()
+			_, err := ConnectContext(ctx, "hive:10000", options)
+			if err == nil || !strings.Contains(err.Error(), "timed out") {
+				t.Fatalf("expected a socket timeout, got %v", err)
+			}
+			if elapsed := time.Since(start); elapsed > tc.limit {
+				t.Errorf("expected the deadline to fire within %v, took %v", tc.limit, elapsed)
+			}
+		})
+	}
+}
+
+func TestDialContext(t *testing.T) {
+	svc := newFakeService()
+	addr := startFakeServer(t, svc)
+	var dialled string
+	options := testOptions()
+	options.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		dialled = address
+		return (&net.Dialer{}).DialContext(ctx, network, addr)
+	}
+
+	conn, err := ConnectContext(context.Background(), "hive.example.com:10000", options)
+	if err != nil {
+		t.Fatalf("ConnectContext error: %v", err)
+	}
+	defer conn.Close()
+	if dialled != "hive.example.com:10000" {
+		t.Errorf("expected the dialer to get the address, got %q", dialled)
+	}
+	if _, err := conn.Exec("SELECT 1"); err != nil {
+		t.Errorf("Exec error: %v", err)
+	}
+}
+
+func TestRemoteAddr(t *testing.T) {
+	svc := newFakeService()
+	addr := startFakeServer(t, svc)
+	_, port, _ := net.SplitHostPort(addr)
+
+	for _, custom := range []bool{false, true} {
+		options := testOptions()
+		if custom {
+			options.DialContext = (&net.Dialer{}).DialContext
+		}
+		conn, err := ConnectContext(context.Background(), "localhost:"+port, options)
+		if err != nil {
+			t.Fatalf("Connect error: %v", err)
+		}
+		if got := conn.RemoteAddr(); got != addr {
+			t.Errorf("DialContext set %v: expected the resolved address %s, got %q", custom, addr, got)
+		}
+
+		rs, err := conn.Query("SELECT 1")
+		if err != nil {
+			t.Fatalf("Query error: %v", err)
+		}
+		status, err := rs.Wait()
+		if err != nil {
+			t.Fatalf("Wait error: %v", err)
+		}
+		if status.RemoteAddr != addr || rs.Stats().RemoteAddr != addr {
+			t.Errorf("expected %s in the status and stats, got %q and %q", addr, status.RemoteAddr, rs.Stats().RemoteAddr)
+		}
+		conn.Close()
+	}
+}
@@ -0,0 +1,128 @@
+package hive
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultPort is HiveServer2's default thrift port.
+const defaultPort = 10000
+
+// Open parses dsn and opens a session with the resulting Options. The
+// DSN has the form
+//
+//	hive://[user[:password]@]host[:port][/database][?param=value&...]
+//
+// The port defaults to 10000. Recognised parameters are batchSize,
+// pollInterval (seconds), connectTimeout and socketTimeout (Go
+// durations), auth (NOSASL, PLAIN or LDAP), connectRetries,
+// connectRetryBackoff, maxConcurrentOperations and anonymous. Parameters
+// named hiveconf:<key> are added to SessionConf as set:hiveconf:<key>.
+// Anything else is an error.
+func Open(ctx context.Context, dsn string) (*Connection, error) {
+	hostPort, options, err := parseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if options.Username != "" {
+		return connect(ctx, hostPort, &options.Username, &options.Password, options)
+	}
+	return ConnectContext(ctx, hostPort, options)
+}
+
+// parseDSN returns the address and Options described by dsn, starting
+// from DefaultOptions.
+func parseDSN(dsn string) (string, Options, error) {
+	options := DefaultOptions
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", options, fmt.Errorf("Invalid DSN: %v", err)
+	}
+	if u.Scheme != "hive" {
+		return "", options, fmt.Errorf("Invalid DSN: scheme must be hive, got %q", u.Scheme)
+	}
+	if u.Opaque != "" || u.Host == "" {
+		return "", options, fmt.Errorf("Invalid DSN %q: missing host", dsn)
+	}
+
+	options.Host = u.Hostname()
+	if options.Host == "" {
+		return "", options, fmt.Errorf("Invalid DSN %q: missing host", dsn)
+	}
+	options.Port = defaultPort
+	if p := u.Port(); p != "" {
+		port, err := strconv.Atoi(p)
+		if err != nil || port < 1 || port > 65535 {
+			return "", options, fmt.Errorf("Invalid DSN port %q", p)
+		}
+		options.Port = port
+	} else if strings.HasSuffix(u.Host, ":") {
+		return "", options, fmt.Errorf("Invalid DSN %q: empty port", dsn)
+	}
+
+	if u.User != nil {
+		options.Username = u.User.Username()
+		options.Password, _ = u.User.Password()
+	}
+
+	database := strings.TrimPrefix(u.Path, "/")
+	if strings.Contains(database, "/") {
+		return "", options, fmt.Errorf("Invalid DSN database %q", database)
+	}
+	options.Database = database
+
+	for key, values := range u.Query() {
+		if len(values) != 1 {
+			return "", options, fmt.Errorf("DSN parameter %s is given %d times", key, len(values))
+		}
+		if err := applyDSNParam(&options, key, values[0]); err != nil {
+			return "", options, err
+		}
+	}
+
+	hostPort := net.JoinHostPort(options.Host, strconv.Itoa(options.Port))
+	return hostPort, options, nil
+}
+
+func applyDSNParam(o *Options, key, value string) error {
+	var err error
+	switch key {
+	case "batchSize":
+		o.BatchSize, err = strconv.ParseInt(value, 10, 64)
+	case "pollInterval":
+		o.PollIntervalSeconds, err = strconv.ParseInt(value, 10, 64)
+	case "connectTimeout":
+		o.ConnectTimeout, err = time.ParseDuration(value)
+	case "socketTimeout":
+		o.SocketTimeout, err = time.ParseDuration(value)
+	case "auth":
+		o.AuthMechanism = strings.ToUpper(value)
+	case "connectRetries":
+		o.ConnectRetries, err = strconv.Atoi(value)
+	case "connectRetryBackoff":
+		o.ConnectRetryBackoff, err = time.ParseDuration(value)
+	case "maxConcurrentOperations":
+		o.MaxConcurrentOperations, err = strconv.Atoi(value)
+	case "anonymous":
+		o.Anonymous, err = strconv.ParseBool(value)
+	default:
+		conf, ok := strings.CutPrefix(key, "hiveconf:")
+		if !ok || conf == "" {
+			return fmt.Errorf("Unknown DSN parameter %q", key)
+		}
+		if o.SessionConf == nil {
+			o.SessionConf = map[string]string{}
+		}
+		o.SessionConf["set:hiveconf:"+conf] = value
+	}
+	if err != nil {
+		return fmt.Errorf("Invalid DSN parameter %s=%q: %v", key, value, err)
+	}
+	return nil
+}
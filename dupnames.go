@@ -0,0 +1,36 @@
+package hive
+
+import "strconv"
+
+// uniqueNames returns the keys under which decoders that build a map per
+// row store the columns named names, so that none is lost to another of
+// the same name. TColumnDesc carries no table alias: HiveServer2 itself
+// qualifies names as alias.column while
+// hive.resultset.use.unique.column.names is on, its default, which keeps
+// the columns of a join apart. With it off, or for a repeated expression
+// alias, the first column keeps its name and later ones get a suffix
+// counting the columns so named, as in id, id_2, id_3, skipping any that
+// another column is named already.
+func uniqueNames(names []string) []string {
+	taken := make(map[string]bool, len(names))
+	for _, name := range names {
+		taken[name] = true
+	}
+	keys := make([]string, len(names))
+	seen := make(map[string]int, len(names))
+	for i, name := range names {
+		seen[name]++
+		if seen[name] == 1 {
+			keys[i] = name
+			continue
+		}
+		key := name + "_" + strconv.Itoa(seen[name])
+		for n := seen[name]; taken[key]; {
+			n++
+			key = name + "_" + strconv.Itoa(n)
+		}
+		taken[key] = true
+		keys[i] = key
+	}
+	return keys
+}
@@ -0,0 +1,58 @@
+package hive
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestUniqueNames(t *testing.T) {
+	for _, tc := range []struct {
+		names, want []string
+	}{
+		{[]string{"a.id", "b.id"}, []string{"a.id", "b.id"}},
+		{[]string{"id", "name", "id"}, []string{"id", "name", "id_2"}},
+		{[]string{"id", "id", "id"}, []string{"id", "id_2", "id_3"}},
+		{[]string{"id", "id", "id_2"}, []string{"id", "id_3", "id_2"}},
+	} {
+		if got := uniqueNames(tc.names); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("uniqueNames(%q): expected %q, got %q", tc.names, tc.want, got)
+		}
+	}
+}
+
+func TestReaderDuplicateColumns(t *testing.T) {
+	// A join with hive.resultset.use.unique.column.names off.
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{
+		columnDesc("id", inf.TTypeId_INT_TYPE, 1),
+		columnDesc("name", inf.TTypeId_STRING_TYPE, 2),
+		columnDesc("id", inf.TTypeId_INT_TYPE, 3),
+	}
+	svc.batches = []*inf.TRowSet{{Columns: []*inf.TColumn{
+		{I32Val: &inf.TI32Column{Values: []int32{1, 2}, Nulls: []byte{}}},
+		{StringVal: &inf.TStringColumn{Values: []string{"a", "b"}, Nulls: []byte{}}},
+		{I32Val: &inf.TI32Column{Values: []int32{10, 20}, Nulls: []byte{}}},
+	}}}
+	conn := connectFake(t, svc, testOptions())
+	rs, err := conn.Query("SELECT a.id, a.name, b.id FROM a JOIN b ON a.name = b.name")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+
+	r, err := rs.Reader(context.Background(), FormatJSONL)
+	if err != nil {
+		t.Fatalf("Reader error: %v", err)
+	}
+	defer r.Close()
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll error: %v", err)
+	}
+	if want := `{"id":1,"id_2":10,"name":"a"}` + "\n" + `{"id":2,"id_2":20,"name":"b"}` + "\n"; string(got) != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,95 @@
+package hive
+
+import (
+	"encoding/hex"
+	"time"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// An Event describes a step in the lifecycle of a session or statement.
+// Events are published to Options.Events when it is set. The concrete
+// types are SessionOpened, StatementSubmitted, BatchFetched,
+// StatementFinished and ErrorOccurred.
+type Event interface {
+	isEvent()
+}
+
+// SessionOpened is published once a session has been opened.
+type SessionOpened struct {
+	HostPort        string
+	ProtocolVersion inf.TProtocolVersion
+}
+
+// StatementSubmitted is published when the server accepts a statement.
+type StatementSubmitted struct {
+	OperationID string
+	SQL         string
+}
+
+// BatchFetched is published for every batch of results fetched, with
+// the same measurements as RowSet.BatchMetrics.
+type BatchFetched struct {
+	OperationID string
+	Rows        int
+	Bytes       int64
+	Duration    time.Duration
+}
+
+// StatementFinished is published when a RowSet has been read to the
+// end. Duration is measured from the creation of the RowSet.
+type StatementFinished struct {
+	OperationID string
+	Rows        int64
+	Duration    time.Duration
+}
+
+// ErrorOccurred is published when a call to the server fails.
+// OperationID is empty for errors not tied to an operation. SQLState and
+// ErrorCode are the server's, when it reported the failure in a status.
+type ErrorOccurred struct {
+	OperationID string
+	Err         error
+	SQLState    string
+	ErrorCode   int32
+}
+
+func (SessionOpened) isEvent()      {}
+func (StatementSubmitted) isEvent() {}
+func (BatchFetched) isEvent()       {}
+func (StatementFinished) isEvent()  {}
+func (ErrorOccurred) isEvent()      {}
+
+// statusErrorEvent describes a failure the server reported in status,
+// which may be nil.
+func statusErrorEvent(operationID string, err error, status *inf.TStatus) ErrorOccurred {
+	ev := ErrorOccurred{OperationID: operationID, Err: err}
+	if status != nil {
+		ev.SQLState = status.GetSqlState()
+		ev.ErrorCode = status.GetErrorCode()
+	}
+	return ev
+}
+
+// emit publishes ev without blocking; events are dropped when the
+// channel is full so a slow consumer can't stall queries.
+func (o Options) emit(ev Event) {
+	if o.Events == nil {
+		return
+	}
+	select {
+	case o.Events <- ev:
+	default:
+	}
+}
+
+// operationID formats the GUID of an operation handle the way
+// HiveServer2 logs it.
+func operationID(h *inf.TOperationHandle) string {
+	guid := h.GetOperationId().GetGUID()
+	if len(guid) != 16 {
+		return hex.EncodeToString(guid)
+	}
+	s := hex.EncodeToString(guid)
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
@@ -0,0 +1,146 @@
+package hive_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+
+	"github.com/jasonlabz/hive"
+	"github.com/jasonlabz/hive/inf"
+)
+
+// A gateway that serves several thrift services on one port expects
+// requests to name the service they are for.
+func ExampleOptions_clientFactory() {
+	options := hive.DefaultOptions
+	options.ClientFactory = func(transport thrift.TTransport, pf thrift.TProtocolFactory) *inf.TCLIServiceClient {
+		protocol := pf.GetProtocol(transport)
+		mux := thrift.NewTMultiplexedProtocol(protocol, "TCLIService")
+		return inf.NewTCLIServiceClient(thrift.NewTStandardClient(protocol, mux))
+	}
+
+	conn, err := hive.ConnectContext(context.Background(), "gateway.example.com:10000", options)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+}
+
+// A command line tool stops its query on Ctrl-C. The cancellation
+// reaches the server, which stops running the query.
+func ExampleWithSignalCancel() {
+	ctx, stop := hive.WithSignalCancel(context.Background())
+	defer stop()
+
+	conn, err := hive.ConnectContext(ctx, "hs2.example.com:10000", hive.DefaultOptions)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+
+	rs, err := conn.QueryWithLogs(ctx, "SELECT COUNT(*) FROM events")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer rs.Close(context.Background())
+
+	for {
+		values, err := rs.NextValues(ctx)
+		if err == io.EOF {
+			break
+		}
+		if errors.Is(err, context.Canceled) {
+			log.Print("interrupted, query cancelled")
+			return
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(values)
+	}
+}
+
+// An export streams a result of any size into object storage a batch at
+// a time. SDK uploaders that read the object from an io.Reader, such as
+// the AWS SDK's s3manager.Uploader, are fed through a pipe; writers such
+// as the *storage.Writer of cloud.google.com/go/storage can be passed to
+// WriteCSVProgress directly, as long as the error from their Close is
+// checked. If the upload fails, the pipe fails the next write and the
+// query is cancelled on the server.
+func ExampleRowSet_WriteCSVProgress() {
+	ctx := context.Background()
+	// upload stands for the SDK call, e.g.
+	//
+	//	uploader.Upload(ctx, &s3.PutObjectInput{Bucket: bucket, Key: key, Body: body})
+	upload := func(ctx context.Context, body io.Reader) error {
+		_, err := io.Copy(io.Discard, body)
+		return err
+	}
+
+	conn, err := hive.ConnectContext(ctx, "hs2.example.com:10000", hive.DefaultOptions)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+
+	rs, err := conn.QueryContext(ctx, "SELECT * FROM events WHERE dt = '2024-03-01'")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer rs.Close(ctx)
+
+	pr, pw := io.Pipe()
+	uploaded := make(chan error, 1)
+	go func() {
+		err := upload(ctx, pr)
+		pr.CloseWithError(err)
+		uploaded <- err
+	}()
+	err = rs.WriteCSVProgress(ctx, pw, hive.CSVOptions{}, func(rows int64) {
+		log.Printf("%d rows exported", rows)
+	})
+	pw.CloseWithError(err)
+	if uploadErr := <-uploaded; err == nil {
+		err = uploadErr
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// A service logs the statements it runs and warns about slow fetches,
+// on top of the built-in metrics middleware. Middleware sees each call's
+// method and request, and wraps the rest of the chain.
+func ExampleRPCMiddleware() {
+	slowFetches := func(next hive.RPCFunc) hive.RPCFunc {
+		return func(ctx context.Context, method string, args, result thrift.TStruct) error {
+			if a, ok := args.(*inf.TCLIServiceExecuteStatementArgs); ok {
+				log.Printf("running %q", a.Req.Statement)
+			}
+			start := time.Now()
+			err := next(ctx, method, args, result)
+			if d := time.Since(start); method == "FetchResults" && d > 5*time.Second {
+				log.Printf("slow fetch: %v", d)
+			}
+			return err
+		}
+	}
+
+	options := hive.DefaultOptions
+	options.Middleware = []hive.RPCMiddleware{
+		hive.MetricsMiddleware(func(method string, d time.Duration, err error) {
+			// e.g. observe d in a latency histogram labelled by method.
+		}),
+		slowFetches,
+	}
+	conn, err := hive.ConnectContext(context.Background(), "hs2.example.com:10000", options)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+}
@@ -0,0 +1,227 @@
+package hive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// A PlanNode is one node of a parsed EXPLAIN plan: a stage, an operator
+// such as "TableScan" or "Select Operator", or a grouping the plan nests
+// operators under, such as "Map Operator Tree".
+type PlanNode struct {
+	Operator string
+	// EstimatedRows is the row estimate from the node's statistics, or
+	// -1 if the plan doesn't give one.
+	EstimatedRows int64
+	// Properties holds the node's scalar attributes as text, keyed as in
+	// the plan without the trailing colon, e.g. "alias" or "Statistics".
+	// Lists of scalars are joined with ", ".
+	Properties map[string]string
+	Children   []*PlanNode
+}
+
+// An ExplainResult is the plan of a query, see ExplainPlan.
+type ExplainResult struct {
+	// Plan is the parsed plan, rooted at a node named "EXPLAIN" whose
+	// children are the plan's top-level sections, typically "STAGE
+	// DEPENDENCIES" and "STAGE PLANS". It is nil when the server
+	// didn't return the plan as JSON.
+	Plan *PlanNode
+	// Text is the output as returned, one entry per row.
+	Text []string
+}
+
+// ExplainPlan returns the plan of query, from EXPLAIN FORMATTED, parsed
+// into a tree of PlanNodes. JSON plans need a server that answers
+// EXPLAIN FORMATTED with JSON; the parser follows the layout of Hive 2
+// and 3, whose Tez plans nest operators under vertices, and older
+// layouts parse into shallower trees. Servers that reject FORMATTED are
+// asked for a plain EXPLAIN instead, and when the output isn't JSON the
+// result has only Text.
+func (c *Connection) ExplainPlan(ctx context.Context, query string) (*ExplainResult, error) {
+	query = strings.TrimRight(strings.TrimSpace(query), ";")
+	lines, err := c.explainLines(ctx, "EXPLAIN FORMATTED "+query)
+	if err != nil {
+		var plainErr error
+		if lines, plainErr = c.explainLines(ctx, "EXPLAIN "+query); plainErr != nil {
+			return nil, err
+		}
+	}
+
+	result := &ExplainResult{Text: lines}
+	if plan, err := parsePlan(strings.Join(lines, "\n")); err == nil {
+		result.Plan = plan
+	}
+	return result, nil
+}
+
+// explainLines runs stmt and returns the first column of every row.
+func (c *Connection) explainLines(ctx context.Context, stmt string) ([]string, error) {
+	executeReq := inf.NewTExecuteStatementReq()
+	executeReq.Statement = stmt
+	rs, err := c.submit(ctx, executeReq)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close(ctx)
+
+	var lines []string
+	for {
+		values, err := rs.NextValues(ctx)
+		if err == io.EOF {
+			return lines, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(values) > 0 {
+			lines = append(lines, formatField(values[0]))
+		}
+	}
+}
+
+// parsePlan parses the JSON output of EXPLAIN FORMATTED. Objects become
+// nodes named by their key and scalars their properties; a "children"
+// object holds the operators a node feeds, which become its children.
+// Key order is kept, since it follows the plan.
+func parsePlan(s string) (*PlanNode, error) {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok != json.Delim('{') {
+		return nil, fmt.Errorf("Plan is not a JSON object")
+	}
+	root := newPlanNode("EXPLAIN")
+	if err := decodePlanObject(dec, root); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("Trailing data after plan")
+	}
+	return root, nil
+}
+
+func newPlanNode(operator string) *PlanNode {
+	return &PlanNode{Operator: operator, EstimatedRows: -1, Properties: map[string]string{}}
+}
+
+// decodePlanObject reads the members of an object, the opening brace
+// having been read, into node.
+func decodePlanObject(dec *json.Decoder, node *PlanNode) error {
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return fmt.Errorf("Unexpected %v in plan", tok)
+		}
+		if err := decodePlanValue(dec, node, strings.TrimSuffix(key, ":")); err != nil {
+			return err
+		}
+	}
+	if _, err := dec.Token(); err != nil { // closing brace
+		return err
+	}
+	node.EstimatedRows = estimatedRows(node.Properties["Statistics"])
+	return nil
+}
+
+// decodePlanValue reads the value of member key of parent.
+func decodePlanValue(dec *json.Decoder, parent *PlanNode, key string) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch tok {
+	case json.Delim('{'):
+		child := newPlanNode(key)
+		if err := decodePlanObject(dec, child); err != nil {
+			return err
+		}
+		attachPlanNode(parent, key, child)
+	case json.Delim('['):
+		// Arrays hold operators, as in "Map Operator Tree", or scalars.
+		holder := newPlanNode(key)
+		var scalars []string
+		if err := decodePlanArray(dec, holder, &scalars); err != nil {
+			return err
+		}
+		if len(scalars) > 0 {
+			parent.Properties[key] = strings.Join(scalars, ", ")
+		}
+		if len(holder.Children) > 0 || len(holder.Properties) > 0 {
+			attachPlanNode(parent, key, holder)
+		}
+	default:
+		if tok != nil {
+			parent.Properties[key] = fmt.Sprint(tok)
+		}
+	}
+	return nil
+}
+
+// decodePlanArray reads the elements of an array, the opening bracket
+// having been read. Objects' members go into holder, scalars into
+// scalars.
+func decodePlanArray(dec *json.Decoder, holder *PlanNode, scalars *[]string) error {
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'):
+			if err := decodePlanObject(dec, holder); err != nil {
+				return err
+			}
+		case json.Delim('['):
+			if err := decodePlanArray(dec, holder, scalars); err != nil {
+				return err
+			}
+		default:
+			if tok != nil {
+				*scalars = append(*scalars, fmt.Sprint(tok))
+			}
+		}
+	}
+	_, err := dec.Token() // closing bracket
+	return err
+}
+
+// attachPlanNode adds child, the value of member key, to parent. The
+// operators under "children" are attached directly.
+func attachPlanNode(parent *PlanNode, key string, child *PlanNode) {
+	if key == "children" {
+		parent.Children = append(parent.Children, child.Children...)
+		return
+	}
+	parent.Children = append(parent.Children, child)
+}
+
+var numRowsPattern = regexp.MustCompile(`Num rows: (\d+)`)
+
+// estimatedRows reads the row count from an operator's statistics, as in
+// "Num rows: 500 Data size: 5312 Basic stats: COMPLETE Column stats: NONE".
+func estimatedRows(stats string) int64 {
+	m := numRowsPattern.FindStringSubmatch(stats)
+	if m == nil {
+		return -1
+	}
+	n, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
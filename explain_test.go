@@ -0,0 +1,98 @@
+package hive
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// explainJSON is EXPLAIN FORMATTED output from Hive 3, trimmed.
+const explainJSON = `{"STAGE DEPENDENCIES":{"Stage-1":{"ROOT STAGE":"TRUE"},"Stage-0":{"DEPENDENT STAGES":"Stage-1"}},` +
+	`"STAGE PLANS":{"Stage-1":{"Tez":{"DagId:":"hive_1","Vertices:":{"Map 1":{"Map Operator Tree:":[` +
+	`{"TableScan":{"alias:":"t","Statistics:":"Num rows: 500 Data size: 5312 Basic stats: COMPLETE Column stats: NONE",` +
+	`"children":{"Filter Operator":{"predicate:":"(id > 10) (type: boolean)","Statistics:":"Num rows: 166 Data size: 1763 Basic stats: COMPLETE Column stats: NONE",` +
+	`"children":{"Select Operator":{"expressions:":"id (type: int)","outputColumnNames:":["_col0"],"children":{"File Output Operator":{"compressed:":"false"}}}}}}}}]}}}},` +
+	`"Stage-0":{"Fetch Operator":{"limit:":"-1","Processor Tree:":{"ListSink":{}}}}}}`
+
+func TestExplainPlan(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("Explain", inf.TTypeId_STRING_TYPE, 1)}
+	svc.results = map[string][]*inf.TRowSet{"EXPLAIN FORMATTED SELECT id FROM t WHERE id > 10": {stringBatch(explainJSON)}}
+	conn := connectFake(t, svc, testOptions())
+
+	result, err := conn.ExplainPlan(context.Background(), "SELECT id FROM t WHERE id > 10;")
+	if err != nil {
+		t.Fatalf("ExplainPlan error: %v", err)
+	}
+	if !reflect.DeepEqual(result.Text, []string{explainJSON}) {
+		t.Errorf("expected the raw output kept, got %q", result.Text)
+	}
+	plan := result.Plan
+	if plan == nil || len(plan.Children) != 2 || plan.Children[1].Operator != "STAGE PLANS" {
+		t.Fatalf("expected the two top-level sections, got %+v", plan)
+	}
+	stages := plan.Children[1].Children
+	if len(stages) != 2 || stages[0].Operator != "Stage-1" || stages[1].Operator != "Stage-0" {
+		t.Fatalf("expected the stages in plan order, got %+v", stages)
+	}
+
+	// Stage-1 > Tez > Vertices > Map 1 > Map Operator Tree > TableScan
+	scan := stages[0]
+	for _, name := range []string{"Tez", "Vertices", "Map 1", "Map Operator Tree", "TableScan"} {
+		if len(scan.Children) != 1 || scan.Children[0].Operator != name {
+			t.Fatalf("expected %s under %s, got %+v", name, scan.Operator, scan.Children)
+		}
+		scan = scan.Children[0]
+	}
+	if scan.EstimatedRows != 500 || scan.Properties["alias"] != "t" {
+		t.Errorf("expected a scan of t estimated at 500 rows, got %+v", scan)
+	}
+	var ops []string
+	var rows []int64
+	for n := scan; n != nil; {
+		ops, rows = append(ops, n.Operator), append(rows, n.EstimatedRows)
+		if len(n.Children) == 0 {
+			break
+		}
+		n = n.Children[0]
+	}
+	if want := []string{"TableScan", "Filter Operator", "Select Operator", "File Output Operator"}; !reflect.DeepEqual(ops, want) {
+		t.Errorf("expected operators %q, got %q", want, ops)
+	}
+	if want := []int64{500, 166, -1, -1}; !reflect.DeepEqual(rows, want) {
+		t.Errorf("expected estimates %v, got %v", want, rows)
+	}
+	if got := scan.Children[0].Children[0].Properties["outputColumnNames"]; got != "_col0" {
+		t.Errorf("expected the output columns as a property, got %q", got)
+	}
+}
+
+func TestExplainPlanText(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("Explain", inf.TTypeId_STRING_TYPE, 1)}
+	lines := []string{"STAGE DEPENDENCIES:", "  Stage-0 is a root stage", ""}
+	svc.results = map[string][]*inf.TRowSet{"EXPLAIN SELECT 1": {stringBatch(lines...)}}
+	svc.onExecute = func(req *inf.TExecuteStatementReq) (*inf.TExecuteStatementResp, error) {
+		if strings.HasPrefix(req.Statement, "EXPLAIN FORMATTED") {
+			return &inf.TExecuteStatementResp{Status: errorStatus("cannot recognize input near 'FORMATTED'")}, nil
+		}
+		svc.mu.Lock()
+		defer svc.mu.Unlock()
+		return &inf.TExecuteStatementResp{Status: okStatus(), OperationHandle: svc.newOperation(req.Statement)}, nil
+	}
+	conn := connectFake(t, svc, testOptions())
+
+	result, err := conn.ExplainPlan(context.Background(), "SELECT 1")
+	if err != nil {
+		t.Fatalf("ExplainPlan error: %v", err)
+	}
+	if result.Plan != nil {
+		t.Errorf("expected no parsed plan for text output, got %+v", result.Plan)
+	}
+	if !reflect.DeepEqual(result.Text, lines) {
+		t.Errorf("expected the text lines %q, got %q", lines, result.Text)
+	}
+}
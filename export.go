@@ -0,0 +1,45 @@
+package hive
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// exportFormats maps the formats accepted by ExportQuery to the storage
+// clause written after the directory.
+var exportFormats = map[string]string{
+	"TEXTFILE": "STORED AS TEXTFILE",
+	"ORC":      "STORED AS ORC",
+	"PARQUET":  "STORED AS PARQUET",
+	"CSV":      "ROW FORMAT DELIMITED FIELDS TERMINATED BY ',' STORED AS TEXTFILE",
+}
+
+// ExportQuery has the server write the results of a SELECT to destPath
+// with INSERT OVERWRITE DIRECTORY, instead of streaming them back to the
+// client. format is one of TEXTFILE, ORC, PARQUET or CSV. Existing files
+// under destPath are replaced. It returns once the export has finished.
+func (c *Connection) ExportQuery(ctx context.Context, query, destPath, format string) error {
+	storage, ok := exportFormats[strings.ToUpper(format)]
+	if !ok {
+		return fmt.Errorf("Unsupported export format %q", format)
+	}
+	if destPath == "" {
+		return fmt.Errorf("ExportQuery requires a destination path")
+	}
+
+	executeReq := inf.NewTExecuteStatementReq()
+	executeReq.Statement = "INSERT OVERWRITE DIRECTORY " + quoteString(destPath) + " " + storage + " " +
+		strings.TrimRight(strings.TrimSpace(query), ";")
+	executeReq.RunAsync = true
+
+	rs, err := c.submit(ctx, executeReq)
+	if err != nil {
+		return err
+	}
+	defer rs.Close(ctx)
+	_, err = rs.wait(ctx)
+	return err
+}
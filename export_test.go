@@ -0,0 +1,47 @@
+package hive
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestExportQuery(t *testing.T) {
+	tests := []struct {
+		format, path string
+		expected     string
+	}{
+		{"ORC", "/exports/orders", `INSERT OVERWRITE DIRECTORY '/exports/orders' STORED AS ORC SELECT * FROM orders`},
+		{"textfile", "/exports/orders", `INSERT OVERWRITE DIRECTORY '/exports/orders' STORED AS TEXTFILE SELECT * FROM orders`},
+		{"PARQUET", "/exports/orders", `INSERT OVERWRITE DIRECTORY '/exports/orders' STORED AS PARQUET SELECT * FROM orders`},
+		{"CSV", "/exports/orders", `INSERT OVERWRITE DIRECTORY '/exports/orders' ROW FORMAT DELIMITED FIELDS TERMINATED BY ',' STORED AS TEXTFILE SELECT * FROM orders`},
+		{"ORC", `/exports/o'brien\2024`, `INSERT OVERWRITE DIRECTORY '/exports/o\'brien\\2024' STORED AS ORC SELECT * FROM orders`},
+	}
+	for _, tt := range tests {
+		svc := newFakeService()
+		conn := connectFake(t, svc, testOptions())
+
+		if err := conn.ExportQuery(context.Background(), " SELECT * FROM orders; ", tt.path, tt.format); err != nil {
+			t.Fatalf("ExportQuery(%q, %q) error: %v", tt.path, tt.format, err)
+		}
+		if statements := svc.executed(); !reflect.DeepEqual(statements, []string{tt.expected}) {
+			t.Errorf("ExportQuery(%q, %q): expected %q, got %q", tt.path, tt.format, tt.expected, statements)
+		}
+	}
+}
+
+func TestExportQueryRejectsArguments(t *testing.T) {
+	svc := newFakeService()
+	conn := connectFake(t, svc, testOptions())
+	ctx := context.Background()
+
+	if err := conn.ExportQuery(ctx, "SELECT * FROM orders", "/exports/orders", "AVRO"); err == nil {
+		t.Error("expected an error for an unsupported format")
+	}
+	if err := conn.ExportQuery(ctx, "SELECT * FROM orders", "", "ORC"); err == nil {
+		t.Error("expected an error for an empty path")
+	}
+	if statements := svc.executed(); len(statements) != 0 {
+		t.Errorf("expected nothing to be sent, got %q", statements)
+	}
+}
@@ -0,0 +1,608 @@
+package hive
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// fakeService is an in-process HiveServer2 used by the unit tests. By
+// default every call succeeds: statements finish immediately and fetches
+// serve batches in order, followed by empty batches. Tests customise the
+// canned data or override individual calls through the on* hooks.
+type fakeService struct {
+	mu sync.Mutex
+
+	protocol inf.TProtocolVersion
+	schema   []*inf.TColumnDesc
+	batches  []*inf.TRowSet
+	// results overrides batches for specific statements.
+	results map[string][]*inf.TRowSet
+	states  []inf.TOperationState
+	logs    []string
+	// saslUsers, if set, makes the server require a SASL PLAIN
+	// negotiation with one of these username/password pairs.
+	saslUsers map[string]string
+	// noScroll rejects FETCH_FIRST like servers without scrollable
+	// cursors.
+	noScroll bool
+	// tlsConfig, if set, makes the server accept TLS connections only.
+	tlsConfig *tls.Config
+	// reverseLimit, if positive, makes the server accept FETCH_LAST and
+	// FETCH_PRIOR on single string column results, returning at most
+	// that many rows each. Otherwise they are rejected like HiveServer2
+	// does.
+	reverseLimit int64
+
+	onOpenSession  func(*inf.TOpenSessionReq) (*inf.TOpenSessionResp, error)
+	onCloseSession func(*inf.TCloseSessionReq) (*inf.TCloseSessionResp, error)
+	onExecute      func(*inf.TExecuteStatementReq) (*inf.TExecuteStatementResp, error)
+	onStatus       func(*inf.TGetOperationStatusReq) (*inf.TGetOperationStatusResp, error)
+	onFetch        func(*inf.TFetchResultsReq) (*inf.TFetchResultsResp, error)
+	onCancel       func(*inf.TCancelOperationReq) (*inf.TCancelOperationResp, error)
+	onClose        func(*inf.TCloseOperationReq) (*inf.TCloseOperationResp, error)
+	onGetInfo      func(*inf.TGetInfoReq) (*inf.TGetInfoResp, error)
+	// onGetQueryId, if set, makes the server implement GetQueryId like
+	// Hive 2.3+; without it the call is an unknown method.
+	onGetQueryId func(*inf.TOperationHandle) string
+	// onSetClientInfo, if set, makes the server implement SetClientInfo
+	// like Hive 4; without it the call is an unknown method.
+	onSetClientInfo func(map[string]string) *inf.TStatus
+	// modifiedRows, if set, is sent as numModifiedRows in every
+	// GetOperationStatus response, like Hive 3 does after DML.
+	modifiedRows *int64
+
+	sessions   []*inf.TOpenSessionReq
+	executes   []*inf.TExecuteStatementReq
+	statements []string
+	fetches    []*inf.TFetchResultsReq
+	cancels    []*inf.TOperationHandle
+	closes     []*inf.TOperationHandle
+	// metadata lists the metadata calls made, such as "GetTables".
+	metadata []string
+
+	ops    map[string]*fakeOperation
+	nextID uint64
+}
+
+type fakeOperation struct {
+	statement string
+	polls     int
+	batch     int
+	logsRead  bool
+	// prior is the row before which FETCH_PRIOR reads.
+	prior int64
+}
+
+func newFakeService() *fakeService {
+	return &fakeService{
+		protocol: inf.TProtocolVersion_HIVE_CLI_SERVICE_PROTOCOL_V7,
+		ops:      map[string]*fakeOperation{},
+	}
+}
+
+// startFakeServer serves svc on a loopback address for the lifetime of
+// the test, returning the address to connect to.
+func startFakeServer(t testing.TB, svc *fakeService) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen error: %v", err)
+	}
+	if svc.tlsConfig != nil {
+		l = tls.NewListener(l, svc.tlsConfig)
+	}
+
+	var (
+		mu    sync.Mutex
+		conns []net.Conn
+	)
+	processor := inf.NewTCLIServiceProcessor(svc)
+	if svc.onGetQueryId != nil {
+		processor.AddToProcessorMap("GetQueryId", fakeGetQueryID{svc})
+	}
+	if svc.onSetClientInfo != nil {
+		processor.AddToProcessorMap("SetClientInfo", fakeSetClientInfo{svc})
+	}
+	if svc.modifiedRows != nil {
+		processor.AddToProcessorMap("GetOperationStatus", fakeGetOperationStatus{svc})
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			conns = append(conns, conn)
+			mu.Unlock()
+			go serveFakeConn(svc, processor, conn)
+		}
+	}()
+
+	t.Cleanup(func() {
+		l.Close()
+		mu.Lock()
+		defer mu.Unlock()
+		for _, conn := range conns {
+			conn.Close()
+		}
+	})
+
+	return l.Addr().String()
+}
+
+func serveFakeConn(svc *fakeService, processor thrift.TProcessor, conn net.Conn) {
+	defer conn.Close()
+	var transport thrift.TTransport = thrift.NewTSocketFromConnConf(conn, nil)
+	if svc.saslUsers != nil {
+		if !fakeSaslHandshake(svc, transport) {
+			return
+		}
+		transport = thrift.NewTFramedTransportConf(transport, nil)
+	}
+	protocol := thrift.NewTBinaryProtocolConf(transport, nil)
+	for {
+		ok, err := processor.Process(context.Background(), protocol, protocol)
+		var appErr thrift.TApplicationException
+		if errors.As(err, &appErr) && appErr.TypeId() == thrift.UNKNOWN_METHOD {
+			// Like HiveServer2, keep serving after an unknown method.
+			continue
+		}
+		if err != nil || !ok {
+			return
+		}
+	}
+}
+
+// fakeSaslHandshake plays the server side of a SASL PLAIN negotiation,
+// rejecting unknown credentials the way HiveServer2's LDAP provider does.
+func fakeSaslHandshake(svc *fakeService, transport thrift.TTransport) bool {
+	status, mechanism, err := readSaslMessage(transport)
+	if err != nil || status != saslStart || string(mechanism) != "PLAIN" {
+		writeSaslMessage(context.Background(), transport, saslError, []byte("Unsupported mechanism"))
+		return false
+	}
+	_, response, err := readSaslMessage(transport)
+	if err != nil {
+		return false
+	}
+
+	parts := strings.Split(string(response), "\x00")
+	if len(parts) != 3 || svc.saslUsers[parts[1]] != parts[2] || parts[2] == "" {
+		writeSaslMessage(context.Background(), transport, saslBad, []byte("Error validating LDAP user"))
+		return false
+	}
+	return writeSaslMessage(context.Background(), transport, saslComplete, nil) == nil
+}
+
+// testOptions are DefaultOptions with timeouts suited to a loopback server.
+func testOptions() Options {
+	options := DefaultOptions
+	options.PollIntervalSeconds = 0
+	options.ConnectTimeout = time.Second
+	options.SocketTimeout = 5 * time.Second
+	return options
+}
+
+// connectFake starts a server for svc and opens a session against it.
+func connectFake(t testing.TB, svc *fakeService, options Options) *Connection {
+	conn, err := ConnectContext(context.Background(), startFakeServer(t, svc), options)
+	if err != nil {
+		t.Fatalf("Connect error: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func okStatus() *inf.TStatus {
+	return &inf.TStatus{StatusCode: inf.TStatusCode_SUCCESS_STATUS}
+}
+
+func errorStatus(msg string) *inf.TStatus {
+	return &inf.TStatus{StatusCode: inf.TStatusCode_ERROR_STATUS, ErrorMessage: &msg}
+}
+
+// columnDesc builds a column descriptor for a primitive type.
+func columnDesc(name string, typ inf.TTypeId, position int32) *inf.TColumnDesc {
+	return &inf.TColumnDesc{
+		ColumnName: name,
+		Position:   position,
+		TypeDesc: &inf.TTypeDesc{Types: []*inf.TTypeEntry{
+			{PrimitiveEntry: &inf.TPrimitiveTypeEntry{Type: typ}},
+		}},
+	}
+}
+
+// stringBatch builds a single-column string batch.
+func stringBatch(values ...string) *inf.TRowSet {
+	return &inf.TRowSet{Columns: []*inf.TColumn{
+		{StringVal: &inf.TStringColumn{Values: values, Nulls: []byte{}}},
+	}}
+}
+
+// reportVersion makes the server answer GetInfo for CLI_DBMS_VER with
+// version, and with "Apache Hive" for anything else.
+func (s *fakeService) reportVersion(version string) {
+	s.onGetInfo = func(req *inf.TGetInfoReq) (*inf.TGetInfoResp, error) {
+		v := "Apache Hive"
+		if req.InfoType == inf.TGetInfoType_CLI_DBMS_VER {
+			v = version
+		}
+		return &inf.TGetInfoResp{Status: okStatus(), InfoValue: &inf.TGetInfoValue{StringValue: thrift.StringPtr(v)}}, nil
+	}
+}
+
+func (s *fakeService) newOperation(statement string) *inf.TOperationHandle {
+	s.nextID++
+	guid := make([]byte, 16)
+	binary.BigEndian.PutUint64(guid[8:], s.nextID)
+	s.ops[string(guid)] = &fakeOperation{statement: statement}
+	return &inf.TOperationHandle{
+		OperationId:   &inf.THandleIdentifier{GUID: guid, Secret: make([]byte, 16)},
+		OperationType: inf.TOperationType_EXECUTE_STATEMENT,
+		HasResultSet:  true,
+	}
+}
+
+func (s *fakeService) operation(h *inf.TOperationHandle) *fakeOperation {
+	if h == nil || h.OperationId == nil {
+		return nil
+	}
+	return s.ops[string(h.OperationId.GUID)]
+}
+
+func (s *fakeService) executed() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.statements...)
+}
+
+func (s *fakeService) OpenSession(ctx context.Context, req *inf.TOpenSessionReq) (*inf.TOpenSessionResp, error) {
+	s.mu.Lock()
+	s.sessions = append(s.sessions, req)
+	s.mu.Unlock()
+	if s.onOpenSession != nil {
+		return s.onOpenSession(req)
+	}
+	// Like HiveServer2, answer with the older of the two versions.
+	return &inf.TOpenSessionResp{
+		Status:                okStatus(),
+		ServerProtocolVersion: min(req.ClientProtocol, s.protocol),
+		SessionHandle: &inf.TSessionHandle{SessionId: &inf.THandleIdentifier{
+			GUID: make([]byte, 16), Secret: make([]byte, 16),
+		}},
+	}, nil
+}
+
+func (s *fakeService) CloseSession(ctx context.Context, req *inf.TCloseSessionReq) (*inf.TCloseSessionResp, error) {
+	if s.onCloseSession != nil {
+		return s.onCloseSession(req)
+	}
+	return &inf.TCloseSessionResp{Status: okStatus()}, nil
+}
+
+func (s *fakeService) GetInfo(ctx context.Context, req *inf.TGetInfoReq) (*inf.TGetInfoResp, error) {
+	if s.onGetInfo != nil {
+		return s.onGetInfo(req)
+	}
+	return &inf.TGetInfoResp{Status: okStatus(), InfoValue: &inf.TGetInfoValue{StringValue: thrift.StringPtr("Hive")}}, nil
+}
+
+func (s *fakeService) ExecuteStatement(ctx context.Context, req *inf.TExecuteStatementReq) (*inf.TExecuteStatementResp, error) {
+	s.mu.Lock()
+	s.executes = append(s.executes, req)
+	s.statements = append(s.statements, req.Statement)
+	s.mu.Unlock()
+	if s.onExecute != nil {
+		return s.onExecute(req)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &inf.TExecuteStatementResp{Status: okStatus(), OperationHandle: s.newOperation(req.Statement)}, nil
+}
+
+func (s *fakeService) metadataOperation(name string) (*inf.TStatus, *inf.TOperationHandle) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metadata = append(s.metadata, name)
+	return okStatus(), s.newOperation(name)
+}
+
+func (s *fakeService) GetTypeInfo(ctx context.Context, req *inf.TGetTypeInfoReq) (*inf.TGetTypeInfoResp, error) {
+	status, op := s.metadataOperation("GetTypeInfo")
+	return &inf.TGetTypeInfoResp{Status: status, OperationHandle: op}, nil
+}
+
+func (s *fakeService) GetCatalogs(ctx context.Context, req *inf.TGetCatalogsReq) (*inf.TGetCatalogsResp, error) {
+	status, op := s.metadataOperation("GetCatalogs")
+	return &inf.TGetCatalogsResp{Status: status, OperationHandle: op}, nil
+}
+
+func (s *fakeService) GetSchemas(ctx context.Context, req *inf.TGetSchemasReq) (*inf.TGetSchemasResp, error) {
+	status, op := s.metadataOperation("GetSchemas")
+	return &inf.TGetSchemasResp{Status: status, OperationHandle: op}, nil
+}
+
+func (s *fakeService) GetTables(ctx context.Context, req *inf.TGetTablesReq) (*inf.TGetTablesResp, error) {
+	status, op := s.metadataOperation("GetTables")
+	return &inf.TGetTablesResp{Status: status, OperationHandle: op}, nil
+}
+
+func (s *fakeService) GetTableTypes(ctx context.Context, req *inf.TGetTableTypesReq) (*inf.TGetTableTypesResp, error) {
+	status, op := s.metadataOperation("GetTableTypes")
+	return &inf.TGetTableTypesResp{Status: status, OperationHandle: op}, nil
+}
+
+func (s *fakeService) GetColumns(ctx context.Context, req *inf.TGetColumnsReq) (*inf.TGetColumnsResp, error) {
+	status, op := s.metadataOperation("GetColumns")
+	return &inf.TGetColumnsResp{Status: status, OperationHandle: op}, nil
+}
+
+func (s *fakeService) GetFunctions(ctx context.Context, req *inf.TGetFunctionsReq) (*inf.TGetFunctionsResp, error) {
+	status, op := s.metadataOperation("GetFunctions")
+	return &inf.TGetFunctionsResp{Status: status, OperationHandle: op}, nil
+}
+
+func (s *fakeService) GetPrimaryKeys(ctx context.Context, req *inf.TGetPrimaryKeysReq) (*inf.TGetPrimaryKeysResp, error) {
+	status, op := s.metadataOperation("GetPrimaryKeys")
+	return &inf.TGetPrimaryKeysResp{Status: status, OperationHandle: op}, nil
+}
+
+func (s *fakeService) GetCrossReference(ctx context.Context, req *inf.TGetCrossReferenceReq) (*inf.TGetCrossReferenceResp, error) {
+	status, op := s.metadataOperation("GetCrossReference")
+	return &inf.TGetCrossReferenceResp{Status: status, OperationHandle: op}, nil
+}
+
+func (s *fakeService) GetOperationStatus(ctx context.Context, req *inf.TGetOperationStatusReq) (*inf.TGetOperationStatusResp, error) {
+	if s.onStatus != nil {
+		return s.onStatus(req)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := inf.TOperationState_FINISHED_STATE
+	if op := s.operation(req.OperationHandle); op != nil && len(s.states) > 0 {
+		i := op.polls
+		if i >= len(s.states) {
+			i = len(s.states) - 1
+		}
+		state = s.states[i]
+		op.polls++
+	}
+	return &inf.TGetOperationStatusResp{Status: okStatus(), OperationState: &state}, nil
+}
+
+func (s *fakeService) CancelOperation(ctx context.Context, req *inf.TCancelOperationReq) (*inf.TCancelOperationResp, error) {
+	s.mu.Lock()
+	s.cancels = append(s.cancels, req.OperationHandle)
+	s.mu.Unlock()
+	if s.onCancel != nil {
+		return s.onCancel(req)
+	}
+	return &inf.TCancelOperationResp{Status: okStatus()}, nil
+}
+
+func (s *fakeService) CloseOperation(ctx context.Context, req *inf.TCloseOperationReq) (*inf.TCloseOperationResp, error) {
+	s.mu.Lock()
+	s.closes = append(s.closes, req.OperationHandle)
+	s.mu.Unlock()
+	if s.onClose != nil {
+		return s.onClose(req)
+	}
+	return &inf.TCloseOperationResp{Status: okStatus()}, nil
+}
+
+func (s *fakeService) GetResultSetMetadata(ctx context.Context, req *inf.TGetResultSetMetadataReq) (*inf.TGetResultSetMetadataResp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &inf.TGetResultSetMetadataResp{
+		Status: okStatus(),
+		Schema: &inf.TTableSchema{Columns: s.schema},
+	}, nil
+}
+
+func (s *fakeService) FetchResults(ctx context.Context, req *inf.TFetchResultsReq) (*inf.TFetchResultsResp, error) {
+	s.mu.Lock()
+	s.fetches = append(s.fetches, req)
+	s.mu.Unlock()
+	if s.onFetch != nil {
+		return s.onFetch(req)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resp := &inf.TFetchResultsResp{Status: okStatus(), HasMoreRows: thrift.BoolPtr(false)}
+	op := s.operation(req.OperationHandle)
+	switch {
+	case op == nil:
+		return &inf.TFetchResultsResp{Status: errorStatus("Invalid OperationHandle")}, nil
+	case req.Orientation == inf.TFetchOrientation_FETCH_FIRST && req.FetchType != int16(FetchLogs):
+		if s.noScroll {
+			return &inf.TFetchResultsResp{Status: errorStatus("The fetch type FETCH_FIRST is not supported for this resultset")}, nil
+		}
+		// Rewind, then step over whole batches until MaxRows are covered.
+		op.batch = 0
+		resp.Results = &inf.TRowSet{Columns: emptyColumns(s.schema)}
+		for rows := int64(0); rows < req.MaxRows && op.batch < len(s.batchesFor(op)); op.batch++ {
+			resp.Results = s.batchesFor(op)[op.batch]
+			rows += int64(len(resp.Results.Columns[0].GetStringVal().GetValues()))
+		}
+	case (req.Orientation == inf.TFetchOrientation_FETCH_LAST || req.Orientation == inf.TFetchOrientation_FETCH_PRIOR) && req.FetchType != int16(FetchLogs):
+		if s.reverseLimit <= 0 {
+			return &inf.TFetchResultsResp{Status: errorStatus("The fetch type " + req.Orientation.String() + " is not supported for this resultset")}, nil
+		}
+		var rows []string
+		for _, b := range s.batchesFor(op) {
+			rows = append(rows, b.Columns[0].GetStringVal().GetValues()...)
+		}
+		end := op.prior
+		if req.Orientation == inf.TFetchOrientation_FETCH_LAST {
+			end = int64(len(rows))
+		}
+		start := end - min(req.MaxRows, s.reverseLimit)
+		if start < 0 {
+			start = 0
+		}
+		op.prior = start
+		resp.Results = stringBatch(rows[start:end]...)
+	case req.FetchType == int16(FetchLogs):
+		resp.Results = stringBatch()
+		if !op.logsRead || req.Orientation == inf.TFetchOrientation_FETCH_FIRST {
+			resp.Results = stringBatch(s.logs...)
+			op.logsRead = true
+		}
+	case op.batch < len(s.batchesFor(op)):
+		resp.Results = s.batchesFor(op)[op.batch]
+		op.batch++
+	default:
+		resp.Results = &inf.TRowSet{Columns: emptyColumns(s.schema)}
+	}
+	return resp, nil
+}
+
+func (s *fakeService) batchesFor(op *fakeOperation) []*inf.TRowSet {
+	if batches, ok := s.results[op.statement]; ok {
+		return batches
+	}
+	return s.batches
+}
+
+// emptyColumns returns a zero-row column set matching schema.
+func emptyColumns(schema []*inf.TColumnDesc) []*inf.TColumn {
+	cols := make([]*inf.TColumn, len(schema))
+	for i := range cols {
+		cols[i] = &inf.TColumn{StringVal: &inf.TStringColumn{Values: []string{}, Nulls: []byte{}}}
+	}
+	return cols
+}
+
+func (s *fakeService) GetDelegationToken(ctx context.Context, req *inf.TGetDelegationTokenReq) (*inf.TGetDelegationTokenResp, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *fakeService) CancelDelegationToken(ctx context.Context, req *inf.TCancelDelegationTokenReq) (*inf.TCancelDelegationTokenResp, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *fakeService) RenewDelegationToken(ctx context.Context, req *inf.TRenewDelegationTokenReq) (*inf.TRenewDelegationTokenResp, error) {
+	return nil, errors.New("not implemented")
+}
+
+// fakeClock advances instantly: After records the requested duration,
+// moves the clock forward and fires immediately.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sleeps = append(c.sleeps, d)
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func (c *fakeClock) slept() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]time.Duration(nil), c.sleeps...)
+}
+
+// fakeGetQueryID serves GetQueryId, which the generated processor lacks.
+type fakeGetQueryID struct {
+	svc *fakeService
+}
+
+func (p fakeGetQueryID) Process(ctx context.Context, seqID int32, iprot, oprot thrift.TProtocol) (bool, thrift.TException) {
+	var args getQueryIDArgs
+	if err := args.Read(ctx, iprot); err != nil {
+		return false, thrift.WrapTException(err)
+	}
+	iprot.ReadMessageEnd(ctx)
+
+	result := getQueryIDResult{Success: &getQueryIDResp{QueryID: p.svc.onGetQueryId(args.Req.OperationHandle)}}
+	oprot.WriteMessageBegin(ctx, "GetQueryId", thrift.REPLY, seqID)
+	result.Write(ctx, oprot)
+	oprot.WriteMessageEnd(ctx)
+	return true, thrift.WrapTException(oprot.Flush(ctx))
+}
+
+// fakeSetClientInfo serves SetClientInfo, which the generated processor
+// lacks.
+type fakeSetClientInfo struct {
+	svc *fakeService
+}
+
+func (p fakeSetClientInfo) Process(ctx context.Context, seqID int32, iprot, oprot thrift.TProtocol) (bool, thrift.TException) {
+	var args setClientInfoArgs
+	if err := args.Read(ctx, iprot); err != nil {
+		return false, thrift.WrapTException(err)
+	}
+	iprot.ReadMessageEnd(ctx)
+
+	result := setClientInfoResult{Success: &setClientInfoResp{Status: p.svc.onSetClientInfo(args.Req.Configuration)}}
+	oprot.WriteMessageBegin(ctx, "SetClientInfo", thrift.REPLY, seqID)
+	result.Write(ctx, oprot)
+	oprot.WriteMessageEnd(ctx)
+	return true, thrift.WrapTException(oprot.Flush(ctx))
+}
+
+// fakeGetOperationStatus serves GetOperationStatus with numModifiedRows,
+// which the generated structs lack. Requests for a progress update are
+// answered with the generated structs instead, which carry the rest of
+// the response.
+type fakeGetOperationStatus struct {
+	svc *fakeService
+}
+
+func (p fakeGetOperationStatus) Process(ctx context.Context, seqID int32, iprot, oprot thrift.TProtocol) (bool, thrift.TException) {
+	var args inf.TCLIServiceGetOperationStatusArgs
+	if err := args.Read(ctx, iprot); err != nil {
+		return false, thrift.WrapTException(err)
+	}
+	iprot.ReadMessageEnd(ctx)
+
+	resp, _ := p.svc.GetOperationStatus(ctx, args.Req)
+	var result thrift.TStruct = &operationStatusResult{Success: &operationStatusResp{
+		Status:          resp.Status,
+		OperationState:  resp.OperationState,
+		NumModifiedRows: p.svc.modifiedRows,
+	}}
+	if args.Req.GetGetProgressUpdate() {
+		result = &inf.TCLIServiceGetOperationStatusResult{Success: resp}
+	}
+	oprot.WriteMessageBegin(ctx, "GetOperationStatus", thrift.REPLY, seqID)
+	result.Write(ctx, oprot)
+	oprot.WriteMessageEnd(ctx)
+	return true, thrift.WrapTException(oprot.Flush(ctx))
+}
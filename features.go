@@ -0,0 +1,66 @@
+package hive
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// Features reported to Options.OnFeatureDowngrade.
+const (
+	// FeatureAsync is asynchronous execution, from protocol V2. Older
+	// servers run every statement to completion within ExecuteStatement,
+	// so Connection calls hold the connection until it is done and
+	// can't be cancelled from the client.
+	FeatureAsync = "async"
+	// FeatureColumnar is columnar results, from protocol V6, unless
+	// Options.PreferColumnarResults turns them off.
+	FeatureColumnar = "columnar"
+	// FeatureQueryTimeout is the server-enforced query timeout, from
+	// Hive 2.3, checked when Options.QueryDefaults sets a Timeout. The
+	// protocol version doesn't tell, so it is probed with GetInfo; on
+	// older servers only the client-side backstop of QueryWithTimeout
+	// applies.
+	FeatureQueryTimeout = "query timeout"
+)
+
+// featureDowngrades lists the features the client asks for that the
+// session's server doesn't support. Failed GetInfo probes are taken as
+// no answer, and don't report a downgrade.
+func (c *Connection) featureDowngrades(ctx context.Context) []string {
+	var features []string
+	if c.protocol < inf.TProtocolVersion_HIVE_CLI_SERVICE_PROTOCOL_V2 {
+		features = append(features, FeatureAsync)
+	}
+	columnar := c.options.PreferColumnarResults == nil || *c.options.PreferColumnarResults
+	if columnar && c.protocol < inf.TProtocolVersion_HIVE_CLI_SERVICE_PROTOCOL_V6 {
+		features = append(features, FeatureColumnar)
+	}
+	if c.options.QueryDefaults.Timeout > 0 {
+		name, err := c.getInfo(ctx, inf.TGetInfoType_CLI_DBMS_NAME)
+		if err == nil && strings.Contains(name.GetStringValue(), "Hive") {
+			if version, err := c.getInfo(ctx, inf.TGetInfoType_CLI_DBMS_VER); err == nil && !versionAtLeast(version.GetStringValue(), 2, 3) {
+				features = append(features, FeatureQueryTimeout)
+			}
+		}
+	}
+	return features
+}
+
+// versionAtLeast reports whether a version such as "2.1.1" or
+// "3.1.3.7.1.7.0-551" is at least major.minor. Versions it can't read
+// count as new enough.
+func versionAtLeast(version string, major, minor int) bool {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return true
+	}
+	gotMajor, err1 := strconv.Atoi(parts[0])
+	gotMinor, err2 := strconv.Atoi(strings.TrimRightFunc(parts[1], func(r rune) bool { return r < '0' || r > '9' }))
+	if err1 != nil || err2 != nil {
+		return true
+	}
+	return gotMajor > major || gotMajor == major && gotMinor >= minor
+}
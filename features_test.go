@@ -0,0 +1,75 @@
+package hive
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestFeatureDowngrade(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		protocol inf.TProtocolVersion
+		columnar bool
+		version  string
+		want     []string
+	}{
+		{name: "current", protocol: inf.TProtocolVersion_HIVE_CLI_SERVICE_PROTOCOL_V6, columnar: true, version: "3.1.3"},
+		{name: "v3", protocol: inf.TProtocolVersion_HIVE_CLI_SERVICE_PROTOCOL_V3, columnar: true, version: "3.1.3",
+			want: []string{FeatureColumnar}},
+		{name: "v3 row-based", protocol: inf.TProtocolVersion_HIVE_CLI_SERVICE_PROTOCOL_V3, version: "3.1.3"},
+		{name: "v1", protocol: inf.TProtocolVersion_HIVE_CLI_SERVICE_PROTOCOL_V1, columnar: true, version: "0.10.0",
+			want: []string{FeatureAsync, FeatureColumnar, FeatureQueryTimeout}},
+		{name: "hive 2.1", protocol: inf.TProtocolVersion_HIVE_CLI_SERVICE_PROTOCOL_V6, columnar: true, version: "2.1.1-cdh6.3.2",
+			want: []string{FeatureQueryTimeout}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := newFakeService()
+			svc.protocol = tc.protocol
+			svc.onGetInfo = func(req *inf.TGetInfoReq) (*inf.TGetInfoResp, error) {
+				v := "Apache Hive"
+				if req.InfoType == inf.TGetInfoType_CLI_DBMS_VER {
+					v = tc.version
+				}
+				return &inf.TGetInfoResp{Status: okStatus(), InfoValue: &inf.TGetInfoValue{StringValue: thrift.StringPtr(v)}}, nil
+			}
+			options := testOptions()
+			options.PreferColumnarResults = &tc.columnar
+			options.QueryDefaults.Timeout = time.Minute
+			var got []string
+			calls := 0
+			options.OnFeatureDowngrade = func(features []string) {
+				calls++
+				got = features
+			}
+			connectFake(t, svc, options)
+
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected downgrades %q, got %q", tc.want, got)
+			}
+			if want := min(len(tc.want), 1); calls != want {
+				t.Errorf("expected %d callback calls, got %d", want, calls)
+			}
+		})
+	}
+}
+
+func TestFeatureDowngradeUnset(t *testing.T) {
+	svc := newFakeService()
+	svc.protocol = inf.TProtocolVersion_HIVE_CLI_SERVICE_PROTOCOL_V1
+	probed := false
+	svc.onGetInfo = func(req *inf.TGetInfoReq) (*inf.TGetInfoResp, error) {
+		probed = true
+		return &inf.TGetInfoResp{Status: okStatus(), InfoValue: &inf.TGetInfoValue{StringValue: thrift.StringPtr("")}}, nil
+	}
+	options := testOptions()
+	options.QueryDefaults.Timeout = time.Minute
+	connectFake(t, svc, options)
+	if probed {
+		t.Error("expected no GetInfo probes without OnFeatureDowngrade")
+	}
+}
@@ -0,0 +1,144 @@
+package hive
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// FetchInto reads the remaining rows of rs into a new T each, which must
+// be a struct, and returns them. A column is stored in the exported field
+// tagged with its name, as in
+//
+//	type Order struct {
+//		ID     int64    `hive:"id"`
+//		Amount *float64 `hive:"amount"`
+//		Note   sql.NullString
+//	}
+//
+// or else in the field whose name matches it ignoring case. Columns
+// qualified by their table, as Hive names them with
+// hive.resultset.use.unique.column.names, match on the part after the
+// dot too. Fields tagged `hive:"-"` are left alone, as are the fields of
+// embedded structs.
+//
+// Values are converted as FetchColumn converts them, and fields
+// implementing sql.Scanner are given the cell to scan. A NULL cell needs
+// a pointer, slice, map, interface or sql.Scanner field, being stored as
+// nil or scanned. A column without a field, or a tagged field without a
+// column, is an error, reported before any row is read.
+func FetchInto[T any](ctx context.Context, rs RowSet) ([]T, error) {
+	target := reflect.TypeOf((*T)(nil)).Elem()
+	if target.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("Can't fetch rows into %v: want a struct", target)
+	}
+	schema, err := rs.Schema(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := structFields(target, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []T
+	row := make([]interface{}, len(schema))
+	for n := 0; ; n++ {
+		if err := rs.NextInto(ctx, row); err != nil {
+			if err == io.EOF {
+				return values, nil
+			}
+			return values, err
+		}
+		var value T
+		v := reflect.ValueOf(&value).Elem()
+		for i, cell := range row {
+			field := target.Field(fields[i])
+			if err := assignField(v.Field(fields[i]), cell); err != nil {
+				return values, fmt.Errorf("Row %d: column %s into field %s: %v", n, schema[i].Name, field.Name, err)
+			}
+		}
+		values = append(values, value)
+	}
+}
+
+// structFields returns, for each column, the index of the field of
+// target it is stored in, see FetchInto.
+func structFields(target reflect.Type, schema []Column) ([]int, error) {
+	tagged := map[string]int{}
+	named := map[string]int{}
+	for i := 0; i < target.NumField(); i++ {
+		field := target.Field(i)
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		switch tag := field.Tag.Get("hive"); tag {
+		case "-":
+		case "":
+			named[strings.ToLower(field.Name)] = i
+		default:
+			tagged[tag] = i
+		}
+	}
+
+	fields := make([]int, len(schema))
+	used := map[int]bool{}
+	for i, col := range schema {
+		_, short, _ := strings.Cut(col.Name, ".")
+		index, ok := tagged[col.Name]
+		if !ok && short != "" {
+			index, ok = tagged[short]
+		}
+		if !ok {
+			index, ok = named[strings.ToLower(col.Name)]
+		}
+		if !ok && short != "" {
+			index, ok = named[strings.ToLower(short)]
+		}
+		if !ok {
+			return nil, fmt.Errorf("Column %s has no field in %v", col.Name, target)
+		}
+		fields[i] = index
+		used[index] = true
+	}
+	for tag, index := range tagged {
+		if !used[index] {
+			return nil, fmt.Errorf("Field %s of %v is tagged %q, which isn't a column of the result", target.Field(index).Name, target, tag)
+		}
+	}
+	return fields, nil
+}
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// assignField stores a cell in field.
+func assignField(field reflect.Value, cell interface{}) error {
+	if field.Addr().Type().Implements(scannerType) {
+		return field.Addr().Interface().(sql.Scanner).Scan(cell)
+	}
+	if cell == nil {
+		switch field.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+			field.SetZero()
+			return nil
+		}
+		return fmt.Errorf("NULL needs a pointer field, not %v", field.Type())
+	}
+	if field.Kind() == reflect.Pointer {
+		elem := reflect.New(field.Type().Elem())
+		if err := assignField(elem.Elem(), cell); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	}
+	v, err := convertTo(cell, field.Type())
+	if err != nil {
+		return err
+	}
+	field.Set(v)
+	return nil
+}
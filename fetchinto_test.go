@@ -0,0 +1,115 @@
+package hive
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestFetchInto(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{
+		columnDesc("t.id", inf.TTypeId_BIGINT_TYPE, 1),
+		columnDesc("t.name", inf.TTypeId_STRING_TYPE, 2),
+		columnDesc("t.score", inf.TTypeId_INT_TYPE, 3),
+		columnDesc("t.note", inf.TTypeId_STRING_TYPE, 4),
+	}
+	svc.batches = []*inf.TRowSet{{Columns: []*inf.TColumn{
+		{I64Val: &inf.TI64Column{Values: []int64{1, 2}, Nulls: []byte{}}},
+		{StringVal: &inf.TStringColumn{Values: []string{"a", "b"}, Nulls: []byte{}}},
+		// Row 1 is NULL.
+		{I32Val: &inf.TI32Column{Values: []int32{90, 0}, Nulls: []byte{0x02}}},
+		// Row 0 is NULL.
+		{StringVal: &inf.TStringColumn{Values: []string{"", "late"}, Nulls: []byte{0x01}}},
+	}}}
+	conn := connectFake(t, svc, testOptions())
+
+	type row struct {
+		ID     int64  `hive:"id"`
+		Name   string `hive:"t.name"`
+		Score  *int64
+		Note   sql.NullString `hive:"note"`
+		Ignore string         `hive:"-"`
+	}
+	rs, err := conn.QueryContext(ctx, "SELECT * FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	defer rs.Close(ctx)
+	rows, err := FetchInto[row](ctx, rs)
+	if err != nil {
+		t.Fatalf("FetchInto error: %v", err)
+	}
+	score := int64(90)
+	expected := []row{
+		{ID: 1, Name: "a", Score: &score},
+		{ID: 2, Name: "b", Note: sql.NullString{String: "late", Valid: true}},
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("expected %+v, got %+v", expected, rows)
+	}
+}
+
+func TestFetchIntoErrors(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{
+		columnDesc("id", inf.TTypeId_BIGINT_TYPE, 1),
+		columnDesc("score", inf.TTypeId_INT_TYPE, 2),
+	}
+	svc.batches = []*inf.TRowSet{{Columns: []*inf.TColumn{
+		{I64Val: &inf.TI64Column{Values: []int64{1}, Nulls: []byte{}}},
+		{I32Val: &inf.TI32Column{Values: []int32{0}, Nulls: []byte{0x01}}},
+	}}}
+	conn := connectFake(t, svc, testOptions())
+
+	fetch := func(f func(RowSet) error) error {
+		rs, err := conn.QueryContext(ctx, "SELECT id, score FROM t")
+		if err != nil {
+			t.Fatalf("Query error: %v", err)
+		}
+		defer rs.Close(ctx)
+		return f(rs)
+	}
+	for _, tc := range []struct {
+		name  string
+		fetch func(RowSet) error
+		want  string
+	}{
+		{"missing field", func(rs RowSet) error {
+			_, err := FetchInto[struct{ ID int64 }](ctx, rs)
+			return err
+		}, "Column score has no field"},
+		{"missing column", func(rs RowSet) error {
+			_, err := FetchInto[struct {
+				ID    int64
+				Score int64
+				Other string `hive:"other"`
+			}](ctx, rs)
+			return err
+		}, `tagged "other"`},
+		{"NULL into a value", func(rs RowSet) error {
+			_, err := FetchInto[struct {
+				ID    int64
+				Score int64
+			}](ctx, rs)
+			return err
+		}, "Row 0: column score into field Score: NULL needs a pointer field"},
+		{"not a struct", func(rs RowSet) error {
+			_, err := FetchInto[int64](ctx, rs)
+			return err
+		}, "want a struct"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := fetch(tc.fetch)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("expected an error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
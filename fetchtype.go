@@ -0,0 +1,49 @@
+package hive
+
+import (
+	"fmt"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// FetchType selects what a FetchResults call reads from an operation.
+type FetchType int16
+
+const (
+	// FetchQueryOutput reads the rows of the result set.
+	FetchQueryOutput FetchType = 0
+	// FetchLogs reads the operation log.
+	FetchLogs FetchType = 1
+)
+
+func (t FetchType) String() string {
+	switch t {
+	case FetchQueryOutput:
+		return "FetchQueryOutput"
+	case FetchLogs:
+		return "FetchLogs"
+	default:
+		return fmt.Sprintf("FetchType(%d)", int16(t))
+	}
+}
+
+// Validate reports an error for values other than FetchQueryOutput and
+// FetchLogs.
+func (t FetchType) Validate() error {
+	switch t {
+	case FetchQueryOutput, FetchLogs:
+		return nil
+	default:
+		return fmt.Errorf("Invalid fetch type %d", int16(t))
+	}
+}
+
+// fetchRequest builds a FetchResults request for the operation.
+func (r *rowSet) fetchRequest(orientation inf.TFetchOrientation, maxRows int64, fetchType FetchType) *inf.TFetchResultsReq {
+	fetchReq := inf.NewTFetchResultsReq()
+	fetchReq.OperationHandle = r.operation
+	fetchReq.Orientation = orientation
+	fetchReq.MaxRows = maxRows
+	fetchReq.FetchType = int16(fetchType)
+	return fetchReq
+}
@@ -0,0 +1,171 @@
+package hive
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// authorizationKey is the setting that switches authorization on.
+const authorizationKey = "hive.security.authorization.enabled"
+
+// Role is a role as listed by SHOW CURRENT ROLES or SHOW ROLE GRANT.
+// Servers only list the columns past Name for role grants.
+type Role struct {
+	Name        string
+	GrantOption bool
+	GrantTime   time.Time
+	Grantor     string
+}
+
+// Privilege is a privilege as listed by SHOW GRANT. Database, Table,
+// Partition and Column name the object it is held on, as far as it is
+// specific.
+type Privilege struct {
+	Database      string
+	Table         string
+	Partition     string
+	Column        string
+	Principal     string
+	PrincipalType string
+	// Privilege is e.g. SELECT, INSERT or ALL.
+	Privilege   string
+	GrantOption bool
+	GrantTime   time.Time
+	Grantor     string
+}
+
+// RoleList is the parsed output of CurrentRoles.
+type RoleList struct {
+	Roles []Role
+	// Rows is the unparsed output, keyed by column name, for anything
+	// the parser doesn't pick out.
+	Rows []map[string]string
+	// Note explains an empty list when the server doesn't enforce
+	// authorization.
+	Note string
+}
+
+// GrantList is the parsed output of ShowGrants.
+type GrantList struct {
+	Privileges []Privilege
+	// Rows is the unparsed output, keyed by column name, for anything
+	// the parser doesn't pick out.
+	Rows []map[string]string
+	// Note explains an empty list when the server doesn't enforce
+	// authorization.
+	Note string
+}
+
+// CurrentRoles lists the roles active in the session with SHOW CURRENT
+// ROLES. Roles are only defined under SQL standard authorization; if
+// authorization isn't enabled on the server, the statement fails, and
+// CurrentRoles returns an empty list saying so in Note instead.
+func (c *Connection) CurrentRoles(ctx context.Context) (*RoleList, error) {
+	rows, note, err := c.showAuthorization(ctx, "SHOW CURRENT ROLES")
+	if err != nil {
+		return nil, err
+	}
+	list := &RoleList{Rows: rows, Note: note}
+	for _, row := range rows {
+		list.Roles = append(list.Roles, Role{
+			Name:        row["role"],
+			GrantOption: row["grant_option"] == "true",
+			GrantTime:   grantTime(row["grant_time"]),
+			Grantor:     row["grantor"],
+		})
+	}
+	return list, nil
+}
+
+// ShowGrants lists the privileges held by principal on any object with
+// SHOW GRANT ... ON ALL. principal is a user name, or a name after
+// "USER ", "GROUP " or "ROLE "; empty lists every principal's
+// privileges, which needs the admin role. If authorization isn't enabled
+// on the server, ShowGrants returns an empty list saying so in Note.
+func (c *Connection) ShowGrants(ctx context.Context, principal string) (*GrantList, error) {
+	stmt := "SHOW GRANT ON ALL"
+	if principal != "" {
+		kind, name := "USER", principal
+		if k, n, ok := strings.Cut(principal, " "); ok {
+			switch strings.ToUpper(k) {
+			case "USER", "GROUP", "ROLE":
+				kind, name = strings.ToUpper(k), strings.TrimSpace(n)
+			}
+		}
+		if name == "" {
+			return nil, fmt.Errorf("Invalid principal %q", principal)
+		}
+		stmt = "SHOW GRANT " + kind + " " + quoteIdentifier(name) + " ON ALL"
+	}
+
+	rows, note, err := c.showAuthorization(ctx, stmt)
+	if err != nil {
+		return nil, err
+	}
+	list := &GrantList{Rows: rows, Note: note}
+	for _, row := range rows {
+		list.Privileges = append(list.Privileges, Privilege{
+			Database:      row["database"],
+			Table:         row["table"],
+			Partition:     row["partition"],
+			Column:        row["column"],
+			Principal:     row["principal_name"],
+			PrincipalType: row["principal_type"],
+			Privilege:     row["privilege"],
+			GrantOption:   row["grant_option"] == "true",
+			GrantTime:     grantTime(row["grant_time"]),
+			Grantor:       row["grantor"],
+		})
+	}
+	return list, nil
+}
+
+// showAuthorization runs an authorization statement and returns its rows
+// keyed by lower-case column name, repeated names made unique as
+// uniqueNames does. When the statement fails and the
+// server reports authorization as switched off, it returns no rows and
+// a note rather than the error.
+func (c *Connection) showAuthorization(ctx context.Context, stmt string) ([]map[string]string, string, error) {
+	values, columns, err := c.QueryAll(ctx, stmt)
+	if err != nil {
+		// The setting defaults to false.
+		if value, defined, confErr := c.readConf(ctx, authorizationKey); confErr == nil && (!defined || value == "false") {
+			return nil, "Authorization is not enabled on the server (" + authorizationKey + "=false), so it reports no roles or privileges", nil
+		}
+		return nil, "", err
+	}
+
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		name := col.Name
+		if i := strings.LastIndexByte(name, '.'); i >= 0 {
+			name = name[i+1:]
+		}
+		names[i] = strings.ToLower(name)
+	}
+	names = uniqueNames(names)
+	rows := make([]map[string]string, len(values))
+	for i, v := range values {
+		row := make(map[string]string, len(names))
+		for j, name := range names {
+			if j < len(v) && v[j] != nil {
+				row[name] = strings.TrimSpace(formatField(v[j]))
+			}
+		}
+		rows[i] = row
+	}
+	return rows, "", nil
+}
+
+// grantTime reads a grant time, which servers list in milliseconds since
+// the epoch; it is zero if unset.
+func grantTime(s string) time.Time {
+	ms, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || ms <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
@@ -0,0 +1,113 @@
+package hive
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestShowGrants(t *testing.T) {
+	names := []string{"database", "table", "partition", "column", "principal_name", "principal_type",
+		"privilege", "grant_option", "grant_time", "grantor"}
+	rows := [][]string{
+		{"sales", "orders", "", "", "alice", "USER", "SELECT", "false", "1700000000000", "admin"},
+		{"sales", "orders", "", "", "alice", "USER", "INSERT", "true", "1700000000000", "admin"},
+	}
+	svc := newFakeService()
+	batch := &inf.TRowSet{}
+	for i, name := range names {
+		svc.schema = append(svc.schema, columnDesc(name, inf.TTypeId_STRING_TYPE, int32(i+1)))
+		values := make([]string, len(rows))
+		for j, row := range rows {
+			values[j] = row[i]
+		}
+		batch.Columns = append(batch.Columns, &inf.TColumn{StringVal: &inf.TStringColumn{Values: values, Nulls: []byte{}}})
+	}
+	svc.batches = []*inf.TRowSet{batch}
+	conn := connectFake(t, svc, testOptions())
+
+	list, err := conn.ShowGrants(context.Background(), "alice")
+	if err != nil {
+		t.Fatalf("ShowGrants error: %v", err)
+	}
+	if got := svc.executed(); len(got) != 1 || got[0] != "SHOW GRANT USER `alice` ON ALL" {
+		t.Errorf("unexpected statements %q", got)
+	}
+	if list.Note != "" || len(list.Privileges) != 2 || len(list.Rows) != 2 {
+		t.Fatalf("unexpected grants %+v", list)
+	}
+	p := list.Privileges[1]
+	if p.Database != "sales" || p.Table != "orders" || p.Principal != "alice" || p.PrincipalType != "USER" ||
+		p.Privilege != "INSERT" || !p.GrantOption || p.Grantor != "admin" || !p.GrantTime.Equal(time.UnixMilli(1700000000000)) {
+		t.Errorf("unexpected privilege %+v", p)
+	}
+	if list.Privileges[0].GrantOption {
+		t.Error("expected no grant option on SELECT")
+	}
+	if list.Rows[0]["privilege"] != "SELECT" {
+		t.Errorf("unexpected raw row %v", list.Rows[0])
+	}
+
+	if _, err := conn.ShowGrants(context.Background(), "ROLE analysts"); err != nil {
+		t.Fatalf("ShowGrants error: %v", err)
+	}
+	if got := svc.executed(); got[len(got)-1] != "SHOW GRANT ROLE `analysts` ON ALL" {
+		t.Errorf("unexpected statement %q", got[len(got)-1])
+	}
+	if _, err := conn.ShowGrants(context.Background(), "ROLE "); err == nil {
+		t.Error("expected an error for a principal without a name")
+	}
+}
+
+func TestCurrentRoles(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("role", inf.TTypeId_STRING_TYPE, 1)}
+	svc.batches = []*inf.TRowSet{stringBatch("public", "analysts")}
+	conn := connectFake(t, svc, testOptions())
+
+	list, err := conn.CurrentRoles(context.Background())
+	if err != nil {
+		t.Fatalf("CurrentRoles error: %v", err)
+	}
+	if len(list.Roles) != 2 || list.Roles[0].Name != "public" || list.Roles[1].Name != "analysts" {
+		t.Errorf("unexpected roles %+v", list.Roles)
+	}
+}
+
+func TestAuthorizationDisabled(t *testing.T) {
+	for _, enabled := range []string{"false", "true"} {
+		t.Run(enabled, func(t *testing.T) {
+			svc := newFakeService()
+			svc.schema = []*inf.TColumnDesc{columnDesc("set", inf.TTypeId_STRING_TYPE, 1)}
+			svc.results = map[string][]*inf.TRowSet{
+				"SET " + authorizationKey: {stringBatch(authorizationKey + "=" + enabled)},
+			}
+			svc.onExecute = func(req *inf.TExecuteStatementReq) (*inf.TExecuteStatementResp, error) {
+				if strings.HasPrefix(req.Statement, "SHOW CURRENT ROLES") {
+					return &inf.TExecuteStatementResp{Status: errorStatus("Error in getting current roles")}, nil
+				}
+				svc.mu.Lock()
+				defer svc.mu.Unlock()
+				return &inf.TExecuteStatementResp{Status: okStatus(), OperationHandle: svc.newOperation(req.Statement)}, nil
+			}
+			conn := connectFake(t, svc, testOptions())
+
+			list, err := conn.CurrentRoles(context.Background())
+			if enabled == "true" {
+				if err == nil {
+					t.Error("expected the error when authorization is enabled")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CurrentRoles error: %v", err)
+			}
+			if len(list.Roles) != 0 || !strings.Contains(list.Note, "not enabled") {
+				t.Errorf("expected an empty list with a note, got %+v", list)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnboundedQuery is returned, before anything is sent, for a SELECT
+// without a LIMIT under Options.InteractiveGuard when no
+// InteractiveLimit is set.
+var ErrUnboundedQuery = errors.New("hive: SELECT without LIMIT rejected by InteractiveGuard")
+
+type unboundedKey struct{}
+
+// WithUnboundedQuery returns a context under which statements are sent
+// as written even if Options.InteractiveGuard is set, for a user who has
+// acknowledged that a query reads the whole result.
+func WithUnboundedQuery(ctx context.Context) context.Context {
+	return context.WithValue(ctx, unboundedKey{}, true)
+}
+
+// guardStatement applies Options.InteractiveGuard to stmt.
+func (c *Connection) guardStatement(ctx context.Context, stmt string) (string, error) {
+	if !c.options.InteractiveGuard || ctx.Value(unboundedKey{}) != nil || !limitable(stmt) {
+		return stmt, nil
+	}
+	if c.options.InteractiveLimit == 0 {
+		return "", ErrUnboundedQuery
+	}
+	return fmt.Sprintf("%s\nLIMIT %d", strings.TrimRight(stmt, " \t\r\n;"), c.options.InteractiveLimit), nil
+}
@@ -0,0 +1,75 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestInteractiveGuard(t *testing.T) {
+	ctx := context.Background()
+	cases := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT * FROM t", "SELECT * FROM t\nLIMIT 100"},
+		{"WITH x AS (SELECT 1) SELECT * FROM x;", "WITH x AS (SELECT 1) SELECT * FROM x\nLIMIT 100"},
+		{"SELECT * FROM t LIMIT 5", "SELECT * FROM t LIMIT 5"},
+		{"SELECT * FROM (SELECT * FROM t LIMIT 5) s", "SELECT * FROM (SELECT * FROM t LIMIT 5) s\nLIMIT 100"},
+		{"SELECT a FROM t WHERE b = 'no limit'", "SELECT a FROM t WHERE b = 'no limit'\nLIMIT 100"},
+		{"INSERT INTO u SELECT * FROM t", "INSERT INTO u SELECT * FROM t"},
+		{"SHOW TABLES", "SHOW TABLES"},
+	}
+
+	svc := newFakeService()
+	options := testOptions()
+	options.InteractiveGuard = true
+	options.InteractiveLimit = 100
+	conn := connectFake(t, svc, options)
+	for _, tc := range cases {
+		if _, err := conn.execContext(ctx, tc.query); err != nil {
+			t.Fatalf("Exec(%q) error: %v", tc.query, err)
+		}
+		stmts := svc.executed()
+		if got := stmts[len(stmts)-1]; got != tc.want {
+			t.Errorf("%q: expected %q sent, got %q", tc.query, tc.want, got)
+		}
+	}
+
+	if _, err := conn.execContext(WithUnboundedQuery(ctx), "SELECT * FROM t"); err != nil {
+		t.Fatalf("Exec error: %v", err)
+	}
+	if stmts := svc.executed(); stmts[len(stmts)-1] != "SELECT * FROM t" {
+		t.Errorf("expected the query sent as written under WithUnboundedQuery, got %q", stmts[len(stmts)-1])
+	}
+}
+
+func TestInteractiveGuardRejects(t *testing.T) {
+	svc := newFakeService()
+	options := testOptions()
+	options.InteractiveGuard = true
+	conn := connectFake(t, svc, options)
+	sent := len(svc.executed())
+
+	if _, err := conn.Query("SELECT * FROM t"); !errors.Is(err, ErrUnboundedQuery) {
+		t.Errorf("expected ErrUnboundedQuery, got %v", err)
+	}
+	if _, err := conn.Query("SELECT * FROM (SELECT * FROM t LIMIT 5) s"); !errors.Is(err, ErrUnboundedQuery) {
+		t.Errorf("expected a LIMIT in a subquery not to count, got %v", err)
+	}
+	if n := len(svc.executed()) - sent; n != 0 {
+		t.Errorf("expected nothing sent, got %d statements", n)
+	}
+	if _, err := conn.Query("SELECT * FROM t LIMIT 10"); err != nil {
+		t.Errorf("expected a limited query to run, got %v", err)
+	}
+	if err := conn.WaitReady(context.Background()); err != nil {
+		t.Errorf("expected WaitReady to bypass the guard, got %v", err)
+	}
+
+	options.InteractiveGuard = false
+	options.InteractiveLimit = 10
+	if err := options.Validate(); err == nil {
+		t.Error("expected InteractiveLimit without InteractiveGuard to be rejected")
+	}
+}
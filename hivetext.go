@@ -0,0 +1,41 @@
+package hive
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+)
+
+// hiveTextEscaper escapes text the way LazySimpleSerDe does with
+// escape.delim set to a backslash.
+var hiveTextEscaper = strings.NewReplacer(`\`, `\\`, "\t", "\\\t", "\n", `\n`, "\r", `\r`)
+
+// WriteHiveText writes the remaining rows to w in Hive's default text
+// format: fields separated by tabs, rows ended by newlines and NULL
+// written as \N. Backslashes, tabs, newlines and carriage returns in
+// values are escaped with a backslash, so that a table declared with
+//
+//	ROW FORMAT DELIMITED FIELDS TERMINATED BY '\t' ESCAPED BY '\\'
+//
+// reads the file back unchanged, e.g. after LOAD DATA. Rows are written
+// a batch at a time as they are fetched, reporting progress to the
+// callback set with WithWriteProgress. If a write to w fails, the
+// operation is aborted and the write's error returned.
+func (r *rowSet) WriteHiveText(ctx context.Context, w io.Writer) error {
+	var buf bytes.Buffer
+	return r.writeBatches(ctx, w, &buf, func(row []interface{}) error {
+		for i, v := range row {
+			if i > 0 {
+				buf.WriteByte('\t')
+			}
+			if v == nil {
+				buf.WriteString(`\N`)
+				continue
+			}
+			hiveTextEscaper.WriteString(&buf, formatField(v))
+		}
+		buf.WriteByte('\n')
+		return nil
+	})
+}
@@ -0,0 +1,57 @@
+package hive
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// countingWriter records the size of each write.
+type countingWriter struct {
+	strings.Builder
+	writes []int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes = append(w.writes, len(p))
+	return w.Builder.Write(p)
+}
+
+func TestWriteHiveText(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{
+		columnDesc("id", inf.TTypeId_INT_TYPE, 1),
+		columnDesc("note", inf.TTypeId_STRING_TYPE, 2),
+	}
+	svc.batches = []*inf.TRowSet{
+		{Columns: []*inf.TColumn{
+			{I32Val: &inf.TI32Column{Values: []int32{1, 0}, Nulls: []byte{0x02}}},
+			{StringVal: &inf.TStringColumn{Values: []string{"a\tb\nc", ""}, Nulls: []byte{0x02}}},
+		}},
+		{Columns: []*inf.TColumn{
+			{I32Val: &inf.TI32Column{Values: []int32{3}, Nulls: []byte{}}},
+			{StringVal: &inf.TStringColumn{Values: []string{`C:\tmp` + "\r"}, Nulls: []byte{}}},
+		}},
+	}
+	conn := connectFake(t, svc, testOptions())
+	rs, err := conn.Query("SELECT id, note FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+
+	var w countingWriter
+	if err := rs.WriteHiveText(context.Background(), &w); err != nil {
+		t.Fatalf("WriteHiveText error: %v", err)
+	}
+	want := "1\ta\\\tb\\nc\n" +
+		"\\N\t\\N\n" +
+		"3\tC:\\\\tmp\\r\n"
+	if got := w.String(); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if len(w.writes) != 2 {
+		t.Errorf("expected one write per batch, got writes of %v bytes", w.writes)
+	}
+}
@@ -0,0 +1,74 @@
+package hive
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrOperationIdleTimeout is returned by a RowSet that was cancelled and
+// closed for going unused longer than Options.OperationIdleTimeout.
+var ErrOperationIdleTimeout = errors.New("hive: operation closed after exceeding OperationIdleTimeout")
+
+// touch records that the operation is in use.
+func (r *rowSet) touch() {
+	if r.options.OperationIdleTimeout <= 0 {
+		return
+	}
+	r.statusMu.Lock()
+	r.lastUsed = r.options.clock().Now()
+	r.statusMu.Unlock()
+}
+
+// idle reports whether the operation has gone unused past
+// Options.OperationIdleTimeout.
+func (r *rowSet) idle() bool {
+	timeout := r.options.OperationIdleTimeout
+	if timeout <= 0 || r.closed {
+		return false
+	}
+	r.statusMu.Lock()
+	last := r.lastUsed
+	r.statusMu.Unlock()
+	if last.IsZero() {
+		last = r.started
+	}
+	return r.options.clock().Now().Sub(last) >= timeout
+}
+
+// checkIdle expires the operation if it has gone unused too long, and
+// reports ErrOperationIdleTimeout if it has been expired.
+func (r *rowSet) checkIdle(ctx context.Context) error {
+	if r.idle() {
+		r.expire(ctx)
+	}
+	if r.err == ErrOperationIdleTimeout {
+		return ErrOperationIdleTimeout
+	}
+	return nil
+}
+
+// expire cancels and closes the operation, leaving
+// ErrOperationIdleTimeout for its next use.
+func (r *rowSet) expire(ctx context.Context) {
+	r.Abort(ctx)
+	r.err = ErrOperationIdleTimeout
+}
+
+// expireIdle expires the tracked operations that have gone unused past
+// Options.OperationIdleTimeout.
+func (c *Connection) expireIdle(ctx context.Context) {
+	if c.options.OperationIdleTimeout <= 0 {
+		return
+	}
+	var idle []*rowSet
+	c.mu.Lock()
+	for rs := range c.operations {
+		if rs.idle() {
+			idle = append(idle, rs)
+		}
+	}
+	c.mu.Unlock()
+	for _, rs := range idle {
+		rs.expire(ctx)
+	}
+}
@@ -0,0 +1,74 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestOperationIdleTimeout(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	svc.batches = []*inf.TRowSet{stringBatch("a", "b")}
+	clock := newFakeClock()
+	options := testOptions()
+	options.testClock = clock
+	options.OperationIdleTimeout = time.Minute
+	conn := connectFake(t, svc, options)
+
+	leaked, err := conn.QueryContext(ctx, "SELECT * FROM leaked")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	clock.advance(2 * time.Minute)
+
+	// Submitting the next statement closes the leaked operation.
+	rs, err := conn.QueryContext(ctx, "SELECT * FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	if len(svc.cancels) != 1 || len(svc.closes) != 1 {
+		t.Errorf("expected the idle operation cancelled and closed, got %d cancels and %d closes", len(svc.cancels), len(svc.closes))
+	}
+	if n := conn.InFlight(); n != 1 {
+		t.Errorf("expected 1 operation in flight, got %d", n)
+	}
+	if leaked.Next() {
+		t.Error("expected no rows from the expired RowSet")
+	}
+	if !errors.Is(leaked.Err(), ErrOperationIdleTimeout) {
+		t.Errorf("expected ErrOperationIdleTimeout, got %v", leaked.Err())
+	}
+
+	// Use within the timeout keeps an operation open.
+	clock.advance(30 * time.Second)
+	if !rs.Next() {
+		t.Fatalf("expected a row, got %v", rs.Err())
+	}
+	clock.advance(30 * time.Second)
+	if !rs.Next() {
+		t.Fatalf("expected a row, got %v", rs.Err())
+	}
+
+	// Coming back to it too late expires it on the spot.
+	clock.advance(time.Minute)
+	if rs.Next() {
+		t.Error("expected no rows after the timeout")
+	}
+	if !errors.Is(rs.Err(), ErrOperationIdleTimeout) {
+		t.Errorf("expected ErrOperationIdleTimeout, got %v", rs.Err())
+	}
+	var s string
+	if err := rs.Scan(&s); !errors.Is(err, ErrOperationIdleTimeout) {
+		t.Errorf("expected ErrOperationIdleTimeout from Scan, got %v", err)
+	}
+	if _, err := rs.Poll(); !errors.Is(err, ErrOperationIdleTimeout) {
+		t.Errorf("expected ErrOperationIdleTimeout from Poll, got %v", err)
+	}
+	if n := conn.InFlight(); n != 0 {
+		t.Errorf("expected no operations in flight, got %d", n)
+	}
+}
@@ -0,0 +1,51 @@
+package hive
+
+import (
+	"fmt"
+	"hash/fnv"
+)
+
+// Integrity summarises the rows a RowSet has returned, for jobs that
+// compare what they read against a count or checksum taken elsewhere.
+// It is computed client-side from the decoded rows; the server makes no
+// guarantee about it.
+type Integrity struct {
+	// Rows is the number of rows returned so far.
+	Rows int64
+	// Checksum is the sum of FNV-1a hashes of the rows, so it doesn't
+	// depend on row order. Rows are hashed after the TypeMapper runs, so
+	// checksums are only comparable between runs with the same mapper.
+	Checksum uint64
+	// Complete is set once the rows ran out without an error. A result
+	// cut short by a failed fetch is never complete.
+	Complete bool
+}
+
+type integrity struct {
+	rows     int64
+	checksum uint64
+}
+
+func (i *integrity) add(row []interface{}) {
+	h := fnv.New64a()
+	for _, v := range row {
+		if v == nil {
+			h.Write([]byte{0})
+			continue
+		}
+		fmt.Fprintf(h, "%T:%v", v, v)
+		h.Write([]byte{0xff})
+	}
+	i.rows++
+	i.checksum += h.Sum64()
+}
+
+// Integrity returns the row count and checksum of the rows returned so
+// far. Options.TrackIntegrity must be set; otherwise it is zero.
+func (r *rowSet) Integrity() Integrity {
+	return Integrity{
+		Rows:     r.integrity.rows,
+		Checksum: r.integrity.checksum,
+		Complete: r.options.TrackIntegrity && r.finished && r.err == nil,
+	}
+}
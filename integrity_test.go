@@ -0,0 +1,63 @@
+package hive
+
+import (
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func drainIntegrity(t *testing.T, svc *fakeService) Integrity {
+	t.Helper()
+	options := testOptions()
+	options.TrackIntegrity = true
+	conn := connectFake(t, svc, options)
+
+	rs, err := conn.Query("SELECT s FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	for rs.Next() {
+	}
+	return rs.Integrity()
+}
+
+func TestIntegrity(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("s", inf.TTypeId_STRING_TYPE, 1)}
+	svc.batches = []*inf.TRowSet{stringBatch("a", "b"), stringBatch("c")}
+	got := drainIntegrity(t, svc)
+	if got.Rows != 3 || !got.Complete {
+		t.Fatalf("expected 3 rows and a complete result, got %+v", got)
+	}
+
+	reordered := newFakeService()
+	reordered.schema = svc.schema
+	reordered.batches = []*inf.TRowSet{stringBatch("c", "a"), stringBatch("b")}
+	if other := drainIntegrity(t, reordered); other.Checksum != got.Checksum {
+		t.Errorf("expected the checksum not to depend on row order, got %x and %x", got.Checksum, other.Checksum)
+	}
+
+	changed := newFakeService()
+	changed.schema = svc.schema
+	changed.batches = []*inf.TRowSet{stringBatch("a", "b"), stringBatch("x")}
+	if other := drainIntegrity(t, changed); other.Checksum == got.Checksum {
+		t.Error("expected a different checksum for different rows")
+	}
+}
+
+func TestIntegrityIncompleteOnFetchError(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("s", inf.TTypeId_STRING_TYPE, 1)}
+	fetches := 0
+	svc.onFetch = func(req *inf.TFetchResultsReq) (*inf.TFetchResultsResp, error) {
+		fetches++
+		if fetches > 1 {
+			return &inf.TFetchResultsResp{Status: errorStatus("connection reset")}, nil
+		}
+		return &inf.TFetchResultsResp{Status: okStatus(), Results: stringBatch("a", "b")}, nil
+	}
+	got := drainIntegrity(t, svc)
+	if got.Rows != 2 || got.Complete {
+		t.Errorf("expected 2 rows and an incomplete result, got %+v", got)
+	}
+}
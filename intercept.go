@@ -0,0 +1,33 @@
+package hive
+
+import "errors"
+
+// errRawBatchIntercepted is reported by the readers of raw batches,
+// which Options.RowInterceptor can't apply to.
+var errRawBatchIntercepted = errors.New("Raw batches aren't available with Options.RowInterceptor set")
+
+// interceptRows runs Options.RowInterceptor on every row of the current
+// batch, keeping the changed cells, and drops the raw batch.
+func (r *rowSet) interceptRows() {
+	intercept := r.options.RowInterceptor
+	if intercept == nil {
+		return
+	}
+	if r.schema == nil {
+		r.schema = make([]Column, len(r.columns))
+		for i, desc := range r.columns {
+			r.schema[i] = newColumn(desc)
+		}
+	}
+	row := make([]interface{}, len(r.resultSet))
+	for i := 0; i < r.batchLength(); i++ {
+		for j, col := range r.resultSet {
+			row[j] = col[i]
+		}
+		intercept(r.schema, row)
+		for j, col := range r.resultSet {
+			col[i] = row[j]
+		}
+	}
+	r.rowSet = nil
+}
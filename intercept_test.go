@@ -0,0 +1,89 @@
+package hive
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestRowInterceptor(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{
+		columnDesc("id", inf.TTypeId_INT_TYPE, 1),
+		columnDesc("ssn", inf.TTypeId_STRING_TYPE, 2),
+	}
+	batch := func(ids []int32, ssns []string) *inf.TRowSet {
+		return &inf.TRowSet{Columns: []*inf.TColumn{
+			{I32Val: &inf.TI32Column{Values: ids, Nulls: []byte{}}},
+			{StringVal: &inf.TStringColumn{Values: ssns, Nulls: []byte{}}},
+		}}
+	}
+	svc.batches = []*inf.TRowSet{
+		batch([]int32{1, 2}, []string{"secret-1", "secret-2"}),
+		batch([]int32{3}, []string{"secret-3"}),
+	}
+
+	dir := t.TempDir()
+	options := testOptions()
+	options.SpoolDir = dir
+	options.SpoolThresholdRows = 2
+	options.RowInterceptor = func(schema []Column, row []interface{}) {
+		for i, col := range schema {
+			if col.Name == "ssn" && row[i] != nil {
+				row[i] = "***"
+			}
+		}
+	}
+	conn := connectFake(t, svc, options)
+
+	rs, err := conn.QueryContext(ctx, "SELECT id, ssn FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	var got [][]interface{}
+	for rs.Next() {
+		var id, ssn interface{}
+		if err := rs.Scan(&id, &ssn); err != nil {
+			t.Fatalf("Scan error: %v", err)
+		}
+		got = append(got, []interface{}{id, ssn})
+
+		if len(got) == 3 {
+			files, _ := filepath.Glob(filepath.Join(dir, "*"))
+			if len(files) != 1 {
+				t.Errorf("expected a spool file, got %d files", len(files))
+			}
+			for _, name := range files {
+				if data, _ := os.ReadFile(name); bytes.Contains(data, []byte("secret")) {
+					t.Errorf("expected the spool file masked, found cleartext in %s", name)
+				}
+			}
+		}
+	}
+	if err := rs.Err(); err != nil {
+		t.Fatalf("Next error: %v", err)
+	}
+	rs.Close(ctx)
+	expected := [][]interface{}{{int32(1), "***"}, {int32(2), "***"}, {int32(3), "***"}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	rs, err = conn.QueryContext(ctx, "SELECT id, ssn FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	defer rs.Close(ctx)
+	if rs.NextBatch(ctx) {
+		t.Error("expected NextBatch to refuse raw batches")
+	}
+	if rs.Err() != errRawBatchIntercepted {
+		t.Errorf("expected errRawBatchIntercepted, got %v", rs.Err())
+	}
+}
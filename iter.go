@@ -0,0 +1,52 @@
+//go:build go1.23
+
+package hive
+
+import (
+	"context"
+	"io"
+	"iter"
+)
+
+// rowIterator adds Rows to RowSet where range-over-func is available.
+type rowIterator interface {
+	Rows(ctx context.Context) iter.Seq2[[]interface{}, error]
+}
+
+// Rows returns an iterator over the remaining rows, as from NextValues:
+//
+//	for row, err := range rs.Rows(ctx) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// Batches are fetched as the loop advances. A failed fetch is yielded
+// once as a nil row with the error, ending the iteration; if ctx ends,
+// the operation is cancelled and the context's error yielded. The
+// operation is closed when the iteration ends, including when the loop
+// breaks early. Rows requires Go 1.23.
+func (r *rowSet) Rows(ctx context.Context) iter.Seq2[[]interface{}, error] {
+	return func(yield func([]interface{}, error) bool) {
+		// Close cancels the operation first if it is still running.
+		defer r.Close(context.WithoutCancel(ctx))
+		for {
+			values, err := r.NextValues(ctx)
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			row := make([]interface{}, len(values))
+			for i, v := range values {
+				row[i] = v
+			}
+			if !yield(row, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !go1.23
+
+package hive
+
+// rowIterator is empty before Go 1.23, which lacks range-over-func; see
+// iter.go.
+type rowIterator interface{}
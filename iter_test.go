@@ -0,0 +1,98 @@
+//go:build go1.23
+
+package hive
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestRowsIterator(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("s", inf.TTypeId_STRING_TYPE, 1)}
+	svc.batches = []*inf.TRowSet{stringBatch("a", "b"), stringBatch("c")}
+	conn := connectFake(t, svc, testOptions())
+
+	rs, err := conn.Query("SELECT s FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	var rows [][]interface{}
+	for row, err := range rs.Rows(context.Background()) {
+		if err != nil {
+			t.Fatalf("Rows error: %v", err)
+		}
+		rows = append(rows, row)
+	}
+	if want := [][]interface{}{{"a"}, {"b"}, {"c"}}; !reflect.DeepEqual(rows, want) {
+		t.Errorf("expected %v, got %v", want, rows)
+	}
+	if len(svc.closes) != 1 {
+		t.Errorf("expected the operation to be closed, got %d closes", len(svc.closes))
+	}
+}
+
+func TestRowsIteratorBreak(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("s", inf.TTypeId_STRING_TYPE, 1)}
+	svc.batches = []*inf.TRowSet{stringBatch("a", "b"), stringBatch("c")}
+	conn := connectFake(t, svc, testOptions())
+
+	rs, err := conn.Query("SELECT s FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	for range rs.Rows(context.Background()) {
+		break
+	}
+	svc.mu.Lock()
+	fetches, closes := len(svc.fetches), len(svc.closes)
+	svc.mu.Unlock()
+	if fetches != 1 || closes != 1 {
+		t.Errorf("expected one fetch and the operation closed on break, got %d fetches and %d closes", fetches, closes)
+	}
+	if conn.InFlight() != 0 {
+		t.Error("expected the operation to be untracked")
+	}
+}
+
+func TestRowsIteratorError(t *testing.T) {
+	svc := newFakeService()
+	svc.onFetch = func(req *inf.TFetchResultsReq) (*inf.TFetchResultsResp, error) {
+		return &inf.TFetchResultsResp{Status: errorStatus("connection reset")}, nil
+	}
+	conn := connectFake(t, svc, testOptions())
+
+	rs, err := conn.Query("SELECT s FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	var errs []error
+	for row, err := range rs.Rows(context.Background()) {
+		if row != nil {
+			t.Errorf("expected no rows, got %v", row)
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) != 1 || errs[0] == nil {
+		t.Errorf("expected one terminal error, got %v", errs)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rs, err = conn.Query("SELECT s FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	errs = nil
+	for _, err := range rs.Rows(ctx) {
+		errs = append(errs, err)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], context.Canceled) {
+		t.Errorf("expected the context's error once, got %v", errs)
+	}
+}
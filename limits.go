@@ -0,0 +1,73 @@
+package hive
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+type queryLimitsKey struct{}
+
+// queryLimitKeys are the settings WithQueryLimits accepts. Each bounds
+// the resources of a single query and is read when the query is
+// compiled, so it takes effect even though it is put back once the
+// statement has been submitted.
+var queryLimitKeys = map[string]bool{
+	"hive.tez.container.size":                       true,
+	"tez.runtime.io.sort.mb":                        true,
+	"tez.task.resource.memory.mb":                   true,
+	"mapreduce.map.memory.mb":                       true,
+	"mapreduce.reduce.memory.mb":                    true,
+	"hive.auto.convert.join.noconditionaltask.size": true,
+	"hive.exec.reducers.max":                        true,
+	"hive.exec.max.dynamic.partitions":              true,
+	"hive.exec.max.created.files":                   true,
+	"hive.limit.query.max.table.partition":          true,
+}
+
+// WithQueryLimits returns a context under which statements run with the
+// resource limits in limits, for constraining one tenant's queries
+// without changing the limits of the whole session. Like
+// WithResourceQueue, each call that sends a statement sets them first
+// and puts back their prior values once it has been submitted, and the
+// two can be combined. Limits the session had not defined are RESET
+// afterwards, so on Hive before 3.0, where RESET <key> clears every
+// setting, such a limit fails the statement with ErrResetUnsupported.
+//
+// Only these keys are accepted, each with a non-negative integer value:
+//
+//   - hive.tez.container.size, tez.task.resource.memory.mb: the memory
+//     of a Tez task's container and of the task, in MB
+//   - tez.runtime.io.sort.mb: the sort buffer of a Tez task, in MB
+//   - mapreduce.map.memory.mb, mapreduce.reduce.memory.mb: the memory of
+//     a MapReduce task, in MB
+//   - hive.auto.convert.join.noconditionaltask.size: the total size, in
+//     bytes, of the tables a map join may hold in memory
+//   - hive.exec.reducers.max: the number of reducers
+//   - hive.exec.max.dynamic.partitions, hive.exec.max.created.files: the
+//     partitions and files a statement may create
+//   - hive.limit.query.max.table.partition: the partitions a query may
+//     scan in one table
+//
+// Other keys, or other values, fail the statement. The server may still
+// cap the values further, e.g. through YARN's maximum allocation.
+func WithQueryLimits(ctx context.Context, limits map[string]string) context.Context {
+	copied := make(map[string]string, len(limits))
+	for key, value := range limits {
+		copied[key] = value
+	}
+	return context.WithValue(ctx, queryLimitsKey{}, copied)
+}
+
+// checkQueryLimits checks limits against queryLimitKeys.
+func checkQueryLimits(limits map[string]string) error {
+	for key, value := range limits {
+		if !queryLimitKeys[key] {
+			return fmt.Errorf("Query limit %q is not allowed", key)
+		}
+		if _, err := strconv.ParseUint(value, 10, 63); err != nil {
+			return fmt.Errorf("Invalid query limit %s=%q: want a non-negative integer", key, value)
+		}
+	}
+	return nil
+}
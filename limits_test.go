@@ -0,0 +1,87 @@
+package hive
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestWithQueryLimits(t *testing.T) {
+	svc := newFakeService()
+	svc.reportVersion("3.1.3")
+	svc.results = map[string][]*inf.TRowSet{
+		"SET hive.tez.container.size": {stringBatch("hive.tez.container.size=-1")},
+		"SET tez.runtime.io.sort.mb":  {stringBatch("tez.runtime.io.sort.mb is undefined")},
+		"SET tez.queue.name":          {stringBatch("tez.queue.name=default")},
+		"SET mapreduce.job.queuename": {stringBatch("mapreduce.job.queuename=default")},
+	}
+	conn := connectFake(t, svc, testOptions())
+	ctx := WithQueryLimits(context.Background(), map[string]string{
+		"hive.tez.container.size": "4096",
+		"tez.runtime.io.sort.mb":  "512",
+	})
+
+	if _, _, err := conn.ExecCount(ctx, "INSERT INTO t SELECT * FROM s"); err != nil {
+		t.Fatalf("Exec error: %v", err)
+	}
+	rs, err := conn.QueryContext(WithResourceQueue(ctx, "etl"), "SELECT * FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	rs.Close(ctx)
+	if _, err := conn.Query("SELECT 1"); err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+
+	expected := []string{
+		"SET hive.tez.container.size",
+		"SET hive.tez.container.size=4096",
+		"SET tez.runtime.io.sort.mb",
+		"SET tez.runtime.io.sort.mb=512",
+		"INSERT INTO t SELECT * FROM s",
+		"RESET tez.runtime.io.sort.mb",
+		"SET hive.tez.container.size=-1",
+
+		"SET hive.tez.container.size",
+		"SET hive.tez.container.size=4096",
+		"SET mapreduce.job.queuename",
+		"SET mapreduce.job.queuename=etl",
+		"SET tez.queue.name",
+		"SET tez.queue.name=etl",
+		"SET tez.runtime.io.sort.mb",
+		"SET tez.runtime.io.sort.mb=512",
+		"SELECT * FROM t",
+		"RESET tez.runtime.io.sort.mb",
+		"SET tez.queue.name=default",
+		"SET mapreduce.job.queuename=default",
+		"SET hive.tez.container.size=-1",
+
+		"SELECT 1",
+	}
+	if got := svc.executed(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected statements\n%q\ngot\n%q", expected, got)
+	}
+}
+
+func TestWithQueryLimitsRejectsInvalidLimits(t *testing.T) {
+	svc := newFakeService()
+	conn := connectFake(t, svc, testOptions())
+	sent := len(svc.executed())
+
+	for _, limits := range []map[string]string{
+		{"hive.execution.engine": "mr"},
+		{"tez.runtime.io.sort.mb": "512;DROP TABLE t"},
+		{"tez.runtime.io.sort.mb": "-1"},
+		{"hive.tez.container.size": "4g"},
+	} {
+		ctx := WithQueryLimits(context.Background(), limits)
+		if _, _, err := conn.ExecCount(ctx, "INSERT INTO t SELECT * FROM s"); err == nil {
+			t.Errorf("expected %v to be rejected", limits)
+		}
+	}
+	if got := svc.executed()[sent:]; len(got) != 0 {
+		t.Errorf("expected nothing sent, got %q", got)
+	}
+}
@@ -0,0 +1,173 @@
+package hive
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EscapeLiteral renders v as a HiveQL literal, the way placeholders are
+// filled in client-side: nil as NULL, bools, integers and finite floats
+// as numbers, strings quoted and escaped, []byte as unhex('...') and
+// time.Time as a TIMESTAMP literal of its wall clock time. Other types,
+// NaN and infinities are rejected.
+func EscapeLiteral(v interface{}) (string, error) {
+	return formatLiteral(v)
+}
+
+// formatLiteral renders v as a HiveQL literal for client-side parameter
+// interpolation.
+func formatLiteral(v interface{}) (string, error) {
+	switch t := v.(type) {
+	case nil:
+		return "NULL", nil
+	case bool:
+		if t {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case int:
+		return strconv.FormatInt(int64(t), 10), nil
+	case int8:
+		return strconv.FormatInt(int64(t), 10), nil
+	case int16:
+		return strconv.FormatInt(int64(t), 10), nil
+	case int32:
+		return strconv.FormatInt(int64(t), 10), nil
+	case int64:
+		return strconv.FormatInt(t, 10), nil
+	case uint:
+		return strconv.FormatUint(uint64(t), 10), nil
+	case uint8:
+		return strconv.FormatUint(uint64(t), 10), nil
+	case uint16:
+		return strconv.FormatUint(uint64(t), 10), nil
+	case uint32:
+		return strconv.FormatUint(uint64(t), 10), nil
+	case uint64:
+		return strconv.FormatUint(t, 10), nil
+	case float32:
+		return formatFloat(float64(t), 32)
+	case float64:
+		return formatFloat(t, 64)
+	case string:
+		return quoteString(t), nil
+	case []byte:
+		return "unhex('" + hex.EncodeToString(t) + "')", nil
+	case time.Time:
+		return "TIMESTAMP '" + t.Format("2006-01-02 15:04:05.999999999") + "'", nil
+	default:
+		return "", fmt.Errorf("Can't format value of type %T as a literal", v)
+	}
+}
+
+func formatFloat(f float64, bitSize int) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", fmt.Errorf("Can't format %v as a literal", f)
+	}
+	return strconv.FormatFloat(f, 'g', -1, bitSize), nil
+}
+
+// quoteString quotes s as a single-quoted Hive string literal, escaping
+// the characters Hive's unescapeSQLString interprets.
+func quoteString(s string) string {
+	var b strings.Builder
+	b.Grow(len(s) + 2)
+	b.WriteByte('\'')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\':
+			b.WriteString(`\\`)
+		case '\'':
+			b.WriteString(`\'`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case 0:
+			// Three digits, so that digits following it aren't read
+			// into an octal escape.
+			b.WriteString(`\000`)
+		case 0x1a:
+			b.WriteString(`\Z`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
+
+// bindParams replaces each "?" placeholder in query with the literal
+// form of the matching argument. Placeholders inside string literals,
+// quoted identifiers and comments are left alone.
+func bindParams(query string, args []interface{}) (string, error) {
+	return fillPlaceholders(splitPlaceholders(query), args)
+}
+
+// splitPlaceholders cuts query at its "?" placeholders, returning the
+// text around them: one more part than there are placeholders.
+func splitPlaceholders(query string) []string {
+	var parts []string
+	last := 0
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end, _ := quotedEnd(query, i)
+			i = end - 1
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			i += end - 1
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				end = len(query) - i
+			} else {
+				end += 4
+			}
+			i += end - 1
+		case c == '?':
+			parts = append(parts, query[last:i])
+			last = i + 1
+		}
+	}
+	return append(parts, query[last:])
+}
+
+// fillPlaceholders joins parts, as returned by splitPlaceholders, with
+// the literal forms of args in between.
+func fillPlaceholders(parts []string, args []interface{}) (string, error) {
+	if n := len(parts) - 1; n != len(args) {
+		if n > len(args) {
+			return "", fmt.Errorf("Query has more placeholders than the %d arguments given", len(args))
+		}
+		return "", fmt.Errorf("Query has %d placeholders but %d arguments were given", n, len(args))
+	}
+	size := 0
+	for _, part := range parts {
+		size += len(part)
+	}
+	var b strings.Builder
+	b.Grow(size + 8*len(args))
+	b.WriteString(parts[0])
+	for i, arg := range args {
+		lit, err := formatLiteral(arg)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(lit)
+		b.WriteString(parts[i+1])
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,139 @@
+package hive
+
+import (
+	"encoding/hex"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// lexStringLiteral reads the string literal at the start of s the way
+// Hive's lexer does, returning it with its quotes and the rest of s.
+func lexStringLiteral(t *testing.T, s string) (string, string) {
+	t.Helper()
+	if s == "" || s[0] != '\'' {
+		t.Fatalf("expected a string literal at %q", s)
+	}
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '\'':
+			return s[:i+1], s[i+1:]
+		}
+	}
+	t.Fatalf("unterminated string literal %q", s)
+	return "", ""
+}
+
+// unescapeSQLString decodes a quoted literal like Hive's
+// BaseSemanticAnalyzer.unescapeSQLString.
+func unescapeSQLString(s string) string {
+	var b strings.Builder
+	s = s[1 : len(s)-1]
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i+1 >= len(s) {
+			b.WriteByte(c)
+			continue
+		}
+		if s[i+1] == 'u' && i+5 < len(s) {
+			if n, err := strconv.ParseUint(s[i+2:i+6], 16, 16); err == nil {
+				b.WriteRune(rune(n))
+				i += 5
+				continue
+			}
+		}
+		if i+3 < len(s) && '0' <= s[i+1] && s[i+1] <= '1' && '0' <= s[i+2] && s[i+2] <= '7' && '0' <= s[i+3] && s[i+3] <= '7' {
+			n, _ := strconv.ParseUint(s[i+1:i+4], 8, 8)
+			b.WriteByte(byte(n))
+			i += 3
+			continue
+		}
+		i++
+		switch e := s[i]; e {
+		case '0':
+			b.WriteByte(0)
+		case 'b':
+			b.WriteByte('\b')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'Z':
+			b.WriteByte(0x1a)
+		case '%', '_':
+			b.WriteByte('\\')
+			b.WriteByte(e)
+		default:
+			b.WriteByte(e)
+		}
+	}
+	return b.String()
+}
+
+func FuzzEscapeLiteral(f *testing.F) {
+	for _, s := range []string{"", "plain", "it's", `back\slash`, `\'; DROP TABLE t; --`, "a\nb\r\tc",
+		"\x00", "\x0012", "\x1a", `A`, "\\", `"quoted"`, "é€😀", "%_"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		if !utf8.ValidString(s) {
+			// The server decodes statements as UTF-8.
+			return
+		}
+		lit, err := EscapeLiteral(s)
+		if err != nil {
+			t.Fatalf("EscapeLiteral(%q) error: %v", s, err)
+		}
+		token, rest := lexStringLiteral(t, lit+" AS x FROM t")
+		if rest != " AS x FROM t" {
+			t.Fatalf("literal %s for %q ends early, leaving %q", lit, s, rest)
+		}
+		if got := unescapeSQLString(token); got != s {
+			t.Errorf("literal %s for %q reads back as %q", lit, s, got)
+		}
+	})
+}
+
+func TestEscapeLiteral(t *testing.T) {
+	ts := time.Date(2024, 2, 29, 13, 4, 5, 120000000, time.UTC)
+	for _, tc := range []struct {
+		in   interface{}
+		want string
+	}{
+		{nil, "NULL"},
+		{true, "TRUE"},
+		{false, "FALSE"},
+		{int8(-8), "-8"},
+		{uint64(18446744073709551615), "18446744073709551615"},
+		{float32(1.5), "1.5"},
+		{1e-7, "1e-07"},
+		{ts, "TIMESTAMP '2024-02-29 13:04:05.12'"},
+		{[]byte{0x00, 0xff, '\''}, "unhex('00ff27')"},
+	} {
+		got, err := EscapeLiteral(tc.in)
+		if err != nil {
+			t.Errorf("EscapeLiteral(%#v) error: %v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("EscapeLiteral(%#v): expected %s, got %s", tc.in, tc.want, got)
+		}
+	}
+
+	lit, _ := EscapeLiteral([]byte("\x00'\\"))
+	if b, err := hex.DecodeString(strings.TrimSuffix(strings.TrimPrefix(lit, "unhex('"), "')")); err != nil || string(b) != "\x00'\\" {
+		t.Errorf("expected %s to decode to the bytes given, got %q, %v", lit, b, err)
+	}
+	for _, v := range []interface{}{struct{}{}, []string{"a"}, float32(math.NaN()), math.Inf(1)} {
+		if lit, err := EscapeLiteral(v); err == nil {
+			t.Errorf("expected %#v to be rejected, got %s", v, lit)
+		}
+	}
+}
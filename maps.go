@@ -0,0 +1,366 @@
+package hive
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// A MapEntry is one key/value pair of a MAP whose keys aren't strings,
+// see Options.DecodeMaps.
+type MapEntry struct {
+	Key   interface{}
+	Value interface{}
+}
+
+// decodeMaps replaces the server's text rendering of MAP cells in the
+// current batch with decoded maps.
+func (r *rowSet) decodeMaps() error {
+	for i, col := range r.resultSet {
+		if i >= len(r.columns) || columnTypeID(r.columns[i]) != inf.TTypeId_MAP_TYPE {
+			continue
+		}
+		types := r.columns[i].GetTypeDesc().GetTypes()
+		for j, raw := range col {
+			s, ok := raw.(string)
+			if !ok {
+				continue
+			}
+			v, err := parseComplex(s, types)
+			if err != nil {
+				return fmt.Errorf("column %s: %v", r.columns[i].GetColumnName(), err)
+			}
+			col[j] = v
+		}
+	}
+	return nil
+}
+
+// parseComplex decodes the rendering of a complex value whose type is
+// the first of types. Hive renders complex values much like JSON, except
+// that map keys of non-string types are left unquoted, as in {1:"a"}.
+func parseComplex(s string, types []*inf.TTypeEntry) (interface{}, error) {
+	p := &complexParser{s: s, types: types}
+	v, err := p.value(0)
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos != len(p.s) {
+		return nil, p.errorf("trailing characters")
+	}
+	return v, nil
+}
+
+type complexParser struct {
+	s     string
+	pos   int
+	types []*inf.TTypeEntry
+}
+
+func (p *complexParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("Invalid complex value %q at offset %d: %s", p.s, p.pos, fmt.Sprintf(format, args...))
+}
+
+// entry returns the type entry at index i, or nil if there isn't one, in
+// which case values are decoded as untyped.
+func (p *complexParser) entry(i inf.TTypeEntryPtr) *inf.TTypeEntry {
+	if i < 0 || int(i) >= len(p.types) {
+		return nil
+	}
+	return p.types[i]
+}
+
+func (p *complexParser) skipSpace() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t' || p.s[p.pos] == '\n' || p.s[p.pos] == '\r') {
+		p.pos++
+	}
+}
+
+// consume skips c, or reports whether it is missing.
+func (p *complexParser) consume(c byte) bool {
+	p.skipSpace()
+	if p.pos < len(p.s) && p.s[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// list parses the elements of an array or object up to the closing
+// delimiter, the opening one having been consumed.
+func (p *complexParser) list(end byte, element func() error) error {
+	if p.consume(end) {
+		return nil
+	}
+	for {
+		if err := element(); err != nil {
+			return err
+		}
+		if p.consume(end) {
+			return nil
+		}
+		if !p.consume(',') {
+			return p.errorf("expected ',' or '%c'", end)
+		}
+	}
+}
+
+func (p *complexParser) value(typeIndex inf.TTypeEntryPtr) (interface{}, error) {
+	p.skipSpace()
+	if strings.HasPrefix(p.s[p.pos:], "null") {
+		p.pos += len("null")
+		return nil, nil
+	}
+
+	entry := p.entry(typeIndex)
+	if entry == nil {
+		// Untyped: go by the rendering.
+		entry = &inf.TTypeEntry{}
+		switch {
+		case p.peek('['):
+			entry.ArrayEntry = &inf.TArrayTypeEntry{ObjectTypePtr: -1}
+		case p.peek('{'):
+			entry.StructEntry = &inf.TStructTypeEntry{}
+		}
+	}
+	switch {
+	case entry.IsSetArrayEntry():
+		if !p.consume('[') {
+			return nil, p.errorf("expected '['")
+		}
+		elem := entry.GetArrayEntry().GetObjectTypePtr()
+		values := []interface{}{}
+		err := p.list(']', func() error {
+			v, err := p.value(elem)
+			values = append(values, v)
+			return err
+		})
+		return values, err
+	case entry.IsSetMapEntry():
+		return p.mapValue(entry.GetMapEntry())
+	case entry.IsSetStructEntry():
+		if !p.consume('{') {
+			return nil, p.errorf("expected '{'")
+		}
+		fields := map[string]interface{}{}
+		err := p.list('}', func() error {
+			name, _, err := p.scalar()
+			if err != nil {
+				return err
+			}
+			if !p.consume(':') {
+				return p.errorf("expected ':'")
+			}
+			ptr := inf.TTypeEntryPtr(-1)
+			if t, ok := entry.GetStructEntry().GetNameToTypePtr()[name]; ok {
+				ptr = t
+			}
+			fields[name], err = p.value(ptr)
+			return err
+		})
+		return fields, err
+	case entry.IsSetUnionEntry():
+		// Rendered as {tag:value}; the value is returned.
+		if !p.consume('{') {
+			return nil, p.errorf("expected '{'")
+		}
+		var v interface{}
+		err := p.list('}', func() error {
+			if _, _, err := p.scalar(); err != nil {
+				return err
+			}
+			if !p.consume(':') {
+				return p.errorf("expected ':'")
+			}
+			var err error
+			v, err = p.value(-1)
+			return err
+		})
+		return v, err
+	}
+
+	text, quoted, err := p.scalar()
+	if err != nil {
+		return nil, err
+	}
+	typeID := inf.TTypeId_STRING_TYPE
+	if entry.IsSetPrimitiveEntry() {
+		typeID = entry.GetPrimitiveEntry().GetType()
+	} else if !quoted {
+		return untypedScalar(text), nil
+	}
+	return primitiveValue(typeID, text)
+}
+
+func (p *complexParser) peek(c byte) bool {
+	return p.pos < len(p.s) && p.s[p.pos] == c
+}
+
+// mapValue decodes a map. Maps keyed by strings become
+// map[string]interface{}; others keep their entries in order, with typed
+// keys, as []MapEntry.
+func (p *complexParser) mapValue(m *inf.TMapTypeEntry) (interface{}, error) {
+	if !p.consume('{') {
+		return nil, p.errorf("expected '{'")
+	}
+	keyType := inf.TTypeId_STRING_TYPE
+	if key := p.entry(m.GetKeyTypePtr()); key != nil && key.IsSetPrimitiveEntry() {
+		keyType = key.GetPrimitiveEntry().GetType()
+	}
+	stringKeys := keyType == inf.TTypeId_STRING_TYPE || keyType == inf.TTypeId_VARCHAR_TYPE || keyType == inf.TTypeId_CHAR_TYPE
+
+	var (
+		byName  = map[string]interface{}{}
+		entries = []MapEntry{}
+	)
+	err := p.list('}', func() error {
+		text, _, err := p.scalar()
+		if err != nil {
+			return err
+		}
+		if !p.consume(':') {
+			return p.errorf("expected ':'")
+		}
+		value, err := p.value(m.GetValueTypePtr())
+		if err != nil {
+			return err
+		}
+		if stringKeys {
+			byName[text] = value
+			return nil
+		}
+		key, err := primitiveValue(keyType, text)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, MapEntry{Key: key, Value: value})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if stringKeys {
+		return byName, nil
+	}
+	return entries, nil
+}
+
+// scalar reads a quoted string or a bare literal.
+func (p *complexParser) scalar() (string, bool, error) {
+	p.skipSpace()
+	if p.peek('"') {
+		s, err := p.quoted()
+		return s, true, err
+	}
+	start := p.pos
+	for p.pos < len(p.s) && !strings.ContainsRune(",:]}", rune(p.s[p.pos])) {
+		p.pos++
+	}
+	text := strings.TrimSpace(p.s[start:p.pos])
+	if text == "" {
+		return "", false, p.errorf("expected a value")
+	}
+	return text, false, nil
+}
+
+// quoted reads a string literal with JSON escapes, as written by Hive.
+func (p *complexParser) quoted() (string, error) {
+	p.pos++ // opening quote
+	var b strings.Builder
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		switch {
+		case c == '"':
+			p.pos++
+			return b.String(), nil
+		case c == '\\' && p.pos+1 < len(p.s):
+			p.pos++
+			switch e := p.s[p.pos]; e {
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				if p.pos+5 > len(p.s) {
+					return "", p.errorf("short \\u escape")
+				}
+				n, err := strconv.ParseUint(p.s[p.pos+1:p.pos+5], 16, 32)
+				if err != nil {
+					return "", p.errorf("invalid \\u escape")
+				}
+				b.WriteRune(rune(n))
+				p.pos += 4
+			default:
+				b.WriteByte(e)
+			}
+			p.pos++
+		default:
+			_, size := utf8.DecodeRuneInString(p.s[p.pos:])
+			b.WriteString(p.s[p.pos : p.pos+size])
+			p.pos += size
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+// primitiveValue converts a rendered primitive to the representation
+// DefaultTypeMapper uses for a column of that type.
+func primitiveValue(typeID inf.TTypeId, text string) (interface{}, error) {
+	var (
+		v   interface{}
+		err error
+	)
+	switch typeID {
+	case inf.TTypeId_BOOLEAN_TYPE:
+		v, err = strconv.ParseBool(text)
+	case inf.TTypeId_TINYINT_TYPE:
+		var n int64
+		n, err = strconv.ParseInt(text, 10, 8)
+		v = int8(n)
+	case inf.TTypeId_SMALLINT_TYPE:
+		var n int64
+		n, err = strconv.ParseInt(text, 10, 16)
+		v = int16(n)
+	case inf.TTypeId_INT_TYPE:
+		var n int64
+		n, err = strconv.ParseInt(text, 10, 32)
+		v = int32(n)
+	case inf.TTypeId_BIGINT_TYPE:
+		v, err = strconv.ParseInt(text, 10, 64)
+	case inf.TTypeId_FLOAT_TYPE, inf.TTypeId_DOUBLE_TYPE:
+		v, err = strconv.ParseFloat(text, 64)
+	default:
+		return text, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Invalid %s value %q", typeName(typeID), text)
+	}
+	return v, nil
+}
+
+// untypedScalar decodes a bare literal of unknown type.
+func untypedScalar(text string) interface{} {
+	switch text {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return n
+	}
+	if f, err := strconv.ParseFloat(text, 64); err == nil {
+		return f
+	}
+	return text
+}
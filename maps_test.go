@@ -0,0 +1,114 @@
+package hive
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func primitiveEntry(typ inf.TTypeId) *inf.TTypeEntry {
+	return &inf.TTypeEntry{PrimitiveEntry: &inf.TPrimitiveTypeEntry{Type: typ}}
+}
+
+// mapIntString is MAP<INT,STRING>.
+var mapIntString = []*inf.TTypeEntry{
+	{MapEntry: &inf.TMapTypeEntry{KeyTypePtr: 1, ValueTypePtr: 2}},
+	primitiveEntry(inf.TTypeId_INT_TYPE),
+	primitiveEntry(inf.TTypeId_STRING_TYPE),
+}
+
+// mapStringIntArray is MAP<STRING,ARRAY<INT>>.
+var mapStringIntArray = []*inf.TTypeEntry{
+	{MapEntry: &inf.TMapTypeEntry{KeyTypePtr: 1, ValueTypePtr: 2}},
+	primitiveEntry(inf.TTypeId_STRING_TYPE),
+	{ArrayEntry: &inf.TArrayTypeEntry{ObjectTypePtr: 3}},
+	primitiveEntry(inf.TTypeId_INT_TYPE),
+}
+
+func TestParseComplex(t *testing.T) {
+	cases := []struct {
+		name  string
+		types []*inf.TTypeEntry
+		in    string
+		want  interface{}
+	}{
+		{"int keys", mapIntString, `{1:"one",2:"t\"wo",3:null}`,
+			[]MapEntry{{int32(1), "one"}, {int32(2), `t"wo`}, {int32(3), nil}}},
+		{"empty", mapIntString, `{}`, []MapEntry{}},
+		{"string keys with arrays", mapStringIntArray, `{"a":[1,2],"b":[],"c":null}`,
+			map[string]interface{}{"a": []interface{}{int32(1), int32(2)}, "b": []interface{}{}, "c": nil}},
+		{"escapes", mapStringIntArray, `{"xé\n":[3]}`,
+			map[string]interface{}{"xé\n": []interface{}{int32(3)}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseComplex(tc.in, tc.types)
+			if err != nil {
+				t.Fatalf("parseComplex error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected %#v, got %#v", tc.want, got)
+			}
+		})
+	}
+
+	for _, in := range []string{`{1:"one"`, `{x:"one"}`, `{"a":[1,}`, `{"a":[1]} x`} {
+		types := mapIntString
+		if in[1] == '"' {
+			types = mapStringIntArray
+		}
+		if _, err := parseComplex(in, types); err == nil {
+			t.Errorf("expected an error for %q", in)
+		}
+	}
+}
+
+func TestDecodeMaps(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{
+		{ColumnName: "codes", Position: 1, TypeDesc: &inf.TTypeDesc{Types: mapIntString}},
+		{ColumnName: "groups", Position: 2, TypeDesc: &inf.TTypeDesc{Types: mapStringIntArray}},
+	}
+	svc.batches = []*inf.TRowSet{{Columns: []*inf.TColumn{
+		{StringVal: &inf.TStringColumn{Values: []string{`{404:"not found"}`, ""}, Nulls: []byte{0x02}}},
+		{StringVal: &inf.TStringColumn{Values: []string{`{"odd":[1,3]}`, `{}`}, Nulls: []byte{}}},
+	}}}
+
+	for _, decode := range []bool{false, true} {
+		options := testOptions()
+		options.DecodeMaps = decode
+		conn := connectFake(t, svc, options)
+		rs, err := conn.Query("SELECT codes, groups FROM t")
+		if err != nil {
+			t.Fatalf("Query error: %v", err)
+		}
+
+		var rows [][]interface{}
+		for {
+			values, err := rs.NextValues(context.Background())
+			if err != nil {
+				break
+			}
+			rows = append(rows, []interface{}{values[0], values[1]})
+		}
+		if err := rs.Err(); err != nil {
+			t.Fatalf("NextValues error: %v", err)
+		}
+
+		want := [][]interface{}{
+			{`{404:"not found"}`, `{"odd":[1,3]}`},
+			{nil, `{}`},
+		}
+		if decode {
+			want = [][]interface{}{
+				{[]MapEntry{{int32(404), "not found"}}, map[string]interface{}{"odd": []interface{}{int32(1), int32(3)}}},
+				{nil, map[string]interface{}{}},
+			}
+		}
+		if !reflect.DeepEqual(rows, want) {
+			t.Errorf("DecodeMaps=%v: expected %#v, got %#v", decode, want, rows)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// MaterializeOptions control Materialize.
+type MaterializeOptions struct {
+	// OnProgress, if set, is called with the operation's summary after
+	// each poll, the last time once it has finished. Progress is best
+	// effort: polls for which the server reports nothing, as servers
+	// without progress updates do, are skipped, and so are failures to
+	// read it.
+	OnProgress func(*QuerySummary)
+}
+
+// Materialize runs a write statement, such as INSERT OVERWRITE ... SELECT
+// or CREATE TABLE ... AS SELECT, to completion, reporting its progress
+// along the way, and returns the number of rows it wrote with ok false
+// when the server doesn't report it, see RowSet.ModifiedRowCount.
+//
+// It is meant for statements whose result is the table they fill; a
+// query's rows are not read. If ctx ends before the statement finishes,
+// the operation is cancelled on the server and ctx's error returned.
+func (c *Connection) Materialize(ctx context.Context, stmt string, opts MaterializeOptions) (n int64, ok bool, err error) {
+	executeReq := inf.NewTExecuteStatementReq()
+	executeReq.Statement = stmt
+	executeReq.RunAsync = true
+
+	rs, err := c.submit(ctx, executeReq)
+	if err != nil {
+		return 0, false, err
+	}
+	defer rs.Close(context.WithoutCancel(ctx))
+
+	for {
+		status, err := rs.poll(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return 0, false, rs.abandon(ctx)
+			}
+			return 0, false, err
+		}
+		if opts.OnProgress != nil {
+			if s, err := rs.Summary(ctx); err == nil {
+				opts.OnProgress(s)
+			} else if !errors.Is(err, ErrSummaryNotAvailable) && ctx.Err() != nil {
+				return 0, false, rs.abandon(ctx)
+			}
+		}
+		if status.IsComplete() {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return 0, false, rs.abandon(ctx)
+		case <-rs.options.clock().After(time.Duration(rs.options.PollIntervalSeconds) * time.Second):
+		}
+	}
+
+	// wait sees the operation complete on its first poll, and turns a
+	// failure into the error Query would return.
+	if _, err := rs.wait(ctx); err != nil {
+		return 0, false, err
+	}
+	n, ok = rs.ModifiedRowCount(ctx)
+	return n, ok, nil
+}
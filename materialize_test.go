@@ -0,0 +1,106 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// progressStatus makes the fake server report the operation running,
+// with progress rising by a quarter on each progress update, until it
+// is complete.
+func progressStatus(svc *fakeService) {
+	var progress float64
+	svc.onStatus = func(req *inf.TGetOperationStatusReq) (*inf.TGetOperationStatusResp, error) {
+		state := inf.TOperationState_RUNNING_STATE
+		if req.GetGetProgressUpdate() {
+			progress = min(progress+0.25, 1)
+		}
+		if progress >= 1 {
+			state = inf.TOperationState_FINISHED_STATE
+		}
+		resp := &inf.TGetOperationStatusResp{Status: okStatus(), OperationState: &state}
+		if req.GetGetProgressUpdate() {
+			resp.ProgressUpdateResponse = &inf.TProgressUpdateResp{ProgressedPercentage: progress}
+		}
+		return resp, nil
+	}
+}
+
+func TestMaterialize(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	modified := int64(1000)
+	svc.modifiedRows = &modified
+	progressStatus(svc)
+	conn := connectFake(t, svc, testOptions())
+
+	var progress []float64
+	n, ok, err := conn.Materialize(ctx, "INSERT OVERWRITE TABLE daily SELECT * FROM events", MaterializeOptions{
+		OnProgress: func(s *QuerySummary) { progress = append(progress, s.Progress) },
+	})
+	if err != nil {
+		t.Fatalf("Materialize error: %v", err)
+	}
+	if !ok || n != 1000 {
+		t.Errorf("expected 1000 modified rows, got %d (ok %v)", n, ok)
+	}
+	if len(progress) < 4 || progress[len(progress)-1] != 1 {
+		t.Errorf("expected progress up to completion, got %v", progress)
+	}
+	for i := 1; i < len(progress); i++ {
+		if progress[i] < progress[i-1] {
+			t.Errorf("expected progress not to go back, got %v", progress)
+		}
+	}
+	if !svc.executes[0].RunAsync {
+		t.Error("expected the statement run asynchronously")
+	}
+	if len(svc.closes) != 1 {
+		t.Errorf("expected the operation closed, got %d closes", len(svc.closes))
+	}
+}
+
+func TestMaterializeCancelled(t *testing.T) {
+	svc := newFakeService()
+	state := inf.TOperationState_RUNNING_STATE
+	svc.onStatus = func(req *inf.TGetOperationStatusReq) (*inf.TGetOperationStatusResp, error) {
+		return &inf.TGetOperationStatusResp{
+			Status:                 okStatus(),
+			OperationState:         &state,
+			ProgressUpdateResponse: &inf.TProgressUpdateResp{ProgressedPercentage: 0.5},
+		}, nil
+	}
+	conn := connectFake(t, svc, testOptions())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, _, err := conn.Materialize(ctx, "INSERT OVERWRITE TABLE daily SELECT * FROM events", MaterializeOptions{
+		OnProgress: func(*QuerySummary) { cancel() },
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if len(svc.cancels) != 1 {
+		t.Errorf("expected the operation cancelled on the server, got %d cancels", len(svc.cancels))
+	}
+}
+
+func TestMaterializeFailed(t *testing.T) {
+	svc := newFakeService()
+	svc.states = []inf.TOperationState{inf.TOperationState_RUNNING_STATE, inf.TOperationState_ERROR_STATE}
+	conn := connectFake(t, svc, testOptions())
+
+	var calls int
+	_, _, err := conn.Materialize(context.Background(), "INSERT INTO t SELECT * FROM s", MaterializeOptions{
+		OnProgress: func(*QuerySummary) { calls++ },
+	})
+	if err == nil {
+		t.Fatal("expected the failed statement to return an error")
+	}
+	if calls != 0 {
+		t.Errorf("expected no progress from a server without progress updates, got %d calls", calls)
+	}
+}
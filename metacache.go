@@ -0,0 +1,56 @@
+package hive
+
+import (
+	"strings"
+	"time"
+)
+
+// metadataEntry is a cached GetSchemas, GetTables or GetColumns result.
+type metadataEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// metadataKey identifies a metadata call by its name and arguments.
+func metadataKey(call string, args ...string) string {
+	return call + "\x00" + strings.Join(args, "\x00")
+}
+
+// cachedMetadata returns a copy of the cached result for key if it
+// hasn't expired, and otherwise calls fetch and caches its result.
+// Nothing is cached unless Options.MetadataCacheTTL is positive.
+func cachedMetadata[T any](c *Connection, key string, fetch func() ([]T, error)) ([]T, error) {
+	ttl := c.options.MetadataCacheTTL
+	if ttl <= 0 {
+		return fetch()
+	}
+
+	now := c.options.clock().Now()
+	c.mu.Lock()
+	entry, ok := c.metadata[key]
+	c.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return append([]T(nil), entry.value.([]T)...), nil
+	}
+
+	value, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	if c.metadata == nil {
+		c.metadata = make(map[string]metadataEntry)
+	}
+	c.metadata[key] = metadataEntry{value: value, expires: now.Add(ttl)}
+	c.mu.Unlock()
+	return append([]T(nil), value...), nil
+}
+
+// ClearMetadataCache drops all results cached under
+// Options.MetadataCacheTTL, e.g. after DDL run through another
+// connection or tool.
+func (c *Connection) ClearMetadataCache() {
+	c.mu.Lock()
+	c.metadata = nil
+	c.mu.Unlock()
+}
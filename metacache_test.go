@@ -0,0 +1,106 @@
+package hive
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// stringRow is a columnar batch of one row of string columns.
+func stringRow(values ...string) *inf.TRowSet {
+	rs := &inf.TRowSet{}
+	for _, v := range values {
+		rs.Columns = append(rs.Columns, &inf.TColumn{StringVal: &inf.TStringColumn{Values: []string{v}, Nulls: []byte{}}})
+	}
+	return rs
+}
+
+func TestGetColumns(t *testing.T) {
+	svc := newFakeService()
+	svc.results = map[string][]*inf.TRowSet{"GetColumns": {stringRow(
+		"", "sales", "orders", "amount", "3", "decimal(10,2)", "10", "", "2", "10",
+		"1", "order total", "", "", "", "", "4", "YES",
+	)}}
+	conn := connectFake(t, svc, testOptions())
+
+	columns, err := conn.GetColumns(context.Background(), "", "sales", "orders", "")
+	if err != nil {
+		t.Fatalf("GetColumns error: %v", err)
+	}
+	expected := []ColumnInfo{{
+		Schema:        "sales",
+		Table:         "orders",
+		Name:          "amount",
+		DataType:      3,
+		TypeName:      "decimal(10,2)",
+		Size:          10,
+		DecimalDigits: 2,
+		Nullable:      true,
+		Remarks:       "order total",
+		Position:      4,
+	}}
+	if !reflect.DeepEqual(columns, expected) {
+		t.Errorf("expected %+v, got %+v", expected, columns)
+	}
+}
+
+func TestMetadataCache(t *testing.T) {
+	svc := newFakeService()
+	svc.results = map[string][]*inf.TRowSet{"GetTables": {stringRow("", "sales", "orders", "TABLE", "")}}
+	clock := newFakeClock()
+	options := testOptions()
+	options.MetadataCacheTTL = time.Minute
+	options.testClock = clock
+	conn := connectFake(t, svc, options)
+	ctx := context.Background()
+
+	getTables := func() []TableInfo {
+		t.Helper()
+		tables, err := conn.GetTables(ctx, "", "sales", "%")
+		if err != nil {
+			t.Fatalf("GetTables error: %v", err)
+		}
+		return tables
+	}
+	calls := func() int {
+		svc.mu.Lock()
+		defer svc.mu.Unlock()
+		return len(svc.metadata)
+	}
+
+	tables := getTables()
+	expected := []TableInfo{{Schema: "sales", Name: "orders", Type: "TABLE"}}
+	if !reflect.DeepEqual(tables, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, tables)
+	}
+	tables[0].Name = "changed"
+	if tables := getTables(); !reflect.DeepEqual(tables, expected) || calls() != 1 {
+		t.Errorf("expected an unchanged cached result without a call, got %+v after %d calls", tables, calls())
+	}
+	if _, err := conn.GetTables(ctx, "", "sales", "o%"); err != nil || calls() != 2 {
+		t.Errorf("expected other arguments to miss the cache, got %v after %d calls", err, calls())
+	}
+
+	if _, err := conn.Exec("CREATE TABLE sales.refunds (id INT)"); err != nil {
+		t.Fatalf("Exec error: %v", err)
+	}
+	getTables()
+	if calls() != 3 {
+		t.Errorf("expected DDL to clear the cache, got %d calls", calls())
+	}
+
+	<-clock.After(2 * time.Minute)
+	getTables()
+	if calls() != 4 {
+		t.Errorf("expected an expired entry to be fetched again, got %d calls", calls())
+	}
+
+	conn.ClearMetadataCache()
+	getTables()
+	if calls() != 5 {
+		t.Errorf("expected ClearMetadataCache to clear the cache, got %d calls", calls())
+	}
+}
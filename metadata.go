@@ -0,0 +1,367 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/apache/thrift/lib/go/thrift"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// ErrNotSupported is returned when the server does not implement a
+// requested call, e.g. GetPrimaryKeys on HiveServer2 releases before 2.1.
+var ErrNotSupported = errors.New("hive: operation not supported by server")
+
+// PrimaryKey describes one column of a table's primary key, as
+// reported by GetPrimaryKeys.
+type PrimaryKey struct {
+	Catalog string
+	Schema  string
+	Table   string
+	Column  string
+	KeySeq  int
+	Name    string
+}
+
+// ForeignKey describes one column of a foreign key relationship, as
+// reported by GetCrossReference.
+type ForeignKey struct {
+	PKCatalog     string
+	PKSchema      string
+	PKTable       string
+	PKColumn      string
+	FKCatalog     string
+	FKSchema      string
+	FKTable       string
+	FKColumn      string
+	KeySeq        int
+	UpdateRule    int
+	DeleteRule    int
+	FKName        string
+	PKName        string
+	Deferrability int
+}
+
+// SchemaInfo is a schema, i.e. a database, as reported by GetSchemas.
+type SchemaInfo struct {
+	Catalog string
+	Name    string
+}
+
+// TableInfo is a table or view as reported by GetTables.
+type TableInfo struct {
+	Catalog string
+	Schema  string
+	Name    string
+	// Type is e.g. TABLE, VIEW or MATERIALIZED_VIEW.
+	Type    string
+	Remarks string
+}
+
+// ColumnInfo is a table column as reported by GetColumns.
+type ColumnInfo struct {
+	Catalog string
+	Schema  string
+	Table   string
+	Name    string
+	// DataType is the java.sql.Types code and TypeName the Hive type,
+	// e.g. "decimal(10,2)".
+	DataType      int
+	TypeName      string
+	Size          int
+	DecimalDigits int
+	Nullable      bool
+	Remarks       string
+	// Position is the 1-based ordinal of the column in the table.
+	Position int
+}
+
+// GetSchemas returns the schemas matching schemaPattern, a LIKE pattern
+// with % and _ wildcards. Empty arguments are left unset in the request,
+// which matches everything. Results are cached when
+// Options.MetadataCacheTTL is set.
+func (c *Connection) GetSchemas(ctx context.Context, catalog, schemaPattern string) ([]SchemaInfo, error) {
+	return cachedMetadata(c, metadataKey("GetSchemas", catalog, schemaPattern), func() ([]SchemaInfo, error) {
+		if err := c.ensureSession(ctx); err != nil {
+			return nil, err
+		}
+		req := inf.NewTGetSchemasReq()
+		req.SessionHandle = c.session
+		req.CatalogName = identifier(catalog)
+		req.SchemaName = pattern(schemaPattern)
+
+		resp, err := c.thrift.GetSchemas(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("Error in GetSchemas: %v", err)
+		}
+		if !isSuccessStatus(resp.Status) {
+			return nil, fmt.Errorf("GetSchemas failed: %s", resp.Status.String())
+		}
+
+		rows, err := metadataRows(ctx, newRowSet(c.thrift, resp.OperationHandle, c.options))
+		if err != nil {
+			return nil, err
+		}
+		schemas := make([]SchemaInfo, 0, len(rows))
+		for _, row := range rows {
+			schemas = append(schemas, SchemaInfo{Name: row.at(0), Catalog: row.at(1)})
+		}
+		return schemas, nil
+	})
+}
+
+// GetTables returns the tables matching the schema and table LIKE
+// patterns, restricted to tableTypes if any are given. Empty arguments
+// are left unset in the request. Results are cached when
+// Options.MetadataCacheTTL is set.
+func (c *Connection) GetTables(ctx context.Context, catalog, schemaPattern, tablePattern string, tableTypes ...string) ([]TableInfo, error) {
+	key := metadataKey("GetTables", append([]string{catalog, schemaPattern, tablePattern}, tableTypes...)...)
+	return cachedMetadata(c, key, func() ([]TableInfo, error) {
+		if err := c.ensureSession(ctx); err != nil {
+			return nil, err
+		}
+		req := inf.NewTGetTablesReq()
+		req.SessionHandle = c.session
+		req.CatalogName = pattern(catalog)
+		req.SchemaName = pattern(schemaPattern)
+		req.TableName = pattern(tablePattern)
+		req.TableTypes = tableTypes
+
+		resp, err := c.thrift.GetTables(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("Error in GetTables: %v", err)
+		}
+		if !isSuccessStatus(resp.Status) {
+			return nil, fmt.Errorf("GetTables failed: %s", resp.Status.String())
+		}
+
+		rows, err := metadataRows(ctx, newRowSet(c.thrift, resp.OperationHandle, c.options))
+		if err != nil {
+			return nil, err
+		}
+		tables := make([]TableInfo, 0, len(rows))
+		for _, row := range rows {
+			tables = append(tables, TableInfo{
+				Catalog: row.at(0),
+				Schema:  row.at(1),
+				Name:    row.at(2),
+				Type:    row.at(3),
+				Remarks: row.at(4),
+			})
+		}
+		return tables, nil
+	})
+}
+
+// GetColumns returns the columns matching the schema, table and column
+// LIKE patterns. Empty arguments are left unset in the request. Results
+// are cached when Options.MetadataCacheTTL is set.
+func (c *Connection) GetColumns(ctx context.Context, catalog, schemaPattern, tablePattern, columnPattern string) ([]ColumnInfo, error) {
+	key := metadataKey("GetColumns", catalog, schemaPattern, tablePattern, columnPattern)
+	return cachedMetadata(c, key, func() ([]ColumnInfo, error) {
+		if err := c.ensureSession(ctx); err != nil {
+			return nil, err
+		}
+		req := inf.NewTGetColumnsReq()
+		req.SessionHandle = c.session
+		req.CatalogName = identifier(catalog)
+		req.SchemaName = pattern(schemaPattern)
+		req.TableName = pattern(tablePattern)
+		req.ColumnName = pattern(columnPattern)
+
+		resp, err := c.thrift.GetColumns(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("Error in GetColumns: %v", err)
+		}
+		if !isSuccessStatus(resp.Status) {
+			return nil, fmt.Errorf("GetColumns failed: %s", resp.Status.String())
+		}
+
+		rows, err := metadataRows(ctx, newRowSet(c.thrift, resp.OperationHandle, c.options))
+		if err != nil {
+			return nil, err
+		}
+		columns := make([]ColumnInfo, 0, len(rows))
+		for _, row := range rows {
+			columns = append(columns, ColumnInfo{
+				Catalog:       row.at(0),
+				Schema:        row.at(1),
+				Table:         row.at(2),
+				Name:          row.at(3),
+				DataType:      atoi(row.at(4)),
+				TypeName:      row.at(5),
+				Size:          atoi(row.at(6)),
+				DecimalDigits: atoi(row.at(8)),
+				Nullable:      row.at(10) != "0",
+				Remarks:       row.at(11),
+				Position:      atoi(row.at(16)),
+			})
+		}
+		return columns, nil
+	})
+}
+
+// GetPrimaryKeys returns the primary key columns of a table. Empty
+// catalog or schema arguments are left unset in the request.
+func (c *Connection) GetPrimaryKeys(ctx context.Context, catalog, schema, table string) ([]PrimaryKey, error) {
+	if err := c.ensureSession(ctx); err != nil {
+		return nil, err
+	}
+	req := inf.NewTGetPrimaryKeysReq()
+	req.SessionHandle = c.session
+	req.CatalogName = identifier(catalog)
+	req.SchemaName = identifier(schema)
+	req.TableName = identifier(table)
+
+	resp, err := c.thrift.GetPrimaryKeys(ctx, req)
+	if err != nil {
+		if isUnknownMethod(err) {
+			return nil, ErrNotSupported
+		}
+		return nil, fmt.Errorf("Error in GetPrimaryKeys: %v", err)
+	}
+
+	if !isSuccessStatus(resp.Status) {
+		return nil, fmt.Errorf("GetPrimaryKeys failed: %s", resp.Status.String())
+	}
+
+	rows, err := metadataRows(ctx, newRowSet(c.thrift, resp.OperationHandle, c.options))
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]PrimaryKey, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, PrimaryKey{
+			Catalog: row.at(0),
+			Schema:  row.at(1),
+			Table:   row.at(2),
+			Column:  row.at(3),
+			KeySeq:  atoi(row.at(4)),
+			Name:    row.at(5),
+		})
+	}
+
+	return keys, nil
+}
+
+// GetCrossReference returns the foreign keys in the foreign table that
+// reference the primary key of the parent table.
+func (c *Connection) GetCrossReference(ctx context.Context, parentCatalog, parentSchema, parentTable, foreignCatalog, foreignSchema, foreignTable string) ([]ForeignKey, error) {
+	if err := c.ensureSession(ctx); err != nil {
+		return nil, err
+	}
+	req := inf.NewTGetCrossReferenceReq()
+	req.SessionHandle = c.session
+	req.ParentCatalogName = identifier(parentCatalog)
+	req.ParentSchemaName = identifier(parentSchema)
+	req.ParentTableName = identifier(parentTable)
+	req.ForeignCatalogName = identifier(foreignCatalog)
+	req.ForeignSchemaName = identifier(foreignSchema)
+	req.ForeignTableName = identifier(foreignTable)
+
+	resp, err := c.thrift.GetCrossReference(ctx, req)
+	if err != nil {
+		if isUnknownMethod(err) {
+			return nil, ErrNotSupported
+		}
+		return nil, fmt.Errorf("Error in GetCrossReference: %v", err)
+	}
+
+	if !isSuccessStatus(resp.Status) {
+		return nil, fmt.Errorf("GetCrossReference failed: %s", resp.Status.String())
+	}
+
+	rows, err := metadataRows(ctx, newRowSet(c.thrift, resp.OperationHandle, c.options))
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]ForeignKey, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, ForeignKey{
+			PKCatalog:     row.at(0),
+			PKSchema:      row.at(1),
+			PKTable:       row.at(2),
+			PKColumn:      row.at(3),
+			FKCatalog:     row.at(4),
+			FKSchema:      row.at(5),
+			FKTable:       row.at(6),
+			FKColumn:      row.at(7),
+			KeySeq:        atoi(row.at(8)),
+			UpdateRule:    atoi(row.at(9)),
+			DeleteRule:    atoi(row.at(10)),
+			FKName:        row.at(11),
+			PKName:        row.at(12),
+			Deferrability: atoi(row.at(13)),
+		})
+	}
+
+	return keys, nil
+}
+
+// metadataRow is a row of a metadata result rendered as strings, with
+// NULL as "".
+type metadataRow []string
+
+// at returns cell i, or "" for servers that send fewer columns.
+func (r metadataRow) at(i int) string {
+	if i < len(r) {
+		return r[i]
+	}
+	return ""
+}
+
+// metadataRows reads and closes a metadata result. Servers differ in the
+// number of columns they send, so cells are picked by position rather
+// than scanned.
+func metadataRows(ctx context.Context, rs RowSet) ([]metadataRow, error) {
+	defer rs.Close(ctx)
+	var rows []metadataRow
+	for {
+		values, err := rs.NextValues(ctx)
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		row := make(metadataRow, len(values))
+		for i, v := range values {
+			if v != nil {
+				row[i] = fmt.Sprint(v)
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+func pattern(s string) *inf.TPatternOrIdentifier {
+	if s == "" {
+		return nil
+	}
+	p := inf.TPatternOrIdentifier(s)
+	return &p
+}
+
+func identifier(s string) *inf.TIdentifier {
+	if s == "" {
+		return nil
+	}
+	id := inf.TIdentifier(s)
+	return &id
+}
+
+// isUnknownMethod reports whether err is the server rejecting a call it
+// doesn't implement.
+func isUnknownMethod(err error) bool {
+	var appErr thrift.TApplicationException
+	return errors.As(err, &appErr) && appErr.TypeId() == thrift.UNKNOWN_METHOD
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
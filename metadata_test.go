@@ -0,0 +1,82 @@
+package hive
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestGetPrimaryKeys(t *testing.T) {
+	svc := newFakeService()
+	svc.results = map[string][]*inf.TRowSet{"GetPrimaryKeys": {stringRow(
+		"", "sales", "orders", "order_id", "2", "pk_orders",
+	)}}
+	conn := connectFake(t, svc, testOptions())
+
+	keys, err := conn.GetPrimaryKeys(context.Background(), "", "sales", "orders")
+	if err != nil {
+		t.Fatalf("GetPrimaryKeys error: %v", err)
+	}
+	expected := []PrimaryKey{{Schema: "sales", Table: "orders", Column: "order_id", KeySeq: 2, Name: "pk_orders"}}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("expected %+v, got %+v", expected, keys)
+	}
+	if len(svc.closes) != 1 {
+		t.Errorf("expected the operation to be closed once, got %d closes", len(svc.closes))
+	}
+}
+
+func TestGetCrossReference(t *testing.T) {
+	svc := newFakeService()
+	svc.results = map[string][]*inf.TRowSet{"GetCrossReference": {stringRow(
+		"", "sales", "customers", "id", "", "sales", "orders", "customer_id",
+		"1", "3", "0", "fk_customer", "pk_customers", "7",
+	)}}
+	conn := connectFake(t, svc, testOptions())
+
+	keys, err := conn.GetCrossReference(context.Background(), "", "sales", "customers", "", "sales", "orders")
+	if err != nil {
+		t.Fatalf("GetCrossReference error: %v", err)
+	}
+	expected := []ForeignKey{{
+		PKSchema:      "sales",
+		PKTable:       "customers",
+		PKColumn:      "id",
+		FKSchema:      "sales",
+		FKTable:       "orders",
+		FKColumn:      "customer_id",
+		KeySeq:        1,
+		UpdateRule:    3,
+		FKName:        "fk_customer",
+		PKName:        "pk_customers",
+		Deferrability: 7,
+	}}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("expected %+v, got %+v", expected, keys)
+	}
+	if len(svc.closes) != 1 {
+		t.Errorf("expected the operation to be closed once, got %d closes", len(svc.closes))
+	}
+}
+
+func TestKeysFetchError(t *testing.T) {
+	svc := newFakeService()
+	svc.onFetch = func(req *inf.TFetchResultsReq) (*inf.TFetchResultsResp, error) {
+		return &inf.TFetchResultsResp{Status: errorStatus("fetch failed")}, nil
+	}
+	conn := connectFake(t, svc, testOptions())
+	ctx := context.Background()
+
+	if _, err := conn.GetPrimaryKeys(ctx, "", "sales", "orders"); err == nil || !strings.HasPrefix(err.Error(), "FetchResults failed") {
+		t.Errorf("expected GetPrimaryKeys to report the fetch error, got %v", err)
+	}
+	if _, err := conn.GetCrossReference(ctx, "", "sales", "customers", "", "sales", "orders"); err == nil || !strings.HasPrefix(err.Error(), "FetchResults failed") {
+		t.Errorf("expected GetCrossReference to report the fetch error, got %v", err)
+	}
+	if len(svc.closes) != 2 {
+		t.Errorf("expected both operations to be closed, got %d closes", len(svc.closes))
+	}
+}
@@ -0,0 +1,121 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+)
+
+// An RPCFunc makes one thrift call: method is its name, such as
+// "ExecuteStatement" or "FetchResults", args the request, e.g. a
+// *inf.TCLIServiceExecuteStatementArgs whose Req is the
+// TExecuteStatementReq, and result is filled in with the response.
+type RPCFunc func(ctx context.Context, method string, args, result thrift.TStruct) error
+
+// An RPCMiddleware wraps the calls made by a Connection, for logging,
+// metrics and the like, see Options.Middleware. It returns a function
+// that does its work around calling next.
+type RPCMiddleware func(next RPCFunc) RPCFunc
+
+// WrapClient returns a thrift client that makes its calls on c through
+// middleware, the first outermost. It is what Options.Middleware applies
+// to the Connection's default client; use it inside a ClientFactory,
+// which Middleware can't wrap, as in
+//
+//	inf.NewTCLIServiceClient(hive.WrapClient(thrift.NewTStandardClient(protocol, mux), middleware...))
+func WrapClient(c thrift.TClient, middleware ...RPCMiddleware) thrift.TClient {
+	if len(middleware) == 0 {
+		return c
+	}
+	call := func(ctx context.Context, method string, args, result thrift.TStruct) error {
+		_, err := c.Call(ctx, method, args, result)
+		return err
+	}
+	for i := len(middleware) - 1; i >= 0; i-- {
+		call = middleware[i](call)
+	}
+	return middlewareClient(call)
+}
+
+// middlewareClient is a thrift client making its calls through a chain.
+type middlewareClient RPCFunc
+
+func (c middlewareClient) Call(ctx context.Context, method string, args, result thrift.TStruct) (thrift.ResponseMeta, error) {
+	return thrift.ResponseMeta{}, c(ctx, method, args, result)
+}
+
+// LoggingMiddleware logs every call with its duration, and its error if
+// it failed, through logf, or log.Printf if logf is nil.
+func LoggingMiddleware(logf func(format string, args ...interface{})) RPCMiddleware {
+	if logf == nil {
+		logf = log.Printf
+	}
+	return MetricsMiddleware(func(method string, d time.Duration, err error) {
+		if err != nil {
+			logf("%s failed after %v: %v\n", method, d, err)
+			return
+		}
+		logf("%s took %v\n", method, d)
+	})
+}
+
+// MetricsMiddleware calls observe after every call with its method, its
+// duration and its error, nil if it succeeded, e.g. to feed a latency
+// histogram. A call the server answers with an error status succeeds
+// here: the status is in the result.
+func MetricsMiddleware(observe func(method string, d time.Duration, err error)) RPCMiddleware {
+	return func(next RPCFunc) RPCFunc {
+		return func(ctx context.Context, method string, args, result thrift.TStruct) error {
+			start := time.Now()
+			err := next(ctx, method, args, result)
+			observe(method, time.Since(start), err)
+			return err
+		}
+	}
+}
+
+// retryableMethods are the calls that only read state, and so can be
+// made again.
+var retryableMethods = map[string]bool{
+	"GetInfo":              true,
+	"GetOperationStatus":   true,
+	"GetResultSetMetadata": true,
+	"GetQueryId":           true,
+}
+
+// RetryMiddleware makes calls that only read state, such as GetInfo and
+// GetOperationStatus, up to attempts times in all, waiting backoff
+// between attempts, while they fail with an error from middleware
+// further down the chain, e.g. a transient failure to refresh an auth
+// token. Thrift transport and protocol errors aren't retried: the call
+// may have been half sent or half answered, which leaves the
+// connection's stream unusable. Neither are calls that change state on
+// the server, such as ExecuteStatement and FetchResults.
+func RetryMiddleware(attempts int, backoff time.Duration) RPCMiddleware {
+	return func(next RPCFunc) RPCFunc {
+		return func(ctx context.Context, method string, args, result thrift.TStruct) error {
+			err := next(ctx, method, args, result)
+			for i := 1; i < attempts && err != nil && retryableMethods[method] && !isThriftError(err); i++ {
+				select {
+				case <-ctx.Done():
+					return err
+				case <-time.After(backoff):
+				}
+				err = next(ctx, method, args, result)
+			}
+			return err
+		}
+	}
+}
+
+// isThriftError reports whether err came from thrift rather than from a
+// middleware.
+func isThriftError(err error) bool {
+	var transportErr thrift.TTransportException
+	var protocolErr thrift.TProtocolException
+	var appErr thrift.TApplicationException
+	return errors.As(err, &transportErr) || errors.As(err, &protocolErr) || errors.As(err, &appErr)
+}
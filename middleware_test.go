@@ -0,0 +1,141 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// recordCalls returns a middleware appending name:method to calls.
+func recordCalls(name string, calls *[]string) RPCMiddleware {
+	return func(next RPCFunc) RPCFunc {
+		return func(ctx context.Context, method string, args, result thrift.TStruct) error {
+			*calls = append(*calls, name+":"+method)
+			return next(ctx, method, args, result)
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	svc.batches = []*inf.TRowSet{stringBatch("a")}
+	var calls, statements []string
+	options := testOptions()
+	options.Middleware = []RPCMiddleware{
+		recordCalls("outer", &calls),
+		recordCalls("inner", &calls),
+		func(next RPCFunc) RPCFunc {
+			return func(ctx context.Context, method string, args, result thrift.TStruct) error {
+				if a, ok := args.(*inf.TCLIServiceExecuteStatementArgs); ok {
+					statements = append(statements, a.Req.Statement)
+				}
+				return next(ctx, method, args, result)
+			}
+		},
+	}
+	conn := connectFake(t, svc, options)
+
+	rs, err := conn.QueryContext(ctx, "SELECT s FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	for rs.Next() {
+	}
+	rs.Close(ctx)
+	if _, ok := rs.ModifiedRowCount(ctx); ok {
+		t.Error("expected no modified row count")
+	}
+
+	if len(calls) < 2 || calls[0] != "outer:OpenSession" || calls[1] != "inner:OpenSession" {
+		t.Errorf("expected OpenSession through both middleware, outer first, got %q", calls)
+	}
+	joined := strings.Join(calls, " ")
+	for _, method := range []string{"ExecuteStatement", "GetOperationStatus", "FetchResults", "CloseOperation"} {
+		if !strings.Contains(joined, "outer:"+method+" inner:"+method) {
+			t.Errorf("expected %s through the chain, got %q", method, calls)
+		}
+	}
+	if len(statements) != 1 || statements[0] != "SELECT s FROM t" {
+		t.Errorf("expected the request visible to middleware, got %q", statements)
+	}
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	svc := newFakeService()
+	var observed []string
+	var logged []string
+	options := testOptions()
+	options.Middleware = []RPCMiddleware{
+		MetricsMiddleware(func(method string, d time.Duration, err error) {
+			observed = append(observed, method)
+		}),
+		LoggingMiddleware(func(format string, args ...interface{}) {
+			logged = append(logged, fmt.Sprintf(format, args...))
+		}),
+	}
+	conn := connectFake(t, svc, options)
+	if _, err := conn.NameQualifierStyle(context.Background()); err != nil {
+		t.Fatalf("NameQualifierStyle error: %v", err)
+	}
+	if len(observed) == 0 || observed[0] != "OpenSession" || len(logged) != len(observed) {
+		t.Errorf("expected every call observed and logged, got %q and %q", observed, logged)
+	}
+	if !strings.HasPrefix(logged[0], "OpenSession took ") {
+		t.Errorf("unexpected log line %q", logged[0])
+	}
+}
+
+func TestRetryMiddleware(t *testing.T) {
+	ctx := context.Background()
+	errToken := errors.New("token refresh failed")
+	for _, tc := range []struct {
+		method string
+		err    error
+		want   int
+	}{
+		{method: "GetOperationStatus", err: errToken, want: 3},
+		{method: "ExecuteStatement", err: errToken, want: 1},
+		{method: "GetInfo", err: thrift.NewTTransportException(thrift.TIMED_OUT, "i/o timeout"), want: 1},
+	} {
+		var attempts int
+		call := RetryMiddleware(3, 0)(func(context.Context, string, thrift.TStruct, thrift.TStruct) error {
+			attempts++
+			return tc.err
+		})
+		if err := call(ctx, tc.method, nil, nil); !errors.Is(err, tc.err) {
+			t.Errorf("%s: expected the error back, got %v", tc.method, err)
+		}
+		if attempts != tc.want {
+			t.Errorf("%s: expected %d attempts, got %d", tc.method, tc.want, attempts)
+		}
+	}
+
+	var attempts int
+	call := RetryMiddleware(3, 0)(func(context.Context, string, thrift.TStruct, thrift.TStruct) error {
+		attempts++
+		if attempts < 2 {
+			return errToken
+		}
+		return nil
+	})
+	if err := call(ctx, "GetInfo", nil, nil); err != nil || attempts != 2 {
+		t.Errorf("expected success on the second attempt, got %v after %d", err, attempts)
+	}
+}
+
+func TestMiddlewareWithClientFactory(t *testing.T) {
+	options := testOptions()
+	options.Middleware = []RPCMiddleware{LoggingMiddleware(nil)}
+	options.ClientFactory = inf.NewTCLIServiceClientFactory
+	if err := options.Validate(); err == nil {
+		t.Error("expected Middleware with ClientFactory rejected")
+	}
+}
@@ -0,0 +1,137 @@
+package hive
+
+import (
+	"context"
+
+	"github.com/apache/thrift/lib/go/thrift"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// ModifiedRowCount returns the number of rows the operation inserted,
+// updated or deleted, as reported by Hive 3 and later for DML on ACID
+// tables. ok is false when the server doesn't report a count, which is
+// the case for older servers, non-DML statements and operations that
+// haven't finished, or when asking for it fails.
+func (r *rowSet) ModifiedRowCount(ctx context.Context) (int64, bool) {
+	if r.conn == nil || r.conn.calls == nil {
+		return 0, false
+	}
+	args := inf.TCLIServiceGetOperationStatusArgs{Req: &inf.TGetOperationStatusReq{OperationHandle: r.operation}}
+	var result operationStatusResult
+	if _, err := r.conn.calls.Call(ctx, "GetOperationStatus", &args, &result); err != nil {
+		return 0, false
+	}
+	resp := result.Success
+	if resp == nil || !isSuccessStatus(resp.Status) || resp.NumModifiedRows == nil {
+		return 0, false
+	}
+	return *resp.NumModifiedRows, true
+}
+
+// ExecCount runs a statement to completion and returns the number of
+// rows it modified, with ok false if the server doesn't report it, see
+// RowSet.ModifiedRowCount.
+func (c *Connection) ExecCount(ctx context.Context, stmt string) (n int64, ok bool, err error) {
+	executeReq := inf.NewTExecuteStatementReq()
+	executeReq.Statement = stmt
+	executeReq.RunAsync = true
+
+	rs, err := c.submit(ctx, executeReq)
+	if err != nil {
+		return 0, false, err
+	}
+	defer rs.Close(context.WithoutCancel(ctx))
+	if _, err := rs.wait(ctx); err != nil {
+		return 0, false, err
+	}
+	n, ok = rs.ModifiedRowCount(ctx)
+	return n, ok, nil
+}
+
+// TGetOperationStatusResp gained a field after the IDL inf was generated
+// from, so the response is read with it here:
+//
+//	11: optional i64 numModifiedRows
+//
+// Only the fields ModifiedRowCount needs are kept.
+type operationStatusResp struct {
+	Status          *inf.TStatus
+	OperationState  *inf.TOperationState
+	NumModifiedRows *int64
+}
+
+type operationStatusResult struct {
+	Success *operationStatusResp
+}
+
+func (p *operationStatusResp) Write(ctx context.Context, oprot thrift.TProtocol) error {
+	return writeStruct(ctx, oprot, "TGetOperationStatusResp", func() error {
+		if err := writeStructField(ctx, oprot, "status", 1, p.Status); err != nil {
+			return err
+		}
+		if p.OperationState != nil {
+			if err := writeField(ctx, oprot, "operationState", thrift.I32, 2, func() error {
+				return oprot.WriteI32(ctx, int32(*p.OperationState))
+			}); err != nil {
+				return err
+			}
+		}
+		if p.NumModifiedRows == nil {
+			return nil
+		}
+		return writeField(ctx, oprot, "numModifiedRows", thrift.I64, 11, func() error {
+			return oprot.WriteI64(ctx, *p.NumModifiedRows)
+		})
+	})
+}
+
+func (p *operationStatusResp) Read(ctx context.Context, iprot thrift.TProtocol) error {
+	return readStruct(ctx, iprot, func(id int16, typ thrift.TType) (bool, error) {
+		switch {
+		case id == 1 && typ == thrift.STRUCT:
+			p.Status = inf.NewTStatus()
+			return true, p.Status.Read(ctx, iprot)
+		case id == 2 && typ == thrift.I32:
+			v, err := iprot.ReadI32(ctx)
+			state := inf.TOperationState(v)
+			p.OperationState = &state
+			return true, err
+		case id == 11 && typ == thrift.I64:
+			v, err := iprot.ReadI64(ctx)
+			p.NumModifiedRows = &v
+			return true, err
+		}
+		return false, nil
+	})
+}
+
+func (p *operationStatusResult) Write(ctx context.Context, oprot thrift.TProtocol) error {
+	return writeStruct(ctx, oprot, "GetOperationStatus_result", func() error {
+		if p.Success == nil {
+			return nil
+		}
+		return writeStructField(ctx, oprot, "success", 0, p.Success)
+	})
+}
+
+func (p *operationStatusResult) Read(ctx context.Context, iprot thrift.TProtocol) error {
+	return readStruct(ctx, iprot, func(id int16, typ thrift.TType) (bool, error) {
+		if id != 0 || typ != thrift.STRUCT {
+			return false, nil
+		}
+		p.Success = &operationStatusResp{}
+		return true, p.Success.Read(ctx, iprot)
+	})
+}
+
+// writeField writes a field whose value is written by value.
+func writeField(ctx context.Context, oprot thrift.TProtocol, name string, typ thrift.TType, id int16, value func() error) error {
+	if err := oprot.WriteFieldBegin(ctx, name, typ, id); err != nil {
+		return err
+	}
+	if err := value(); err != nil {
+		return err
+	}
+	return oprot.WriteFieldEnd(ctx)
+}
@@ -0,0 +1,42 @@
+package hive
+
+import (
+	"context"
+	"testing"
+)
+
+func TestExecCount(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	modified := int64(42)
+	svc.modifiedRows = &modified
+	conn := connectFake(t, svc, testOptions())
+
+	n, ok, err := conn.ExecCount(ctx, "UPDATE t SET x = 1 WHERE y > 2")
+	if err != nil {
+		t.Fatalf("ExecCount error: %v", err)
+	}
+	if !ok || n != 42 {
+		t.Errorf("expected 42 modified rows, got %d (ok %v)", n, ok)
+	}
+	if len(svc.closes) != 1 {
+		t.Errorf("expected the operation closed, got %d closes", len(svc.closes))
+	}
+}
+
+func TestModifiedRowCountUnreported(t *testing.T) {
+	ctx := context.Background()
+	conn := connectFake(t, newFakeService(), testOptions())
+
+	rs, err := conn.Query("INSERT INTO t VALUES (1)")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	defer rs.Close(ctx)
+	if _, err := rs.Wait(); err != nil {
+		t.Fatalf("Wait error: %v", err)
+	}
+	if n, ok := rs.ModifiedRowCount(ctx); ok {
+		t.Errorf("expected no count from a server without numModifiedRows, got %d", n)
+	}
+}
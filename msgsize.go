@@ -0,0 +1,48 @@
+package hive
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/apache/thrift/lib/go/thrift"
+)
+
+// ErrMessageTooLarge is returned when a response, or a list or string in
+// it, exceeds the limits set by Options.MaxMessageSize and MaxFrameSize.
+// Raise MaxMessageSize or lower BatchSize. The rest of the response is
+// left unread, so the connection can't be used afterwards.
+var ErrMessageTooLarge = errors.New("hive: response exceeds MaxMessageSize; raise MaxMessageSize or lower BatchSize")
+
+// messageSizeError translates thrift's size limit errors into
+// ErrMessageTooLarge, keeping the original in the chain. Other errors
+// are returned as they are.
+func messageSizeError(err error) error {
+	// Transport exceptions have type IDs too, which overlap with the
+	// protocol's, so they are told apart first. Framed transports report
+	// an oversized frame only by its message.
+	var transportErr thrift.TTransportException
+	if errors.As(err, &transportErr) {
+		if strings.Contains(err.Error(), "Incorrect frame size") {
+			return fmt.Errorf("%w: %w", ErrMessageTooLarge, err)
+		}
+		return err
+	}
+	var protocolErr thrift.TProtocolException
+	if errors.As(err, &protocolErr) && protocolErr.TypeId() == thrift.SIZE_LIMIT {
+		return fmt.Errorf("%w: %w", ErrMessageTooLarge, err)
+	}
+	return err
+}
+
+// warnMessageSize logs when MaxMessageSize is too small for a full batch
+// of BatchSize rows. Thrift applies the limit to every list it reads, and
+// each column of a batch is a list of BatchSize values, so such a fetch
+// is bound to fail. HiveServer2 doesn't advertise its own limits, so
+// nothing is negotiated.
+func warnMessageSize(options Options) {
+	if options.MaxMessageSize > 0 && options.BatchSize > int64(options.MaxMessageSize) {
+		log.Printf("MaxMessageSize %d is smaller than BatchSize %d; full batches will fail with ErrMessageTooLarge\n", options.MaxMessageSize, options.BatchSize)
+	}
+}
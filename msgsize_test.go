@@ -0,0 +1,49 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestOversizedFetch(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("doc", inf.TTypeId_STRING_TYPE, 1)}
+	svc.batches = []*inf.TRowSet{stringBatch(strings.Repeat("x", 4096))}
+	options := testOptions()
+	options.MaxMessageSize = 1024
+	conn := connectFake(t, svc, options)
+
+	rs, err := conn.Query("SELECT doc FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	if rs.Next() {
+		t.Fatal("expected the fetch to fail")
+	}
+	if !errors.Is(rs.Err(), ErrMessageTooLarge) {
+		t.Errorf("expected ErrMessageTooLarge, got %v", rs.Err())
+	}
+
+	// The rest of the response is still unread; don't wait on the
+	// session's close.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	conn.CloseContext(ctx)
+}
+
+func TestMessageSizeError(t *testing.T) {
+	frameErr := thrift.NewTTransportException(thrift.UNKNOWN_TRANSPORT_EXCEPTION, "Incorrect frame size (20000000)")
+	if err := messageSizeError(frameErr); !errors.Is(err, ErrMessageTooLarge) || !errors.Is(err, frameErr) {
+		t.Errorf("expected ErrMessageTooLarge wrapping the frame error, got %v", err)
+	}
+	other := thrift.NewTTransportException(thrift.TIMED_OUT, "i/o timeout")
+	if err := messageSizeError(other); err != other {
+		t.Errorf("expected other errors as they are, got %v", err)
+	}
+}
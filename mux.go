@@ -0,0 +1,154 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// ErrMuxClosed is returned for requests made after SessionMux.Close.
+var ErrMuxClosed = errors.New("hive: session mux is closed")
+
+// A SessionMux shares a single Connection between goroutines. Requests
+// are queued and run one at a time, in the order they were made, so the
+// session's state (database, SET variables, temporary functions) is
+// preserved without opening a connection per goroutine.
+type SessionMux struct {
+	conn *Connection
+
+	mu     sync.Mutex
+	queue  []*muxRequest
+	closed bool
+	wake   chan struct{}
+	done   chan struct{}
+}
+
+type muxRequest struct {
+	ctx    context.Context
+	fn     func(*Connection) error
+	result chan error
+}
+
+// NewSessionMux starts serving requests on conn. The caller still owns
+// conn and closes it after closing the mux.
+func NewSessionMux(conn *Connection) *SessionMux {
+	m := &SessionMux{
+		conn: conn,
+		wake: make(chan struct{}, 1),
+		done: make(chan struct{}),
+	}
+	go m.serve()
+	return m
+}
+
+// Do queues fn and waits for it to run with exclusive use of the
+// connection. If ctx is done while the request is still queued, it is
+// dropped and ctx.Err() returned; once dispatched, fn runs to completion.
+func (m *SessionMux) Do(ctx context.Context, fn func(*Connection) error) error {
+	req := &muxRequest{ctx: ctx, fn: fn, result: make(chan error, 1)}
+
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return ErrMuxClosed
+	}
+	m.queue = append(m.queue, req)
+	m.mu.Unlock()
+	m.signal()
+
+	select {
+	case err := <-req.result:
+		return err
+	case <-ctx.Done():
+		if m.dequeue(req) {
+			return ctx.Err()
+		}
+		return <-req.result
+	}
+}
+
+// Query runs query through the mux and passes its RowSet to fn, which
+// holds the connection until it returns. The operation is closed
+// afterwards.
+func (m *SessionMux) Query(ctx context.Context, query string, fn func(RowSet) error) error {
+	return m.Do(ctx, func(c *Connection) error {
+		executeReq := inf.NewTExecuteStatementReq()
+		executeReq.Statement = query
+		executeReq.RunAsync = true
+
+		rs, err := c.submit(ctx, executeReq)
+		if err != nil {
+			return err
+		}
+		defer rs.Close(context.Background())
+
+		if err := rs.waitForSuccess(ctx); err != nil {
+			return err
+		}
+		return fn(rs)
+	})
+}
+
+// QueueDepth returns the number of requests waiting to be dispatched.
+func (m *SessionMux) QueueDepth() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.queue)
+}
+
+// Close stops accepting requests and waits for the queued ones to
+// finish.
+func (m *SessionMux) Close() {
+	m.mu.Lock()
+	m.closed = true
+	m.mu.Unlock()
+	m.signal()
+	<-m.done
+}
+
+func (m *SessionMux) signal() {
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+// dequeue removes req if it hasn't been dispatched yet.
+func (m *SessionMux) dequeue(req *muxRequest) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, queued := range m.queue {
+		if queued == req {
+			m.queue = append(m.queue[:i], m.queue[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (m *SessionMux) serve() {
+	defer close(m.done)
+	for {
+		m.mu.Lock()
+		if len(m.queue) == 0 {
+			closed := m.closed
+			m.mu.Unlock()
+			if closed {
+				return
+			}
+			<-m.wake
+			continue
+		}
+		req := m.queue[0]
+		m.queue = m.queue[1:]
+		m.mu.Unlock()
+
+		if err := req.ctx.Err(); err != nil {
+			req.result <- err
+			continue
+		}
+		req.result <- req.fn(m.conn)
+	}
+}
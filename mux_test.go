@@ -0,0 +1,106 @@
+package hive
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestSessionMuxOrdering(t *testing.T) {
+	conn := connectFake(t, newFakeService(), testOptions())
+	mux := NewSessionMux(conn)
+	defer mux.Close()
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go mux.Do(context.Background(), func(*Connection) error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+
+	var (
+		mu    sync.Mutex
+		order []int
+		wg    sync.WaitGroup
+	)
+	cancelled, cancel := context.WithCancel(context.Background())
+	for i := 1; i <= 3; i++ {
+		ctx := context.Background()
+		if i == 2 {
+			ctx = cancelled
+		}
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mux.Do(ctx, func(*Connection) error {
+				mu.Lock()
+				order = append(order, i)
+				mu.Unlock()
+				return nil
+			})
+		}()
+		waitFor(t, func() bool { return mux.QueueDepth() == i })
+	}
+
+	cancel()
+	waitFor(t, func() bool { return mux.QueueDepth() == 2 })
+	close(release)
+	wg.Wait()
+
+	if expected := []int{1, 3}; !reflect.DeepEqual(order, expected) {
+		t.Errorf("expected FIFO order without the cancelled request %v, got %v", expected, order)
+	}
+}
+
+func TestSessionMuxQuery(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("name", inf.TTypeId_STRING_TYPE, 1)}
+	svc.batches = []*inf.TRowSet{stringBatch("a", "b")}
+	conn := connectFake(t, svc, testOptions())
+	mux := NewSessionMux(conn)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := mux.Query(context.Background(), "SELECT name FROM t", func(rs RowSet) error {
+				names, err := FetchColumn[string](rs, 0)
+				if err == nil && len(names) != 2 {
+					t.Errorf("expected 2 rows, got %v", names)
+				}
+				return err
+			})
+			if err != nil {
+				t.Errorf("Query error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	mux.Close()
+
+	if err := mux.Do(context.Background(), func(*Connection) error { return nil }); err != ErrMuxClosed {
+		t.Errorf("expected ErrMuxClosed after Close, got %v", err)
+	}
+	if conn.InFlight() != 0 {
+		t.Error("expected every operation to be closed")
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not reached")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
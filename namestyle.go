@@ -0,0 +1,106 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// A NameStyle describes how the server expects qualified table names to
+// be written, see Connection.NameQualifierStyle.
+type NameStyle struct {
+	// DBMSName is the server's product name, e.g. "Apache Hive" or
+	// "Spark SQL".
+	DBMSName string
+	// UsesCatalogs is true when names may carry a catalog part, as in
+	// catalog.schema.table; DefaultCatalog names the session's catalog
+	// where known.
+	UsesCatalogs   bool
+	DefaultCatalog string
+	// Separator joins the parts of a name and Quote quotes each part.
+	Separator string
+	Quote     string
+}
+
+// Qualify joins the non-empty parts of a table name, quoting each; the
+// catalog is left out unless the server uses catalogs.
+func (s NameStyle) Qualify(catalog, schema, table string) string {
+	if !s.UsesCatalogs {
+		catalog = ""
+	}
+	var parts []string
+	for _, p := range []string{catalog, schema, table} {
+		if p != "" {
+			parts = append(parts, s.Quote+strings.ReplaceAll(p, s.Quote, s.Quote+s.Quote)+s.Quote)
+		}
+	}
+	return strings.Join(parts, s.Separator)
+}
+
+// NameQualifierStyle probes the server with GetInfo for how it qualifies
+// names, and caches the answer for the Connection. HiveServer2 names
+// tables schema.table; Spark's Thrift server, from Spark 3, also takes a
+// catalog in front, spark_catalog by default. Servers that answer
+// CLI_CATALOG_NAME with "Y" are taken to use catalogs too. Info types a
+// server doesn't support fall back to Hive's conventions: no catalogs,
+// "." and backticks.
+func (c *Connection) NameQualifierStyle(ctx context.Context) (NameStyle, error) {
+	c.mu.Lock()
+	cached := c.nameStyle
+	c.mu.Unlock()
+	if cached != nil {
+		return *cached, nil
+	}
+
+	if err := c.ensureSession(ctx); err != nil {
+		return NameStyle{}, err
+	}
+	name, err := c.getInfo(ctx, inf.TGetInfoType_CLI_DBMS_NAME)
+	if err != nil {
+		return NameStyle{}, err
+	}
+	style := NameStyle{DBMSName: name.GetStringValue(), Separator: ".", Quote: "`"}
+	if strings.Contains(style.DBMSName, "Spark") {
+		version, err := c.getInfo(ctx, inf.TGetInfoType_CLI_DBMS_VER)
+		if err == nil {
+			major, _, _ := strings.Cut(version.GetStringValue(), ".")
+			if n, err := strconv.Atoi(major); err == nil && n >= 3 {
+				style.UsesCatalogs = true
+				style.DefaultCatalog = "spark_catalog"
+			}
+		}
+	}
+	if v, err := c.getInfo(ctx, inf.TGetInfoType_CLI_CATALOG_NAME); err == nil && v.GetStringValue() == "Y" {
+		style.UsesCatalogs = true
+	}
+	if v, err := c.getInfo(ctx, inf.TGetInfoType_CLI_IDENTIFIER_QUOTE_CHAR); err == nil && len(v.GetStringValue()) == 1 {
+		style.Quote = v.GetStringValue()
+	}
+
+	c.mu.Lock()
+	c.nameStyle = &style
+	c.mu.Unlock()
+	return style, nil
+}
+
+// getInfo asks the server for one item of information about itself.
+func (c *Connection) getInfo(ctx context.Context, infoType inf.TGetInfoType) (*inf.TGetInfoValue, error) {
+	if !c.isOpen() {
+		return nil, errors.New("Session is closed")
+	}
+	req := inf.NewTGetInfoReq()
+	req.SessionHandle = c.session
+	req.InfoType = infoType
+	resp, err := c.thrift.GetInfo(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("Error in GetInfo: %v", err)
+	}
+	if !isSuccessStatus(resp.Status) {
+		return nil, fmt.Errorf("GetInfo failed: %s", resp.Status.String())
+	}
+	return resp.GetInfoValue(), nil
+}
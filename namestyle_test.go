@@ -0,0 +1,68 @@
+package hive
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestNameQualifierStyle(t *testing.T) {
+	cases := []struct {
+		name  string
+		info  map[inf.TGetInfoType]string
+		want  NameStyle
+		table string
+	}{
+		{"hive", map[inf.TGetInfoType]string{
+			inf.TGetInfoType_CLI_DBMS_NAME: "Apache Hive",
+			inf.TGetInfoType_CLI_DBMS_VER:  "3.1.3",
+		}, NameStyle{DBMSName: "Apache Hive", Separator: ".", Quote: "`"}, "`db`.`t`"},
+		{"spark", map[inf.TGetInfoType]string{
+			inf.TGetInfoType_CLI_DBMS_NAME: "Spark SQL",
+			inf.TGetInfoType_CLI_DBMS_VER:  "3.5.1",
+		}, NameStyle{DBMSName: "Spark SQL", UsesCatalogs: true, DefaultCatalog: "spark_catalog", Separator: ".", Quote: "`"}, "`spark_catalog`.`db`.`t`"},
+		{"catalog support reported", map[inf.TGetInfoType]string{
+			inf.TGetInfoType_CLI_DBMS_NAME:             "Other",
+			inf.TGetInfoType_CLI_CATALOG_NAME:          "Y",
+			inf.TGetInfoType_CLI_IDENTIFIER_QUOTE_CHAR: `"`,
+		}, NameStyle{DBMSName: "Other", UsesCatalogs: true, Separator: ".", Quote: `"`}, `"spark_catalog"."db"."t"`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := newFakeService()
+			calls := 0
+			svc.onGetInfo = func(req *inf.TGetInfoReq) (*inf.TGetInfoResp, error) {
+				calls++
+				v, ok := tc.info[req.InfoType]
+				if !ok {
+					return &inf.TGetInfoResp{
+						Status:    errorStatus("Unrecognized GetInfoType value: " + req.InfoType.String()),
+						InfoValue: &inf.TGetInfoValue{StringValue: thrift.StringPtr("")},
+					}, nil
+				}
+				return &inf.TGetInfoResp{Status: okStatus(), InfoValue: &inf.TGetInfoValue{StringValue: thrift.StringPtr(v)}}, nil
+			}
+			conn := connectFake(t, svc, testOptions())
+			ctx := context.Background()
+
+			style, err := conn.NameQualifierStyle(ctx)
+			if err != nil {
+				t.Fatalf("NameQualifierStyle error: %v", err)
+			}
+			if !reflect.DeepEqual(style, tc.want) {
+				t.Errorf("expected %+v, got %+v", tc.want, style)
+			}
+			if got := style.Qualify("spark_catalog", "db", "t"); got != tc.table {
+				t.Errorf("expected %s, got %s", tc.table, got)
+			}
+
+			probes := calls
+			if _, err := conn.NameQualifierStyle(ctx); err != nil || calls != probes {
+				t.Errorf("expected the cached style without more GetInfo calls, got %d more (%v)", calls-probes, err)
+			}
+		})
+	}
+}
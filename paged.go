@@ -0,0 +1,166 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// ErrPagedResultClosed is returned by NextPage once the PagedResult has
+// been closed.
+var ErrPagedResultClosed = errors.New("hive: paged result is closed")
+
+// ErrPagedResultExpired is returned by NextPage once the PagedResult has
+// been closed for going unused past its idle timeout.
+var ErrPagedResultExpired = errors.New("hive: paged result closed after exceeding its idle timeout")
+
+// A PagedResult keeps a query's operation open between requests for
+// pages of its rows, e.g. across the calls of a paginated HTTP API,
+// which may come from different goroutines. It is safe for concurrent
+// use: calls are served one at a time, in turn. A PagedResult left
+// unused for its idle timeout closes itself, so abandoned cursors don't
+// hold the operation on the server.
+type PagedResult struct {
+	rs   *rowSet
+	idle time.Duration
+	// turn holds a token while a call is being served.
+	turn  chan struct{}
+	timer *time.Timer
+	// err is set once the result is closed, and used is when the last
+	// call ended; both are guarded by turn.
+	err  error
+	used time.Time
+}
+
+// QueryPaged runs query and returns its result for reading in pages
+// with NextPage. It waits for the query to complete, so that its
+// failure is reported here. If idleTimeout is positive, the result
+// closes itself once that long has passed since the last call; otherwise
+// it stays open until Close.
+func (c *Connection) QueryPaged(ctx context.Context, query string, idleTimeout time.Duration) (*PagedResult, error) {
+	if idleTimeout < 0 {
+		return nil, fmt.Errorf("Invalid idle timeout %v: must not be negative", idleTimeout)
+	}
+	executeReq := inf.NewTExecuteStatementReq()
+	executeReq.Statement = query
+	executeReq.RunAsync = true
+
+	rs, err := c.submit(ctx, executeReq)
+	if err != nil {
+		return nil, err
+	}
+	if err := rs.waitForSuccess(ctx); err != nil {
+		rs.Close(context.WithoutCancel(ctx))
+		return nil, err
+	}
+
+	p := &PagedResult{rs: rs, idle: idleTimeout, turn: make(chan struct{}, 1), used: time.Now()}
+	if idleTimeout > 0 {
+		p.timer = time.AfterFunc(idleTimeout, p.expire)
+	}
+	return p, nil
+}
+
+// Columns returns the names of the result's columns.
+func (p *PagedResult) Columns() []string {
+	return p.rs.Columns()
+}
+
+// NextPage returns the next size rows, as from NextValues, fetched from
+// the server with FETCH_NEXT. more is false once the rows have run out;
+// a page that ends exactly at the last row has more set, and is followed
+// by an empty one. A call made while another is being served waits its
+// turn, or until ctx is done.
+func (p *PagedResult) NextPage(ctx context.Context, size int) (rows [][]interface{}, more bool, err error) {
+	if size <= 0 {
+		return nil, false, fmt.Errorf("Invalid page size %d: must be positive", size)
+	}
+	if err := p.acquire(ctx); err != nil {
+		return nil, false, err
+	}
+	defer p.release()
+	if p.err != nil {
+		return nil, false, p.err
+	}
+
+	if err := p.rs.SetFetchSize(int64(size)); err != nil {
+		return nil, false, err
+	}
+	for len(rows) < size {
+		values, err := p.rs.NextValues(ctx)
+		if err == io.EOF {
+			return rows, false, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		row := make([]interface{}, len(values))
+		for i, v := range values {
+			row[i] = v
+		}
+		rows = append(rows, row)
+	}
+	return rows, true, nil
+}
+
+// Close closes the operation. Later calls to NextPage return
+// ErrPagedResultClosed; closing again does nothing.
+func (p *PagedResult) Close(ctx context.Context) error {
+	if err := p.acquire(ctx); err != nil {
+		return err
+	}
+	defer p.release()
+	return p.close(ctx, ErrPagedResultClosed)
+}
+
+// close closes the operation, leaving err for later calls. The caller
+// must hold the turn.
+func (p *PagedResult) close(ctx context.Context, err error) error {
+	if p.err != nil {
+		return nil
+	}
+	p.err = err
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+	return p.rs.Close(ctx)
+}
+
+// expire closes the result for going unused; it is run by the idle
+// timer. A call may have been served while it waited for its turn, in
+// which case the timer has been restarted.
+func (p *PagedResult) expire() {
+	p.turn <- struct{}{}
+	defer func() { <-p.turn }()
+	if time.Since(p.used) < p.idle {
+		return
+	}
+	p.close(context.Background(), ErrPagedResultExpired)
+}
+
+// acquire waits for the turn to serve a call, stopping the idle timer
+// meanwhile.
+func (p *PagedResult) acquire(ctx context.Context) error {
+	select {
+	case p.turn <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+	return nil
+}
+
+// release ends the call being served, restarting the idle timer.
+func (p *PagedResult) release() {
+	p.used = time.Now()
+	if p.timer != nil && p.err == nil {
+		p.timer.Reset(p.idle)
+	}
+	<-p.turn
+}
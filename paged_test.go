@@ -0,0 +1,132 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func pagedService() *fakeService {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("name", inf.TTypeId_STRING_TYPE, 1)}
+	svc.batches = []*inf.TRowSet{stringBatch("a", "b"), stringBatch("c", "d"), stringBatch("e")}
+	return svc
+}
+
+func TestPagedResult(t *testing.T) {
+	svc := pagedService()
+	conn := connectFake(t, svc, testOptions())
+	ctx := context.Background()
+	p, err := conn.QueryPaged(ctx, "SELECT name FROM t", time.Minute)
+	if err != nil {
+		t.Fatalf("QueryPaged error: %v", err)
+	}
+	defer p.Close(ctx)
+
+	var pages [][][]interface{}
+	for {
+		rows, more, err := p.NextPage(ctx, 2)
+		if err != nil {
+			t.Fatalf("NextPage error: %v", err)
+		}
+		pages = append(pages, rows)
+		if !more {
+			break
+		}
+	}
+	expected := [][][]interface{}{{{"a"}, {"b"}}, {{"c"}, {"d"}}, {{"e"}}}
+	if !reflect.DeepEqual(pages, expected) {
+		t.Errorf("expected pages %v, got %v", expected, pages)
+	}
+	for _, req := range svc.fetches {
+		if req.Orientation != inf.TFetchOrientation_FETCH_NEXT || req.MaxRows != 2 {
+			t.Errorf("expected FETCH_NEXT of 2 rows, got %v of %d", req.Orientation, req.MaxRows)
+		}
+	}
+
+	if err := p.Close(ctx); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+	if _, _, err := p.NextPage(ctx, 2); err != ErrPagedResultClosed {
+		t.Errorf("expected ErrPagedResultClosed, got %v", err)
+	}
+	if len(svc.closes) != 1 {
+		t.Errorf("expected the operation closed once, got %d closes", len(svc.closes))
+	}
+}
+
+func TestPagedResultConcurrent(t *testing.T) {
+	conn := connectFake(t, pagedService(), testOptions())
+	ctx := context.Background()
+	p, err := conn.QueryPaged(ctx, "SELECT name FROM t", 0)
+	if err != nil {
+		t.Fatalf("QueryPaged error: %v", err)
+	}
+	defer p.Close(ctx)
+
+	var (
+		mu  sync.Mutex
+		got []string
+		wg  sync.WaitGroup
+	)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rows, _, err := p.NextPage(ctx, 1)
+			if err != nil {
+				t.Errorf("NextPage error: %v", err)
+				return
+			}
+			mu.Lock()
+			for _, row := range rows {
+				got = append(got, row[0].(string))
+			}
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	sort.Strings(got)
+	if expected := []string{"a", "b", "c", "d", "e"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected each row once, got %q", got)
+	}
+}
+
+func TestPagedResultIdleClose(t *testing.T) {
+	svc := pagedService()
+	conn := connectFake(t, svc, testOptions())
+	ctx := context.Background()
+	p, err := conn.QueryPaged(ctx, "SELECT name FROM t", 20*time.Millisecond)
+	if err != nil {
+		t.Fatalf("QueryPaged error: %v", err)
+	}
+	if _, _, err := p.NextPage(ctx, 2); err != nil {
+		t.Fatalf("NextPage error: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		svc.mu.Lock()
+		closes := len(svc.closes)
+		svc.mu.Unlock()
+		if closes == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the idle result to close its operation")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if _, _, err := p.NextPage(ctx, 2); !errors.Is(err, ErrPagedResultExpired) {
+		t.Errorf("expected ErrPagedResultExpired, got %v", err)
+	}
+	if err := p.Close(ctx); err != nil {
+		t.Errorf("Close error: %v", err)
+	}
+}
@@ -0,0 +1,223 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// PartitionScan describes a table read split into one query per
+// partition.
+type PartitionScan struct {
+	// Table is the partitioned table, optionally qualified as db.table.
+	Table string
+	// Columns is the select list; empty means "*".
+	Columns string
+	// Filter, if set, picks the partitions to read from their key values.
+	Filter func(partition map[string]string) bool
+	// Concurrency is the number of partitions read at once, each over its
+	// own session. Values below 1 mean 1.
+	Concurrency int
+}
+
+// ScanPartitions reads scan.Table partition by partition, running up to
+// scan.Concurrency queries at once, and sends every row to rows. The
+// table must be partitioned: partitions are listed with SHOW PARTITIONS,
+// which fails for other tables. Rows from different partitions are
+// interleaved.
+//
+// ScanPartitions closes rows and returns once every partition has been
+// read. The first error cancels the remaining queries and is returned.
+func ScanPartitions(ctx context.Context, hostPort string, options Options, scan PartitionScan, rows chan<- []interface{}) error {
+	defer close(rows)
+
+	partitions, err := listPartitions(ctx, hostPort, options, scan.Table)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	fail := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+		mu.Unlock()
+	}
+
+	work := make(chan map[string]string)
+	workers := scan.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := scanWorker(ctx, hostPort, options, scan, work, rows); err != nil {
+				fail(err)
+			}
+		}()
+	}
+
+feed:
+	for _, p := range partitions {
+		if scan.Filter != nil && !scan.Filter(p) {
+			continue
+		}
+		select {
+		case work <- p:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// scanWorker reads the partitions it receives from work over a session
+// of its own.
+func scanWorker(ctx context.Context, hostPort string, options Options, scan PartitionScan, work <-chan map[string]string, rows chan<- []interface{}) error {
+	var conn *Connection
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+
+	for partition := range work {
+		if conn == nil {
+			var err error
+			if conn, err = ConnectContext(ctx, hostPort, options); err != nil {
+				return err
+			}
+		}
+		if err := scanPartition(ctx, conn, scan, partition, rows); err != nil {
+			return fmt.Errorf("Partition %s: %v", partitionSpec(partition), err)
+		}
+	}
+	return nil
+}
+
+func scanPartition(ctx context.Context, conn *Connection, scan PartitionScan, partition map[string]string, rows chan<- []interface{}) error {
+	columns := scan.Columns
+	if columns == "" {
+		columns = "*"
+	}
+	keys := sortedKeys(partition)
+	conds := make([]string, len(keys))
+	for i, k := range keys {
+		conds[i] = quoteIdentifier(k) + " = " + quoteString(partition[k])
+	}
+
+	executeReq := inf.NewTExecuteStatementReq()
+	executeReq.Statement = "SELECT " + columns + " FROM " + quoteTableName(scan.Table) + " WHERE " + strings.Join(conds, " AND ")
+	executeReq.RunAsync = true
+	// Reading whole partitions is the point, whatever InteractiveGuard
+	// says.
+	rs, err := conn.submit(WithUnboundedQuery(ctx), executeReq)
+	if err != nil {
+		return err
+	}
+	defer rs.Close(context.Background())
+
+	for rs.next(ctx) {
+		row := make([]interface{}, len(rs.nextRow))
+		copy(row, rs.nextRow)
+		select {
+		case rows <- row:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return rs.Err()
+}
+
+// listPartitions returns the key values of every partition of table.
+func listPartitions(ctx context.Context, hostPort string, options Options, table string) ([]map[string]string, error) {
+	conn, err := ConnectContext(ctx, hostPort, options)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	specs, err := conn.ShowPartitions(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+
+	partitions := make([]map[string]string, 0, len(specs))
+	for _, spec := range specs {
+		p, err := parsePartitionSpec(spec)
+		if err != nil {
+			return nil, err
+		}
+		partitions = append(partitions, p)
+	}
+	return partitions, nil
+}
+
+// parsePartitionSpec parses a SHOW PARTITIONS line such as
+// "dt=2024-01-01/country=US". Keys and values are path-escaped by Hive.
+func parsePartitionSpec(spec string) (map[string]string, error) {
+	p := map[string]string{}
+	for _, part := range strings.Split(spec, "/") {
+		k, v, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("Invalid partition spec %q", spec)
+		}
+		key, err1 := url.PathUnescape(k)
+		value, err2 := url.PathUnescape(v)
+		if err := errors.Join(err1, err2); err != nil {
+			return nil, fmt.Errorf("Invalid partition spec %q: %v", spec, err)
+		}
+		p[key] = value
+	}
+	return p, nil
+}
+
+func partitionSpec(partition map[string]string) string {
+	keys := sortedKeys(partition)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + partition[k]
+	}
+	return strings.Join(parts, "/")
+}
+
+// quoteTableName quotes each part of a possibly qualified table name.
+func quoteTableName(name string) string {
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		parts[i] = quoteIdentifier(p)
+	}
+	return strings.Join(parts, ".")
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,77 @@
+package hive
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func partitionService() *fakeService {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("id", inf.TTypeId_STRING_TYPE, 1)}
+	svc.results = map[string][]*inf.TRowSet{
+		"SHOW PARTITIONS `sales`.`orders`": {stringBatch("dt=2024-01-01/cc=US", "dt=2024-01-02/cc=D%3DE", "dt=2024-01-03/cc=FR")},
+
+		"SELECT id FROM `sales`.`orders` WHERE `cc` = 'US' AND `dt` = '2024-01-01'":  {stringBatch("1", "2")},
+		"SELECT id FROM `sales`.`orders` WHERE `cc` = 'D=E' AND `dt` = '2024-01-02'": {stringBatch("3")},
+		"SELECT id FROM `sales`.`orders` WHERE `cc` = 'FR' AND `dt` = '2024-01-03'":  {stringBatch("4")},
+	}
+	return svc
+}
+
+func TestScanPartitions(t *testing.T) {
+	svc := partitionService()
+	addr := startFakeServer(t, svc)
+
+	scan := PartitionScan{
+		Table:       "sales.orders",
+		Columns:     "id",
+		Filter:      func(p map[string]string) bool { return p["cc"] != "FR" },
+		Concurrency: 2,
+	}
+	rows := make(chan []interface{})
+	done := make(chan error, 1)
+	go func() { done <- ScanPartitions(context.Background(), addr, testOptions(), scan, rows) }()
+
+	var ids []string
+	for row := range rows {
+		ids = append(ids, row[0].(string))
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("ScanPartitions error: %v", err)
+	}
+
+	sort.Strings(ids)
+	if expected := []string{"1", "2", "3"}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("expected rows %v, got %v", expected, ids)
+	}
+}
+
+func TestScanPartitionsStopsOnError(t *testing.T) {
+	svc := partitionService()
+	svc.onExecute = func(req *inf.TExecuteStatementReq) (*inf.TExecuteStatementResp, error) {
+		if strings.Contains(req.Statement, "'FR'") {
+			return &inf.TExecuteStatementResp{Status: errorStatus("Permission denied")}, nil
+		}
+		svc.mu.Lock()
+		defer svc.mu.Unlock()
+		return &inf.TExecuteStatementResp{Status: okStatus(), OperationHandle: svc.newOperation(req.Statement)}, nil
+	}
+	addr := startFakeServer(t, svc)
+
+	rows := make(chan []interface{})
+	done := make(chan error, 1)
+	scan := PartitionScan{Table: "sales.orders", Columns: "id", Concurrency: 3}
+	go func() { done <- ScanPartitions(context.Background(), addr, testOptions(), scan, rows) }()
+
+	for range rows {
+	}
+	err := <-done
+	if err == nil || !strings.Contains(err.Error(), "cc=FR") {
+		t.Errorf("expected the partition's error, got %v", err)
+	}
+}
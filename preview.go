@@ -0,0 +1,114 @@
+package hive
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// Preview runs query and returns at most limit of its rows, as from
+// NextValues, with the result schema. It is meant for UIs that show the
+// first rows of a query, and tries to keep the server from producing
+// the rest: when query is a single SELECT, or WITH ... SELECT, without a
+// LIMIT of its own outside parentheses, "LIMIT limit" is appended. Other
+// statements, or ones it can't tell apart, such as a SELECT already
+// limited, a multi-statement script, SHOW or EXPLAIN, are sent as
+// written; Preview then stops reading after limit rows and closes the
+// operation, which cancels it if it is still running.
+func (c *Connection) Preview(ctx context.Context, query string, limit int64) ([][]interface{}, []Column, error) {
+	if limit <= 0 {
+		return nil, nil, fmt.Errorf("Invalid preview limit %d", limit)
+	}
+
+	executeReq := inf.NewTExecuteStatementReq()
+	executeReq.Statement = query
+	if limitable(query) {
+		executeReq.Statement = fmt.Sprintf("%s\nLIMIT %d", strings.TrimRight(query, " \t\r\n;"), limit)
+	}
+	executeReq.RunAsync = true
+
+	rs, err := c.submit(ctx, executeReq)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rs.Close(context.WithoutCancel(ctx))
+	if rs.options.BatchSize == 0 || limit < rs.options.BatchSize {
+		rs.options.BatchSize = limit
+		rs.options.TargetBatchBytes = 0
+	}
+
+	columns, err := rs.Schema(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	var rows [][]interface{}
+	for int64(len(rows)) < limit {
+		values, err := rs.NextValues(ctx)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		row := make([]interface{}, len(values))
+		for i, v := range values {
+			row[i] = v
+		}
+		rows = append(rows, row)
+	}
+	return rows, columns, nil
+}
+
+// limitable reports whether a LIMIT clause can safely be appended to
+// query: it must be a single SELECT, possibly behind common table
+// expressions, with no LIMIT outside parentheses.
+func limitable(query string) bool {
+	if stmts, err := SplitStatements(query); err != nil || len(stmts) != 1 {
+		return false
+	}
+	keyword, _ := leadingWord(skipSpaceAndComments(query))
+	switch strings.ToUpper(keyword) {
+	case "SELECT", "WITH":
+	default:
+		return false
+	}
+	if ClassifyStatement(query) != StatementRead {
+		// e.g. WITH ... INSERT
+		return false
+	}
+	return !hasTopLevelWord(query, "LIMIT")
+}
+
+// hasTopLevelWord reports whether the keyword word occurs in sql outside
+// parentheses, literals and comments.
+func hasTopLevelWord(sql, word string) bool {
+	depth := 0
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end, _ := quotedEnd(sql, i)
+			i = end
+		case c == '-' && strings.HasPrefix(sql[i:], "--"), c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			i = len(sql) - len(skipSpaceAndComments(sql[i:]))
+		case c == '(':
+			depth++
+			i++
+		case c == ')':
+			depth--
+			i++
+		case !isWordBoundary(sql, i):
+			w, _ := leadingWord(sql[i:])
+			if depth == 0 && strings.EqualFold(w, word) {
+				return true
+			}
+			i += len(w)
+		default:
+			i++
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package hive
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestLimitable(t *testing.T) {
+	cases := []struct {
+		query string
+		want  bool
+	}{
+		{"SELECT * FROM t", true},
+		{"select * from t;", true},
+		{"  -- preview\nSELECT * FROM t", true},
+		{"WITH x AS (SELECT * FROM t LIMIT 5) SELECT * FROM x", true},
+		{"SELECT * FROM (SELECT * FROM t LIMIT 5) s", true},
+		{"SELECT 'LIMIT' AS `limit` FROM t -- LIMIT", true},
+		{"SELECT * FROM t LIMIT 10", false},
+		{"SELECT * FROM t limit 10 offset 5", false},
+		{"WITH x AS (SELECT 1) INSERT INTO t SELECT * FROM x", false},
+		{"SHOW TABLES", false},
+		{"EXPLAIN SELECT * FROM t", false},
+		{"SELECT 1; SELECT 2", false},
+		{"SELECT 'unterminated", false},
+	}
+	for _, tc := range cases {
+		if got := limitable(tc.query); got != tc.want {
+			t.Errorf("limitable(%q): expected %v, got %v", tc.query, tc.want, got)
+		}
+	}
+}
+
+func TestPreview(t *testing.T) {
+	for _, tc := range []struct {
+		query, sent string
+	}{
+		{"SELECT s FROM t -- all of it\n;", "SELECT s FROM t -- all of it\nLIMIT 3"},
+		{"SELECT s FROM t LIMIT 100", "SELECT s FROM t LIMIT 100"},
+	} {
+		svc := newFakeService()
+		svc.schema = []*inf.TColumnDesc{columnDesc("s", inf.TTypeId_STRING_TYPE, 1)}
+		svc.batches = []*inf.TRowSet{stringBatch("a", "b"), stringBatch("c", "d"), stringBatch("e")}
+		conn := connectFake(t, svc, testOptions())
+
+		rows, columns, err := conn.Preview(context.Background(), tc.query, 3)
+		if err != nil {
+			t.Fatalf("Preview error: %v", err)
+		}
+		if want := [][]interface{}{{"a"}, {"b"}, {"c"}}; !reflect.DeepEqual(rows, want) {
+			t.Errorf("%q: expected %v, got %v", tc.query, want, rows)
+		}
+		if len(columns) != 1 || columns[0].Name != "s" {
+			t.Errorf("%q: expected the schema, got %+v", tc.query, columns)
+		}
+		if statements := svc.executed(); statements[len(statements)-1] != tc.sent {
+			t.Errorf("%q: expected %q to be sent, got %q", tc.query, tc.sent, statements[len(statements)-1])
+		}
+		svc.mu.Lock()
+		fetches, closes := len(svc.fetches), len(svc.closes)
+		maxRows := svc.fetches[0].MaxRows
+		svc.mu.Unlock()
+		if fetches != 2 || maxRows != 3 || closes != 1 {
+			t.Errorf("%q: expected 2 fetches of 3 rows and a close, got %d fetches of %d and %d closes", tc.query, fetches, maxRows, closes)
+		}
+	}
+
+	conn := connectFake(t, newFakeService(), testOptions())
+	if _, _, err := conn.Preview(context.Background(), "SELECT 1", 0); err == nil {
+		t.Error("expected a zero limit to be rejected")
+	}
+}
@@ -0,0 +1,72 @@
+package hive
+
+import (
+	"context"
+	"fmt"
+)
+
+// A Priority ranks a statement against others on a cluster that
+// schedules by priority, see WithPriority.
+type Priority int
+
+const (
+	PriorityLow    Priority = -1
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 1
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "LOW"
+	case PriorityNormal:
+		return "NORMAL"
+	case PriorityHigh:
+		return "HIGH"
+	}
+	return fmt.Sprintf("Priority(%d)", int(p))
+}
+
+// Conf returns the settings WithPriority applies for p: the YARN
+// application priority of the statement's jobs, mapreduce.job.priority,
+// as LOW, NORMAL or HIGH. It is nil for a value that isn't one of the
+// constants.
+func (p Priority) Conf() map[string]string {
+	switch p {
+	case PriorityLow, PriorityNormal, PriorityHigh:
+		return map[string]string{"mapreduce.job.priority": p.String()}
+	}
+	return nil
+}
+
+type priorityKey struct{}
+
+// WithPriority returns a context under which statements run at level,
+// so that interactive queries can go ahead of batch jobs. Like
+// WithResourceQueue, each call that sends a statement applies the
+// settings of level.Conf first and puts back their prior values once it
+// has been submitted, and it can be combined with WithResourceQueue and
+// WithQueryLimits. mapreduce.job.priority is often undefined until set;
+// putting it back then takes RESET <key>, which Hive before 3.0 can't do
+// for one key alone, so there the statement fails with
+// ErrResetUnsupported.
+//
+// YARN honors the priority only where its scheduler is set up for
+// application priorities. Engines that take theirs from elsewhere, such
+// as a Tez session routed to a high-priority queue or a pool of Hive's
+// workload management, are handled with WithPriorityConf.
+func WithPriority(ctx context.Context, level Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, level.Conf())
+}
+
+// WithPriorityConf is like WithPriority, but applies conf, settings of
+// the caller's choosing, instead of those of a Priority, e.g.
+// {"tez.queue.name": "interactive"}. It replaces any priority set
+// further up.
+func WithPriorityConf(ctx context.Context, conf map[string]string) context.Context {
+	copied := make(map[string]string, len(conf))
+	for key, value := range conf {
+		copied[key] = value
+	}
+	return context.WithValue(ctx, priorityKey{}, copied)
+}
@@ -0,0 +1,91 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestWithPriority(t *testing.T) {
+	svc := newFakeService()
+	svc.results = map[string][]*inf.TRowSet{
+		"SET mapreduce.job.priority": {stringBatch("mapreduce.job.priority=NORMAL")},
+	}
+	conn := connectFake(t, svc, testOptions())
+	ctx := WithPriority(context.Background(), PriorityHigh)
+
+	if _, _, err := conn.ExecCount(ctx, "INSERT INTO t SELECT 1"); err != nil {
+		t.Fatalf("Exec error: %v", err)
+	}
+	if _, err := conn.Exec("INSERT INTO t SELECT 2"); err != nil {
+		t.Fatalf("Exec error: %v", err)
+	}
+
+	expected := []string{
+		"SET mapreduce.job.priority",
+		"SET mapreduce.job.priority=HIGH",
+		"INSERT INTO t SELECT 1",
+		"SET mapreduce.job.priority=NORMAL",
+		"INSERT INTO t SELECT 2",
+	}
+	if got := svc.executed(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected statements\n%q\ngot\n%q", expected, got)
+	}
+}
+
+func TestWithPriorityUndefinedOnOldServer(t *testing.T) {
+	svc := newFakeService()
+	svc.reportVersion("2.3.9")
+	svc.results = map[string][]*inf.TRowSet{
+		"SET mapreduce.job.priority": {stringBatch("mapreduce.job.priority is undefined")},
+	}
+	conn := connectFake(t, svc, testOptions())
+	ctx := WithPriority(context.Background(), PriorityHigh)
+
+	if _, _, err := conn.ExecCount(ctx, "INSERT INTO t SELECT 1"); !errors.Is(err, ErrResetUnsupported) {
+		t.Errorf("expected ErrResetUnsupported, got %v", err)
+	}
+	if got, expected := svc.executed(), []string{"SET mapreduce.job.priority"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected statements %q, got %q", expected, got)
+	}
+}
+
+func TestWithPriorityConf(t *testing.T) {
+	svc := newFakeService()
+	svc.reportVersion("3.1.3")
+	svc.results = map[string][]*inf.TRowSet{
+		"SET tez.queue.name": {stringBatch("tez.queue.name is undefined")},
+	}
+	conn := connectFake(t, svc, testOptions())
+	ctx := WithPriority(context.Background(), PriorityLow)
+	ctx = WithPriorityConf(ctx, map[string]string{"tez.queue.name": "interactive"})
+
+	if _, _, err := conn.ExecCount(ctx, "INSERT INTO t SELECT 1"); err != nil {
+		t.Fatalf("Exec error: %v", err)
+	}
+	expected := []string{
+		"SET tez.queue.name",
+		"SET tez.queue.name=interactive",
+		"INSERT INTO t SELECT 1",
+		"RESET tez.queue.name",
+	}
+	if got := svc.executed(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected statements\n%q\ngot\n%q", expected, got)
+	}
+}
+
+func TestWithPriorityRejectsInvalidLevel(t *testing.T) {
+	svc := newFakeService()
+	conn := connectFake(t, svc, testOptions())
+	sent := len(svc.executed())
+
+	if _, _, err := conn.ExecCount(WithPriority(context.Background(), Priority(5)), "SELECT 1"); err == nil {
+		t.Error("expected an invalid priority to be rejected")
+	}
+	if got := svc.executed()[sent:]; len(got) != 0 {
+		t.Errorf("expected nothing sent, got %q", got)
+	}
+}
@@ -0,0 +1,72 @@
+package hive
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+type profile struct {
+	hostPort string
+	options  Options
+}
+
+var (
+	profilesMu sync.RWMutex
+	profiles   = map[string]profile{}
+)
+
+// A ProfileOption overrides part of a registered profile's Options for a
+// single ConnectProfile call.
+type ProfileOption func(*Options)
+
+// WithDatabase overrides the profile's Database.
+func WithDatabase(database string) ProfileOption {
+	return func(o *Options) {
+		o.Database = database
+	}
+}
+
+// WithCredentials overrides the profile's Username and Password.
+func WithCredentials(username, password string) ProfileOption {
+	return func(o *Options) {
+		o.Username = username
+		o.Password = password
+	}
+}
+
+// RegisterProfile stores a named connection target for ConnectProfile.
+// Registering a name again replaces the earlier profile.
+func RegisterProfile(name string, hostPort string, options Options) {
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+	profiles[name] = profile{hostPort: hostPort, options: options}
+}
+
+// ConnectProfile opens a session to the target registered under name,
+// applying opts to a copy of its Options. The session is opened with
+// the profile's Username and Password when Username is set.
+func ConnectProfile(ctx context.Context, name string, opts ...ProfileOption) (*Connection, error) {
+	profilesMu.RLock()
+	p, ok := profiles[name]
+	profilesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("No connection profile named %q", name)
+	}
+
+	options := p.options
+	if p.options.SessionConf != nil {
+		options.SessionConf = make(map[string]string, len(p.options.SessionConf))
+		for k, v := range p.options.SessionConf {
+			options.SessionConf[k] = v
+		}
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if options.Username != "" {
+		return connect(ctx, p.hostPort, &options.Username, &options.Password, options)
+	}
+	return connect(ctx, p.hostPort, nil, nil, options)
+}
@@ -0,0 +1,31 @@
+package hive
+
+import (
+	"context"
+	"testing"
+)
+
+func TestConnectProfile(t *testing.T) {
+	svc := newFakeService()
+	options := testOptions()
+	options.Database = "sales"
+	RegisterProfile("warehouse", startFakeServer(t, svc), options)
+
+	conn, err := ConnectProfile(context.Background(), "warehouse",
+		WithDatabase("web"), WithCredentials("etl", "secret"))
+	if err != nil {
+		t.Fatalf("ConnectProfile error: %v", err)
+	}
+	defer conn.Close()
+
+	if db := svc.sessions[0].Configuration["use:database"]; db != "web" {
+		t.Errorf("expected the database override to be used, got %q", db)
+	}
+	if req := svc.sessions[0]; req.GetUsername() != "etl" || req.GetPassword() != "secret" {
+		t.Errorf("expected the credential override, got %q/%q", req.GetUsername(), req.GetPassword())
+	}
+
+	if _, err := ConnectProfile(context.Background(), "missing"); err == nil {
+		t.Error("expected an error for an unknown profile")
+	}
+}
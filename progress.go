@@ -0,0 +1,120 @@
+package hive
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"io"
+)
+
+type writeProgressKey struct{}
+
+// WithWriteProgress returns a context that makes the RowSet writers
+// WriteCSVProgress, WriteHiveText and Reader call fn with the number of
+// rows written so far, once per batch fetched and once at the end. fn is
+// called synchronously between writes, so it should return quickly, e.g.
+// by storing the count for a display to pick up.
+func WithWriteProgress(ctx context.Context, fn func(rowsWritten int64)) context.Context {
+	return context.WithValue(ctx, writeProgressKey{}, fn)
+}
+
+// writeProgress returns the progress callback set by WithWriteProgress,
+// or one that does nothing.
+func writeProgress(ctx context.Context) func(int64) {
+	if fn, ok := ctx.Value(writeProgressKey{}).(func(int64)); ok && fn != nil {
+		return fn
+	}
+	return func(int64) {}
+}
+
+// CSVOptions control WriteCSVProgress.
+type CSVOptions struct {
+	// Comma is the field delimiter. It defaults to ','.
+	Comma rune
+	// NoHeader leaves out the header line with the column names.
+	NoHeader bool
+}
+
+// WriteCSVProgress writes the remaining rows to w as CSV, like Reader's
+// FormatCSV: a header line with the column names, unless opts.NoHeader
+// is set, then one RFC 4180 record per row with NULL as an empty field.
+// Rows are written a batch at a time as they are fetched, and after each
+// batch onProgress, if not nil, is called with the number of rows
+// written so far, see WithWriteProgress, which it takes the place of.
+// Only a batch is held in memory, so w may be a cloud storage object
+// writer for results of any size; if a write to w fails, the operation
+// is aborted and the write's error returned.
+func (r *rowSet) WriteCSVProgress(ctx context.Context, w io.Writer, opts CSVOptions, onProgress func(rowsWritten int64)) error {
+	if onProgress != nil {
+		ctx = WithWriteProgress(ctx, onProgress)
+	}
+	if err := r.waitForSuccess(ctx); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	if opts.Comma != 0 {
+		cw.Comma = opts.Comma
+	}
+	if !opts.NoHeader {
+		if err := cw.Write(r.Columns()); err != nil {
+			return err
+		}
+		cw.Flush()
+	}
+	return r.writeBatches(ctx, w, &buf, func(row []interface{}) error {
+		record := make([]string, len(row))
+		for i, v := range row {
+			record[i] = formatField(v)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+		cw.Flush()
+		return cw.Error()
+	})
+}
+
+// writeBatches encodes the remaining rows into buf with encode, and
+// writes buf to w whenever a batch is done, reporting the progress set
+// by WithWriteProgress after each write. Whatever buf holds to begin
+// with goes out with the first batch. At most a batch is held at a time,
+// so results of any size can be written to a network or cloud storage
+// writer; a failed write cancels the operation, see writeFailed.
+func (r *rowSet) writeBatches(ctx context.Context, w io.Writer, buf *bytes.Buffer, encode func(row []interface{}) error) error {
+	progress := writeProgress(ctx)
+	var rows, buffered int64
+	for r.next(ctx) {
+		if err := encode(r.nextRow); err != nil {
+			return r.writeFailed(ctx, err)
+		}
+		buffered++
+		if r.offset < r.batchLength() {
+			continue
+		}
+		if _, err := buf.WriteTo(w); err != nil {
+			return r.writeFailed(ctx, err)
+		}
+		rows += buffered
+		buffered = 0
+		progress(rows)
+	}
+	if _, err := buf.WriteTo(w); err != nil {
+		return r.writeFailed(ctx, err)
+	}
+	if buffered > 0 {
+		rows += buffered
+		progress(rows)
+	}
+	return r.Err()
+}
+
+// writeFailed aborts the operation once a write of its rows has failed,
+// since the rest of the result can't be written, and returns err. The
+// server stops running the query rather than wait for fetches that
+// won't come.
+func (r *rowSet) writeFailed(ctx context.Context, err error) error {
+	r.Abort(context.WithoutCancel(ctx))
+	return err
+}
@@ -0,0 +1,139 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func progressService() *fakeService {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("name", inf.TTypeId_STRING_TYPE, 1)}
+	svc.batches = []*inf.TRowSet{stringBatch("a", "b"), stringBatch("c, d"), stringBatch("e", "f", "g")}
+	return svc
+}
+
+func TestWriteCSVProgress(t *testing.T) {
+	conn := connectFake(t, progressService(), testOptions())
+	rs, err := conn.Query("SELECT name FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+
+	var counts []int64
+	var w countingWriter
+	if err := rs.WriteCSVProgress(context.Background(), &w, CSVOptions{}, func(n int64) { counts = append(counts, n) }); err != nil {
+		t.Fatalf("WriteCSVProgress error: %v", err)
+	}
+	if want := "name\na\nb\n\"c, d\"\ne\nf\ng\n"; w.String() != want {
+		t.Errorf("expected %q, got %q", want, w.String())
+	}
+	if want := []int64{2, 3, 6}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("expected progress %v, got %v", want, counts)
+	}
+	if len(w.writes) != 3 {
+		t.Errorf("expected a write per batch, got %v", w.writes)
+	}
+}
+
+func TestWriteCSVProgressOptions(t *testing.T) {
+	conn := connectFake(t, progressService(), testOptions())
+	rs, err := conn.Query("SELECT name FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	var b strings.Builder
+	if err := rs.WriteCSVProgress(context.Background(), &b, CSVOptions{Comma: ';', NoHeader: true}, nil); err != nil {
+		t.Fatalf("WriteCSVProgress error: %v", err)
+	}
+	if want := "a\nb\nc, d\ne\nf\ng\n"; b.String() != want {
+		t.Errorf("expected %q, got %q", want, b.String())
+	}
+}
+
+func TestWithWriteProgress(t *testing.T) {
+	var counts []int64
+	ctx := WithWriteProgress(context.Background(), func(n int64) { counts = append(counts, n) })
+	conn := connectFake(t, progressService(), testOptions())
+
+	rs, err := conn.Query("SELECT name FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	if err := rs.WriteHiveText(ctx, io.Discard); err != nil {
+		t.Fatalf("WriteHiveText error: %v", err)
+	}
+	if want := []int64{2, 3, 6}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("expected WriteHiveText progress %v, got %v", want, counts)
+	}
+
+	counts = nil
+	rs, err = conn.Query("SELECT name FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	rd, err := rs.Reader(ctx, FormatJSONL)
+	if err != nil {
+		t.Fatalf("Reader error: %v", err)
+	}
+	defer rd.Close()
+	if _, err := io.Copy(io.Discard, rd); err != nil {
+		t.Fatalf("Read error: %v", err)
+	}
+	if want := []int64{2, 3, 6}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("expected Reader progress %v, got %v", want, counts)
+	}
+}
+
+// flakyWriter fails every write after the first ok ones.
+type flakyWriter struct {
+	ok     int
+	writes int
+}
+
+var errFlakyWriter = errors.New("connection reset by peer")
+
+func (w *flakyWriter) Write(p []byte) (int, error) {
+	w.writes++
+	if w.writes > w.ok {
+		return 0, errFlakyWriter
+	}
+	return len(p), nil
+}
+
+func TestWriteFailureCancels(t *testing.T) {
+	for name, write := range map[string]func(RowSet, io.Writer) error{
+		"csv": func(rs RowSet, w io.Writer) error {
+			return rs.WriteCSVProgress(context.Background(), w, CSVOptions{}, nil)
+		},
+		"hive text": func(rs RowSet, w io.Writer) error { return rs.WriteHiveText(context.Background(), w) },
+		"table": func(rs RowSet, w io.Writer) error {
+			return rs.WriteTable(context.Background(), w, TableOptions{BufferRows: 1})
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			svc := progressService()
+			conn := connectFake(t, svc, testOptions())
+			rs, err := conn.Query("SELECT name FROM t")
+			if err != nil {
+				t.Fatalf("Query error: %v", err)
+			}
+
+			w := &flakyWriter{ok: 1}
+			if err := write(rs, w); !errors.Is(err, errFlakyWriter) {
+				t.Fatalf("expected the write error, got %v", err)
+			}
+			if len(svc.cancels) != 1 || len(svc.closes) != 1 {
+				t.Errorf("expected the operation cancelled and closed, got %d cancels and %d closes", len(svc.cancels), len(svc.closes))
+			}
+			if len(svc.fetches) > 2 {
+				t.Errorf("expected fetching to stop at the failed write, got %d fetches", len(svc.fetches))
+			}
+		})
+	}
+}
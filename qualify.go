@@ -0,0 +1,168 @@
+package hive
+
+import (
+	"context"
+	"strings"
+)
+
+type skipQualifyKey struct{}
+
+// WithoutTableQualification returns a context under which statements
+// are sent as written even if Options.QualifyTables is set, for the
+// queries that deliberately reach into other databases through
+// unqualified names, or that the rewrite gets wrong.
+func WithoutTableQualification(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipQualifyKey{}, true)
+}
+
+// qualifyStatement applies Options.QualifyTables to stmt.
+func (c *Connection) qualifyStatement(ctx context.Context, stmt string) string {
+	if !c.options.QualifyTables || ctx.Value(skipQualifyKey{}) != nil {
+		return stmt
+	}
+	return QualifyTableNames(stmt, c.options.Database)
+}
+
+// fromListEnd lists the keywords that end a FROM clause's table list, so
+// that later commas aren't taken for more tables.
+var fromListEnd = map[string]bool{
+	"WHERE": true, "GROUP": true, "ORDER": true, "HAVING": true, "LIMIT": true,
+	"UNION": true, "ON": true, "USING": true, "LATERAL": true, "SORT": true,
+	"CLUSTER": true, "DISTRIBUTE": true, "WINDOW": true, "SELECT": true,
+	"INSERT": true, "JOIN": true,
+}
+
+// QualifyTableNames prefixes the unqualified table names in sql's FROM
+// and JOIN clauses and INSERT INTO or INSERT OVERWRITE TABLE targets with
+// database, e.g. "SELECT * FROM t JOIN u" becomes
+// "SELECT * FROM `db`.t JOIN `db`.u". Names of common table expressions
+// declared by a leading WITH, table functions and anything already
+// qualified are left alone, as are the contents of literals and
+// comments.
+//
+// It is a scanner, not a parser, with known limits: nested WITH clauses,
+// DDL, DESCRIBE, LOAD, UPDATE, DELETE and MERGE aren't rewritten, and
+// names in unusual positions may be missed. It backs Options.QualifyTables.
+func QualifyTableNames(sql, database string) string {
+	var (
+		b      strings.Builder
+		ctes   = cteNames(sql)
+		prefix = quoteIdentifier(database) + "."
+		// queries says, for each open parenthesis, whether it holds a
+		// query, as opposed to e.g. a function's arguments.
+		queries     = []bool{true}
+		expectTable bool
+		fromList    bool
+		prevWord    string
+	)
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"':
+			end, _ := quotedEnd(sql, i)
+			b.WriteString(sql[i:end])
+			i = end
+			expectTable = false
+		case c == '-' && strings.HasPrefix(sql[i:], "--"), c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			rest := skipSpaceAndComments(sql[i:])
+			end := len(sql) - len(rest)
+			b.WriteString(sql[i:end])
+			i = end
+		case c == '(':
+			word, _ := leadingWord(skipSpaceAndComments(sql[i+1:]))
+			word = strings.ToUpper(word)
+			queries = append(queries, word == "SELECT" || word == "WITH")
+			b.WriteByte(c)
+			i++
+			expectTable, fromList = false, false
+		case c == ')':
+			if len(queries) > 1 {
+				queries = queries[:len(queries)-1]
+			}
+			b.WriteByte(c)
+			i++
+		case c == ',':
+			if fromList {
+				expectTable = true
+			}
+			b.WriteByte(c)
+			i++
+		case c == '`' || !isWordBoundary(sql, i):
+			word, rest := leadingWord(sql[i:])
+			upper := strings.ToUpper(word)
+			following := skipSpaceAndComments(rest)
+			switch {
+			case expectTable && upper == "TABLE":
+				// INSERT INTO TABLE t
+			case expectTable:
+				expectTable = false
+				name := word
+				name = unquoteIdentifier(name)
+				qualified := strings.HasPrefix(following, ".") || strings.Contains(name, ".")
+				function := strings.HasPrefix(following, "(")
+				if !qualified && !function && !ctes[strings.ToLower(name)] {
+					b.WriteString(prefix)
+				}
+			case upper == "FROM" && queries[len(queries)-1]:
+				expectTable, fromList = true, true
+			case upper == "JOIN":
+				expectTable, fromList = true, false
+			case upper == "INTO" && strings.EqualFold(prevWord, "INSERT"):
+				expectTable, fromList = true, false
+			case upper == "TABLE" && strings.EqualFold(prevWord, "OVERWRITE"):
+				expectTable, fromList = true, false
+			case fromListEnd[upper]:
+				fromList = false
+			}
+			b.WriteString(word)
+			i += len(word)
+			prevWord = word
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+// cteNames returns the lower-cased names of the common table expressions
+// declared by a WITH at the start of sql.
+func cteNames(sql string) map[string]bool {
+	s := skipSpaceAndComments(sql)
+	for strings.HasPrefix(s, "(") {
+		s = skipSpaceAndComments(s[1:])
+	}
+	keyword, s := leadingWord(s)
+	if !strings.EqualFold(keyword, "WITH") {
+		return nil
+	}
+
+	names := map[string]bool{}
+	for {
+		s = skipSpaceAndComments(s)
+		var name string
+		name, s = leadingWord(s)
+		names[strings.ToLower(unquoteIdentifier(name))] = true
+		s = skipSpaceAndComments(s)
+		if strings.HasPrefix(s, "(") {
+			s = skipSpaceAndComments(skipParens(s))
+		}
+		as, rest := leadingWord(s)
+		if !strings.EqualFold(as, "AS") {
+			return names
+		}
+		s = skipSpaceAndComments(skipParens(skipSpaceAndComments(rest)))
+		if !strings.HasPrefix(s, ",") {
+			return names
+		}
+		s = s[1:]
+	}
+}
+
+// unquoteIdentifier removes the backticks around a quoted identifier.
+func unquoteIdentifier(name string) string {
+	if len(name) >= 2 && name[0] == '`' && name[len(name)-1] == '`' {
+		return strings.ReplaceAll(name[1:len(name)-1], "``", "`")
+	}
+	return name
+}
@@ -0,0 +1,73 @@
+package hive
+
+import (
+	"context"
+	"testing"
+)
+
+func TestQualifyTableNames(t *testing.T) {
+	cases := []struct {
+		in, want string
+	}{
+		{"SELECT * FROM orders", "SELECT * FROM `sales`.orders"},
+		{"select id from orders o where o.id > 1", "select id from `sales`.orders o where o.id > 1"},
+		{"SELECT * FROM other.orders", "SELECT * FROM other.orders"},
+		{"SELECT * FROM `other`.`orders`", "SELECT * FROM `other`.`orders`"},
+		{"SELECT * FROM `order items`", "SELECT * FROM `sales`.`order items`"},
+		{"SELECT * FROM a JOIN b ON a.id = b.id LEFT OUTER JOIN c USING (id)",
+			"SELECT * FROM `sales`.a JOIN `sales`.b ON a.id = b.id LEFT OUTER JOIN `sales`.c USING (id)"},
+		{"SELECT * FROM a x, b y WHERE x.id = y.id GROUP BY x.k, y.k",
+			"SELECT * FROM `sales`.a x, `sales`.b y WHERE x.id = y.id GROUP BY x.k, y.k"},
+		{"SELECT * FROM (SELECT id FROM a) s JOIN b ON s.id = b.id",
+			"SELECT * FROM (SELECT id FROM `sales`.a) s JOIN `sales`.b ON s.id = b.id"},
+		{"WITH recent AS (SELECT * FROM orders) SELECT * FROM recent JOIN users ON recent.u = users.id",
+			"WITH recent AS (SELECT * FROM `sales`.orders) SELECT * FROM recent JOIN `sales`.users ON recent.u = users.id"},
+		{"INSERT INTO totals SELECT k, count(*) FROM orders GROUP BY k",
+			"INSERT INTO `sales`.totals SELECT k, count(*) FROM `sales`.orders GROUP BY k"},
+		{"INSERT INTO TABLE totals VALUES (1)", "INSERT INTO TABLE `sales`.totals VALUES (1)"},
+		{"INSERT OVERWRITE TABLE totals PARTITION (dt='x') SELECT * FROM staging",
+			"INSERT OVERWRITE TABLE `sales`.totals PARTITION (dt='x') SELECT * FROM `sales`.staging"},
+		{"SELECT extract(year FROM dt) FROM orders", "SELECT extract(year FROM dt) FROM `sales`.orders"},
+		{"SELECT * FROM explode(array(1, 2)) e", "SELECT * FROM explode(array(1, 2)) e"},
+		{"SELECT 'FROM x' FROM orders -- FROM y", "SELECT 'FROM x' FROM `sales`.orders -- FROM y"},
+		{"SELECT * FROM /* note */ orders", "SELECT * FROM /* note */ `sales`.orders"},
+		{"SELECT 1", "SELECT 1"},
+		{"SET hive.exec.parallel=true", "SET hive.exec.parallel=true"},
+	}
+	for _, tc := range cases {
+		if got := QualifyTableNames(tc.in, "sales"); got != tc.want {
+			t.Errorf("QualifyTableNames(%q):\nexpected %s\n     got %s", tc.in, tc.want, got)
+		}
+	}
+}
+
+func TestQualifyTablesOption(t *testing.T) {
+	svc := newFakeService()
+	options := testOptions()
+	options.Database = "sales"
+	options.QualifyTables = true
+	conn := connectFake(t, svc, options)
+	ctx := context.Background()
+
+	if _, err := conn.Exec("SELECT * FROM orders"); err != nil {
+		t.Fatalf("Exec error: %v", err)
+	}
+	rs, err := conn.QueryContext(WithoutTableQualification(ctx), "SELECT * FROM users")
+	if err != nil {
+		t.Fatalf("QueryContext error: %v", err)
+	}
+	rs.Close(ctx)
+
+	statements := svc.executed()
+	want := []string{"SELECT * FROM `sales`.orders", "SELECT * FROM users"}
+	got := statements[len(statements)-2:]
+	if got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	options = DefaultOptions
+	options.QualifyTables = true
+	if err := options.Validate(); err == nil {
+		t.Error("expected QualifyTables without Database to be rejected")
+	}
+}
@@ -0,0 +1,43 @@
+package hive
+
+import (
+	"context"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// Querier is the part of Connection most code needs, so that callers
+// can depend on it and substitute a mock in their tests. Connection
+// implements it; construct one with the Connect variants or Open.
+type Querier interface {
+	Query(query string) (RowSet, error)
+	QueryContext(ctx context.Context, query string) (RowSet, error)
+	Exec(query string) (*inf.TExecuteStatementResp, error)
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+var _ Querier = (*Connection)(nil)
+
+// QueryContext issues a query like Query, with ctx bounding the submit.
+func (c *Connection) QueryContext(ctx context.Context, query string) (RowSet, error) {
+	executeReq := inf.NewTExecuteStatementReq()
+	executeReq.Statement = query
+
+	rs, err := c.submit(ctx, executeReq)
+	if err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+// Ping checks that the session is usable by asking the server for its
+// name with GetInfo. With Options.LazyConnect it opens the session
+// first.
+func (c *Connection) Ping(ctx context.Context) error {
+	if err := c.ensureSession(ctx); err != nil {
+		return err
+	}
+	_, err := c.getInfo(ctx, inf.TGetInfoType_CLI_SERVER_NAME)
+	return err
+}
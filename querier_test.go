@@ -0,0 +1,41 @@
+package hive
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestPing(t *testing.T) {
+	svc := newFakeService()
+	var infoType inf.TGetInfoType
+	healthy := true
+	name := "Hive"
+	svc.onGetInfo = func(req *inf.TGetInfoReq) (*inf.TGetInfoResp, error) {
+		infoType = req.InfoType
+		if !healthy {
+			return &inf.TGetInfoResp{Status: errorStatus("Invalid SessionHandle"), InfoValue: &inf.TGetInfoValue{StringValue: &name}}, nil
+		}
+		return &inf.TGetInfoResp{Status: okStatus(), InfoValue: &inf.TGetInfoValue{StringValue: &name}}, nil
+	}
+	var q Querier = connectFake(t, svc, testOptions())
+
+	ctx := context.Background()
+	if err := q.Ping(ctx); err != nil {
+		t.Fatalf("Ping error: %v", err)
+	}
+	if infoType != inf.TGetInfoType_CLI_SERVER_NAME {
+		t.Errorf("expected a CLI_SERVER_NAME request, got %v", infoType)
+	}
+
+	healthy = false
+	if err := q.Ping(ctx); err == nil {
+		t.Error("expected Ping to report the failed GetInfo")
+	}
+
+	q.Close()
+	if err := q.Ping(ctx); err == nil {
+		t.Error("expected Ping on a closed connection to fail")
+	}
+}
@@ -0,0 +1,60 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// ErrTooManyRows is returned by QueryAll when the result has more rows
+// than Options.MaxResultRows.
+var ErrTooManyRows = errors.New("hive: result exceeds MaxResultRows")
+
+// QueryAll runs query and returns all of its rows, as from NextValues,
+// with the result schema. Submitting, waiting, fetching and closing the
+// operation are all bounded by ctx: if it ends first, the operation is
+// cancelled on the server and the context's error returned.
+//
+// The whole result is held in memory, so QueryAll suits small results
+// such as lookups and scripts. Set Options.MaxResultRows to fail with
+// ErrTooManyRows rather than exhaust memory when a query returns more
+// than expected; use Next or StreamBatches for large results.
+func (c *Connection) QueryAll(ctx context.Context, query string) ([][]interface{}, []Column, error) {
+	executeReq := inf.NewTExecuteStatementReq()
+	executeReq.Statement = query
+	executeReq.RunAsync = true
+
+	rs, err := c.submit(ctx, executeReq)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Close cancels the operation first if it is still running.
+	defer rs.Close(context.WithoutCancel(ctx))
+
+	columns, err := rs.Schema(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var rows [][]interface{}
+	for {
+		values, err := rs.NextValues(ctx)
+		if err == io.EOF {
+			return rows, columns, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if max := c.options.MaxResultRows; max > 0 && int64(len(rows)) >= max {
+			return nil, nil, fmt.Errorf("%w: more than %d rows", ErrTooManyRows, max)
+		}
+		row := make([]interface{}, len(values))
+		for i, v := range values {
+			row[i] = v
+		}
+		rows = append(rows, row)
+	}
+}
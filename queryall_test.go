@@ -0,0 +1,66 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestQueryAll(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("name", inf.TTypeId_STRING_TYPE, 1)}
+	svc.batches = []*inf.TRowSet{stringBatch("a", "b"), stringBatch("c")}
+	conn := connectFake(t, svc, testOptions())
+
+	rows, columns, err := conn.QueryAll(context.Background(), "SELECT name FROM t")
+	if err != nil {
+		t.Fatalf("QueryAll error: %v", err)
+	}
+	if want := [][]interface{}{{"a"}, {"b"}, {"c"}}; !reflect.DeepEqual(rows, want) {
+		t.Errorf("expected %v, got %v", want, rows)
+	}
+	if len(columns) != 1 || columns[0].Name != "name" {
+		t.Errorf("expected the name column, got %+v", columns)
+	}
+	if len(svc.closes) != 1 {
+		t.Errorf("expected the operation to be closed, got %d closes", len(svc.closes))
+	}
+}
+
+func TestQueryAllMaxResultRows(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("name", inf.TTypeId_STRING_TYPE, 1)}
+	svc.batches = []*inf.TRowSet{stringBatch("a", "b"), stringBatch("c")}
+	options := testOptions()
+	options.MaxResultRows = 2
+	conn := connectFake(t, svc, options)
+
+	if _, _, err := conn.QueryAll(context.Background(), "SELECT name FROM t"); !errors.Is(err, ErrTooManyRows) {
+		t.Errorf("expected ErrTooManyRows, got %v", err)
+	}
+	if len(svc.closes) != 1 {
+		t.Errorf("expected the operation to be closed, got %d closes", len(svc.closes))
+	}
+}
+
+func TestQueryAllTimeout(t *testing.T) {
+	svc := newFakeService()
+	running := inf.TOperationState_RUNNING_STATE
+	svc.onStatus = func(*inf.TGetOperationStatusReq) (*inf.TGetOperationStatusResp, error) {
+		return &inf.TGetOperationStatusResp{Status: okStatus(), OperationState: &running}, nil
+	}
+	conn := connectFake(t, svc, testOptions())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, _, err := conn.QueryAll(ctx, "SELECT slow()"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a deadline error, got %v", err)
+	}
+	if len(svc.cancels) != 1 || len(svc.closes) != 1 {
+		t.Errorf("expected 1 cancel and 1 close, got %d and %d", len(svc.cancels), len(svc.closes))
+	}
+}
@@ -0,0 +1,183 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/apache/thrift/lib/go/thrift"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// QueryID returns the server's query ID for the operation, the one that
+// appears in HiveServer2 and YARN logs. Servers older than Hive 2.3 don't
+// implement GetQueryId; for those, and for operations not tracked by a
+// Connection, the operation GUID is returned instead.
+func (r *rowSet) QueryID(ctx context.Context) (string, error) {
+	if r.conn == nil || r.conn.calls == nil {
+		return operationID(r.operation), nil
+	}
+
+	args := getQueryIDArgs{Req: &getQueryIDReq{OperationHandle: r.operation}}
+	var result getQueryIDResult
+	if _, err := r.conn.calls.Call(ctx, "GetQueryId", &args, &result); err != nil {
+		var appErr thrift.TApplicationException
+		if errors.As(err, &appErr) && appErr.TypeId() == thrift.UNKNOWN_METHOD {
+			return operationID(r.operation), nil
+		}
+		return "", fmt.Errorf("Error getting query ID: %v", err)
+	}
+	if result.Success == nil {
+		return "", errors.New("GetQueryId returned no result")
+	}
+	return result.Success.QueryID, nil
+}
+
+// The GetQueryId call postdates the IDL inf was generated from, so its
+// structs are written out here:
+//
+//	struct TGetQueryIdReq { 1: required TOperationHandle operationHandle }
+//	struct TGetQueryIdResp { 1: required string queryId }
+type getQueryIDReq struct {
+	OperationHandle *inf.TOperationHandle
+}
+
+type getQueryIDResp struct {
+	QueryID string
+}
+
+type getQueryIDArgs struct {
+	Req *getQueryIDReq
+}
+
+type getQueryIDResult struct {
+	Success *getQueryIDResp
+}
+
+func (p *getQueryIDReq) Write(ctx context.Context, oprot thrift.TProtocol) error {
+	return writeStruct(ctx, oprot, "TGetQueryIdReq", func() error {
+		return writeStructField(ctx, oprot, "operationHandle", 1, p.OperationHandle)
+	})
+}
+
+func (p *getQueryIDReq) Read(ctx context.Context, iprot thrift.TProtocol) error {
+	return readStruct(ctx, iprot, func(id int16, typ thrift.TType) (bool, error) {
+		if id != 1 || typ != thrift.STRUCT {
+			return false, nil
+		}
+		p.OperationHandle = inf.NewTOperationHandle()
+		return true, p.OperationHandle.Read(ctx, iprot)
+	})
+}
+
+func (p *getQueryIDResp) Write(ctx context.Context, oprot thrift.TProtocol) error {
+	return writeStruct(ctx, oprot, "TGetQueryIdResp", func() error {
+		if err := oprot.WriteFieldBegin(ctx, "queryId", thrift.STRING, 1); err != nil {
+			return err
+		}
+		if err := oprot.WriteString(ctx, p.QueryID); err != nil {
+			return err
+		}
+		return oprot.WriteFieldEnd(ctx)
+	})
+}
+
+func (p *getQueryIDResp) Read(ctx context.Context, iprot thrift.TProtocol) error {
+	return readStruct(ctx, iprot, func(id int16, typ thrift.TType) (bool, error) {
+		if id != 1 || typ != thrift.STRING {
+			return false, nil
+		}
+		v, err := iprot.ReadString(ctx)
+		p.QueryID = v
+		return true, err
+	})
+}
+
+func (p *getQueryIDArgs) Write(ctx context.Context, oprot thrift.TProtocol) error {
+	return writeStruct(ctx, oprot, "GetQueryId_args", func() error {
+		return writeStructField(ctx, oprot, "req", 1, p.Req)
+	})
+}
+
+func (p *getQueryIDArgs) Read(ctx context.Context, iprot thrift.TProtocol) error {
+	return readStruct(ctx, iprot, func(id int16, typ thrift.TType) (bool, error) {
+		if id != 1 || typ != thrift.STRUCT {
+			return false, nil
+		}
+		p.Req = &getQueryIDReq{}
+		return true, p.Req.Read(ctx, iprot)
+	})
+}
+
+func (p *getQueryIDResult) Write(ctx context.Context, oprot thrift.TProtocol) error {
+	return writeStruct(ctx, oprot, "GetQueryId_result", func() error {
+		if p.Success == nil {
+			return nil
+		}
+		return writeStructField(ctx, oprot, "success", 0, p.Success)
+	})
+}
+
+func (p *getQueryIDResult) Read(ctx context.Context, iprot thrift.TProtocol) error {
+	return readStruct(ctx, iprot, func(id int16, typ thrift.TType) (bool, error) {
+		if id != 0 || typ != thrift.STRUCT {
+			return false, nil
+		}
+		p.Success = &getQueryIDResp{}
+		return true, p.Success.Read(ctx, iprot)
+	})
+}
+
+func writeStruct(ctx context.Context, oprot thrift.TProtocol, name string, fields func() error) error {
+	if err := oprot.WriteStructBegin(ctx, name); err != nil {
+		return err
+	}
+	if err := fields(); err != nil {
+		return thrift.PrependError(name+" write error: ", err)
+	}
+	if err := oprot.WriteFieldStop(ctx); err != nil {
+		return err
+	}
+	return oprot.WriteStructEnd(ctx)
+}
+
+func writeStructField(ctx context.Context, oprot thrift.TProtocol, name string, id int16, v thrift.TStruct) error {
+	if err := oprot.WriteFieldBegin(ctx, name, thrift.STRUCT, id); err != nil {
+		return err
+	}
+	if err := v.Write(ctx, oprot); err != nil {
+		return err
+	}
+	return oprot.WriteFieldEnd(ctx)
+}
+
+// readStruct reads a struct, handing each field to readField. Fields it
+// doesn't consume are skipped.
+func readStruct(ctx context.Context, iprot thrift.TProtocol, readField func(id int16, typ thrift.TType) (bool, error)) error {
+	if _, err := iprot.ReadStructBegin(ctx); err != nil {
+		return err
+	}
+	for {
+		_, typ, id, err := iprot.ReadFieldBegin(ctx)
+		if err != nil {
+			return err
+		}
+		if typ == thrift.STOP {
+			break
+		}
+		ok, err := readField(id, typ)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			if err := iprot.Skip(ctx, typ); err != nil {
+				return err
+			}
+		}
+		if err := iprot.ReadFieldEnd(ctx); err != nil {
+			return err
+		}
+	}
+	return iprot.ReadStructEnd(ctx)
+}
@@ -0,0 +1,98 @@
+package hive
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// QueryOptions gathers the per-query settings otherwise applied one by
+// one, for Connection.QueryWith and, as connection-wide defaults,
+// Options.QueryDefaults.
+type QueryOptions struct {
+	// FetchSize, if positive, is the MaxRows of every fetch, as set by
+	// RowSet.SetFetchSize. Unset, Options.BatchSize applies.
+	FetchSize int64
+	// Timeout, if positive, bounds the query's execution as for
+	// QueryWithTimeout, and QueryWith then waits for the query to
+	// complete.
+	Timeout time.Duration
+	// ResourceQueue, if set, is the YARN queue the query runs in, see
+	// WithResourceQueue.
+	ResourceQueue string
+	// Limits are resource limits for the query, see WithQueryLimits.
+	Limits map[string]string
+	// SQLComment, if set, is put in front of the statement, see
+	// WithSQLComment.
+	SQLComment string
+}
+
+// QueryWith runs query with opts. Each field of opts left at its zero
+// value is taken from Options.QueryDefaults instead, and, if that is
+// unset too, the Connection's usual behaviour applies: BatchSize
+// fetches, no timeout, the session's queue and limits and no comment.
+// Limits are merged key by key, opts' values overriding the defaults'.
+// Settings a ctx brings, e.g. from WithResourceQueue, give way to the
+// merged ones.
+//
+// Without a Timeout, QueryWith returns once the query is submitted, as
+// QueryContext does.
+func (c *Connection) QueryWith(ctx context.Context, query string, opts QueryOptions) (RowSet, error) {
+	opts = opts.merge(c.options.QueryDefaults)
+	if opts.FetchSize < 0 {
+		return nil, fmt.Errorf("Invalid fetch size %d: must be positive", opts.FetchSize)
+	}
+	if opts.ResourceQueue != "" {
+		ctx = WithResourceQueue(ctx, opts.ResourceQueue)
+	}
+	if len(opts.Limits) > 0 {
+		ctx = WithQueryLimits(ctx, opts.Limits)
+	}
+	if opts.SQLComment != "" {
+		ctx = WithSQLComment(ctx, opts.SQLComment)
+	}
+
+	var (
+		rs  RowSet
+		err error
+	)
+	if opts.Timeout > 0 {
+		rs, err = c.QueryWithTimeout(ctx, query, opts.Timeout)
+	} else {
+		rs, err = c.QueryContext(ctx, query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if opts.FetchSize > 0 {
+		rs.SetFetchSize(opts.FetchSize)
+	}
+	return rs, nil
+}
+
+// merge fills the unset fields of o from defaults.
+func (o QueryOptions) merge(defaults QueryOptions) QueryOptions {
+	if o.FetchSize == 0 {
+		o.FetchSize = defaults.FetchSize
+	}
+	if o.Timeout == 0 {
+		o.Timeout = defaults.Timeout
+	}
+	if o.ResourceQueue == "" {
+		o.ResourceQueue = defaults.ResourceQueue
+	}
+	if o.SQLComment == "" {
+		o.SQLComment = defaults.SQLComment
+	}
+	if len(defaults.Limits) > 0 {
+		limits := make(map[string]string, len(defaults.Limits)+len(o.Limits))
+		for key, value := range defaults.Limits {
+			limits[key] = value
+		}
+		for key, value := range o.Limits {
+			limits[key] = value
+		}
+		o.Limits = limits
+	}
+	return o
+}
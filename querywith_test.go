@@ -0,0 +1,128 @@
+package hive
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestQueryWith(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	svc.results = map[string][]*inf.TRowSet{
+		"SET mapreduce.job.queuename": {stringBatch("mapreduce.job.queuename=default")},
+		"SET tez.queue.name":          {stringBatch("tez.queue.name=default")},
+		"SET hive.tez.container.size": {stringBatch("hive.tez.container.size=-1")},
+		"SET tez.runtime.io.sort.mb":  {stringBatch("tez.runtime.io.sort.mb=100")},
+	}
+	svc.batches = []*inf.TRowSet{stringBatch("a")}
+	options := testOptions()
+	options.QueryDefaults = QueryOptions{
+		FetchSize:     50,
+		ResourceQueue: "etl",
+		Limits:        map[string]string{"hive.tez.container.size": "4096"},
+		SQLComment:    "job=nightly",
+	}
+	conn := connectFake(t, svc, options)
+
+	run := func(opts QueryOptions) (statements []string, fetchSize int64) {
+		t.Helper()
+		sent := len(svc.executed())
+		rs, err := conn.QueryWith(ctx, "SELECT * FROM t", opts)
+		if err != nil {
+			t.Fatalf("QueryWith error: %v", err)
+		}
+		for rs.Next() {
+		}
+		if err := rs.Err(); err != nil {
+			t.Fatalf("Next error: %v", err)
+		}
+		rs.Close(ctx)
+		return svc.executed()[sent:], svc.fetches[len(svc.fetches)-1].MaxRows
+	}
+
+	t.Run("inherit", func(t *testing.T) {
+		statements, fetchSize := run(QueryOptions{})
+		expected := []string{
+			"SET hive.tez.container.size",
+			"SET hive.tez.container.size=4096",
+			"SET mapreduce.job.queuename",
+			"SET mapreduce.job.queuename=etl",
+			"SET tez.queue.name",
+			"SET tez.queue.name=etl",
+			"/* job=nightly */ SELECT * FROM t",
+			"SET tez.queue.name=default",
+			"SET mapreduce.job.queuename=default",
+			"SET hive.tez.container.size=-1",
+		}
+		if !reflect.DeepEqual(statements, expected) {
+			t.Errorf("expected statements\n%q\ngot\n%q", expected, statements)
+		}
+		if fetchSize != 50 {
+			t.Errorf("expected the default fetch size 50, got %d", fetchSize)
+		}
+	})
+
+	t.Run("override", func(t *testing.T) {
+		statements, fetchSize := run(QueryOptions{
+			FetchSize:     7,
+			ResourceQueue: "adhoc",
+			Limits:        map[string]string{"tez.runtime.io.sort.mb": "512"},
+			SQLComment:    "job=manual",
+		})
+		expected := []string{
+			"SET hive.tez.container.size",
+			"SET hive.tez.container.size=4096",
+			"SET mapreduce.job.queuename",
+			"SET mapreduce.job.queuename=adhoc",
+			"SET tez.queue.name",
+			"SET tez.queue.name=adhoc",
+			"SET tez.runtime.io.sort.mb",
+			"SET tez.runtime.io.sort.mb=512",
+			"/* job=manual */ SELECT * FROM t",
+			"SET tez.runtime.io.sort.mb=100",
+			"SET tez.queue.name=default",
+			"SET mapreduce.job.queuename=default",
+			"SET hive.tez.container.size=-1",
+		}
+		if !reflect.DeepEqual(statements, expected) {
+			t.Errorf("expected statements\n%q\ngot\n%q", expected, statements)
+		}
+		if fetchSize != 7 {
+			t.Errorf("expected the fetch size 7, got %d", fetchSize)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		executes := len(svc.executes)
+		rs, err := conn.QueryWith(ctx, "SELECT * FROM t", QueryOptions{Timeout: 1500 * time.Millisecond})
+		if err != nil {
+			t.Fatalf("QueryWith error: %v", err)
+		}
+		rs.Close(ctx)
+		var timeouts []int64
+		for _, req := range svc.executes[executes:] {
+			if req.Statement == "/* job=nightly */ SELECT * FROM t" {
+				timeouts = append(timeouts, req.QueryTimeout)
+			}
+		}
+		if !reflect.DeepEqual(timeouts, []int64{2}) {
+			t.Errorf("expected the query sent with a 2s QueryTimeout, got %v", timeouts)
+		}
+	})
+}
+
+func TestQueryOptionsMerge(t *testing.T) {
+	defaults := QueryOptions{FetchSize: 50, Timeout: time.Minute, Limits: map[string]string{"a": "1", "b": "2"}}
+	got := QueryOptions{Timeout: time.Second, Limits: map[string]string{"b": "3"}}.merge(defaults)
+	expected := QueryOptions{FetchSize: 50, Timeout: time.Second, Limits: map[string]string{"a": "1", "b": "3"}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+	if defaults.Limits["b"] != "2" {
+		t.Errorf("expected the defaults unchanged, got %v", defaults.Limits)
+	}
+}
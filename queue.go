@@ -0,0 +1,81 @@
+package hive
+
+import (
+	"context"
+	"fmt"
+)
+
+type resourceQueueKey struct{}
+
+// WithResourceQueue returns a context under which statements run in the
+// YARN queue name, for routing a tenant's work without changing the
+// queue of the whole session. Each call that sends a statement, such as
+// Query, Exec or ExecBatch, sets mapreduce.job.queuename and
+// tez.queue.name first, so the queue applies whichever engine runs the
+// statement, and puts back their prior values once it has been
+// submitted, as WithSession does. The settings are session-wide in the
+// meantime, so other goroutines sharing the Connection see them too. A
+// setting the session had not defined is cleared afterwards with RESET
+// <key>; Hive before 3.0 would reset every setting instead, so there the
+// statement fails with ErrResetUnsupported.
+//
+// Queue names may hold letters, digits, '_', '-' and '.', as in
+// "root.etl"; others fail the statement.
+func WithResourceQueue(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, resourceQueueKey{}, name)
+}
+
+// queueKeys are the settings that pick the queue, for MapReduce and Tez.
+var queueKeys = []string{"mapreduce.job.queuename", "tez.queue.name"}
+
+// inStatementScope runs fn, and reports true, if ctx carries a queue
+// from WithResourceQueue, limits from WithQueryLimits or a priority from
+// WithPriority, with their settings applied by WithSession; fn sees a
+// ctx without them.
+func (c *Connection) inStatementScope(ctx context.Context, fn func(ctx context.Context) error) (bool, error) {
+	queue, hasQueue := ctx.Value(resourceQueueKey{}).(string)
+	limits, hasLimits := ctx.Value(queryLimitsKey{}).(map[string]string)
+	priority, hasPriority := ctx.Value(priorityKey{}).(map[string]string)
+	if !hasQueue && !hasLimits && !hasPriority {
+		return false, nil
+	}
+	if hasPriority && priority == nil {
+		return true, fmt.Errorf("Invalid priority: not one of PriorityLow, PriorityNormal or PriorityHigh")
+	}
+	overrides := make(map[string]string, len(queueKeys)+len(limits)+len(priority))
+	if hasQueue {
+		if !validQueueName(queue) {
+			return true, fmt.Errorf("Invalid resource queue %q", queue)
+		}
+		for _, key := range queueKeys {
+			overrides[key] = queue
+		}
+	}
+	if err := checkQueryLimits(limits); err != nil {
+		return true, err
+	}
+	for key, value := range limits {
+		overrides[key] = value
+	}
+	for key, value := range priority {
+		overrides[key] = value
+	}
+	ctx = context.WithValue(ctx, resourceQueueKey{}, nil)
+	ctx = context.WithValue(ctx, queryLimitsKey{}, nil)
+	ctx = context.WithValue(ctx, priorityKey{}, nil)
+	return true, c.WithSession(ctx, overrides, func(*Connection) error {
+		return fn(ctx)
+	})
+}
+
+func validQueueName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if c := name[i]; isWordBoundary(name, i) && c != '-' && c != '.' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,57 @@
+package hive
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestWithResourceQueue(t *testing.T) {
+	svc := newFakeService()
+	svc.reportVersion("3.1.3")
+	svc.results = map[string][]*inf.TRowSet{
+		"SET mapreduce.job.queuename": {stringBatch("mapreduce.job.queuename=default")},
+		"SET tez.queue.name":          {stringBatch("tez.queue.name is undefined")},
+	}
+	conn := connectFake(t, svc, testOptions())
+	ctx := WithResourceQueue(context.Background(), "root.etl")
+
+	rs, err := conn.QueryContext(ctx, "SELECT * FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	rs.Close(ctx)
+	if _, err := conn.Query("SELECT 1"); err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+
+	expected := []string{
+		"SET mapreduce.job.queuename",
+		"SET mapreduce.job.queuename=root.etl",
+		"SET tez.queue.name",
+		"SET tez.queue.name=root.etl",
+		"SELECT * FROM t",
+		"RESET tez.queue.name",
+		"SET mapreduce.job.queuename=default",
+		"SELECT 1",
+	}
+	if got := svc.executed(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected statements\n%q\ngot\n%q", expected, got)
+	}
+}
+
+func TestWithResourceQueueRejectsInvalidName(t *testing.T) {
+	svc := newFakeService()
+	conn := connectFake(t, svc, testOptions())
+	sent := len(svc.executed())
+
+	ctx := WithResourceQueue(context.Background(), "etl;DROP")
+	if _, err := conn.ExecBatch(ctx, "INSERT INTO t VALUES (?)", [][]interface{}{{1}}); err == nil {
+		t.Error("expected an invalid queue name to be rejected")
+	}
+	if got := svc.executed()[sent:]; len(got) != 0 {
+		t.Errorf("expected nothing sent, got %q", got)
+	}
+}
@@ -0,0 +1,68 @@
+package hive
+
+import (
+	"context"
+	"time"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// IsQueued reports whether the operation is waiting to run: PENDING, as
+// HiveServer2 reports an asynchronous statement waiting for a thread of
+// its background pool (hive.server2.async.exec.threads), or INITIALIZED,
+// before it has been handed to the pool. Waits further down, such as
+// for YARN to grant a Tez application its containers, are reported as
+// RUNNING.
+func (s Status) IsQueued() bool {
+	if s.state == nil {
+		return false
+	}
+	return *s.state == inf.TOperationState_PENDING_STATE || *s.state == inf.TOperationState_INITIALIZED_STATE
+}
+
+// IsQueued polls the operation and reports whether it is still waiting
+// to run, see Status.IsQueued.
+func (r *rowSet) IsQueued(ctx context.Context) (bool, error) {
+	status, err := r.poll(ctx)
+	if err != nil {
+		return false, err
+	}
+	return status.IsQueued(), nil
+}
+
+// observe notes when the operation was first seen running and complete,
+// for phaseTimes. statusMu must be held.
+func (r *rowSet) observe(state inf.TOperationState, at time.Time) {
+	status := Status{state: &state}
+	switch {
+	case status.IsQueued():
+		r.sawQueued = true
+	case r.runningAt.IsZero():
+		r.runningAt = at
+	}
+	if status.IsComplete() && r.completedAt.IsZero() {
+		r.completedAt = at
+	}
+}
+
+// phaseTimes splits the operation's time since submission into the time
+// it was queued and the time it has been running, up to completion or
+// up to now. The boundaries are when polls first saw it running and
+// complete, so they lag by up to PollIntervalSeconds. An operation
+// never seen queued counts as running from the start.
+func (r *rowSet) phaseTimes() (queued, running time.Duration) {
+	r.statusMu.Lock()
+	defer r.statusMu.Unlock()
+	end := r.completedAt
+	if end.IsZero() {
+		end = r.options.clock().Now()
+	}
+	start := r.started
+	if r.sawQueued {
+		if r.runningAt.IsZero() {
+			return end.Sub(r.started), 0
+		}
+		start = r.runningAt
+	}
+	return start.Sub(r.started), end.Sub(start)
+}
@@ -0,0 +1,81 @@
+package hive
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestQueuedTime(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	svc.states = []inf.TOperationState{
+		inf.TOperationState_PENDING_STATE,
+		inf.TOperationState_PENDING_STATE,
+		inf.TOperationState_RUNNING_STATE,
+		inf.TOperationState_FINISHED_STATE,
+	}
+	clock := newFakeClock()
+	options := testOptions()
+	options.testClock = clock
+	options.PollIntervalSeconds = 1
+	conn := connectFake(t, svc, options)
+
+	rs, err := conn.Query("SELECT count(*) FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	defer rs.Close(ctx)
+	if queued, err := rs.IsQueued(ctx); err != nil || !queued {
+		t.Errorf("expected the operation queued, got %v (err %v)", queued, err)
+	}
+	clock.advance(time.Second)
+	if _, err := rs.Wait(); err != nil {
+		t.Fatalf("Wait error: %v", err)
+	}
+	if queued, err := rs.IsQueued(ctx); err != nil || queued {
+		t.Errorf("expected the finished operation not queued, got %v (err %v)", queued, err)
+	}
+
+	// Polls saw PENDING at 0s and 1s, RUNNING at 2s and FINISHED at 3s.
+	svc.onStatus = func(req *inf.TGetOperationStatusReq) (*inf.TGetOperationStatusResp, error) {
+		state := inf.TOperationState_FINISHED_STATE
+		return &inf.TGetOperationStatusResp{Status: okStatus(), OperationState: &state, OperationStarted: thrift.Int64Ptr(1)}, nil
+	}
+	clock.advance(time.Minute)
+	s, err := rs.Summary(ctx)
+	if err != nil {
+		t.Fatalf("Summary error: %v", err)
+	}
+	if s.QueuedTime != 2*time.Second || s.RunningTime != time.Second {
+		t.Errorf("expected 2s queued and 1s running, got %v and %v", s.QueuedTime, s.RunningTime)
+	}
+}
+
+func TestNeverQueued(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	svc.states = []inf.TOperationState{inf.TOperationState_RUNNING_STATE, inf.TOperationState_FINISHED_STATE}
+	clock := newFakeClock()
+	options := testOptions()
+	options.testClock = clock
+	options.PollIntervalSeconds = 2
+	conn := connectFake(t, svc, options)
+
+	rs, err := conn.Query("SELECT count(*) FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	defer rs.Close(ctx)
+	if _, err := rs.Wait(); err != nil {
+		t.Fatalf("Wait error: %v", err)
+	}
+	queued, running := rs.(*rowSet).phaseTimes()
+	if queued != 0 || running != 2*time.Second {
+		t.Errorf("expected no queued time and 2s running, got %v and %v", queued, running)
+	}
+}
@@ -0,0 +1,142 @@
+package hive
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Formats accepted by RowSet.Reader.
+const (
+	// FormatCSV writes a header line with the column names, then one
+	// RFC 4180 record per row. NULL is written as an empty field.
+	FormatCSV = "csv"
+	// FormatJSONL writes one JSON object per line, keyed by column name.
+	// HiveServer2 qualifies the names of a join's columns as alias.column
+	// unless hive.resultset.use.unique.column.names is off; of columns
+	// that still share a name, later ones are keyed with a suffix, as in
+	// id, id_2.
+	FormatJSONL = "jsonl"
+)
+
+// rowReader encodes rows into a buffer as the reader is drained,
+// fetching another batch from the server only when the current one has
+// been consumed.
+type rowReader struct {
+	ctx    context.Context
+	rs     *rowSet
+	format string
+	buf    bytes.Buffer
+	csv    *csv.Writer
+	header bool
+	done   bool
+	// keys are the JSONL object keys, one per column.
+	keys []string
+	// rows counts the rows encoded, for the progress callback.
+	rows     int64
+	progress func(int64)
+}
+
+// Reader returns the remaining rows encoded as format. Rows are fetched
+// lazily as the reader is consumed, so a slow consumer pauses the
+// fetches. Closing the reader closes the operation. The callback set with
+// WithWriteProgress is called with the rows encoded once per batch.
+func (r *rowSet) Reader(ctx context.Context, format string) (io.ReadCloser, error) {
+	switch format {
+	case FormatCSV, FormatJSONL:
+	default:
+		return nil, fmt.Errorf("Unsupported row format %q", format)
+	}
+
+	rr := &rowReader{ctx: ctx, rs: r, format: format, progress: writeProgress(ctx)}
+	if format == FormatCSV {
+		rr.csv = csv.NewWriter(&rr.buf)
+	}
+	return rr, nil
+}
+
+func (rr *rowReader) Read(p []byte) (int, error) {
+	for rr.buf.Len() == 0 {
+		if rr.done {
+			return 0, io.EOF
+		}
+		if err := rr.fill(); err != nil {
+			return 0, err
+		}
+	}
+	return rr.buf.Read(p)
+}
+
+// fill encodes the next row, preceded by the header on the first call.
+func (rr *rowReader) fill() error {
+	if !rr.header {
+		rr.header = true
+		if err := rr.rs.waitForSuccess(rr.ctx); err != nil {
+			return err
+		}
+		if rr.csv != nil {
+			if err := rr.csv.Write(rr.rs.Columns()); err != nil {
+				return err
+			}
+			rr.csv.Flush()
+			return rr.csv.Error()
+		}
+	}
+
+	if !rr.rs.next(rr.ctx) {
+		rr.done = true
+		return rr.rs.Err()
+	}
+	rr.rows++
+	if rr.rs.offset == rr.rs.batchLength() {
+		defer rr.progress(rr.rows)
+	}
+
+	switch rr.format {
+	case FormatCSV:
+		record := make([]string, len(rr.rs.nextRow))
+		for i, v := range rr.rs.nextRow {
+			record[i] = formatField(v)
+		}
+		if err := rr.csv.Write(record); err != nil {
+			return err
+		}
+		rr.csv.Flush()
+		return rr.csv.Error()
+	default:
+		if rr.keys == nil {
+			rr.keys = uniqueNames(rr.rs.Columns())
+		}
+		obj := make(map[string]interface{}, len(rr.keys))
+		for i, v := range rr.rs.nextRow {
+			if b, ok := v.([]byte); ok {
+				v = string(b)
+			}
+			obj[rr.keys[i]] = v
+		}
+		// Encode appends the newline that ends the line.
+		return json.NewEncoder(&rr.buf).Encode(obj)
+	}
+}
+
+func (rr *rowReader) Close() error {
+	rr.done = true
+	return rr.rs.Close(rr.ctx)
+}
+
+// formatField renders a cell as text, with NULL as the empty string.
+func formatField(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case []byte:
+		return string(t)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,98 @@
+package hive
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func readerService() *fakeService {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{
+		columnDesc("id", inf.TTypeId_INT_TYPE, 1),
+		columnDesc("name", inf.TTypeId_STRING_TYPE, 2),
+	}
+	batch := func(ids []int32, names []string, nulls []byte) *inf.TRowSet {
+		return &inf.TRowSet{Columns: []*inf.TColumn{
+			{I32Val: &inf.TI32Column{Values: ids, Nulls: []byte{}}},
+			{StringVal: &inf.TStringColumn{Values: names, Nulls: nulls}},
+		}}
+	}
+	svc.batches = []*inf.TRowSet{
+		batch([]int32{1, 2}, []string{"a,b", ""}, []byte{0x02}),
+		batch([]int32{3}, []string{`say "hi"`}, []byte{}),
+	}
+	return svc
+}
+
+func TestReaderFormats(t *testing.T) {
+	expected := map[string]string{
+		FormatCSV:   "id,name\n1,\"a,b\"\n2,\n3,\"say \"\"hi\"\"\"\n",
+		FormatJSONL: `{"id":1,"name":"a,b"}` + "\n" + `{"id":2,"name":null}` + "\n" + `{"id":3,"name":"say \"hi\""}` + "\n",
+	}
+
+	for format, want := range expected {
+		svc := readerService()
+		conn := connectFake(t, svc, testOptions())
+		rs, err := conn.Query("SELECT id, name FROM t")
+		if err != nil {
+			t.Fatalf("Query error: %v", err)
+		}
+
+		r, err := rs.Reader(context.Background(), format)
+		if err != nil {
+			t.Fatalf("Reader error: %v", err)
+		}
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("%s: ReadAll error: %v", format, err)
+		}
+		if string(got) != want {
+			t.Errorf("%s: expected %q, got %q", format, want, got)
+		}
+
+		if err := r.Close(); err != nil {
+			t.Errorf("Close error: %v", err)
+		}
+		if len(svc.closes) != 1 {
+			t.Errorf("%s: expected Close to close the operation", format)
+		}
+	}
+}
+
+func TestReaderFetchesLazily(t *testing.T) {
+	svc := readerService()
+	conn := connectFake(t, svc, testOptions())
+	rs, err := conn.Query("SELECT id, name FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+
+	r, err := rs.Reader(context.Background(), FormatCSV)
+	if err != nil {
+		t.Fatalf("Reader error: %v", err)
+	}
+	defer r.Close()
+
+	line, err := bufio.NewReaderSize(r, 16).ReadString('\n')
+	if err != nil || line != "id,name\n" {
+		t.Fatalf("expected the header line, got %q, %v", line, err)
+	}
+	if n := len(svc.fetches); n > 1 {
+		t.Errorf("expected at most one fetch after reading the first row, got %d", n)
+	}
+}
+
+func TestReaderRejectsUnknownFormat(t *testing.T) {
+	conn := connectFake(t, newFakeService(), testOptions())
+	rs, err := conn.Query("SELECT 1")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	if _, err := rs.Reader(context.Background(), "xml"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
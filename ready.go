@@ -0,0 +1,46 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Backoff bounds for WaitReady.
+const (
+	waitReadyInitialBackoff = 500 * time.Millisecond
+	waitReadyMaxBackoff     = 10 * time.Second
+)
+
+// WaitReady runs SELECT 1 until it succeeds, pausing between attempts
+// with a backoff that doubles from half a second up to ten seconds. It
+// is meant for servers that accept sessions before they can run queries,
+// e.g. while LLAP daemons start. If ctx ends first, the context's error
+// is returned along with the last failure.
+func (c *Connection) WaitReady(ctx context.Context) error {
+	c.openMu.Lock()
+	closed := c.closed
+	c.openMu.Unlock()
+	if closed {
+		return errors.New("Session is closed")
+	}
+
+	backoff := waitReadyInitialBackoff
+	for {
+		_, _, err := c.QueryAll(WithUnboundedQuery(ctx), "SELECT 1")
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("Server not ready: %w (last error: %v)", ctx.Err(), err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("Server not ready: %w (last error: %v)", ctx.Err(), err)
+		case <-c.options.clock().After(backoff):
+		}
+		backoff = min(2*backoff, waitReadyMaxBackoff)
+	}
+}
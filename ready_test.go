@@ -0,0 +1,59 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestWaitReady(t *testing.T) {
+	svc := newFakeService()
+	failures := 3
+	svc.onExecute = func(req *inf.TExecuteStatementReq) (*inf.TExecuteStatementResp, error) {
+		svc.mu.Lock()
+		defer svc.mu.Unlock()
+		if failures > 0 {
+			failures--
+			return &inf.TExecuteStatementResp{Status: errorStatus("LLAP daemons are not running")}, nil
+		}
+		return &inf.TExecuteStatementResp{Status: okStatus(), OperationHandle: svc.newOperation(req.Statement)}, nil
+	}
+	clock := newFakeClock()
+	options := testOptions()
+	options.testClock = clock
+	conn := connectFake(t, svc, options)
+
+	if err := conn.WaitReady(context.Background()); err != nil {
+		t.Fatalf("WaitReady error: %v", err)
+	}
+	if statements := svc.executed(); len(statements) != 4 || statements[3] != "SELECT 1" {
+		t.Errorf("expected 4 attempts at SELECT 1, got %q", statements)
+	}
+	expected := []time.Duration{500 * time.Millisecond, time.Second, 2 * time.Second}
+	if slept := clock.slept(); !reflect.DeepEqual(slept, expected) {
+		t.Errorf("expected backoff %v, got %v", expected, slept)
+	}
+}
+
+func TestWaitReadyDeadline(t *testing.T) {
+	svc := newFakeService()
+	svc.onExecute = func(req *inf.TExecuteStatementReq) (*inf.TExecuteStatementResp, error) {
+		return &inf.TExecuteStatementResp{Status: errorStatus("LLAP daemons are not running")}, nil
+	}
+	conn := connectFake(t, svc, testOptions())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := conn.WaitReady(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the deadline error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "last error") {
+		t.Errorf("expected the last failure in the error, got %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package hive
+
+import "github.com/jasonlabz/hive/inf"
+
+// rowColumns turns a row-based result, as sent to sessions below
+// protocol V6, into the per-column cells fetchAll builds from columnar
+// results. Cells without a value are NULL.
+func rowColumns(rows []*inf.TRow) [][]interface{} {
+	width := len(rows[0].GetColVals())
+	cols := make([][]interface{}, width)
+	for i := range cols {
+		cols[i] = make([]interface{}, len(rows))
+	}
+	for j, row := range rows {
+		for i, v := range row.GetColVals() {
+			if i < width {
+				cols[i][j] = columnValue(v)
+			}
+		}
+	}
+	return cols
+}
+
+// columnValue returns the Go value of a row-based cell, using the same
+// types as the columnar decoding, or nil for NULL.
+func columnValue(v *inf.TColumnValue) interface{} {
+	switch {
+	case v.IsSetBoolVal() && v.BoolVal.IsSetValue():
+		return v.BoolVal.GetValue()
+	case v.IsSetByteVal() && v.ByteVal.IsSetValue():
+		return v.ByteVal.GetValue()
+	case v.IsSetI16Val() && v.I16Val.IsSetValue():
+		return v.I16Val.GetValue()
+	case v.IsSetI32Val() && v.I32Val.IsSetValue():
+		return v.I32Val.GetValue()
+	case v.IsSetI64Val() && v.I64Val.IsSetValue():
+		return v.I64Val.GetValue()
+	case v.IsSetDoubleVal() && v.DoubleVal.IsSetValue():
+		return v.DoubleVal.GetValue()
+	case v.IsSetStringVal() && v.StringVal.IsSetValue():
+		return v.StringVal.GetValue()
+	}
+	return nil
+}
+
+func estimateValueBytes(v *inf.TColumnValue) int64 {
+	switch {
+	case v.IsSetStringVal():
+		return int64(len(v.StringVal.GetValue())) + 4
+	case v.IsSetI64Val(), v.IsSetDoubleVal():
+		return 8
+	case v.IsSetI32Val():
+		return 4
+	case v.IsSetI16Val():
+		return 2
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,123 @@
+package hive
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func rowBatch(rows ...[]*inf.TColumnValue) *inf.TRowSet {
+	rs := &inf.TRowSet{Rows: []*inf.TRow{}}
+	for _, vals := range rows {
+		rs.Rows = append(rs.Rows, &inf.TRow{ColVals: vals})
+	}
+	return rs
+}
+
+func i32Value(v int32) *inf.TColumnValue {
+	return &inf.TColumnValue{I32Val: &inf.TI32Value{Value: &v}}
+}
+
+func stringValue(v string) *inf.TColumnValue {
+	return &inf.TColumnValue{StringVal: &inf.TStringValue{Value: &v}}
+}
+
+func TestDefaultClientProtocol(t *testing.T) {
+	columnar := true
+	for name, prefer := range map[string]*bool{"unset": nil, "true": &columnar} {
+		svc := newFakeService()
+		options := testOptions()
+		options.PreferColumnarResults = prefer
+		connectFake(t, svc, options)
+
+		if got := svc.sessions[0].ClientProtocol; got != inf.TProtocolVersion_HIVE_CLI_SERVICE_PROTOCOL_V7 {
+			t.Errorf("PreferColumnarResults %s: expected the session to ask for protocol V7, got %v", name, got)
+		}
+	}
+}
+
+func TestRowBasedResults(t *testing.T) {
+	svc := newFakeService()
+	svc.protocol = inf.TProtocolVersion_HIVE_CLI_SERVICE_PROTOCOL_V5
+	svc.schema = []*inf.TColumnDesc{
+		columnDesc("id", inf.TTypeId_INT_TYPE, 1),
+		columnDesc("name", inf.TTypeId_STRING_TYPE, 2),
+	}
+	svc.batches = []*inf.TRowSet{rowBatch(
+		[]*inf.TColumnValue{i32Value(1), stringValue("a")},
+		[]*inf.TColumnValue{i32Value(2), {StringVal: &inf.TStringValue{}}},
+	)}
+
+	options := testOptions()
+	columnar := false
+	options.PreferColumnarResults = &columnar
+	conn := connectFake(t, svc, options)
+
+	if got := svc.sessions[0].ClientProtocol; got != inf.TProtocolVersion_HIVE_CLI_SERVICE_PROTOCOL_V5 {
+		t.Errorf("expected the session to ask for protocol V5, got %v", got)
+	}
+
+	rs, err := conn.Query("SELECT id, name FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	var got [][]interface{}
+	for rs.Next() {
+		var id int32
+		var name interface{}
+		if err := rs.Scan(&id, &name); err != nil {
+			t.Fatalf("Scan error: %v", err)
+		}
+		got = append(got, []interface{}{id, name})
+	}
+	if want := [][]interface{}{{int32(1), "a"}, {int32(2), nil}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func benchmarkDecode(b *testing.B, batch *inf.TRowSet) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{
+		columnDesc("id", inf.TTypeId_INT_TYPE, 1),
+		columnDesc("name", inf.TTypeId_STRING_TYPE, 2),
+	}
+	svc.batches = []*inf.TRowSet{batch}
+	conn := connectFake(b, svc, testOptions())
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rs, err := conn.Query("SELECT id, name FROM t")
+		if err != nil {
+			b.Fatalf("Query error: %v", err)
+		}
+		for rs.Next() {
+		}
+		rs.Close(context.Background())
+	}
+}
+
+const benchmarkRows = 1000
+
+func BenchmarkDecodeColumnar(b *testing.B) {
+	ids := make([]int32, benchmarkRows)
+	names := make([]string, benchmarkRows)
+	for i := range ids {
+		ids[i] = int32(i)
+		names[i] = "name-" + strconv.Itoa(i)
+	}
+	benchmarkDecode(b, &inf.TRowSet{Columns: []*inf.TColumn{
+		{I32Val: &inf.TI32Column{Values: ids, Nulls: []byte{}}},
+		{StringVal: &inf.TStringColumn{Values: names, Nulls: []byte{}}},
+	}})
+}
+
+func BenchmarkDecodeRows(b *testing.B) {
+	rows := make([][]*inf.TColumnValue, benchmarkRows)
+	for i := range rows {
+		rows[i] = []*inf.TColumnValue{i32Value(int32(i)), stringValue("name-" + strconv.Itoa(i))}
+	}
+	benchmarkDecode(b, rowBatch(rows...))
+}
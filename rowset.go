@@ -2,10 +2,14 @@ package hive
 
 import (
 	"context"
+	"database/sql/driver"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"reflect"
+	"strings"
+	"sync"
 	"time"
 
 	inf "github.com/jasonlabz/hive/inf"
@@ -13,6 +17,19 @@ import (
 	"github.com/apache/thrift/lib/go/thrift"
 )
 
+// ErrOperationLost is returned when the server no longer knows the
+// operation, typically because HiveServer2 restarted. The statement has
+// to be run again; retrying the fetch won't help.
+var ErrOperationLost = errors.New("hive: operation handle is no longer valid on the server")
+
+// ErrTooManyBatches is returned when reading a result would take more
+// FetchResults calls than Options.MaxFetchBatches.
+var ErrTooManyBatches = errors.New("hive: result exceeds MaxFetchBatches")
+
+// ErrRowSetClosed is reported by Next, through Err, and Scan once a
+// RowSet has been aborted.
+var ErrRowSetClosed = errors.New("hive: RowSet is closed")
+
 type rowSet struct {
 	thrift    *inf.TCLIServiceClient
 	operation *inf.TOperationHandle
@@ -20,6 +37,8 @@ type rowSet struct {
 
 	columns    []*inf.TColumnDesc
 	columnStrs []string
+	// schema is handed to Options.RowInterceptor, built on first use.
+	schema []Column
 
 	offset    int
 	rowSet    *inf.TRowSet
@@ -27,12 +46,54 @@ type rowSet struct {
 	ready     bool
 	resultSet [][]interface{}
 	nextRow   []interface{}
+
+	stats RowSetStats
+
+	collectLogs bool
+	logs        []string
+
+	started   time.Time
+	finished  bool
+	err       error
+	spool     *spool
+	integrity integrity
+
+	// batchMetrics has a sample for every batch fetched.
+	batchMetrics []BatchMetric
+	// nextBatch is the adaptive MaxRows for the next fetch, zero until
+	// the first batch has been measured.
+	nextBatch int64
+	// fetchSize is the MaxRows set by SetFetchSize, if any.
+	fetchSize int64
+
+	scrollProbed bool
+	scrollable   bool
+
+	// conn is the Connection tracking this operation, if any.
+	conn      *Connection
+	closed    bool
+	cancelled bool
+
+	// lastUsed is when the operation was last polled or fetched from,
+	// for Options.OperationIdleTimeout; it is guarded by statusMu.
+	lastUsed time.Time
+	// sawQueued, runningAt and completedAt record how polls saw the
+	// operation progress, for QuerySummary's QueuedTime and RunningTime;
+	// they are guarded by statusMu.
+	sawQueued   bool
+	runningAt   time.Time
+	completedAt time.Time
+
+	// sql and lastStatus describe the operation for ListOperations.
+	sql        string
+	statusMu   sync.Mutex
+	lastStatus *Status
 }
 
 // A RowSet represents an asyncronous hive operation. You can
 // Reattach to a previously submitted hive operation if you
-// have a valid thrift client, and the serialized Handle()
-// from the prior operation.
+// have an open Connection to the same server, and the serialized
+// Handle() from the prior operation.
 type RowSet interface {
 	Handle(ctx context.Context) ([]byte, error)
 	Columns() []string
@@ -40,6 +101,109 @@ type RowSet interface {
 	Scan(dest ...interface{}) error
 	Poll() (*Status, error)
 	Wait() (*Status, error)
+	Stats() RowSetStats
+	BatchMetrics() []BatchMetric
+	Schema(ctx context.Context) ([]Column, error)
+	Close(ctx context.Context) error
+	Abort(ctx context.Context) error
+	Err() error
+	NextValues(ctx context.Context) ([]driver.Value, error)
+	NextInto(ctx context.Context, dest []interface{}) error
+	SupportsScrolling(ctx context.Context) bool
+	IsQueued(ctx context.Context) (bool, error)
+	Summary(ctx context.Context) (*QuerySummary, error)
+	ApplicationIDs(ctx context.Context) ([]string, error)
+	Reader(ctx context.Context, format string) (io.ReadCloser, error)
+	WriteHiveText(ctx context.Context, w io.Writer) error
+	WriteCSVProgress(ctx context.Context, w io.Writer, opts CSVOptions, onProgress func(rowsWritten int64)) error
+	WriteTable(ctx context.Context, w io.Writer, opts TableOptions) error
+	Transform(ctx context.Context, fn func(row []interface{}) ([]interface{}, error), sink func([]interface{}) error) error
+	QueryID(ctx context.Context) (string, error)
+	ModifiedRowCount(ctx context.Context) (int64, bool)
+	Integrity() Integrity
+	Tail(ctx context.Context, n int64) ([][]driver.Value, error)
+	StreamBatches(ctx context.Context) <-chan Batch
+	SetFetchSize(n int64) error
+	NextBatch(ctx context.Context) bool
+	ColumnNulls(i int) ([]byte, error)
+	ColumnInt64(i int) ([]int64, error)
+	ColumnFloat64(i int) ([]float64, error)
+	ColumnString(i int) ([]string, error)
+	ColumnBool(i int) ([]bool, error)
+	ColumnBytes(i int) ([][]byte, error)
+	// Rows, on Go 1.23 and later, returns an iterator over the
+	// remaining rows.
+	rowIterator
+}
+
+// Column describes one column of a result set.
+type Column struct {
+	Name string
+	// Type is the Hive type name, e.g. "INT", "STRING" or "ARRAY".
+	Type   string
+	TypeID inf.TTypeId
+	// Length is the declared length of a CHAR or VARCHAR column.
+	Length int
+	// Precision and Scale are declared for DECIMAL columns.
+	Precision int
+	Scale     int
+	// Comment is the column comment from the table definition, if any.
+	Comment string
+	// ComplexType is the full type of an ARRAY, MAP, STRUCT or UNIONTYPE
+	// column in Hive's syntax, e.g. "array<string>"; it is empty for
+	// primitive types.
+	ComplexType string
+	// NotNull marks a column known not to hold NULLs, e.g. from a NOT
+	// NULL constraint. Result set metadata doesn't report nullability, so
+	// Schema leaves it unset; it is used by GoTypeFor.
+	NotNull bool
+}
+
+// DatabaseTypeName returns the type with its declared parameters, e.g.
+// "VARCHAR(255)" or "DECIMAL(10,2)".
+func (c Column) DatabaseTypeName() string {
+	switch {
+	case c.Length > 0:
+		return fmt.Sprintf("%s(%d)", c.Type, c.Length)
+	case c.TypeID == inf.TTypeId_DECIMAL_TYPE && c.Precision > 0:
+		return fmt.Sprintf("%s(%d,%d)", c.Type, c.Precision, c.Scale)
+	default:
+		return c.Type
+	}
+}
+
+// RowSetStats summarizes how much data a RowSet has fetched so far.
+// Bytes is an estimate of the payload size of the fetched batches,
+// not an exact count of bytes read off the wire.
+type RowSetStats struct {
+	Rows          int64
+	Batches       int
+	Bytes         int64
+	FetchDuration time.Duration
+	// RemoteAddr is the address of the server running the operation, see
+	// Connection.RemoteAddr.
+	RemoteAddr string
+}
+
+// BatchMetric describes one fetch of a RowSet's results, for tuning
+// Options.BatchSize: whether larger batches spread the cost of a round
+// trip over more rows or only make each fetch take longer.
+type BatchMetric struct {
+	// MaxRows is the batch size asked for, and Rows the rows returned;
+	// the fetch that finds the end of the result returns none.
+	MaxRows int64
+	Rows    int
+	// Bytes is the estimated payload size, as in RowSetStats.
+	Bytes int64
+	// Duration is the time the FetchResults call took.
+	Duration time.Duration
+}
+
+// A LogRowSet is a RowSet that also collects the operation log of its
+// statement while waiting for it to complete.
+type LogRowSet interface {
+	RowSet
+	Logs() []string
 }
 
 // Represents job status, including success state and time the
@@ -48,72 +212,180 @@ type Status struct {
 	state *inf.TOperationState
 	Error error
 	At    time.Time
+	// SQLState and ErrorCode are reported by the server for a failed
+	// operation.
+	SQLState  string
+	ErrorCode int32
+	// RemoteAddr is the address of the server that reported the status,
+	// see Connection.RemoteAddr.
+	RemoteAddr string
 }
 
 func newRowSet(thrift *inf.TCLIServiceClient, operation *inf.TOperationHandle, options Options) RowSet {
-	return &rowSet{thrift, operation, options, nil, nil, 0, nil, true, false, nil, nil}
+	return &rowSet{
+		thrift:    thrift,
+		operation: operation,
+		options:   options,
+		hasMore:   operation != nil && operation.HasResultSet,
+		started:   options.clock().Now(),
+	}
 }
 
 // Issue a thrift call to check for the job's current status.
 func (r *rowSet) Poll() (*Status, error) {
+	return r.poll(context.Background())
+}
+
+func (r *rowSet) poll(ctx context.Context) (*Status, error) {
+	if err := r.checkIdle(ctx); err != nil {
+		return nil, err
+	}
+	defer r.touch()
 	req := inf.NewTGetOperationStatusReq()
 	req.OperationHandle = r.operation
 
-	resp, err := r.thrift.GetOperationStatus(context.Background(), req)
+	resp, err := r.thrift.GetOperationStatus(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("Error getting status: %+v, %v", resp, err)
 	}
 
-	if !isSuccessStatus(resp.Status) {
-		return nil, fmt.Errorf("GetStatus call failed: %s", resp.Status.String())
+	if isStillExecuting(resp.Status) && resp.OperationState == nil {
+		state := inf.TOperationState_RUNNING_STATE
+		return &Status{state: &state, At: r.options.clock().Now()}, nil
+	}
+
+	if !isSuccessStatus(resp.Status) && !isStillExecuting(resp.Status) {
+		return nil, operationStatusError("GetStatus call failed", resp.Status)
 	}
 
 	if resp.OperationState == nil {
 		return nil, errors.New("No error from GetStatus, but nil status!")
 	}
 
-	return &Status{resp.OperationState, nil, time.Now()}, nil
+	status := &Status{
+		state:      resp.OperationState,
+		At:         r.options.clock().Now(),
+		SQLState:   resp.GetSqlState(),
+		ErrorCode:  resp.GetErrorCode(),
+		RemoteAddr: r.stats.RemoteAddr,
+	}
+	if resp.IsSetErrorMessage() {
+		status.Error = errors.New(resp.GetErrorMessage())
+	}
+	r.statusMu.Lock()
+	r.lastStatus = status
+	r.observe(*resp.OperationState, status.At)
+	r.statusMu.Unlock()
+	return status, nil
+}
+
+// waitStarted polls until the operation is running or complete, unless
+// it has been seen to be already.
+func (r *rowSet) waitStarted(ctx context.Context) error {
+	r.statusMu.Lock()
+	last := r.lastStatus
+	r.statusMu.Unlock()
+	if r.ready || last != nil && last.IsStarted() {
+		return nil
+	}
+	for {
+		status, err := r.poll(ctx)
+		if err != nil {
+			return err
+		}
+		if status.IsStarted() {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-r.options.clock().After(time.Duration(r.options.PollIntervalSeconds) * time.Second):
+		}
+	}
 }
 
 // Wait until the job is complete, one way or another, returning Status and error.
 func (r *rowSet) Wait() (*Status, error) {
+	return r.wait(context.Background())
+}
+
+func (r *rowSet) wait(ctx context.Context) (*Status, error) {
 	for {
-		status, err := r.Poll()
+		status, err := r.poll(ctx)
 
 		if err != nil {
+			r.emitError(err)
 			return nil, err
 		}
 
+		// A log fetch racing ahead of the operation's start can fail,
+		// which would switch collection off for good.
+		if r.collectLogs && status.IsStarted() {
+			r.fetchLogs()
+		}
+
 		if status.IsComplete() {
 			if status.IsSuccess() {
-				// Fetch operation metadata.
-				metadataReq := inf.NewTGetResultSetMetadataReq()
-				metadataReq.OperationHandle = r.operation
-
-				metadataResp, err := r.thrift.GetResultSetMetadata(context.Background(), metadataReq)
-				if err != nil {
-					return nil, err
+				// Statements without a result set, such as DDL, have no
+				// schema to fetch, and Next returns false straight away.
+				if r.operation.GetHasResultSet() {
+					if err := r.fetchMetadata(ctx); err != nil {
+						return nil, err
+					}
 				}
+				r.ready = true
 
-				if !isSuccessStatus(metadataResp.Status) {
-					return nil, fmt.Errorf("GetResultSetMetadata failed: %s", metadataResp.Status.String())
+				// Drain whatever the final poll didn't pick up.
+				for r.collectLogs {
+					if r.fetchLogs() == 0 {
+						break
+					}
 				}
 
-				r.columns = metadataResp.Schema.Columns
-				r.ready = true
-
 				return status, nil
 			}
-			return nil, fmt.Errorf("Query failed execution: %s", status.state.String())
+			err := fmt.Errorf("Query failed execution: %s", status.state.String())
+			if status.Error != nil {
+				err = fmt.Errorf("%v: %v", err, status.Error)
+			}
+			r.options.emit(ErrorOccurred{
+				OperationID: operationID(r.operation),
+				Err:         err,
+				SQLState:    status.SQLState,
+				ErrorCode:   status.ErrorCode,
+			})
+			return status, err
 		}
 
-		time.Sleep(time.Duration(r.options.PollIntervalSeconds) * time.Second)
+		select {
+		case <-ctx.Done():
+			return nil, r.abandon(ctx)
+		case <-r.options.clock().After(time.Duration(r.options.PollIntervalSeconds) * time.Second):
+		}
 	}
 }
 
-func (r *rowSet) waitForSuccess() error {
+// fetchMetadata reads the result set schema of the operation.
+func (r *rowSet) fetchMetadata(ctx context.Context) error {
+	metadataReq := inf.NewTGetResultSetMetadataReq()
+	metadataReq.OperationHandle = r.operation
+
+	metadataResp, err := r.thrift.GetResultSetMetadata(ctx, metadataReq)
+	if err != nil {
+		return err
+	}
+
+	if !isSuccessStatus(metadataResp.Status) {
+		return fmt.Errorf("GetResultSetMetadata failed: %s", metadataResp.Status.String())
+	}
+
+	r.columns = metadataResp.GetSchema().GetColumns()
+	return nil
+}
+
+func (r *rowSet) waitForSuccess(ctx context.Context) error {
 	if !r.ready {
-		status, err := r.Wait()
+		status, err := r.wait(ctx)
 		if err != nil {
 			return err
 		}
@@ -125,32 +397,94 @@ func (r *rowSet) waitForSuccess() error {
 	return nil
 }
 
-func (r *rowSet) fetchAll() bool {
-	if !r.hasMore {
+func (r *rowSet) fetchAll(ctx context.Context) bool {
+	results, ok := r.fetchRaw(ctx)
+	if !ok {
 		return false
 	}
 
-	fetchReq := inf.NewTFetchResultsReq()
-	fetchReq.OperationHandle = r.operation
-	fetchReq.Orientation = inf.TFetchOrientation_FETCH_NEXT
-	fetchReq.MaxRows = r.options.BatchSize
+	if err := r.decodeBatch(results); err != nil {
+		log.Printf("Decoding results failed: %v\n", err)
+		r.err = err
+		r.emitError(err)
+		return false
+	}
+	return true
+}
 
-	resp, err := r.thrift.FetchResults(context.Background(), fetchReq)
+// fetchRaw fetches the next batch and accounts for it in r.stats without
+// decoding it. It returns false at the end of the result or on error,
+// which is left in r.err.
+func (r *rowSet) fetchRaw(ctx context.Context) (*inf.TRowSet, bool) {
+	if !r.hasMore {
+		return nil, false
+	}
+	if err := r.checkIdle(ctx); err != nil {
+		r.err = err
+		return nil, false
+	}
+	if limit := r.options.MaxFetchBatches; limit > 0 && int64(r.stats.Batches) >= limit {
+		r.err = fmt.Errorf("%w: %d fetches", ErrTooManyBatches, limit)
+		if err := r.cancel(ctx); err != nil {
+			r.err = fmt.Errorf("%w; cancelling the operation failed: %v", r.err, err)
+		}
+		r.emitError(r.err)
+		return nil, false
+	}
+
+	fetchReq := r.fetchRequest(inf.TFetchOrientation_FETCH_NEXT, r.batchSize(), FetchQueryOutput)
+
+	start := r.options.clock().Now()
+	resp, err := r.fetchResults(ctx, fetchReq)
+	elapsed := r.options.clock().Now().Sub(start)
+	r.stats.FetchDuration += elapsed
 	if err != nil {
 		log.Printf("FetchResults failed: %v\n", err)
-		return false
+		r.err = fmt.Errorf("Error in FetchResults: %w", messageSizeError(err))
+		r.emitError(err)
+		return nil, false
 	}
 
 	if !isSuccessStatus(resp.Status) {
 		log.Printf("FetchResults failed: %s\n", resp.Status.String())
-		return false
+		r.err = operationStatusError("FetchResults failed", resp.Status)
+		r.options.emit(statusErrorEvent(operationID(r.operation), r.err, resp.Status))
+		return nil, false
+	}
+
+	// HiveServer2 reports hasMoreRows=false on every response, so keep
+	// fetching until the server hands back an empty batch.
+	results := resp.GetResults()
+	rows := rowSetLength(results)
+	r.hasMore = resp.GetHasMoreRows() || rows > 0
+
+	bytes := estimateRowSetBytes(results)
+	r.stats.Rows += int64(rows)
+	r.stats.Batches++
+	r.stats.Bytes += bytes
+	r.batchMetrics = append(r.batchMetrics, BatchMetric{MaxRows: fetchReq.MaxRows, Rows: rows, Bytes: bytes, Duration: elapsed})
+	r.adaptBatchSize()
+	r.options.emit(BatchFetched{OperationID: operationID(r.operation), Rows: rows, Bytes: bytes, Duration: elapsed})
+
+	return results, true
+}
+
+// rowSetLength returns the number of rows in a fetched batch.
+func rowSetLength(results *inf.TRowSet) int {
+	if cols := results.GetColumns(); len(cols) > 0 {
+		_, length := convertColumn(cols[0])
+		return length
 	}
+	return len(results.GetRows())
+}
 
+// decodeBatch makes results the current batch, converting its columns
+// to r.resultSet.
+func (r *rowSet) decodeBatch(results *inf.TRowSet) error {
 	r.offset = 0
-	r.rowSet = resp.GetResults()
-	r.hasMore = *resp.HasMoreRows
+	r.rowSet = results
 
-	rs := r.rowSet.Columns
+	rs := r.rowSet.GetColumns()
 	colLen := len(rs)
 	r.resultSet = make([][]interface{}, colLen)
 
@@ -158,14 +492,185 @@ func (r *rowSet) fetchAll() bool {
 	for i := 0; i < colLen; i++ {
 		v, length := convertColumn(rs[i])
 		c := make([]interface{}, length)
+		nulls := columnNulls(rs[i])
 		for j := 0; j < length; j++ {
+			if isNull(nulls, j) {
+				continue
+			}
 			c[j] = reflect.ValueOf(v).Index(j).Interface()
 		}
 		r.resultSet[i] = c
 	}
+	if colLen == 0 && len(r.rowSet.GetRows()) > 0 {
+		r.resultSet = rowColumns(r.rowSet.GetRows())
+	}
 
-	return true
+	if err := r.checkUTF8(); err != nil {
+		return err
+	}
+	if r.options.DecodeMaps {
+		if err := r.decodeMaps(); err != nil {
+			return err
+		}
+	}
+	if err := r.mapTypes(); err != nil {
+		return err
+	}
+	r.interceptRows()
+	return nil
+}
+
+// mapTypes runs the fetched cells through Options.TypeMapper.
+func (r *rowSet) mapTypes() error {
+	mapper := r.options.TypeMapper
+	if mapper == nil {
+		return nil
+	}
+
+	for i, col := range r.resultSet {
+		if i >= len(r.columns) {
+			break
+		}
+		colType := columnTypeID(r.columns[i])
+		qualifiers := columnQualifiers(r.columns[i])
+		for j, raw := range col {
+			if raw == nil {
+				continue
+			}
+			v, err := mapper.Decode(colType, qualifiers, raw)
+			if err != nil {
+				return fmt.Errorf("column %s: %v", r.columns[i].GetColumnName(), err)
+			}
+			col[j] = v
+		}
+	}
+	return nil
+}
+
+// fetchLogs appends the next chunk of the operation log to r.logs and
+// returns the number of lines read. Log collection is switched off if
+// the server can't serve logs, e.g. when operation logging is disabled.
+func (r *rowSet) fetchLogs() int {
+	fetchReq := r.fetchRequest(inf.TFetchOrientation_FETCH_NEXT, r.options.BatchSize, FetchLogs)
+
+	resp, err := r.thrift.FetchResults(context.Background(), fetchReq)
+	if err != nil || !isSuccessStatus(resp.Status) {
+		r.collectLogs = false
+		return 0
+	}
+
+	lines := logLines(resp.GetResults())
+	r.logs = append(r.logs, lines...)
+	return len(lines)
+}
+
+// logLines returns the lines of a batch of the operation log, which is
+// a single string column.
+func logLines(results *inf.TRowSet) []string {
+	var lines []string
+	cols := results.GetColumns()
+	switch {
+	case len(cols) > 0 && cols[0].IsSetStringVal():
+		lines = cols[0].GetStringVal().GetValues()
+	case len(cols) == 0:
+		for _, row := range results.GetRows() {
+			if vals := row.GetColVals(); len(vals) > 0 {
+				lines = append(lines, vals[0].GetStringVal().GetValue())
+			}
+		}
+	}
+	return lines
+}
+
+// Close releases the operation on the server. The RowSet can't be used
+// afterwards; closing it again is a no-op. It can be deferred right
+// after the query is submitted: an operation not yet seen to complete is
+// cancelled before it is closed. If ctx ends before the server answers,
+// the connection's transport is dropped and ctx's error returned, as with
+// Connection.CloseContext; a ctx that has already ended is replaced by
+// one allowing defaultCloseTimeout.
+func (r *rowSet) Close(ctx context.Context) error {
+	if r.closed {
+		return nil
+	}
+
+	// An operation that may still be running is cancelled first, so a
+	// deferred Close also stops the query. The server rejects cancelling
+	// finished operations, which is harmless here.
+	if ctx.Err() != nil {
+		// Close is often deferred with the query's own ctx, which may
+		// have ended; the cleanup gets defaultCloseTimeout then rather
+		// than dropping the transport straight away.
+		var done context.CancelFunc
+		ctx, done = context.WithTimeout(context.WithoutCancel(ctx), defaultCloseTimeout)
+		defer done()
+	}
+	if r.conn != nil {
+		// A server that doesn't answer the cleanup calls before ctx ends
+		// loses the transport instead, which the connection shares.
+		stop := r.conn.dropOnDone(ctx)
+		defer stop()
+	}
+	if !r.cancelled && !r.completed() {
+		r.cancel(ctx)
+	}
+
+	req := inf.NewTCloseOperationReq()
+	req.OperationHandle = r.operation
+	resp, err := r.thrift.CloseOperation(ctx, req)
+
+	// The RowSet is done with whatever the server says: one that failed
+	// to close, e.g. as already expired by
+	// hive.server2.idle.operation.timeout, would otherwise keep its
+	// MaxConcurrentOperations slot for good.
+	r.closed = true
+	r.spool.remove()
+	if r.conn != nil {
+		r.conn.untrack(r)
+	}
+	switch {
+	case err != nil && ctx.Err() != nil:
+		return fmt.Errorf("Error closing operation: %w", ctx.Err())
+	case err != nil:
+		return fmt.Errorf("Error closing operation: %v", err)
+	case !isSuccessStatus(resp.Status):
+		return fmt.Errorf("CloseOperation failed: %s", resp.Status.String())
+	}
+	return nil
+}
 
+// Abort stops reading the result early: it cancels the operation, so
+// the server stops running the query and producing rows nobody will
+// read, and then closes it as Close does. This is the way to give up on
+// a result part way; leaving the RowSet unclosed keeps the operation and
+// its resources on the server until the session ends. A failed cancel
+// is reported only for an operation not yet seen to complete, as the
+// server refuses to cancel finished ones. Next then returns false, with
+// Err and Scan returning ErrRowSetClosed.
+func (r *rowSet) Abort(ctx context.Context) error {
+	if r.closed {
+		return nil
+	}
+	if ctx.Err() != nil {
+		var done context.CancelFunc
+		ctx, done = context.WithTimeout(context.WithoutCancel(ctx), defaultCloseTimeout)
+		defer done()
+	}
+	var cancelErr error
+	if !r.cancelled {
+		if cancelErr = r.cancel(ctx); cancelErr != nil && r.completed() {
+			cancelErr = nil
+		}
+		// Close would otherwise try again.
+		r.cancelled = true
+	}
+	closeErr := r.Close(ctx)
+	r.err = ErrRowSetClosed
+	r.nextRow = nil
+	if closeErr != nil {
+		return closeErr
+	}
+	return cancelErr
 }
 
 // Prepares a row for scanning into memory, by reading data from hive if
@@ -174,31 +679,118 @@ func (r *rowSet) fetchAll() bool {
 // Returns true is a row is available to Scan(), and false if the
 // results are empty or any other error occurs.
 func (r *rowSet) Next() bool {
-	if err := r.waitForSuccess(); err != nil {
+	return r.next(context.Background())
+}
+
+func (r *rowSet) next(ctx context.Context) bool {
+	return r.nextInto(ctx, nil)
+}
+
+// nextInto is next, building the row in dest as advanceInto does.
+func (r *rowSet) nextInto(ctx context.Context, dest []interface{}) bool {
+	if !r.advanceInto(ctx, dest) {
 		return false
 	}
-
-	if r.resultSet == nil {
-		r.fetchAll()
-		r.offset = 0
-		//		fmt.Println(r.resultSet)
+	if r.options.TrackIntegrity {
+		r.integrity.add(r.nextRow)
 	}
+	return true
+}
 
-	if len(r.resultSet) <= 0 {
+// advanceInto moves r.nextRow to the next row, fetching or reading the
+// spool as needed. The row is built in dest's backing array if dest is
+// non-nil.
+func (r *rowSet) advanceInto(ctx context.Context, dest []interface{}) bool {
+	if r.err != nil {
 		return false
 	}
-	if r.offset >= len(r.resultSet[0]) {
+	if err := r.waitForSuccess(ctx); err != nil {
+		r.err = err
 		return false
 	}
-	r.nextRow = make([]interface{}, 0)
+
+	if r.spool != nil {
+		return r.nextSpooledInto(dest)
+	}
+
+	for r.resultSet == nil || r.offset >= r.batchLength() {
+		if ctx.Err() != nil {
+			r.err = r.abandon(ctx)
+			return false
+		}
+		if r.shouldSpool() {
+			if err := r.fillSpool(ctx); err != nil {
+				r.err = err
+				return false
+			}
+			return r.nextSpooledInto(dest)
+		}
+		if !r.fetchAll(ctx) {
+			if !r.hasMore {
+				r.finish()
+			}
+			return false
+		}
+	}
+
+	row := dest[:0]
+	if dest == nil {
+		row = make([]interface{}, 0, len(r.resultSet))
+	}
 	for _, v := range r.resultSet {
-		r.nextRow = append(r.nextRow, v[r.offset])
+		row = append(row, v[r.offset])
 	}
-	//	fmt.Println(r.nextRow)
+	r.nextRow = row
 	r.offset++
 	return true
 }
 
+// Err returns the error, if any, that ended the last Next call early.
+// It is nil when Next returned false because the rows ran out.
+func (r *rowSet) Err() error {
+	return r.err
+}
+
+// operationStatusError describes a failed status, wrapping
+// ErrOperationLost when the server no longer knows the operation.
+func operationStatusError(prefix string, status *inf.TStatus) error {
+	if status == nil {
+		return fmt.Errorf("%s: no status in response", prefix)
+	}
+	msg := strings.ToLower(status.GetErrorMessage())
+	if strings.Contains(msg, "invalid operationhandle") || strings.Contains(msg, "invalid operation handle") ||
+		strings.Contains(msg, "operation not found") {
+		return fmt.Errorf("%w: %s", ErrOperationLost, status.GetErrorMessage())
+	}
+	return fmt.Errorf("%s: %s", prefix, status.String())
+}
+
+// finish publishes StatementFinished the first time the RowSet is
+// exhausted.
+func (r *rowSet) finish() {
+	if r.finished {
+		return
+	}
+	r.finished = true
+	r.options.emit(StatementFinished{
+		OperationID: operationID(r.operation),
+		Rows:        r.stats.Rows,
+		Duration:    r.options.clock().Now().Sub(r.started),
+	})
+}
+
+func (r *rowSet) emitError(err error) {
+	r.options.emit(ErrorOccurred{OperationID: operationID(r.operation), Err: err})
+}
+
+// batchLength returns the number of rows in the current batch.
+func (r *rowSet) batchLength() int {
+	if len(r.resultSet) == 0 {
+		return 0
+	}
+	return len(r.resultSet[0])
+}
+
 // Scan the last row prepared via Next() into the destination(s) provided,
 // which must be pointers to value types, as in database.sql. Further,
 // only pointers of the following types are supported:
@@ -206,11 +798,19 @@ func (r *rowSet) Next() bool {
 //   - string, []byte
 //   - float64
 //   - bool
+//   - interface{}, which receives the value as is
+//
+// A NULL cell sets the destination to its zero value, or nil for
+// *interface{}. A cell that doesn't fit its destination fails the scan
+// with a *DecodeError.
 func (r *rowSet) Scan(dest ...interface{}) error {
 	// TODO: Add type checking and conversion between compatible
 	// types where possible, as well as some common error checking,
 	// like passing nil. database/sql's method is very convenient,
 	// for example: http://golang.org/src/pkg/database/sql/convert.go, like 85
+	if r.err == ErrRowSetClosed || errors.Is(r.err, ErrOperationIdleTimeout) {
+		return r.err
+	}
 	if r.nextRow == nil {
 		return errors.New("No row to scan! Did you call Next() first?")
 	}
@@ -221,33 +821,91 @@ func (r *rowSet) Scan(dest ...interface{}) error {
 
 	for i, val := range r.nextRow {
 		d := dest[i]
-		switch dt := d.(type) {
-		case *string:
-			switch st := val.(type) {
-			case string:
-				*dt = st
-			default:
-				*dt = fmt.Sprintf("%v", val)
+		if dt, ok := d.(*interface{}); ok {
+			*dt = val
+			continue
+		}
+		if val == nil {
+			if err := scanNull(d); err != nil {
+				return r.decodeError(i, val, err)
 			}
-		case *[]byte:
-			*dt = []byte(val.(string))
-		case *int:
-			*dt = int(val.(int32))
-		case *int64:
-			*dt = val.(int64)
-		case *int32:
-			*dt = val.(int32)
-		case *int16:
-			*dt = val.(int16)
-		case *float64:
-			*dt = val.(float64)
-		case *bool:
-			*dt = val.(bool)
+			continue
+		}
+		if err := scanValue(d, val); err != nil {
+			return r.decodeError(i, val, err)
+		}
+	}
+
+	return nil
+}
+
+// scanValue stores a non-NULL cell in a supported Scan destination.
+func scanValue(dest, val interface{}) error {
+	ok := true
+	switch dt := dest.(type) {
+	case *string:
+		switch st := val.(type) {
+		case string:
+			*dt = st
+		case []byte:
+			*dt = string(st)
 		default:
-			return fmt.Errorf("Can't scan value of type %T with value %v", dt, val)
+			*dt = fmt.Sprintf("%v", val)
 		}
+	case *[]byte:
+		switch bt := val.(type) {
+		case []byte:
+			*dt = bt
+		case string:
+			*dt = []byte(bt)
+		default:
+			ok = false
+		}
+	case *int:
+		var n int32
+		n, ok = val.(int32)
+		*dt = int(n)
+	case *int64:
+		*dt, ok = val.(int64)
+	case *int32:
+		*dt, ok = val.(int32)
+	case *int16:
+		*dt, ok = val.(int16)
+	case *float64:
+		*dt, ok = val.(float64)
+	case *bool:
+		*dt, ok = val.(bool)
+	default:
+		return fmt.Errorf("unsupported destination %T", dest)
+	}
+	if !ok {
+		return fmt.Errorf("cannot scan %T into %T", val, dest)
 	}
+	return nil
+}
 
+// scanNull stores the zero value in a supported Scan destination.
+func scanNull(dest interface{}) error {
+	switch dt := dest.(type) {
+	case *string:
+		*dt = ""
+	case *[]byte:
+		*dt = nil
+	case *int:
+		*dt = 0
+	case *int64:
+		*dt = 0
+	case *int32:
+		*dt = 0
+	case *int16:
+		*dt = 0
+	case *float64:
+		*dt = 0
+	case *bool:
+		*dt = false
+	default:
+		return fmt.Errorf("cannot scan NULL into %T", dt)
+	}
 	return nil
 }
 
@@ -255,7 +913,7 @@ func (r *rowSet) Scan(dest ...interface{}) error {
 // blocking if necessary until the information is available.
 func (r *rowSet) Columns() []string {
 	if r.columnStrs == nil {
-		if err := r.waitForSuccess(); err != nil {
+		if err := r.waitForSuccess(context.Background()); err != nil {
 			return nil
 		}
 
@@ -270,6 +928,90 @@ func (r *rowSet) Columns() []string {
 	return r.columnStrs
 }
 
+// Returns the row, batch and byte counts accumulated by the fetches
+// issued so far.
+func (r *rowSet) Stats() RowSetStats {
+	return r.stats
+}
+
+// BatchMetrics returns a sample for every batch fetched so far, in
+// order. The samples are also published as BatchFetched events when
+// Options.Events is set.
+func (r *rowSet) BatchMetrics() []BatchMetric {
+	return append([]BatchMetric(nil), r.batchMetrics...)
+}
+
+// Returns the operation log lines collected so far. Only populated for
+// RowSets returned by QueryWithLogs.
+func (r *rowSet) Logs() []string {
+	return r.logs
+}
+
+// Returns the result set schema, waiting for the operation to complete
+// if necessary. The schema is read with GetResultSetMetadata, so it is
+// available even when the query returns no rows.
+func (r *rowSet) Schema(ctx context.Context) ([]Column, error) {
+	if err := r.waitForSuccess(ctx); err != nil {
+		return nil, err
+	}
+
+	cols := make([]Column, len(r.columns))
+	for i, desc := range r.columns {
+		cols[i] = newColumn(desc)
+	}
+	return cols, nil
+}
+
+func newColumn(desc *inf.TColumnDesc) Column {
+	id := columnTypeID(desc)
+	qualifiers := columnQualifiers(desc)
+	col := Column{
+		Name:      desc.GetColumnName(),
+		Type:      typeName(id),
+		TypeID:    id,
+		Length:    qualifierInt(qualifiers, inf.CHARACTER_MAXIMUM_LENGTH),
+		Precision: qualifierInt(qualifiers, inf.PRECISION),
+		Scale:     qualifierInt(qualifiers, inf.SCALE),
+		Comment:   desc.GetComment(),
+	}
+	switch id {
+	case inf.TTypeId_ARRAY_TYPE, inf.TTypeId_MAP_TYPE, inf.TTypeId_STRUCT_TYPE, inf.TTypeId_UNION_TYPE:
+		col.ComplexType = typeSignature(desc.GetTypeDesc().GetTypes(), 0, 0)
+	}
+	return col
+}
+
+func typeName(id inf.TTypeId) string {
+	if name, ok := inf.TYPE_NAMES[id]; ok {
+		return name
+	}
+	return id.String()
+}
+
+// columnTypeID returns the type of a column, treating complex types by
+// their outermost kind.
+func columnTypeID(desc *inf.TColumnDesc) inf.TTypeId {
+	types := desc.GetTypeDesc().GetTypes()
+	if len(types) == 0 {
+		return inf.TTypeId_STRING_TYPE
+	}
+	entry := types[0]
+	switch {
+	case entry.IsSetPrimitiveEntry():
+		return entry.GetPrimitiveEntry().GetType()
+	case entry.IsSetArrayEntry():
+		return inf.TTypeId_ARRAY_TYPE
+	case entry.IsSetMapEntry():
+		return inf.TTypeId_MAP_TYPE
+	case entry.IsSetStructEntry():
+		return inf.TTypeId_STRUCT_TYPE
+	case entry.IsSetUnionEntry():
+		return inf.TTypeId_UNION_TYPE
+	default:
+		return inf.TTypeId_USER_DEFINED_TYPE
+	}
+}
+
 // Return a serialized representation of an identifier that can later
 // be used to reattach to a running operation. This identifier and
 // serialized representation should be considered opaque by users.
@@ -293,11 +1035,81 @@ func convertColumn(col *inf.TColumn) (colValues interface{}, length int) {
 		return col.GetI64Val().GetValues(), len(col.GetI64Val().GetValues())
 	case col.IsSetDoubleVal():
 		return col.GetDoubleVal().GetValues(), len(col.GetDoubleVal().GetValues())
+	case col.IsSetBinaryVal():
+		return col.GetBinaryVal().GetValues(), len(col.GetBinaryVal().GetValues())
 	default:
 		return nil, 0
 	}
 }
 
+// columnNulls returns the null bitmap of a column.
+func columnNulls(col *inf.TColumn) []byte {
+	switch {
+	case col.IsSetStringVal():
+		return col.GetStringVal().GetNulls()
+	case col.IsSetBoolVal():
+		return col.GetBoolVal().GetNulls()
+	case col.IsSetByteVal():
+		return col.GetByteVal().GetNulls()
+	case col.IsSetI16Val():
+		return col.GetI16Val().GetNulls()
+	case col.IsSetI32Val():
+		return col.GetI32Val().GetNulls()
+	case col.IsSetI64Val():
+		return col.GetI64Val().GetNulls()
+	case col.IsSetDoubleVal():
+		return col.GetDoubleVal().GetNulls()
+	case col.IsSetBinaryVal():
+		return col.GetBinaryVal().GetNulls()
+	default:
+		return nil
+	}
+}
+
+// isNull reports whether row i is set in a null bitmap, which holds one
+// bit per row, least significant bit first.
+func isNull(nulls []byte, i int) bool {
+	return i/8 < len(nulls) && nulls[i/8]&(1<<(uint(i)%8)) != 0
+}
+
+// estimateRowSetBytes approximates the payload size of a fetched batch
+// from the values it carries.
+func estimateRowSetBytes(rs *inf.TRowSet) int64 {
+	var n int64
+	for _, row := range rs.GetRows() {
+		for _, v := range row.GetColVals() {
+			n += estimateValueBytes(v)
+		}
+	}
+	for _, col := range rs.GetColumns() {
+		switch {
+		case col.IsSetStringVal():
+			for _, v := range col.GetStringVal().GetValues() {
+				n += int64(len(v)) + 4
+			}
+			n += int64(len(col.GetStringVal().GetNulls()))
+		case col.IsSetBinaryVal():
+			for _, v := range col.GetBinaryVal().GetValues() {
+				n += int64(len(v)) + 4
+			}
+			n += int64(len(col.GetBinaryVal().GetNulls()))
+		case col.IsSetBoolVal():
+			n += int64(len(col.GetBoolVal().GetValues())) + int64(len(col.GetBoolVal().GetNulls()))
+		case col.IsSetByteVal():
+			n += int64(len(col.GetByteVal().GetValues())) + int64(len(col.GetByteVal().GetNulls()))
+		case col.IsSetI16Val():
+			n += 2*int64(len(col.GetI16Val().GetValues())) + int64(len(col.GetI16Val().GetNulls()))
+		case col.IsSetI32Val():
+			n += 4*int64(len(col.GetI32Val().GetValues())) + int64(len(col.GetI32Val().GetNulls()))
+		case col.IsSetI64Val():
+			n += 8*int64(len(col.GetI64Val().GetValues())) + int64(len(col.GetI64Val().GetNulls()))
+		case col.IsSetDoubleVal():
+			n += 8*int64(len(col.GetDoubleVal().GetValues())) + int64(len(col.GetDoubleVal().GetNulls()))
+		}
+	}
+	return n
+}
+
 // Returns a string representation of operation status.
 func (s Status) String() string {
 	if s.state == nil {
@@ -316,13 +1128,37 @@ func (s Status) IsComplete() bool {
 	case inf.TOperationState_FINISHED_STATE,
 		inf.TOperationState_CANCELED_STATE,
 		inf.TOperationState_CLOSED_STATE,
-		inf.TOperationState_ERROR_STATE:
+		inf.TOperationState_ERROR_STATE,
+		inf.TOperationState_TIMEDOUT_STATE:
 		return true
 	}
 
 	return false
 }
 
+// IsStarted returns true once the job is past INITIALIZED and PENDING,
+// running or complete. Some servers reject fetching an operation's log
+// before then.
+func (s Status) IsStarted() bool {
+	if s.state == nil {
+		return false
+	}
+
+	switch *s.state {
+	case inf.TOperationState_INITIALIZED_STATE,
+		inf.TOperationState_PENDING_STATE:
+		return false
+	}
+
+	return true
+}
+
+// IsTimedOut returns true if the server stopped the job because it
+// exceeded its query timeout.
+func (s Status) IsTimedOut() bool {
+	return s.state != nil && *s.state == inf.TOperationState_TIMEDOUT_STATE
+}
+
 // Returns true if the job compelted successfully.
 func (s Status) IsSuccess() bool {
 	if s.state == nil {
@@ -0,0 +1,703 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestSchemaWithZeroRows(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{
+		columnDesc("t.id", inf.TTypeId_BIGINT_TYPE, 1),
+		columnDesc("t.name", inf.TTypeId_STRING_TYPE, 2),
+	}
+	conn := connectFake(t, svc, testOptions())
+
+	rs, err := conn.Query("SELECT id, name FROM t WHERE 1 = 0")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+
+	schema, err := rs.Schema(context.Background())
+	if err != nil {
+		t.Fatalf("Schema error: %v", err)
+	}
+
+	expected := []Column{
+		{Name: "t.id", Type: "BIGINT", TypeID: inf.TTypeId_BIGINT_TYPE},
+		{Name: "t.name", Type: "STRING", TypeID: inf.TTypeId_STRING_TYPE},
+	}
+	if !reflect.DeepEqual(schema, expected) {
+		t.Errorf("expected schema %+v, got %+v", expected, schema)
+	}
+
+	if rs.Next() {
+		t.Error("expected no rows")
+	}
+}
+
+func TestQueryWithoutResultSet(t *testing.T) {
+	svc := newFakeService()
+	svc.onExecute = func(req *inf.TExecuteStatementReq) (*inf.TExecuteStatementResp, error) {
+		svc.mu.Lock()
+		defer svc.mu.Unlock()
+		op := svc.newOperation(req.Statement)
+		op.HasResultSet = false
+		return &inf.TExecuteStatementResp{Status: okStatus(), OperationHandle: op}, nil
+	}
+	svc.onFetch = func(*inf.TFetchResultsReq) (*inf.TFetchResultsResp, error) {
+		return &inf.TFetchResultsResp{Status: errorStatus("No result set for this operation")}, nil
+	}
+	conn := connectFake(t, svc, testOptions())
+
+	rs, err := conn.Query("CREATE TABLE t (id INT)")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	if rs.Next() {
+		t.Error("expected no rows")
+	}
+	if err := rs.Err(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if _, err := rs.NextValues(context.Background()); !errors.Is(err, io.EOF) {
+		t.Errorf("expected io.EOF from NextValues, got %v", err)
+	}
+	schema, err := rs.Schema(context.Background())
+	if err != nil || len(schema) != 0 {
+		t.Errorf("expected an empty schema, got %v, %v", schema, err)
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	if len(svc.fetches) != 0 {
+		t.Errorf("expected no FetchResults calls, got %d", len(svc.fetches))
+	}
+}
+
+func TestLogsWaitForOperationStart(t *testing.T) {
+	svc := newFakeService()
+	svc.states = []inf.TOperationState{
+		inf.TOperationState_INITIALIZED_STATE,
+		inf.TOperationState_RUNNING_STATE,
+		inf.TOperationState_FINISHED_STATE,
+	}
+	var early int
+	svc.onFetch = func(req *inf.TFetchResultsReq) (*inf.TFetchResultsResp, error) {
+		svc.mu.Lock()
+		defer svc.mu.Unlock()
+		op := svc.operation(req.OperationHandle)
+		resp := &inf.TFetchResultsResp{Status: okStatus(), Results: stringBatch()}
+		switch {
+		case req.FetchType != int16(FetchLogs):
+		case op.polls < 2:
+			// Only the INITIALIZED state has been reported.
+			early++
+			resp.Status = errorStatus("Operation not yet running")
+		case !op.logsRead:
+			op.logsRead = true
+			resp.Results = stringBatch("INFO  : Compiling command", "INFO  : Executing command")
+		}
+		return resp, nil
+	}
+	conn := connectFake(t, svc, testOptions())
+
+	rs, err := conn.QueryWithLogs(context.Background(), "SELECT 1")
+	if err != nil {
+		t.Fatalf("QueryWithLogs error: %v", err)
+	}
+	if _, err := rs.Wait(); err != nil {
+		t.Fatalf("Wait error: %v", err)
+	}
+	if early != 0 {
+		t.Errorf("expected no log fetch before the operation started, got %d", early)
+	}
+	if logs := rs.Logs(); len(logs) != 2 {
+		t.Errorf("expected the operation log, got %q", logs)
+	}
+}
+
+func TestQueryWithLogs(t *testing.T) {
+	svc := newFakeService()
+	svc.states = []inf.TOperationState{inf.TOperationState_RUNNING_STATE, inf.TOperationState_FINISHED_STATE}
+	svc.schema = []*inf.TColumnDesc{columnDesc("s", inf.TTypeId_STRING_TYPE, 1)}
+	svc.batches = []*inf.TRowSet{stringBatch("a", "b")}
+	svc.logs = []string{"INFO  : Compiling command", "INFO  : Executing command"}
+	conn := connectFake(t, svc, testOptions())
+
+	rs, err := conn.QueryWithLogs(context.Background(), "SELECT s FROM t")
+	if err != nil {
+		t.Fatalf("QueryWithLogs error: %v", err)
+	}
+	var rows []string
+	for rs.Next() {
+		var s string
+		if err := rs.Scan(&s); err != nil {
+			t.Fatalf("Scan error: %v", err)
+		}
+		rows = append(rows, s)
+	}
+	if err := rs.Err(); err != nil {
+		t.Fatalf("Next error: %v", err)
+	}
+
+	if !svc.executes[0].RunAsync {
+		t.Error("expected the statement to be submitted asynchronously")
+	}
+	if !reflect.DeepEqual(rows, []string{"a", "b"}) {
+		t.Errorf("expected the result rows, got %q", rows)
+	}
+	if logs := rs.Logs(); !reflect.DeepEqual(logs, svc.logs) {
+		t.Errorf("expected the operation log, got %q", logs)
+	}
+	var logFetches, resultFetches int
+	for _, req := range svc.fetches {
+		switch req.FetchType {
+		case int16(FetchLogs):
+			logFetches++
+		case int16(FetchQueryOutput):
+			resultFetches++
+		}
+	}
+	if logFetches == 0 || resultFetches == 0 || logFetches+resultFetches != len(svc.fetches) {
+		t.Errorf("expected log and result fetches only, got %d and %d of %d", logFetches, resultFetches, len(svc.fetches))
+	}
+}
+
+func TestQueryWithLogsUnavailable(t *testing.T) {
+	svc := newFakeService()
+	svc.states = []inf.TOperationState{inf.TOperationState_RUNNING_STATE, inf.TOperationState_RUNNING_STATE, inf.TOperationState_FINISHED_STATE}
+	svc.schema = []*inf.TColumnDesc{columnDesc("s", inf.TTypeId_STRING_TYPE, 1)}
+	batches := []*inf.TRowSet{stringBatch("a"), stringBatch()}
+	var logFetches, resultFetches int
+	svc.onFetch = func(req *inf.TFetchResultsReq) (*inf.TFetchResultsResp, error) {
+		if req.FetchType == int16(FetchLogs) {
+			logFetches++
+			return &inf.TFetchResultsResp{Status: errorStatus("Operation logging is disabled")}, nil
+		}
+		resultFetches++
+		return &inf.TFetchResultsResp{Status: okStatus(), Results: batches[min(resultFetches, len(batches))-1]}, nil
+	}
+	conn := connectFake(t, svc, testOptions())
+
+	rs, err := conn.QueryWithLogs(context.Background(), "SELECT s FROM t")
+	if err != nil {
+		t.Fatalf("QueryWithLogs error: %v", err)
+	}
+	var rows int
+	for rs.Next() {
+		rows++
+	}
+	if err := rs.Err(); err != nil {
+		t.Fatalf("Next error: %v", err)
+	}
+	if rows != 1 {
+		t.Errorf("expected the result row despite the missing log, got %d rows", rows)
+	}
+	if logFetches != 1 {
+		t.Errorf("expected log collection to stop after the first failure, got %d log fetches", logFetches)
+	}
+	if logs := rs.Logs(); len(logs) != 0 {
+		t.Errorf("expected no log, got %q", logs)
+	}
+}
+
+func TestMaxFetchBatches(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("n", inf.TTypeId_STRING_TYPE, 1)}
+	svc.onFetch = func(*inf.TFetchResultsReq) (*inf.TFetchResultsResp, error) {
+		more := true
+		return &inf.TFetchResultsResp{Status: okStatus(), HasMoreRows: &more, Results: stringBatch("x")}, nil
+	}
+	options := testOptions()
+	options.MaxFetchBatches = 3
+	conn := connectFake(t, svc, options)
+
+	rs, err := conn.Query("SELECT n FROM drip")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	rows := 0
+	for rs.Next() {
+		rows++
+	}
+	if !errors.Is(rs.Err(), ErrTooManyBatches) {
+		t.Errorf("expected ErrTooManyBatches, got %v", rs.Err())
+	}
+	if rows != 3 {
+		t.Errorf("expected the rows of 3 batches, got %d", rows)
+	}
+
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	if len(svc.fetches) != 3 || len(svc.cancels) != 1 {
+		t.Errorf("expected 3 fetches and a cancel, got %d and %d", len(svc.fetches), len(svc.cancels))
+	}
+}
+
+func TestWaitPollsOnClock(t *testing.T) {
+	svc := newFakeService()
+	svc.states = []inf.TOperationState{
+		inf.TOperationState_RUNNING_STATE,
+		inf.TOperationState_RUNNING_STATE,
+		inf.TOperationState_FINISHED_STATE,
+	}
+	clock := newFakeClock()
+	options := testOptions()
+	options.PollIntervalSeconds = 60
+	options.testClock = clock
+	conn := connectFake(t, svc, options)
+
+	rs, err := conn.Query("SELECT 1")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+
+	status, err := rs.Wait()
+	if err != nil {
+		t.Fatalf("Wait error: %v", err)
+	}
+	if !status.IsSuccess() {
+		t.Fatalf("expected success, got %v", status)
+	}
+
+	expected := []time.Duration{time.Minute, time.Minute}
+	if slept := clock.slept(); !reflect.DeepEqual(slept, expected) {
+		t.Errorf("expected sleeps %v, got %v", expected, slept)
+	}
+	if want := clock.Now(); !status.At.Equal(want) {
+		t.Errorf("expected status time %v, got %v", want, status.At)
+	}
+}
+
+func TestEventsPublishedForQuery(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("name", inf.TTypeId_STRING_TYPE, 1)}
+	svc.batches = []*inf.TRowSet{stringBatch("a", "b")}
+
+	events := make(chan Event, 16)
+	options := testOptions()
+	options.Events = events
+	conn := connectFake(t, svc, options)
+
+	rs, err := conn.Query("SELECT name FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	for rs.Next() {
+	}
+	close(events)
+
+	var kinds []string
+	for ev := range events {
+		kinds = append(kinds, reflect.TypeOf(ev).Name())
+		if f, ok := ev.(StatementFinished); ok && f.Rows != 2 {
+			t.Errorf("expected 2 rows in StatementFinished, got %d", f.Rows)
+		}
+	}
+	expected := []string{"SessionOpened", "StatementSubmitted", "BatchFetched", "BatchFetched", "StatementFinished"}
+	if !reflect.DeepEqual(kinds, expected) {
+		t.Errorf("expected events %v, got %v", expected, kinds)
+	}
+}
+
+func TestErrorEventCarriesSQLState(t *testing.T) {
+	svc := newFakeService()
+	svc.onStatus = func(*inf.TGetOperationStatusReq) (*inf.TGetOperationStatusResp, error) {
+		state := inf.TOperationState_ERROR_STATE
+		sqlState := "42000"
+		code := int32(10001)
+		msg := "Table not found 'missing'"
+		return &inf.TGetOperationStatusResp{
+			Status:         okStatus(),
+			OperationState: &state,
+			SqlState:       &sqlState,
+			ErrorCode:      &code,
+			ErrorMessage:   &msg,
+		}, nil
+	}
+
+	events := make(chan Event, 16)
+	options := testOptions()
+	options.Events = events
+	conn := connectFake(t, svc, options)
+
+	rs, err := conn.Query("SELECT * FROM missing")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	status, err := rs.Wait()
+	if err == nil {
+		t.Fatal("expected Wait to fail")
+	}
+	if status.SQLState != "42000" || status.ErrorCode != 10001 {
+		t.Errorf("expected 42000/10001 in status, got %q/%d", status.SQLState, status.ErrorCode)
+	}
+	close(events)
+
+	var failed []ErrorOccurred
+	for ev := range events {
+		if e, ok := ev.(ErrorOccurred); ok {
+			failed = append(failed, e)
+		}
+	}
+	if len(failed) != 1 {
+		t.Fatalf("expected one ErrorOccurred, got %v", failed)
+	}
+	e := failed[0]
+	if e.SQLState != "42000" || e.ErrorCode != 10001 || e.OperationID == "" {
+		t.Errorf("expected SQLState, error code and operation ID, got %+v", e)
+	}
+}
+
+func TestFetchAfterServerRestart(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("name", inf.TTypeId_STRING_TYPE, 1)}
+	svc.batches = []*inf.TRowSet{stringBatch("a"), stringBatch("b")}
+	conn := connectFake(t, svc, testOptions())
+
+	rs, err := conn.Query("SELECT name FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	if !rs.Next() {
+		t.Fatalf("expected a first row, got error %v", rs.Err())
+	}
+
+	// A restarted server has forgotten every operation.
+	svc.mu.Lock()
+	svc.ops = map[string]*fakeOperation{}
+	svc.mu.Unlock()
+
+	if rs.Next() {
+		t.Fatal("expected Next to fail")
+	}
+	if !errors.Is(rs.Err(), ErrOperationLost) {
+		t.Errorf("expected ErrOperationLost, got %v", rs.Err())
+	}
+}
+
+func TestSupportsScrolling(t *testing.T) {
+	for _, scrollable := range []bool{true, false} {
+		svc := newFakeService()
+		svc.schema = []*inf.TColumnDesc{columnDesc("name", inf.TTypeId_STRING_TYPE, 1)}
+		svc.batches = []*inf.TRowSet{stringBatch("a", "b"), stringBatch("c")}
+		svc.noScroll = !scrollable
+		conn := connectFake(t, svc, testOptions())
+
+		rs, err := conn.Query("SELECT name FROM t")
+		if err != nil {
+			t.Fatalf("Query error: %v", err)
+		}
+
+		var names []string
+		for rs.Next() {
+			var name string
+			rs.Scan(&name)
+			names = append(names, name)
+
+			if len(names) == 1 {
+				if got := rs.SupportsScrolling(context.Background()); got != scrollable {
+					t.Errorf("expected SupportsScrolling %v, got %v", scrollable, got)
+				}
+			}
+		}
+
+		// The probe must not disturb the rows still to be read.
+		if expected := []string{"a", "b", "c"}; !reflect.DeepEqual(names, expected) {
+			t.Errorf("scrollable=%v: expected %v, got %v", scrollable, expected, names)
+		}
+	}
+}
+
+func TestPollStillExecuting(t *testing.T) {
+	svc := newFakeService()
+	conn := connectFake(t, svc, testOptions())
+
+	polls := 0
+	svc.onStatus = func(req *inf.TGetOperationStatusReq) (*inf.TGetOperationStatusResp, error) {
+		polls++
+		if polls == 1 {
+			return &inf.TGetOperationStatusResp{Status: &inf.TStatus{StatusCode: inf.TStatusCode_STILL_EXECUTING_STATUS}}, nil
+		}
+		state := inf.TOperationState_FINISHED_STATE
+		return &inf.TGetOperationStatusResp{Status: okStatus(), OperationState: &state}, nil
+	}
+
+	rs, err := conn.Query("SELECT 1")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+
+	status, err := rs.Poll()
+	if err != nil {
+		t.Fatalf("expected STILL_EXECUTING to be a running operation, got %v", err)
+	}
+	if status.IsComplete() {
+		t.Errorf("expected the operation to be running, got %s", status)
+	}
+}
+
+func TestQueryID(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	svc.onGetQueryId = func(h *inf.TOperationHandle) string {
+		return "hive_20260101000000_" + operationID(h)
+	}
+	conn := connectFake(t, svc, testOptions())
+
+	rs, err := conn.Query("SELECT 1")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	id, err := rs.QueryID(ctx)
+	if err != nil {
+		t.Fatalf("QueryID error: %v", err)
+	}
+	if want := "hive_20260101000000_" + operationID(rs.(*rowSet).operation); id != want {
+		t.Errorf("expected query ID %q, got %q", want, id)
+	}
+}
+
+func TestQueryIDFallsBackToGUID(t *testing.T) {
+	ctx := context.Background()
+	conn := connectFake(t, newFakeService(), testOptions())
+
+	rs, err := conn.Query("SELECT 1")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	id, err := rs.QueryID(ctx)
+	if err != nil {
+		t.Fatalf("QueryID error: %v", err)
+	}
+	if want := operationID(rs.(*rowSet).operation); id != want {
+		t.Errorf("expected the operation GUID %q, got %q", want, id)
+	}
+
+	// The connection is still usable after the unknown method.
+	if _, err := conn.Query("SELECT 2"); err != nil {
+		t.Errorf("Query after fallback error: %v", err)
+	}
+}
+
+func TestFetchTypeValidate(t *testing.T) {
+	for _, ft := range []FetchType{FetchQueryOutput, FetchLogs} {
+		if err := ft.Validate(); err != nil {
+			t.Errorf("%v: unexpected error %v", ft, err)
+		}
+	}
+	if err := FetchType(2).Validate(); err == nil {
+		t.Error("expected an error for an unknown fetch type")
+	}
+}
+
+func TestCloseByState(t *testing.T) {
+	ctx := context.Background()
+	running := inf.TOperationState_RUNNING_STATE
+	finished := inf.TOperationState_FINISHED_STATE
+
+	cases := []struct {
+		name    string
+		state   inf.TOperationState
+		cancels int
+	}{
+		{"running", running, 1},
+		{"finished", finished, 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := newFakeService()
+			state := tc.state
+			svc.onStatus = func(*inf.TGetOperationStatusReq) (*inf.TGetOperationStatusResp, error) {
+				return &inf.TGetOperationStatusResp{Status: okStatus(), OperationState: &state}, nil
+			}
+			conn := connectFake(t, svc, testOptions())
+
+			rs, err := conn.Query("SELECT 1")
+			if err != nil {
+				t.Fatalf("Query error: %v", err)
+			}
+			if _, err := rs.Poll(); err != nil {
+				t.Fatalf("Poll error: %v", err)
+			}
+
+			if err := rs.Close(ctx); err != nil {
+				t.Fatalf("Close error: %v", err)
+			}
+			if len(svc.cancels) != tc.cancels || len(svc.closes) != 1 {
+				t.Errorf("expected %d cancels and 1 close, got %d and %d", tc.cancels, len(svc.cancels), len(svc.closes))
+			}
+			if n := conn.InFlight(); n != 0 {
+				t.Errorf("expected the operation untracked, %d in flight", n)
+			}
+
+			// Closing again sends nothing.
+			if err := rs.Close(ctx); err != nil {
+				t.Fatalf("second Close error: %v", err)
+			}
+			if len(svc.cancels) != tc.cancels || len(svc.closes) != 1 {
+				t.Errorf("expected no calls for an already closed operation, got %d cancels and %d closes",
+					len(svc.cancels), len(svc.closes))
+			}
+		})
+	}
+}
+
+func TestAbort(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	svc.batches = []*inf.TRowSet{stringBatch("a", "b"), stringBatch("c")}
+	var calls []string
+	svc.onCancel = func(*inf.TCancelOperationReq) (*inf.TCancelOperationResp, error) {
+		calls = append(calls, "cancel")
+		return &inf.TCancelOperationResp{Status: okStatus()}, nil
+	}
+	svc.onClose = func(*inf.TCloseOperationReq) (*inf.TCloseOperationResp, error) {
+		calls = append(calls, "close")
+		return &inf.TCloseOperationResp{Status: okStatus()}, nil
+	}
+	conn := connectFake(t, svc, testOptions())
+
+	rs, err := conn.QueryContext(ctx, "SELECT * FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	if !rs.Next() {
+		t.Fatalf("expected a row, got %v", rs.Err())
+	}
+	if err := rs.Abort(ctx); err != nil {
+		t.Fatalf("Abort error: %v", err)
+	}
+	if expected := []string{"cancel", "close"}; !reflect.DeepEqual(calls, expected) {
+		t.Errorf("expected calls %v, got %v", expected, calls)
+	}
+	if n := conn.InFlight(); n != 0 {
+		t.Errorf("expected the operation untracked, %d in flight", n)
+	}
+
+	if rs.Next() {
+		t.Error("expected no rows after Abort")
+	}
+	if err := rs.Err(); err != ErrRowSetClosed {
+		t.Errorf("expected ErrRowSetClosed from Err, got %v", err)
+	}
+	var s string
+	if err := rs.Scan(&s); err != ErrRowSetClosed {
+		t.Errorf("expected ErrRowSetClosed from Scan, got %v", err)
+	}
+
+	// Neither aborting nor closing again sends anything.
+	if err := rs.Abort(ctx); err != nil {
+		t.Errorf("second Abort error: %v", err)
+	}
+	if err := rs.Close(ctx); err != nil {
+		t.Errorf("Close error: %v", err)
+	}
+	if len(calls) != 2 {
+		t.Errorf("expected no further calls, got %v", calls)
+	}
+}
+
+func TestRowSetStats(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("s", inf.TTypeId_STRING_TYPE, 1)}
+	batches := []*inf.TRowSet{stringBatch("ab", "c"), stringBatch("d"), stringBatch()}
+	clock := newFakeClock()
+	var fetches int
+	svc.onFetch = func(req *inf.TFetchResultsReq) (*inf.TFetchResultsResp, error) {
+		fetches++
+		clock.advance(5 * time.Millisecond)
+		// Like HiveServer2, report no more rows even when there are.
+		return &inf.TFetchResultsResp{Status: okStatus(), HasMoreRows: thrift.BoolPtr(false), Results: batches[min(fetches, len(batches))-1]}, nil
+	}
+	options := testOptions()
+	options.testClock = clock
+	conn := connectFake(t, svc, options)
+
+	rs, err := conn.Query("SELECT s FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	var rows []string
+	for rs.Next() {
+		var s string
+		if err := rs.Scan(&s); err != nil {
+			t.Fatalf("Scan error: %v", err)
+		}
+		rows = append(rows, s)
+	}
+	if err := rs.Err(); err != nil {
+		t.Fatalf("Next error: %v", err)
+	}
+	if !reflect.DeepEqual(rows, []string{"ab", "c", "d"}) {
+		t.Errorf("expected the rows of every batch, got %q", rows)
+	}
+
+	// Each string costs its length plus a 4 byte length prefix.
+	stats := rs.Stats()
+	expected := RowSetStats{Rows: 3, Batches: 3, Bytes: 6 + 5 + 5, FetchDuration: 15 * time.Millisecond, RemoteAddr: stats.RemoteAddr}
+	if stats != expected {
+		t.Errorf("expected %+v, got %+v", expected, stats)
+	}
+}
+
+func TestBatchMetrics(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("s", inf.TTypeId_STRING_TYPE, 1)}
+	batches := []*inf.TRowSet{stringBatch("a", "b", "c"), stringBatch("d"), stringBatch()}
+	clock := newFakeClock()
+	var fetches int
+	svc.onFetch = func(req *inf.TFetchResultsReq) (*inf.TFetchResultsResp, error) {
+		fetches++
+		clock.advance(time.Duration(fetches) * 10 * time.Millisecond)
+		return &inf.TFetchResultsResp{Status: okStatus(), Results: batches[min(fetches, len(batches))-1]}, nil
+	}
+	options := testOptions()
+	options.testClock = clock
+	options.BatchSize = 3
+	events := make(chan Event, 16)
+	options.Events = events
+	conn := connectFake(t, svc, options)
+
+	rs, err := conn.Query("SELECT s FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	for rs.Next() {
+	}
+	if err := rs.Err(); err != nil {
+		t.Fatalf("Next error: %v", err)
+	}
+
+	metrics := rs.BatchMetrics()
+	if len(metrics) != 3 {
+		t.Fatalf("expected a sample per fetch, got %+v", metrics)
+	}
+	for i, want := range []int{3, 1, 0} {
+		m := metrics[i]
+		if m.Rows != want || m.MaxRows != 3 || m.Duration != time.Duration(i+1)*10*time.Millisecond {
+			t.Errorf("unexpected sample %d: %+v", i, m)
+		}
+	}
+	if metrics[0].Bytes <= metrics[1].Bytes {
+		t.Errorf("expected the larger batch to be larger, got %+v", metrics)
+	}
+
+	var fetched []BatchFetched
+	for len(events) > 0 {
+		if ev, ok := (<-events).(BatchFetched); ok {
+			fetched = append(fetched, ev)
+		}
+	}
+	if len(fetched) != 3 || fetched[0].Bytes != metrics[0].Bytes || fetched[1].Duration != metrics[1].Duration {
+		t.Errorf("expected the samples published, got %+v", fetched)
+	}
+}
@@ -0,0 +1,191 @@
+package hive
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/apache/thrift/lib/go/thrift"
+)
+
+// Authentication mechanisms for Options.AuthMechanism.
+const (
+	// AuthNoSASL talks plain binary thrift, for servers configured with
+	// hive.server2.authentication=NOSASL. It is the default.
+	AuthNoSASL = "NOSASL"
+	// AuthPlain negotiates SASL PLAIN, used by servers configured with
+	// NONE or CUSTOM authentication. Without a username the session is
+	// opened as "anonymous".
+	AuthPlain = "PLAIN"
+	// AuthLDAP negotiates SASL PLAIN against a server configured with
+	// LDAP authentication. The password is the LDAP bind password; both
+	// a username and a password are required, since LDAP servers accept
+	// an empty password as an anonymous bind.
+	AuthLDAP = "LDAP"
+)
+
+// ErrAuthenticationFailed is returned when the server rejects the
+// credentials during the SASL negotiation.
+var ErrAuthenticationFailed = errors.New("hive: authentication failed")
+
+// ErrInsufficientQOP is returned by the Connect variants when the SASL
+// quality of protection is weaker than Options.RequireQOP.
+var ErrInsufficientQOP = errors.New("hive: insufficient SASL quality of protection")
+
+// SASL quality of protection levels, weakest first, for
+// Options.RequireQOP and Connection.SASLQOP.
+const (
+	// QOPAuth authenticates the connection only.
+	QOPAuth = "auth"
+	// QOPAuthInt adds integrity protection of the traffic.
+	QOPAuthInt = "auth-int"
+	// QOPAuthConf adds confidentiality, i.e. encryption of the traffic.
+	QOPAuthConf = "auth-conf"
+)
+
+// qopRank orders QOP levels; an unknown or empty level ranks lowest.
+func qopRank(qop string) int {
+	switch qop {
+	case QOPAuth:
+		return 1
+	case QOPAuthInt:
+		return 2
+	case QOPAuthConf:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// checkQOP compares the negotiated QOP with Options.RequireQOP.
+func checkQOP(qop string, options Options) error {
+	if qopRank(qop) >= qopRank(options.RequireQOP) {
+		return nil
+	}
+	if qop == "" {
+		qop = "none"
+	}
+	return fmt.Errorf("%w: negotiated %s, %s required", ErrInsufficientQOP, qop, options.RequireQOP)
+}
+
+// SASL negotiation status bytes, as in Hive's TSaslTransport.
+const (
+	saslStart    byte = 1
+	saslOK       byte = 2
+	saslBad      byte = 3
+	saslError    byte = 4
+	saslComplete byte = 5
+)
+
+// authMechanism returns the normalised mechanism name of o.
+func (o Options) authMechanism() string {
+	if o.AuthMechanism == "" {
+		return AuthNoSASL
+	}
+	return strings.ToUpper(o.AuthMechanism)
+}
+
+// saslCredentials picks the credentials for the negotiation: those passed
+// to ConnectWithUser take precedence over Options.Username and Password.
+func saslCredentials(username, password *string, options Options) (string, string, error) {
+	user, pass := options.Username, options.Password
+	if username != nil {
+		user = *username
+	}
+	if password != nil {
+		pass = *password
+	}
+
+	switch options.authMechanism() {
+	case AuthLDAP:
+		if user == "" || pass == "" {
+			return "", "", errors.New("LDAP authentication requires a username and password")
+		}
+	case AuthPlain:
+		if user == "" {
+			user = "anonymous"
+		}
+	}
+	return user, pass, nil
+}
+
+// saslHandshake runs the SASL PLAIN negotiation on an open transport and
+// returns the negotiated QOP, which for PLAIN is always QOPAuth.
+// Afterwards the connection carries length-prefixed frames, as written by
+// thrift.TFramedTransport.
+//
+// The transport's reads and writes don't watch ctx, so the caller closes
+// it once ctx ends, which fails a frame in progress; between frames
+// saslHandshake stops by itself, returning ctx's error.
+func saslHandshake(ctx context.Context, transport thrift.TTransport, options Options, username, password string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if err := writeSaslMessage(ctx, transport, saslStart, []byte("PLAIN")); err != nil {
+		return "", err
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	response := "\x00" + username + "\x00" + password
+	if err := writeSaslMessage(ctx, transport, saslComplete, []byte(response)); err != nil {
+		return "", err
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	status, payload, err := readSaslMessage(transport)
+	if err != nil {
+		return "", err
+	}
+	switch status {
+	case saslComplete, saslOK:
+		return QOPAuth, nil
+	case saslBad, saslError:
+		if options.authMechanism() == AuthLDAP {
+			return "", fmt.Errorf("%w: LDAP bind for user %q rejected: %s", ErrAuthenticationFailed, username, payload)
+		}
+		return "", fmt.Errorf("%w: %s", ErrAuthenticationFailed, payload)
+	default:
+		return "", fmt.Errorf("Unexpected SASL status %d during negotiation", status)
+	}
+}
+
+func writeSaslMessage(ctx context.Context, transport thrift.TTransport, status byte, payload []byte) error {
+	header := make([]byte, 5)
+	header[0] = status
+	binary.BigEndian.PutUint32(header[1:], uint32(len(payload)))
+	if _, err := transport.Write(append(header, payload...)); err != nil {
+		return fmt.Errorf("Error in SASL negotiation: %v", err)
+	}
+	if err := transport.Flush(ctx); err != nil {
+		return fmt.Errorf("Error in SASL negotiation: %v", err)
+	}
+	return nil
+}
+
+func readSaslMessage(transport thrift.TTransport) (byte, []byte, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(transport, header); err != nil {
+		return 0, nil, fmt.Errorf("Error in SASL negotiation: %v", err)
+	}
+	n := binary.BigEndian.Uint32(header[1:])
+	if n > thrift.DEFAULT_MAX_FRAME_SIZE {
+		return 0, nil, fmt.Errorf("SASL message of %d bytes exceeds the frame size limit", n)
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(transport, payload); err != nil {
+		return 0, nil, fmt.Errorf("Error in SASL negotiation: %v", err)
+	}
+	return header[0], payload, nil
+}
+
+// SASLQOP returns the SASL quality of protection negotiated for the
+// connection, or "" if it doesn't use SASL or isn't open yet.
+func (c *Connection) SASLQOP() string {
+	return c.qop
+}
@@ -0,0 +1,164 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestLDAPAuthentication(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	svc.saslUsers = map[string]string{"alice": "s3cret"}
+	addr := startFakeServer(t, svc)
+
+	options := testOptions()
+	options.AuthMechanism = AuthLDAP
+
+	conn, err := ConnectWithUserContext(ctx, addr, "alice", "s3cret", options)
+	if err != nil {
+		t.Fatalf("ConnectWithUserContext error: %v", err)
+	}
+	if _, err := conn.Exec("SELECT 1"); err != nil {
+		t.Errorf("Exec over the SASL transport failed: %v", err)
+	}
+	conn.Close()
+
+	_, err = ConnectWithUserContext(ctx, addr, "alice", "wrong", options)
+	if !errors.Is(err, ErrAuthenticationFailed) {
+		t.Errorf("expected ErrAuthenticationFailed for a bad password, got %v", err)
+	}
+
+	if _, err := ConnectWithUserContext(ctx, addr, "alice", "", options); err == nil {
+		t.Error("expected an empty LDAP password to be rejected before dialling")
+	}
+	if _, err := ConnectContext(ctx, addr, options); err == nil {
+		t.Error("expected LDAP without credentials to be rejected")
+	}
+}
+
+func TestPlainAuthenticationUsesOptions(t *testing.T) {
+	svc := newFakeService()
+	svc.saslUsers = map[string]string{"bob": "pw"}
+
+	options := testOptions()
+	options.AuthMechanism = "plain"
+	options.Username = "bob"
+	options.Password = "pw"
+	conn := connectFake(t, svc, options)
+	conn.Close()
+}
+
+func TestValidateAuthMechanism(t *testing.T) {
+	options := DefaultOptions
+	options.AuthMechanism = "KERBEROS"
+	if err := options.Validate(); err == nil {
+		t.Error("expected an unsupported mechanism to be rejected")
+	}
+}
+
+func TestRequireQOP(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	svc.saslUsers = map[string]string{"bob": "pw"}
+	saslAddr := startFakeServer(t, svc)
+	plainAddr := startFakeServer(t, newFakeService())
+
+	for _, tc := range []struct {
+		name      string
+		auth      string
+		require   string
+		wantQOP   string
+		wantError bool
+	}{
+		{"PLAIN negotiates auth", AuthPlain, "", QOPAuth, false},
+		{"auth required and met", AuthPlain, QOPAuth, QOPAuth, false},
+		{"auth-int over PLAIN", AuthPlain, QOPAuthInt, "", true},
+		{"auth-conf over PLAIN", AuthPlain, QOPAuthConf, "", true},
+		{"no SASL", AuthNoSASL, "", "", false},
+		{"auth required without SASL", AuthNoSASL, QOPAuth, "", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			options := testOptions()
+			options.AuthMechanism = tc.auth
+			options.RequireQOP = tc.require
+			addr := plainAddr
+			if tc.auth != AuthNoSASL {
+				addr = saslAddr
+				options.Username, options.Password = "bob", "pw"
+			}
+
+			conn, err := ConnectContext(ctx, addr, options)
+			if tc.wantError {
+				if !errors.Is(err, ErrInsufficientQOP) {
+					t.Errorf("expected ErrInsufficientQOP, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Connect error: %v", err)
+			}
+			defer conn.Close()
+			if got := conn.SASLQOP(); got != tc.wantQOP {
+				t.Errorf("expected QOP %q, got %q", tc.wantQOP, got)
+			}
+		})
+	}
+
+	options := DefaultOptions
+	options.RequireQOP = "privacy"
+	if err := options.Validate(); err == nil {
+		t.Error("expected an unknown QOP to be rejected")
+	}
+}
+
+func TestSASLHandshakeCancelled(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen error: %v", err)
+	}
+	defer l.Close()
+	// The server reads the client's SASL messages and never answers, as
+	// when the server is stuck waiting on its KDC.
+	closed := make(chan error, 1)
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		_, err = io.Copy(io.Discard, c)
+		closed <- err
+	}()
+
+	before := runtime.NumGoroutine()
+	options := testOptions()
+	options.AuthMechanism = AuthPlain
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = ConnectContext(ctx, l.Addr().String(), options)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the context's error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the handshake to stop with the context, took %v", elapsed)
+	}
+
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("expected the connection to be closed")
+	}
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("expected no goroutines left behind, %d before and %d after", before, n)
+	}
+}
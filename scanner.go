@@ -0,0 +1,233 @@
+package hive
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A StatementScanner reads the statements of a HiveQL script from a
+// stream, splitting them as SplitStatements does without holding more
+// than the current statement in memory. Statements, strings and comments
+// may span any number of reads.
+type StatementScanner struct {
+	r    *bufio.Reader
+	line int
+	stmt string
+	at   int
+	err  error
+}
+
+// NewStatementScanner returns a scanner reading script from r.
+func NewStatementScanner(r io.Reader) *StatementScanner {
+	return &StatementScanner{r: bufio.NewReader(r), line: 1}
+}
+
+// Scan advances to the next statement, returning false at the end of the
+// script or on error; check Err afterwards.
+func (s *StatementScanner) Scan() bool {
+	if s.err != nil {
+		return false
+	}
+	var cur strings.Builder
+	s.at = 0
+	for {
+		c, err := s.r.ReadByte()
+		if err != nil {
+			if err != io.EOF {
+				s.err = err
+				return false
+			}
+			if s.emit(&cur) {
+				return true
+			}
+			s.err = io.EOF
+			return false
+		}
+		if c != ' ' && c != '\t' && c != '\n' && c != '\r' && c != ';' && s.at == 0 &&
+			!(c == '-' && s.peek('-')) && !(c == '/' && s.peek('*')) {
+			s.at = s.line
+		}
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			if err := s.quoted(&cur, c); err != nil {
+				s.err = err
+				return false
+			}
+		case c == '-' && s.peek('-'):
+			if err := s.skipLine(); err != nil {
+				s.err = err
+				return false
+			}
+			cur.WriteByte('\n')
+		case c == '/' && s.peek('*'):
+			if err := s.skipComment(); err != nil {
+				s.err = err
+				return false
+			}
+			cur.WriteByte(' ')
+		case c == ';':
+			if s.emit(&cur) {
+				return true
+			}
+		default:
+			if c == '\n' {
+				s.line++
+			}
+			cur.WriteByte(c)
+		}
+	}
+}
+
+// Statement returns the statement found by the last successful Scan.
+func (s *StatementScanner) Statement() string {
+	return s.stmt
+}
+
+// Line returns the line, counting from 1, on which the statement found
+// by the last successful Scan starts.
+func (s *StatementScanner) Line() int {
+	return s.at
+}
+
+// Err returns the error that ended Scan, or nil at the end of the script.
+// An unterminated string, identifier or block comment is an error, as
+// for SplitStatements.
+func (s *StatementScanner) Err() error {
+	if s.err == io.EOF {
+		return nil
+	}
+	return s.err
+}
+
+// emit makes the trimmed contents of cur the current statement, and
+// reports whether it is non-empty.
+func (s *StatementScanner) emit(cur *strings.Builder) bool {
+	s.stmt = strings.TrimSpace(cur.String())
+	cur.Reset()
+	if s.stmt == "" {
+		s.at = 0
+		return false
+	}
+	return true
+}
+
+// peek reports whether the next byte is c.
+func (s *StatementScanner) peek(c byte) bool {
+	b, err := s.r.Peek(1)
+	return err == nil && b[0] == c
+}
+
+// quoted copies a string literal or quoted identifier to cur, its
+// opening quote already read; see quotedEnd.
+func (s *StatementScanner) quoted(cur *strings.Builder, quote byte) error {
+	start := s.line
+	cur.WriteByte(quote)
+	for {
+		c, err := s.r.ReadByte()
+		if err != nil {
+			return s.unterminated(err, string(quote), start)
+		}
+		if c == '\n' {
+			s.line++
+		}
+		cur.WriteByte(c)
+		switch c {
+		case '\\':
+			if quote == '`' {
+				continue
+			}
+			c, err := s.r.ReadByte()
+			if err != nil {
+				return s.unterminated(err, string(quote), start)
+			}
+			if c == '\n' {
+				s.line++
+			}
+			cur.WriteByte(c)
+		case quote:
+			if quote == '`' && s.peek('`') {
+				s.r.ReadByte()
+				cur.WriteByte('`')
+				continue
+			}
+			return nil
+		}
+	}
+}
+
+// skipLine skips a "--" comment, its first '-' already read, up to and
+// including the newline ending it.
+func (s *StatementScanner) skipLine() error {
+	_, err := s.r.ReadString('\n')
+	if err == io.EOF {
+		return nil
+	}
+	s.line++
+	return err
+}
+
+// skipComment skips a "/* */" comment, its '/' already read.
+func (s *StatementScanner) skipComment() error {
+	start := s.line
+	s.r.ReadByte()
+	var prev byte
+	for {
+		c, err := s.r.ReadByte()
+		if err != nil {
+			return s.unterminated(err, "/*", start)
+		}
+		if c == '\n' {
+			s.line++
+		}
+		if prev == '*' && c == '/' {
+			return nil
+		}
+		prev = c
+	}
+}
+
+func (s *StatementScanner) unterminated(err error, what string, line int) error {
+	if err == io.EOF {
+		return fmt.Errorf("Unterminated %s at line %d", what, line)
+	}
+	return err
+}
+
+// ExecScriptReader runs the statements of the script read from r one
+// after another as ExecScript does, reading and splitting it as it goes
+// with a StatementScanner, so a large script is never held in memory
+// whole. Statements before an unterminated string or comment are run
+// before the error is found. It stops at the first failure with a
+// *ScriptError.
+func (c *Connection) ExecScriptReader(ctx context.Context, r io.Reader) error {
+	s := NewStatementScanner(r)
+	for n := 1; s.Scan(); n++ {
+		if err := c.execScriptStatement(ctx, s.Statement()); err != nil {
+			return &ScriptError{Statement: n, Line: s.Line(), SQL: s.Statement(), Err: err}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return fmt.Errorf("Error reading script: %w", err)
+	}
+	return nil
+}
+
+// A ScriptError reports the statement of a script that failed.
+type ScriptError struct {
+	// Statement counts from 1, and Line is the line it starts on.
+	Statement int
+	Line      int
+	SQL       string
+	Err       error
+}
+
+func (e *ScriptError) Error() string {
+	return fmt.Sprintf("Statement %d (line %d): %v", e.Statement, e.Line, e.Err)
+}
+
+func (e *ScriptError) Unwrap() error {
+	return e.Err
+}
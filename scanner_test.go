@@ -0,0 +1,127 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// scanAll splits script with a StatementScanner reading a byte at a time,
+// so every token spans reads.
+func scanAll(script string) ([]string, []int, error) {
+	s := NewStatementScanner(iotest.OneByteReader(strings.NewReader(script)))
+	var (
+		stmts []string
+		lines []int
+	)
+	for s.Scan() {
+		stmts = append(stmts, s.Statement())
+		lines = append(lines, s.Line())
+	}
+	return stmts, lines, s.Err()
+}
+
+func TestStatementScannerMatchesSplitStatements(t *testing.T) {
+	for _, script := range []string{
+		"SELECT 1; SELECT 2;",
+		"SELECT ';'; SELECT \"a;b\"",
+		`SELECT 'it\'s; fine'`,
+		"SELECT `odd;name` FROM t",
+		"SELECT `a``;b` FROM t",
+		"-- header; comment\nSELECT 1",
+		"SELECT 1 -- trailing; comment\n;",
+		"SELECT /* a; b */ 1; /* only */;",
+		"SELECT /**/ 1; SELECT /*/ x */ 2",
+		"SELECT '--not a comment'; SELECT 2",
+		"  ;; \n ; ",
+		"SET x=1;\nUSE db;\nSELECT * FROM t\n--",
+		"SELECT 'open",
+		"SELECT `open",
+		`SELECT 'escaped end\'`,
+		"SELECT 1 /* open",
+	} {
+		want, wantErr := SplitStatements(script)
+		got, _, err := scanAll(script)
+		if (err != nil) != (wantErr != nil) {
+			t.Errorf("%q: expected error %v, got %v", script, wantErr, err)
+			continue
+		}
+		if wantErr == nil && !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: expected %q, got %q", script, want, got)
+		}
+	}
+}
+
+func TestStatementScannerLines(t *testing.T) {
+	script := "-- migration\nUSE db;\n\n/* create\n the table */ CREATE TABLE t (\n  s STRING\n);\nINSERT INTO t VALUES ('a\nb'); SELECT 1;\nSELECT 'open"
+	stmts, lines, err := scanAll(script)
+	if err == nil || err.Error() != "Unterminated ' at line 10" {
+		t.Errorf("expected the unterminated string reported at line 10, got %v", err)
+	}
+	if expected := []int{2, 5, 8, 9}; !reflect.DeepEqual(lines, expected) {
+		t.Errorf("expected statements at lines %v, got %v (%q)", expected, lines, stmts)
+	}
+}
+
+func FuzzStatementScanner(f *testing.F) {
+	for _, seed := range []string{
+		"SELECT 1; SELECT 2",
+		"SELECT 'a;b' -- c;\n; /* d; */ SELECT `e;f`",
+		`SELECT '\'; SELECT "\"";`,
+		"SELECT `a``b`;",
+		"--;\n/*;*/;",
+		"'",
+		"/*",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, script string) {
+		want, wantErr := SplitStatements(script)
+		got, _, err := scanAll(script)
+		if (err != nil) != (wantErr != nil) {
+			t.Fatalf("expected error %v, got %v", wantErr, err)
+		}
+		if wantErr == nil && !reflect.DeepEqual(got, want) {
+			t.Fatalf("expected %q, got %q", want, got)
+		}
+	})
+}
+
+func TestExecScriptReader(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	svc.onExecute = func(req *inf.TExecuteStatementReq) (*inf.TExecuteStatementResp, error) {
+		if strings.HasPrefix(req.Statement, "BROKEN") {
+			return &inf.TExecuteStatementResp{Status: errorStatus("ParseException")}, nil
+		}
+		svc.mu.Lock()
+		defer svc.mu.Unlock()
+		return &inf.TExecuteStatementResp{Status: okStatus(), OperationHandle: svc.newOperation(req.Statement)}, nil
+	}
+	conn := connectFake(t, svc, testOptions())
+
+	script := "USE sales; -- switch\nCREATE TABLE t (s STRING);\n\nBROKEN\nSTATEMENT;\nINSERT INTO t VALUES ('a;b');"
+	err := conn.ExecScriptReader(ctx, iotest.OneByteReader(strings.NewReader(script)))
+	var scriptErr *ScriptError
+	if !errors.As(err, &scriptErr) {
+		t.Fatalf("expected a ScriptError, got %v", err)
+	}
+	if scriptErr.Statement != 3 || scriptErr.Line != 4 || scriptErr.SQL != "BROKEN\nSTATEMENT" {
+		t.Errorf("expected statement 3 on line 4 reported, got %+v", scriptErr)
+	}
+	if !strings.HasPrefix(err.Error(), "Statement 3 (line 4):") {
+		t.Errorf("unexpected message %q", err)
+	}
+	want := []string{"USE sales", "CREATE TABLE t (s STRING)", "BROKEN\nSTATEMENT"}
+	if got := svc.executed(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if n := conn.InFlight(); n != 0 {
+		t.Errorf("expected every statement closed, %d in flight", n)
+	}
+}
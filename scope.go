@@ -0,0 +1,171 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// ErrResetUnsupported is returned by WithSession for an override of a
+// setting the session doesn't define, on a server too old to clear a
+// single setting again.
+var ErrResetUnsupported = errors.New("hive: server can't reset a single setting; RESET <key> needs Hive 3")
+
+// WithSession runs fn with the session settings in overrides applied by
+// SET statements, and afterwards puts back the values they had before.
+// The prior values are read from the server with SET <key>; keys that
+// were undefined are cleared with RESET <key>. Hive before 3.0 ignores
+// the key of RESET and resets every setting of the session, so when
+// GetInfo reports such a version, or fails, an override of an undefined
+// key fails with ErrResetUnsupported before it is set.
+// The restore runs even if fn fails or ctx is cancelled. An error from
+// fn takes precedence over one from restoring. With
+// Options.CoalesceSessionConf, settings the session already has are
+// left out.
+//
+// The overrides apply to the whole session, so other goroutines sharing
+// the Connection see them while fn runs.
+func (c *Connection) WithSession(ctx context.Context, overrides map[string]string, fn func(c *Connection) error) (err error) {
+	keys := make([]string, 0, len(overrides))
+	for key, value := range overrides {
+		if key == "" || strings.ContainsAny(key, "= \t\n;") || strings.ContainsAny(value, "\n;") {
+			return fmt.Errorf("Invalid session setting %q=%q", key, value)
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	type prior struct {
+		key, value string
+		defined    bool
+	}
+	var applied []prior
+	defer func() {
+		restoreCtx := context.WithoutCancel(ctx)
+		var restoreErr error
+		for i := len(applied) - 1; i >= 0; i-- {
+			p := applied[i]
+			stmt := "RESET " + p.key
+			if p.defined {
+				stmt = "SET " + p.key + "=" + p.value
+			}
+			if _, e := c.execContext(restoreCtx, stmt); e != nil && restoreErr == nil {
+				restoreErr = fmt.Errorf("Error restoring %s: %v", p.key, e)
+			}
+		}
+		if err == nil {
+			err = restoreErr
+		}
+	}()
+
+	for _, key := range keys {
+		value, defined := c.knownConf(key)
+		if defined && value == overrides[key] {
+			continue
+		}
+		if !defined {
+			var err error
+			if value, defined, err = c.readConf(ctx, key); err != nil {
+				return err
+			}
+			if !defined && !c.resetsKeys(ctx) {
+				return fmt.Errorf("Cannot override %s: %w", key, ErrResetUnsupported)
+			}
+		}
+		if _, err := c.execContext(ctx, "SET "+key+"="+overrides[key]); err != nil {
+			return fmt.Errorf("Error setting %s: %v", key, err)
+		}
+		applied = append(applied, prior{key, value, defined})
+	}
+	return fn(c)
+}
+
+// resetsKeys reports whether the server clears a single setting with
+// RESET <key>, which Hive does from 3.0, going by the version GetInfo
+// reports, and caches the answer for the Connection. The negotiated
+// protocol doesn't tell: the client asks for V7, which Hive 3 answers
+// with V7 too. A server whose GetInfo fails counts as too old.
+func (c *Connection) resetsKeys(ctx context.Context) bool {
+	c.mu.Lock()
+	cached := c.keyReset
+	c.mu.Unlock()
+	if cached != nil {
+		return *cached
+	}
+
+	supported := false
+	if version, err := c.getInfo(ctx, inf.TGetInfoType_CLI_DBMS_VER); err == nil {
+		supported = versionAtLeast(version.GetStringValue(), 3, 0)
+	}
+	c.mu.Lock()
+	c.keyReset = &supported
+	c.mu.Unlock()
+	return supported
+}
+
+// knownConf returns the value the session is known to have for key,
+// under Options.CoalesceSessionConf. OpenSession configuration keys may
+// carry the "set:hiveconf:" prefix.
+func (c *Connection) knownConf(key string) (string, bool) {
+	if !c.options.CoalesceSessionConf {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if value, ok := c.sessionConf[key]; ok {
+		return value, true
+	}
+	value, ok := c.sessionConf["set:hiveconf:"+key]
+	return value, ok
+}
+
+// execContext runs stmt synchronously.
+func (c *Connection) execContext(ctx context.Context, stmt string) (*inf.TExecuteStatementResp, error) {
+	var resp *inf.TExecuteStatementResp
+	if queued, err := c.inStatementScope(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = c.execContext(ctx, stmt)
+		return err
+	}); queued {
+		return resp, err
+	}
+
+	if err := c.ensureSession(ctx); err != nil {
+		return nil, err
+	}
+	executeReq := inf.NewTExecuteStatementReq()
+	executeReq.Statement = stmt
+	return c.executeStatement(ctx, executeReq)
+}
+
+// readConf reads a session setting with SET <key>. defined is false if
+// the server reports the key as undefined.
+func (c *Connection) readConf(ctx context.Context, key string) (value string, defined bool, err error) {
+	executeReq := inf.NewTExecuteStatementReq()
+	executeReq.Statement = "SET " + key
+	rs, err := c.submit(ctx, executeReq)
+	if err != nil {
+		return "", false, err
+	}
+	defer rs.Close(ctx)
+
+	if !rs.next(ctx) {
+		if rs.err != nil {
+			return "", false, rs.err
+		}
+		return "", false, errors.New("SET " + key + " returned no rows")
+	}
+	line := strings.TrimSpace(fmt.Sprint(rs.nextRow[0]))
+	if line == key+" is undefined" {
+		return "", false, nil
+	}
+	k, value, ok := strings.Cut(line, "=")
+	if !ok || strings.TrimSpace(k) != key {
+		return "", false, fmt.Errorf("Server doesn't report %s: %q", key, line)
+	}
+	return strings.TrimSpace(value), true, nil
+}
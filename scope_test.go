@@ -0,0 +1,216 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestWithSession(t *testing.T) {
+	svc := newFakeService()
+	svc.reportVersion("3.1.3")
+	svc.results = map[string][]*inf.TRowSet{
+		"SET hive.exec.parallel":     {stringBatch("hive.exec.parallel=false")},
+		"SET mapreduce.job.priority": {stringBatch("mapreduce.job.priority is undefined")},
+	}
+	conn := connectFake(t, svc, testOptions())
+
+	failure := errors.New("query failed")
+	err := conn.WithSession(context.Background(), map[string]string{
+		"hive.exec.parallel":     "true",
+		"mapreduce.job.priority": "HIGH",
+	}, func(c *Connection) error {
+		if _, err := c.Exec("INSERT INTO t SELECT * FROM s"); err != nil {
+			return err
+		}
+		return failure
+	})
+	if err != failure {
+		t.Errorf("expected the error from fn, got %v", err)
+	}
+
+	expected := []string{
+		"SET hive.exec.parallel",
+		"SET hive.exec.parallel=true",
+		"SET mapreduce.job.priority",
+		"SET mapreduce.job.priority=HIGH",
+		"INSERT INTO t SELECT * FROM s",
+		"RESET mapreduce.job.priority",
+		"SET hive.exec.parallel=false",
+	}
+	if got := svc.executed(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected statements\n%q\ngot\n%q", expected, got)
+	}
+
+	info, err := conn.SessionInfo(context.Background())
+	if err != nil {
+		t.Fatalf("SessionInfo error: %v", err)
+	}
+	if _, ok := info.SessionConf["mapreduce.job.priority"]; ok || info.SessionConf["hive.exec.parallel"] != "false" {
+		t.Errorf("expected the restored settings in SessionInfo, got %v", info.SessionConf)
+	}
+}
+
+func TestWithSessionUndefinedKeyOnOldServer(t *testing.T) {
+	svc := newFakeService()
+	svc.reportVersion("2.3.9")
+	svc.results = map[string][]*inf.TRowSet{
+		"SET hive.exec.parallel":     {stringBatch("hive.exec.parallel=false")},
+		"SET mapreduce.job.priority": {stringBatch("mapreduce.job.priority is undefined")},
+	}
+	conn := connectFake(t, svc, testOptions())
+
+	ran := false
+	err := conn.WithSession(context.Background(), map[string]string{
+		"hive.exec.parallel":     "true",
+		"mapreduce.job.priority": "HIGH",
+	}, func(c *Connection) error {
+		ran = true
+		return nil
+	})
+	if !errors.Is(err, ErrResetUnsupported) {
+		t.Errorf("expected ErrResetUnsupported, got %v", err)
+	}
+	if ran {
+		t.Error("expected fn not to run")
+	}
+
+	// The key already applied is put back; nothing is RESET.
+	expected := []string{
+		"SET hive.exec.parallel",
+		"SET hive.exec.parallel=true",
+		"SET mapreduce.job.priority",
+		"SET hive.exec.parallel=false",
+	}
+	if got := svc.executed(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected statements\n%q\ngot\n%q", expected, got)
+	}
+}
+
+func TestWithSessionProbesVersionOnce(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		version string
+		fail    bool
+		reset   bool
+	}{
+		{name: "hive 3", version: "3.1.3", reset: true},
+		{name: "hive 2", version: "2.3.9-amzn-2"},
+		{name: "no answer", fail: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := newFakeService()
+			svc.results = map[string][]*inf.TRowSet{
+				"SET tez.queue.name": {stringBatch("tez.queue.name is undefined")},
+			}
+			probes := 0
+			svc.onGetInfo = func(req *inf.TGetInfoReq) (*inf.TGetInfoResp, error) {
+				probes++
+				if tc.fail {
+					return &inf.TGetInfoResp{Status: errorStatus("Unrecognized GetInfoType value"), InfoValue: &inf.TGetInfoValue{StringValue: thrift.StringPtr("")}}, nil
+				}
+				return &inf.TGetInfoResp{Status: okStatus(), InfoValue: &inf.TGetInfoValue{StringValue: thrift.StringPtr(tc.version)}}, nil
+			}
+			conn := connectFake(t, svc, testOptions())
+
+			for i := 0; i < 2; i++ {
+				err := conn.WithSession(context.Background(), map[string]string{"tez.queue.name": "etl"}, func(*Connection) error { return nil })
+				if tc.reset && err != nil {
+					t.Errorf("WithSession error: %v", err)
+				}
+				if !tc.reset && !errors.Is(err, ErrResetUnsupported) {
+					t.Errorf("expected ErrResetUnsupported, got %v", err)
+				}
+			}
+			if probes != 1 {
+				t.Errorf("expected the version to be probed once, got %d probes", probes)
+			}
+		})
+	}
+}
+
+func TestWithSessionCoalesceSessionConf(t *testing.T) {
+	svc := newFakeService()
+	svc.results = map[string][]*inf.TRowSet{
+		"SET mapreduce.job.queuename": {stringBatch("mapreduce.job.queuename=default")},
+	}
+	options := testOptions()
+	options.CoalesceSessionConf = true
+	options.SessionConf = map[string]string{"set:hiveconf:hive.execution.engine": "tez"}
+	conn := connectFake(t, svc, options)
+	ctx := context.Background()
+
+	if _, err := conn.Exec("SET tez.queue.name=etl"); err != nil {
+		t.Fatalf("Exec error: %v", err)
+	}
+	overrides := map[string]string{
+		"hive.execution.engine":   "tez",
+		"tez.queue.name":          "etl",
+		"mapreduce.job.queuename": "etl",
+	}
+	run := func() {
+		err := conn.WithSession(ctx, overrides, func(c *Connection) error {
+			_, err := c.Exec("INSERT INTO t SELECT * FROM s")
+			return err
+		})
+		if err != nil {
+			t.Fatalf("WithSession error: %v", err)
+		}
+	}
+
+	// Only the queue MapReduce uses isn't known yet.
+	run()
+	expected := []string{
+		"SET tez.queue.name=etl",
+		"SET mapreduce.job.queuename",
+		"SET mapreduce.job.queuename=etl",
+		"INSERT INTO t SELECT * FROM s",
+		"SET mapreduce.job.queuename=default",
+	}
+	if got := svc.executed(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected statements\n%q\ngot\n%q", expected, got)
+	}
+
+	// Its prior value is known now, so it is set and restored without
+	// being read again.
+	run()
+	expected = append(expected,
+		"SET mapreduce.job.queuename=etl",
+		"INSERT INTO t SELECT * FROM s",
+		"SET mapreduce.job.queuename=default",
+	)
+	if got := svc.executed(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected statements\n%q\ngot\n%q", expected, got)
+	}
+
+	// Re-applying settings the session has issues no SET statements.
+	if _, err := conn.Exec("SET mapreduce.job.queuename=etl"); err != nil {
+		t.Fatalf("Exec error: %v", err)
+	}
+	run()
+	expected = append(expected, "SET mapreduce.job.queuename=etl", "INSERT INTO t SELECT * FROM s")
+	if got := svc.executed(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected statements\n%q\ngot\n%q", expected, got)
+	}
+}
+
+func TestWithSessionRejectsInvalidSetting(t *testing.T) {
+	svc := newFakeService()
+	conn := connectFake(t, svc, testOptions())
+
+	called := false
+	err := conn.WithSession(context.Background(), map[string]string{"a=b": "c"}, func(*Connection) error {
+		called = true
+		return nil
+	})
+	if err == nil || called {
+		t.Errorf("expected an error without running fn, got %v (called %v)", err, called)
+	}
+	if got := svc.executed(); len(got) != 0 {
+		t.Errorf("expected no statements, got %q", got)
+	}
+}
@@ -0,0 +1,34 @@
+package hive
+
+import (
+	"context"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// SupportsScrolling reports whether the server can rewind this result
+// set with FETCH_FIRST, which only some servers and result sets allow.
+// The answer is probed once, after the operation completes, and cached.
+//
+// The probe rewinds the server-side cursor, so if rows were already
+// fetched it reads them again, in one request, to restore the position.
+func (r *rowSet) SupportsScrolling(ctx context.Context) bool {
+	if r.scrollProbed {
+		return r.scrollable
+	}
+	if err := r.waitForSuccess(ctx); err != nil {
+		return false
+	}
+
+	fetchReq := r.fetchRequest(inf.TFetchOrientation_FETCH_FIRST, r.stats.Rows, FetchQueryOutput)
+
+	resp, err := r.thrift.FetchResults(ctx, fetchReq)
+	if err != nil {
+		// Without a response the cursor position is unknown; don't cache.
+		return false
+	}
+
+	r.scrollProbed = true
+	r.scrollable = isSuccessStatus(resp.Status)
+	return r.scrollable
+}
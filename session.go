@@ -0,0 +1,235 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// SessionInfo describes the state of a session as tracked by the client.
+type SessionInfo struct {
+	// Database is the database selected with Options.Database or the last
+	// USE statement run on the connection. Empty means the server default.
+	Database        string
+	ProtocolVersion inf.TProtocolVersion
+	// SessionConf holds Options.SessionConf plus any SET key=value
+	// statements run since.
+	SessionConf map[string]string
+	// Operations is the number of RowSets opened on the connection that
+	// haven't been closed.
+	Operations int
+}
+
+// SessionInfo returns the client-side view of the session. It doesn't
+// contact the server.
+func (c *Connection) SessionInfo(ctx context.Context) (SessionInfo, error) {
+	if !c.isOpen() {
+		return SessionInfo{}, errors.New("Session is closed")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	conf := make(map[string]string, len(c.sessionConf))
+	for k, v := range c.sessionConf {
+		conf[k] = v
+	}
+	return SessionInfo{
+		Database:        c.database,
+		ProtocolVersion: c.protocol,
+		SessionConf:     conf,
+		Operations:      len(c.operations),
+	}, nil
+}
+
+// InFlight returns the number of RowSets opened on the connection that
+// haven't been closed.
+func (c *Connection) InFlight() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.operations)
+}
+
+// submit executes executeReq and returns a RowSet for it, tracked until
+// it is closed. With Options.MaxConcurrentOperations set it first waits
+// for a free slot.
+func (c *Connection) submit(ctx context.Context, executeReq *inf.TExecuteStatementReq) (*rowSet, error) {
+	var rs *rowSet
+	if queued, err := c.inStatementScope(ctx, func(ctx context.Context) error {
+		var err error
+		rs, err = c.submit(ctx, executeReq)
+		return err
+	}); queued {
+		if err != nil && rs != nil {
+			rs.Close(context.WithoutCancel(ctx))
+			rs = nil
+		}
+		return rs, err
+	}
+
+	if err := c.ensureSession(ctx); err != nil {
+		return nil, err
+	}
+	c.expireIdle(ctx)
+	if err := c.acquire(ctx); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.executeStatement(ctx, executeReq)
+	if err != nil {
+		if c.slots != nil {
+			<-c.slots
+		}
+		return nil, err
+	}
+
+	rs = c.track(resp.OperationHandle)
+	rs.sql = executeReq.Statement
+	return rs, nil
+}
+
+// acquire waits for a free operation slot, if the connection has a limit.
+func (c *Connection) acquire(ctx context.Context) error {
+	if c.slots == nil {
+		return nil
+	}
+	select {
+	case c.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// track returns a RowSet for operation, counted until it is closed. The
+// caller must hold a slot from acquire.
+func (c *Connection) track(operation *inf.TOperationHandle) *rowSet {
+	rs := newRowSet(c.thrift, operation, c.options).(*rowSet)
+	rs.conn = c
+
+	c.mu.Lock()
+	rs.stats.RemoteAddr = c.remoteAddr
+	c.operations[rs] = struct{}{}
+	c.mu.Unlock()
+	return rs
+}
+
+func (c *Connection) untrack(rs *rowSet) {
+	c.mu.Lock()
+	c.release(rs)
+	c.mu.Unlock()
+}
+
+// release forgets rs and frees its slot. c.mu must be held.
+func (c *Connection) release(rs *rowSet) {
+	if _, ok := c.operations[rs]; !ok {
+		return
+	}
+	delete(c.operations, rs)
+	if c.slots != nil {
+		<-c.slots
+	}
+}
+
+// trackStatement records the session state changed by a successful USE,
+// SET or RESET <key> statement, and clears the metadata cache after DDL.
+func (c *Connection) trackStatement(stmt string) {
+	if ClassifyStatement(stmt) == StatementDDL {
+		c.ClearMetadataCache()
+		return
+	}
+	stmt = strings.TrimRight(strings.TrimSpace(stmt), "; \t\n")
+	keyword, rest := stmt, ""
+	if i := strings.IndexAny(stmt, " \t\n"); i >= 0 {
+		keyword, rest = stmt[:i], strings.TrimSpace(stmt[i+1:])
+	}
+
+	switch strings.ToUpper(keyword) {
+	case "USE":
+		if rest == "" {
+			return
+		}
+		if len(rest) > 1 && rest[0] == '`' && rest[len(rest)-1] == '`' {
+			rest = strings.ReplaceAll(rest[1:len(rest)-1], "``", "`")
+		}
+		c.mu.Lock()
+		c.database = rest
+		c.mu.Unlock()
+	case "SET":
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			return
+		}
+		c.mu.Lock()
+		c.sessionConf[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		c.mu.Unlock()
+	case "RESET":
+		if rest == "" {
+			return
+		}
+		c.mu.Lock()
+		delete(c.sessionConf, rest)
+		c.mu.Unlock()
+	}
+}
+
+// OperationInfo describes an operation tracked by a Connection.
+type OperationInfo struct {
+	// ID is the operation GUID, as in Event.OperationID.
+	ID      string
+	SQL     string
+	Started time.Time
+	// Status is the last polled status, or nil if it hasn't been polled.
+	Status *Status
+}
+
+// ListOperations returns the operations opened by this Connection that
+// haven't been closed. It only knows about this client's operations, not
+// everything running on the server or in the session.
+func (c *Connection) ListOperations() []OperationInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ops := make([]OperationInfo, 0, len(c.operations))
+	for rs := range c.operations {
+		rs.statusMu.Lock()
+		status := rs.lastStatus
+		rs.statusMu.Unlock()
+		ops = append(ops, OperationInfo{
+			ID:      operationID(rs.operation),
+			SQL:     rs.sql,
+			Started: rs.started,
+			Status:  status,
+		})
+	}
+	sort.SliceStable(ops, func(i, j int) bool { return ops[i].Started.Before(ops[j].Started) })
+	return ops
+}
+
+// KillOperation cancels and closes the tracked operation with the given
+// ID, as listed by ListOperations. As with RowSet.Close, an operation
+// already seen to complete is only closed, since HiveServer2 refuses to
+// cancel finished operations. It uses the connection's transport, so
+// like any other call it must not run concurrently with calls from other
+// goroutines; share the connection through a SessionMux for that.
+func (c *Connection) KillOperation(ctx context.Context, id string) error {
+	var target *rowSet
+	c.mu.Lock()
+	for rs := range c.operations {
+		if operationID(rs.operation) == id {
+			target = rs
+			break
+		}
+	}
+	c.mu.Unlock()
+
+	if target == nil {
+		return fmt.Errorf("No tracked operation with ID %s", id)
+	}
+	return target.Close(ctx)
+}
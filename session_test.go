@@ -0,0 +1,248 @@
+package hive
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestSessionInfo(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	options := testOptions()
+	options.Database = "sales"
+	options.SessionConf = map[string]string{"set:hiveconf:hive.exec.parallel": "true"}
+	conn := connectFake(t, svc, options)
+
+	expectedConf := map[string]string{"set:hiveconf:hive.exec.parallel": "true", "use:database": "sales"}
+	if conf := svc.sessions[0].Configuration; !reflect.DeepEqual(conf, expectedConf) {
+		t.Errorf("expected OpenSession configuration %v, got %v", expectedConf, conf)
+	}
+	if len(options.SessionConf) != 1 {
+		t.Errorf("expected Options.SessionConf to be left unchanged, got %v", options.SessionConf)
+	}
+
+	rs, err := conn.Query("SELECT 1")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	if _, err := conn.Exec("USE `web``logs`"); err != nil {
+		t.Fatalf("Exec error: %v", err)
+	}
+	if _, err := conn.Exec("SET hive.execution.engine = tez;"); err != nil {
+		t.Fatalf("Exec error: %v", err)
+	}
+
+	info, err := conn.SessionInfo(ctx)
+	if err != nil {
+		t.Fatalf("SessionInfo error: %v", err)
+	}
+	expected := SessionInfo{
+		Database:        "web`logs",
+		ProtocolVersion: inf.TProtocolVersion_HIVE_CLI_SERVICE_PROTOCOL_V7,
+		SessionConf: map[string]string{
+			"set:hiveconf:hive.exec.parallel": "true",
+			"hive.execution.engine":           "tez",
+		},
+		Operations: 1,
+	}
+	if !reflect.DeepEqual(info, expected) {
+		t.Errorf("expected %+v, got %+v", expected, info)
+	}
+
+	if err := rs.Close(ctx); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+	if info, _ := conn.SessionInfo(ctx); info.Operations != 0 {
+		t.Errorf("expected no open operations after Close, got %d", info.Operations)
+	}
+	if len(svc.closes) != 1 {
+		t.Errorf("expected one CloseOperation call, got %d", len(svc.closes))
+	}
+}
+
+func TestMaxConcurrentOperations(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	options := testOptions()
+	options.MaxConcurrentOperations = 1
+	conn := connectFake(t, svc, options)
+
+	first, err := conn.Query("SELECT 1")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+
+	cancelled, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := conn.QueryWithLogs(cancelled, "SELECT 2"); err != context.DeadlineExceeded {
+		t.Errorf("expected the second submission to wait until the deadline, got %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := conn.QueryWithLogs(ctx, "SELECT 3")
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("expected the submission to block, returned %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	if err := first.Close(ctx); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("QueryWithLogs error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the submission to resume after Close")
+	}
+
+	if n := conn.InFlight(); n != 1 {
+		t.Errorf("expected 1 operation in flight, got %d", n)
+	}
+	if statements := svc.executed(); len(statements) != 2 {
+		t.Errorf("expected 2 executed statements, got %q", statements)
+	}
+}
+
+func TestFailedCloseFreesSlot(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	svc.onClose = func(req *inf.TCloseOperationReq) (*inf.TCloseOperationResp, error) {
+		return &inf.TCloseOperationResp{Status: errorStatus("Invalid OperationHandle")}, nil
+	}
+	options := testOptions()
+	options.MaxConcurrentOperations = 1
+	conn := connectFake(t, svc, options)
+
+	for i := 0; i < 3; i++ {
+		submitCtx, cancel := context.WithTimeout(ctx, time.Second)
+		rs, err := conn.QueryContext(submitCtx, "SELECT 1")
+		cancel()
+		if err != nil {
+			t.Fatalf("Query %d error: %v", i, err)
+		}
+		if err := rs.Close(ctx); err == nil {
+			t.Errorf("expected Close %d to report the failed status", i)
+		}
+		if n := conn.InFlight(); n != 0 {
+			t.Errorf("expected the operation to be released after Close %d, got %d in flight", i, n)
+		}
+		if err := rs.Close(ctx); err != nil {
+			t.Errorf("expected closing again to do nothing, got %v", err)
+		}
+	}
+	if len(svc.closes) != 3 {
+		t.Errorf("expected one CloseOperation call per operation, got %d", len(svc.closes))
+	}
+}
+
+func TestReattach(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("name", inf.TTypeId_STRING_TYPE, 1)}
+	svc.batches = []*inf.TRowSet{stringBatch("a", "b")}
+	addr := startFakeServer(t, svc)
+
+	submitter, err := ConnectContext(ctx, addr, testOptions())
+	if err != nil {
+		t.Fatalf("Connect error: %v", err)
+	}
+	defer submitter.Close()
+	rs, err := submitter.Query("SELECT name FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	handle, err := rs.Handle(ctx)
+	if err != nil {
+		t.Fatalf("Handle error: %v", err)
+	}
+
+	worker, err := ConnectContext(ctx, addr, testOptions())
+	if err != nil {
+		t.Fatalf("Connect error: %v", err)
+	}
+	defer worker.Close()
+	reattached, err := worker.Reattach(ctx, handle)
+	if err != nil {
+		t.Fatalf("Reattach error: %v", err)
+	}
+
+	var names []string
+	for reattached.Next() {
+		var name string
+		if err := reattached.Scan(&name); err != nil {
+			t.Fatalf("Scan error: %v", err)
+		}
+		names = append(names, name)
+	}
+	if expected := []string{"a", "b"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+
+	if _, err := worker.Reattach(ctx, []byte("garbage")); err == nil {
+		t.Error("expected an error for a malformed handle")
+	}
+}
+
+func TestListAndKillOperations(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	conn := connectFake(t, svc, testOptions())
+
+	first, err := conn.Query("SELECT 1")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	if _, err := first.Poll(); err != nil {
+		t.Fatalf("Poll error: %v", err)
+	}
+	if _, err := conn.Query("SELECT 2"); err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+
+	ops := conn.ListOperations()
+	if len(ops) != 2 || ops[0].SQL != "SELECT 1" || ops[1].SQL != "SELECT 2" {
+		t.Fatalf("unexpected operations %+v", ops)
+	}
+	if ops[0].Status == nil || !ops[0].Status.IsSuccess() || ops[1].Status != nil {
+		t.Errorf("expected only the polled operation to have a status, got %+v", ops)
+	}
+
+	if err := conn.KillOperation(ctx, ops[1].ID); err != nil {
+		t.Fatalf("KillOperation error: %v", err)
+	}
+	if len(svc.cancels) != 1 || len(svc.closes) != 1 {
+		t.Errorf("expected a cancel and a close, got %d and %d", len(svc.cancels), len(svc.closes))
+	}
+	if remaining := conn.ListOperations(); len(remaining) != 1 || remaining[0].ID != ops[0].ID {
+		t.Errorf("expected only the first operation to remain, got %+v", remaining)
+	}
+
+	// The first operation has finished, which the server won't cancel.
+	svc.onCancel = func(req *inf.TCancelOperationReq) (*inf.TCancelOperationResp, error) {
+		return &inf.TCancelOperationResp{Status: errorStatus("Cannot cancel a finished operation")}, nil
+	}
+	if err := conn.KillOperation(ctx, ops[0].ID); err != nil {
+		t.Fatalf("KillOperation error for a finished operation: %v", err)
+	}
+	if len(svc.closes) != 2 {
+		t.Errorf("expected the finished operation to be closed, got %d closes", len(svc.closes))
+	}
+	if remaining := conn.ListOperations(); len(remaining) != 0 {
+		t.Errorf("expected no operations to remain, got %+v", remaining)
+	}
+
+	if err := conn.KillOperation(ctx, "no-such-operation"); err == nil {
+		t.Error("expected an error for an unknown operation")
+	}
+}
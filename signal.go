@@ -0,0 +1,17 @@
+package hive
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// WithSignalCancel returns a copy of ctx that is cancelled when the
+// process receives SIGINT or SIGTERM, for command line tools that should
+// stop on Ctrl-C. Operations waited on or fetched with the context are
+// cancelled on the server, not just abandoned. Call stop once done to
+// restore the default signal handling.
+func WithSignalCancel(ctx context.Context) (_ context.Context, stop context.CancelFunc) {
+	return signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+}
@@ -0,0 +1,75 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestCancelledWaitCancelsOperation(t *testing.T) {
+	svc := newFakeService()
+	svc.onStatus = func(*inf.TGetOperationStatusReq) (*inf.TGetOperationStatusResp, error) {
+		state := inf.TOperationState_RUNNING_STATE
+		return &inf.TGetOperationStatusResp{Status: okStatus(), OperationState: &state}, nil
+	}
+	conn := connectFake(t, svc, testOptions())
+
+	rs, err := conn.Query("SELECT slow()")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = rs.(*rowSet).wait(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if len(svc.cancels) != 1 {
+		t.Errorf("expected the operation to be cancelled on the server, got %d cancels", len(svc.cancels))
+	}
+}
+
+func TestCancelledFetchCancelsOperation(t *testing.T) {
+	svc := newFakeService()
+	svc.batches = append(svc.batches, stringBatch("a"), stringBatch("b"))
+	conn := connectFake(t, svc, testOptions())
+
+	rs, err := conn.Query("SELECT s FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, err := rs.NextValues(ctx); err != nil {
+		t.Fatalf("NextValues error: %v", err)
+	}
+	cancel()
+	if _, err := rs.NextValues(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if len(svc.cancels) != 1 {
+		t.Errorf("expected the operation to be cancelled on the server, got %d cancels", len(svc.cancels))
+	}
+}
+
+func TestWithSignalCancel(t *testing.T) {
+	ctx, stop := WithSignalCancel(context.Background())
+	defer stop()
+
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("FindProcess error: %v", err)
+	}
+	if err := p.Signal(os.Interrupt); err != nil {
+		t.Skipf("can't send an interrupt on this platform: %v", err)
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("expected the context to be cancelled by SIGINT")
+	}
+}
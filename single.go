@@ -0,0 +1,76 @@
+package hive
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrMultipleStatements is returned for a statement that holds more than
+// one, such as "SET x=1; SELECT 1". Run scripts with ExecScript, or set
+// Options.AllowMultiStatement for servers that accept them.
+var ErrMultipleStatements = errors.New("hive: more than one statement; use ExecScript to run a script")
+
+// ErrEmptyStatement is returned for a statement that is empty, or only
+// whitespace and comments, which servers reject with a parse error that
+// doesn't say so.
+var ErrEmptyStatement = errors.New("hive: empty statement")
+
+// singleStatement checks that stmt is one statement, dropping a trailing
+// semicolon, which older servers reject. Semicolons inside string
+// literals, quoted identifiers and comments don't count. With
+// allowMulti, several statements are passed on as they are. A statement
+// with nothing to run fails with ErrEmptyStatement.
+func singleStatement(stmt string, allowMulti bool) (string, error) {
+	if stmts, err := SplitStatements(stmt); err == nil && len(stmts) == 0 {
+		return "", ErrEmptyStatement
+	}
+	i := statementEnd(stmt)
+	if i < 0 {
+		return stmt, nil
+	}
+	rest, err := SplitStatements(stmt[i+1:])
+	if err != nil {
+		// The server reports the unterminated quote or comment.
+		return stmt, nil
+	}
+	if len(rest) == 0 {
+		// Any further semicolons are dropped too.
+		return singleStatement(strings.TrimRight(stmt[:i]+stmt[i+1:], " \t\r\n"), allowMulti)
+	}
+	if allowMulti {
+		return stmt, nil
+	}
+	return "", ErrMultipleStatements
+}
+
+// statementEnd returns the index of the first semicolon in s outside
+// string literals, quoted identifiers and comments, or -1 if there is
+// none.
+func statementEnd(s string) int {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end, ok := quotedEnd(s, i)
+			if !ok {
+				return -1
+			}
+			i = end - 1
+		case c == '-' && strings.HasPrefix(s[i:], "--"):
+			end := strings.IndexByte(s[i:], '\n')
+			if end < 0 {
+				return -1
+			}
+			i += end
+		case c == '/' && strings.HasPrefix(s[i:], "/*"):
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				return -1
+			}
+			i += end + 3
+		case c == ';':
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,92 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSingleStatement(t *testing.T) {
+	for _, tc := range []struct {
+		stmt, want string
+		multi      bool
+	}{
+		{stmt: "SELECT 1", want: "SELECT 1"},
+		{stmt: "SELECT 1;", want: "SELECT 1"},
+		{stmt: "SELECT 1 ;\n  ", want: "SELECT 1"},
+		{stmt: "SELECT 1; -- done", want: "SELECT 1 -- done"},
+		{stmt: "SELECT 'a;b' FROM t", want: "SELECT 'a;b' FROM t"},
+		{stmt: "SELECT `x;y`, 'it''s; fine' FROM t;", want: "SELECT `x;y`, 'it''s; fine' FROM t"},
+		{stmt: "SELECT 1 /* a; b */ FROM t -- c; d", want: "SELECT 1 /* a; b */ FROM t -- c; d"},
+		{stmt: "SET x=1; SELECT 1", multi: true},
+		{stmt: "SELECT 1;;", want: "SELECT 1"},
+	} {
+		got, err := singleStatement(tc.stmt, false)
+		if tc.multi {
+			if !errors.Is(err, ErrMultipleStatements) {
+				t.Errorf("%q: expected ErrMultipleStatements, got %q, %v", tc.stmt, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("%q: expected %q, got %q (err %v)", tc.stmt, tc.want, got, err)
+		}
+	}
+}
+
+func TestMultiStatementRejected(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	conn := connectFake(t, svc, testOptions())
+
+	if _, err := conn.QueryContext(ctx, "SET hive.exec.parallel=true; SELECT * FROM t"); !errors.Is(err, ErrMultipleStatements) {
+		t.Fatalf("expected ErrMultipleStatements, got %v", err)
+	}
+	if got := svc.executed(); len(got) != 0 {
+		t.Errorf("expected nothing sent, got %q", got)
+	}
+
+	rs, err := conn.QueryContext(ctx, "SELECT ';' FROM t;")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	rs.Close(ctx)
+	if got := svc.executed(); len(got) != 1 || got[0] != "SELECT ';' FROM t" {
+		t.Errorf("expected the trailing semicolon dropped, got %q", got)
+	}
+}
+
+func TestAllowMultiStatement(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	options := testOptions()
+	options.AllowMultiStatement = true
+	conn := connectFake(t, svc, options)
+
+	rs, err := conn.QueryContext(ctx, "SET hive.exec.parallel=true; SELECT * FROM t;")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	rs.Close(ctx)
+	if got := svc.executed(); len(got) != 1 || got[0] != "SET hive.exec.parallel=true; SELECT * FROM t;" {
+		t.Errorf("expected the statements sent as they are, got %q", got)
+	}
+}
+
+func TestEmptyStatementRejected(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	conn := connectFake(t, svc, testOptions())
+
+	for _, stmt := range []string{"", "  ", "-- just a comment", "/* block */", " ; "} {
+		if _, err := conn.QueryContext(ctx, stmt); !errors.Is(err, ErrEmptyStatement) {
+			t.Errorf("Query %q: expected ErrEmptyStatement, got %v", stmt, err)
+		}
+		if _, err := conn.Exec(stmt); !errors.Is(err, ErrEmptyStatement) {
+			t.Errorf("Exec %q: expected ErrEmptyStatement, got %v", stmt, err)
+		}
+	}
+	if got := svc.executed(); len(got) != 0 {
+		t.Errorf("expected nothing sent, got %q", got)
+	}
+}
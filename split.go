@@ -0,0 +1,112 @@
+package hive
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// SplitStatements splits a HiveQL script into its statements at
+// semicolons, leaving alone semicolons inside string literals, backtick
+// identifiers and comments. "--" and "/* */" comments are removed, and
+// statements that are empty once trimmed are dropped. An unterminated
+// string, identifier or block comment is an error.
+func SplitStatements(script string) ([]string, error) {
+	var (
+		stmts []string
+		cur   strings.Builder
+	)
+	flush := func() {
+		if s := strings.TrimSpace(cur.String()); s != "" {
+			stmts = append(stmts, s)
+		}
+		cur.Reset()
+	}
+
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end, ok := quotedEnd(script, i)
+			if !ok {
+				return nil, fmt.Errorf("Unterminated %c at offset %d", c, i)
+			}
+			cur.WriteString(script[i:end])
+			i = end - 1
+		case c == '-' && strings.HasPrefix(script[i:], "--"):
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				i = len(script)
+				continue
+			}
+			cur.WriteByte('\n')
+			i += end
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("Unterminated /* comment at offset %d", i)
+			}
+			cur.WriteByte(' ')
+			i += end + 3
+		case c == ';':
+			flush()
+		default:
+			cur.WriteByte(c)
+		}
+	}
+	flush()
+	return stmts, nil
+}
+
+// quotedEnd returns the index just past the quoted section starting at
+// s[start], and whether its closing quote was found. Backslash escapes
+// apply in string literals; a doubled backtick stays inside a quoted
+// identifier.
+func quotedEnd(s string, start int) (int, bool) {
+	quote := s[start]
+	for i := start + 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case quote:
+			if quote == '`' && i+1 < len(s) && s[i+1] == '`' {
+				i++
+				continue
+			}
+			return i + 1, true
+		}
+	}
+	return len(s), false
+}
+
+// ExecScript runs the statements of script, as split by SplitStatements,
+// one after another, waiting for each to finish and discarding any
+// results. It stops at the first failure, returning a *ScriptError as
+// ExecScriptReader does. Unlike ExecScriptReader, it runs nothing if the
+// script is malformed.
+func (c *Connection) ExecScript(ctx context.Context, script string) error {
+	if _, err := SplitStatements(script); err != nil {
+		return err
+	}
+	return c.ExecScriptReader(ctx, strings.NewReader(script))
+}
+
+// execScriptStatement runs stmt, waiting for it to finish, and discards
+// any results.
+func (c *Connection) execScriptStatement(ctx context.Context, stmt string) error {
+	executeReq := inf.NewTExecuteStatementReq()
+	executeReq.Statement = stmt
+	executeReq.RunAsync = true
+
+	rs, err := c.submit(ctx, executeReq)
+	if err != nil {
+		return err
+	}
+	_, err = rs.wait(ctx)
+	rs.Close(ctx)
+	return err
+}
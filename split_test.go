@@ -0,0 +1,120 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestSplitStatements(t *testing.T) {
+	cases := map[string][]string{
+		"SELECT 1; SELECT 2;":                    {"SELECT 1", "SELECT 2"},
+		"SELECT ';'; SELECT \"a;b\"":             {"SELECT ';'", "SELECT \"a;b\""},
+		`SELECT 'it\'s; fine'`:                   {`SELECT 'it\'s; fine'`},
+		"SELECT `odd;name` FROM t":               {"SELECT `odd;name` FROM t"},
+		"SELECT `a``;b` FROM t":                  {"SELECT `a``;b` FROM t"},
+		"-- header; comment\nSELECT 1":           {"SELECT 1"},
+		"SELECT 1 -- trailing; comment\n;":       {"SELECT 1"},
+		"SELECT /* a; b */ 1; /* only */;":       {"SELECT   1"},
+		"SELECT '--not a comment'; SELECT 2":     {"SELECT '--not a comment'", "SELECT 2"},
+		"  ;; \n ; ":                             nil,
+		"SET x=1;\nUSE db;\nSELECT * FROM t\n--": {"SET x=1", "USE db", "SELECT * FROM t"},
+	}
+	for script, want := range cases {
+		got, err := SplitStatements(script)
+		if err != nil {
+			t.Errorf("SplitStatements(%q) error: %v", script, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("SplitStatements(%q): expected %q, got %q", script, want, got)
+		}
+	}
+}
+
+func TestSplitStatementsUnterminated(t *testing.T) {
+	for _, script := range []string{
+		"SELECT 'open",
+		"SELECT \"open",
+		"SELECT `open",
+		`SELECT 'escaped end\'`,
+		"SELECT 1 /* open",
+	} {
+		if _, err := SplitStatements(script); err == nil {
+			t.Errorf("SplitStatements(%q): expected an error", script)
+		}
+	}
+}
+
+func FuzzSplitStatements(f *testing.F) {
+	for _, seed := range []string{
+		"SELECT 1; SELECT 2",
+		"SELECT 'a;b' -- c;\n; /* d; */ SELECT `e;f`",
+		`SELECT '\'; SELECT "\"";`,
+		"SELECT `a``b`;",
+		"--;\n/*;*/;",
+		"'",
+		"/*",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, script string) {
+		stmts, err := SplitStatements(script)
+		if err != nil {
+			return
+		}
+		for _, s := range stmts {
+			if s == "" || s != strings.TrimSpace(s) {
+				t.Fatalf("statement %q isn't trimmed", s)
+			}
+		}
+		// Splitting is stable: the statements split back into themselves.
+		again, err := SplitStatements(strings.Join(stmts, ";\n"))
+		if err != nil {
+			t.Fatalf("re-split error: %v", err)
+		}
+		if !reflect.DeepEqual(again, stmts) {
+			t.Fatalf("re-split %q into %q", stmts, again)
+		}
+	})
+}
+
+func TestExecScript(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	conn := connectFake(t, svc, testOptions())
+
+	err := conn.ExecScript(ctx, "USE sales; -- switch\nCREATE TABLE t (s STRING);\nINSERT INTO t VALUES ('a;b');")
+	if err != nil {
+		t.Fatalf("ExecScript error: %v", err)
+	}
+	want := []string{"USE sales", "CREATE TABLE t (s STRING)", "INSERT INTO t VALUES ('a;b')"}
+	if got := svc.executed(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	if n := conn.InFlight(); n != 0 {
+		t.Errorf("expected every statement closed, %d in flight", n)
+	}
+}
+
+func TestExecScriptStopsAtFailure(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	svc.onExecute = func(req *inf.TExecuteStatementReq) (*inf.TExecuteStatementResp, error) {
+		return &inf.TExecuteStatementResp{Status: errorStatus("ParseException")}, nil
+	}
+	conn := connectFake(t, svc, testOptions())
+
+	err := conn.ExecScript(ctx, "SELECT 1;\nSELECT 2")
+	var scriptErr *ScriptError
+	if !errors.As(err, &scriptErr) || scriptErr.Statement != 1 || scriptErr.Line != 1 || scriptErr.SQL != "SELECT 1" {
+		t.Fatalf("expected a ScriptError for statement 1, got %v", err)
+	}
+	if len(svc.executes) != 1 {
+		t.Errorf("expected to stop after the failure, got %d executes", len(svc.executes))
+	}
+}
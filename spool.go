@@ -0,0 +1,109 @@
+package hive
+
+import (
+	"bufio"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+)
+
+// spool holds the rows of a RowSet that were written to disk.
+type spool struct {
+	file *os.File
+	dec  *gob.Decoder
+}
+
+// shouldSpool reports whether the rest of the result should go to disk,
+// which happens once the in-memory rows reach the threshold.
+func (r *rowSet) shouldSpool() bool {
+	return r.options.SpoolDir != "" && r.hasMore && r.stats.Rows >= r.options.SpoolThresholdRows
+}
+
+// fillSpool fetches every remaining batch into a temporary file and
+// rewinds it for reading. Rows are stored gob-encoded, so values produced
+// by a TypeMapper must be of types registered with gob.Register.
+func (r *rowSet) fillSpool(ctx context.Context) (err error) {
+	f, err := os.CreateTemp(r.options.SpoolDir, "hive-spool-*")
+	if err != nil {
+		return fmt.Errorf("Error creating spool file: %v", err)
+	}
+	r.spool = &spool{file: f}
+	defer func() {
+		if err != nil {
+			r.spool.remove()
+			r.spool = nil
+		}
+	}()
+
+	w := bufio.NewWriter(f)
+	enc := gob.NewEncoder(w)
+	for r.fetchAll(ctx) {
+		for i := 0; i < r.batchLength(); i++ {
+			row := make([]interface{}, len(r.resultSet))
+			for j, col := range r.resultSet {
+				row[j] = col[i]
+			}
+			if err := enc.Encode(row); err != nil {
+				return fmt.Errorf("Error spooling row: %v", err)
+			}
+		}
+	}
+	if r.err != nil {
+		return r.err
+	}
+	r.resultSet = nil
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("Error spooling row: %v", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("Error rewinding spool file: %v", err)
+	}
+	r.spool.dec = gob.NewDecoder(bufio.NewReader(f))
+	return nil
+}
+
+// nextSpooled reads the next row from the spool file, removing the file
+// once it is exhausted.
+func (r *rowSet) nextSpooled() bool {
+	if r.spool.dec == nil {
+		return false
+	}
+	var row []interface{}
+	if err := r.spool.dec.Decode(&row); err != nil {
+		if err != io.EOF {
+			r.err = fmt.Errorf("Error reading spool file: %v", err)
+		} else {
+			r.finish()
+		}
+		r.spool.remove()
+		r.spool.dec = nil
+		return false
+	}
+	r.nextRow = row
+	return true
+}
+
+// nextSpooledInto is nextSpooled, copying the row into dest when dest
+// is non-nil.
+func (r *rowSet) nextSpooledInto(dest []interface{}) bool {
+	if !r.nextSpooled() {
+		return false
+	}
+	if dest != nil {
+		r.nextRow = dest[:copy(dest, r.nextRow)]
+	}
+	return true
+}
+
+// remove closes and deletes the spool file. It is safe on a nil spool.
+func (s *spool) remove() {
+	if s == nil || s.file == nil {
+		return
+	}
+	s.file.Close()
+	os.Remove(s.file.Name())
+	s.file = nil
+}
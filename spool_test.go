@@ -0,0 +1,72 @@
+package hive
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestSpoolToDisk(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{
+		columnDesc("id", inf.TTypeId_INT_TYPE, 1),
+		columnDesc("name", inf.TTypeId_STRING_TYPE, 2),
+	}
+	batch := func(ids []int32, names []string, nulls []byte) *inf.TRowSet {
+		return &inf.TRowSet{Columns: []*inf.TColumn{
+			{I32Val: &inf.TI32Column{Values: ids, Nulls: []byte{}}},
+			{StringVal: &inf.TStringColumn{Values: names, Nulls: nulls}},
+		}}
+	}
+	svc.batches = []*inf.TRowSet{
+		batch([]int32{1, 2}, []string{"a", "b"}, []byte{}),
+		batch([]int32{3, 4}, []string{"", "d"}, []byte{0x01}),
+		batch([]int32{5}, []string{"e"}, []byte{}),
+	}
+
+	dir := t.TempDir()
+	options := testOptions()
+	options.SpoolDir = dir
+	options.SpoolThresholdRows = 2
+	conn := connectFake(t, svc, options)
+
+	rs, err := conn.Query("SELECT id, name FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+
+	var got [][]interface{}
+	for rs.Next() {
+		var id, name interface{}
+		if err := rs.Scan(&id, &name); err != nil {
+			t.Fatalf("Scan error: %v", err)
+		}
+		got = append(got, []interface{}{id, name})
+
+		if len(got) == 3 {
+			if files, _ := os.ReadDir(dir); len(files) != 1 {
+				t.Errorf("expected a spool file while spooled rows are read, got %d files", len(files))
+			}
+		}
+	}
+	if err := rs.Err(); err != nil {
+		t.Fatalf("Next error: %v", err)
+	}
+
+	expected := [][]interface{}{
+		{int32(1), "a"}, {int32(2), "b"}, {int32(3), nil}, {int32(4), "d"}, {int32(5), "e"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Errorf("expected the spool file to be removed, got %d files", len(files))
+	}
+	if rs.Next() {
+		t.Error("expected no more rows")
+	}
+	rs.Close(context.Background())
+}
@@ -0,0 +1,110 @@
+package hive
+
+import (
+	"context"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// Batch is one fetched chunk of a result in columnar form, as sent by
+// the server.
+type Batch struct {
+	Columns []BatchColumn
+	Rows    int
+}
+
+// BatchColumn is one column vector of a Batch.
+type BatchColumn struct {
+	Name string
+	Type inf.TTypeId
+	// Values is the vector as decoded from the wire: []bool, []int8,
+	// []int16, []int32, []int64, []float64, []string or [][]byte. Entries
+	// for NULLs hold the zero value. Row-based results, see
+	// Options.PreferColumnarResults, are turned into []interface{} with
+	// nil for NULL.
+	Values interface{}
+	// Nulls is the null bitmap, one bit per row, least significant bit
+	// first. It may be shorter than Rows when the trailing rows aren't
+	// NULL.
+	Nulls []byte
+}
+
+// IsNull reports whether row i of the column is NULL.
+func (c BatchColumn) IsNull(i int) bool {
+	if values, ok := c.Values.([]interface{}); ok {
+		return i < len(values) && values[i] == nil
+	}
+	return isNull(c.Nulls, i)
+}
+
+// StreamBatches fetches the rest of the result and sends it one batch
+// at a time, without converting it to rows, so that whole columns can be
+// forwarded, e.g. to a gRPC stream. TypeMapper, TrackIntegrity and
+// spooling don't apply. The channel is closed at the end of the result,
+// on error, or when ctx is done; check Err afterwards. The RowSet must
+// not be used otherwise until the channel is closed.
+func (r *rowSet) StreamBatches(ctx context.Context) <-chan Batch {
+	batches := make(chan Batch, 1)
+	go func() {
+		defer close(batches)
+		if r.options.RowInterceptor != nil {
+			r.err = errRawBatchIntercepted
+			return
+		}
+		if err := r.waitForSuccess(ctx); err != nil {
+			r.err = err
+			return
+		}
+
+		for r.err == nil {
+			if ctx.Err() != nil {
+				r.err = r.abandon(ctx)
+				return
+			}
+			results, ok := r.fetchRaw(ctx)
+			if !ok {
+				break
+			}
+			batch := r.batch(results)
+			if batch.Rows == 0 {
+				continue
+			}
+			select {
+			case batches <- batch:
+			case <-ctx.Done():
+				r.err = r.abandon(ctx)
+				return
+			}
+		}
+		if r.err == nil {
+			r.finish()
+		}
+	}()
+	return batches
+}
+
+// batch wraps fetched results, with names and types from the schema.
+func (r *rowSet) batch(results *inf.TRowSet) Batch {
+	b := Batch{Rows: rowSetLength(results)}
+	cols := results.GetColumns()
+	if len(cols) == 0 && len(results.GetRows()) > 0 {
+		for i, values := range rowColumns(results.GetRows()) {
+			b.Columns = append(b.Columns, r.batchColumn(i, values, nil))
+		}
+		return b
+	}
+	for i, col := range cols {
+		values, _ := convertColumn(col)
+		b.Columns = append(b.Columns, r.batchColumn(i, values, columnNulls(col)))
+	}
+	return b
+}
+
+func (r *rowSet) batchColumn(i int, values interface{}, nulls []byte) BatchColumn {
+	c := BatchColumn{Type: inf.TTypeId_STRING_TYPE, Values: values, Nulls: nulls}
+	if i < len(r.columns) {
+		c.Name = r.columns[i].GetColumnName()
+		c.Type = columnTypeID(r.columns[i])
+	}
+	return c
+}
@@ -0,0 +1,90 @@
+package hive
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestStreamBatches(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{
+		columnDesc("id", inf.TTypeId_INT_TYPE, 1),
+		columnDesc("name", inf.TTypeId_STRING_TYPE, 2),
+	}
+	svc.batches = []*inf.TRowSet{
+		{Columns: []*inf.TColumn{
+			{I32Val: &inf.TI32Column{Values: []int32{1, 0, 3}, Nulls: []byte{0x02}}},
+			{StringVal: &inf.TStringColumn{Values: []string{"a", "b", "c"}, Nulls: []byte{}}},
+		}},
+		{Columns: []*inf.TColumn{
+			{I32Val: &inf.TI32Column{Values: []int32{4}, Nulls: []byte{}}},
+			{StringVal: &inf.TStringColumn{Values: []string{""}, Nulls: []byte{0x01}}},
+		}},
+	}
+	conn := connectFake(t, svc, testOptions())
+
+	rs, err := conn.Query("SELECT id, name FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	var batches []Batch
+	for b := range rs.StreamBatches(context.Background()) {
+		batches = append(batches, b)
+	}
+	if err := rs.Err(); err != nil {
+		t.Fatalf("StreamBatches error: %v", err)
+	}
+	if len(batches) != 2 {
+		t.Fatalf("expected 2 batches, got %d", len(batches))
+	}
+
+	first := batches[0]
+	if first.Rows != 3 || len(first.Columns) != 2 {
+		t.Fatalf("expected 3 rows of 2 columns, got %+v", first)
+	}
+	id, name := first.Columns[0], first.Columns[1]
+	if id.Name != "id" || id.Type != inf.TTypeId_INT_TYPE || name.Type != inf.TTypeId_STRING_TYPE {
+		t.Errorf("expected the schema's names and types, got %+v and %+v", id, name)
+	}
+	if !reflect.DeepEqual(id.Values, []int32{1, 0, 3}) {
+		t.Errorf("expected the int vector as sent, got %#v", id.Values)
+	}
+	if !id.IsNull(1) || id.IsNull(0) || id.IsNull(2) {
+		t.Errorf("expected only row 1 of id to be NULL, bitmap %v", id.Nulls)
+	}
+	if !batches[1].Columns[1].IsNull(0) {
+		t.Error("expected the NULL name in the second batch")
+	}
+	if stats := rs.Stats(); stats.Rows != 4 {
+		t.Errorf("expected 4 rows in Stats, got %d", stats.Rows)
+	}
+}
+
+func TestStreamBatchesRowBased(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("id", inf.TTypeId_INT_TYPE, 1)}
+	svc.batches = []*inf.TRowSet{rowBatch(
+		[]*inf.TColumnValue{i32Value(7)},
+		[]*inf.TColumnValue{{I32Val: &inf.TI32Value{}}},
+	)}
+	conn := connectFake(t, svc, testOptions())
+
+	rs, err := conn.Query("SELECT id FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	var batches []Batch
+	for b := range rs.StreamBatches(context.Background()) {
+		batches = append(batches, b)
+	}
+	if len(batches) != 1 {
+		t.Fatalf("expected 1 batch, got %d (error %v)", len(batches), rs.Err())
+	}
+	col := batches[0].Columns[0]
+	if !reflect.DeepEqual(col.Values, []interface{}{int32(7), nil}) || col.IsNull(0) || !col.IsNull(1) {
+		t.Errorf("expected [7 NULL], got %#v", col.Values)
+	}
+}
@@ -0,0 +1,111 @@
+package hive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// ErrSummaryNotAvailable is returned by Summary when the server reports
+// no runtime information for the operation.
+var ErrSummaryNotAvailable = errors.New("hive: query summary not available")
+
+// QuerySummary is the runtime information HiveServer2 reports for an
+// operation. Which parts are filled in depends on the server version and
+// execution engine.
+type QuerySummary struct {
+	Started   time.Time
+	Completed time.Time
+
+	// Tasks lists the stages of the query, from the status's task JSON.
+	Tasks []TaskSummary
+
+	// Progress is the completed fraction, 0 to 1, from the progress
+	// update. ProgressHeaders and ProgressRows hold the engine's progress
+	// table, e.g. Tez vertices with their task counts, and Footer its
+	// summary line.
+	Progress        float64
+	ProgressHeaders []string
+	ProgressRows    [][]string
+	Footer          string
+
+	// QueuedTime and RunningTime split the time since the statement was
+	// submitted into waiting to run and running, up to its completion,
+	// see Status.IsQueued. The client measures them from its polls, so
+	// they are only as precise as PollIntervalSeconds.
+	QueuedTime  time.Duration
+	RunningTime time.Duration
+}
+
+// TaskSummary describes one task of a query.
+type TaskSummary struct {
+	ID     string `json:"taskId"`
+	Name   string `json:"name"`
+	Type   string `json:"taskType"`
+	Status string `json:"status"`
+	// ExternalHandle is the engine's job ID, e.g. a YARN application.
+	ExternalHandle string `json:"externalHandle"`
+	BeginTime      int64  `json:"beginTime"`
+	EndTime        int64  `json:"endTime"`
+	ElapsedTime    int64  `json:"elapsedTime"`
+	ReturnValue    *int   `json:"returnValue"`
+	ErrorMsg       string `json:"errorMsg"`
+	StatusMessage  string `json:"statusMessage"`
+}
+
+// Summary fetches the operation's runtime information. It returns
+// ErrSummaryNotAvailable if the server provides none.
+func (r *rowSet) Summary(ctx context.Context) (*QuerySummary, error) {
+	req := inf.NewTGetOperationStatusReq()
+	req.OperationHandle = r.operation
+	req.GetProgressUpdate = thrift.BoolPtr(true)
+
+	resp, err := r.thrift.GetOperationStatus(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting status: %v", err)
+	}
+	if !isSuccessStatus(resp.Status) {
+		return nil, operationStatusError("GetStatus call failed", resp.Status)
+	}
+
+	if resp.OperationState != nil {
+		r.statusMu.Lock()
+		r.observe(*resp.OperationState, r.options.clock().Now())
+		r.statusMu.Unlock()
+	}
+	s := &QuerySummary{}
+	s.QueuedTime, s.RunningTime = r.phaseTimes()
+	available := false
+	if resp.IsSetOperationStarted() {
+		s.Started = time.UnixMilli(resp.GetOperationStarted())
+		available = true
+	}
+	if resp.IsSetOperationCompleted() && resp.GetOperationCompleted() > 0 {
+		s.Completed = time.UnixMilli(resp.GetOperationCompleted())
+		available = true
+	}
+	if tasks := resp.GetTaskStatus(); tasks != "" {
+		if err := json.Unmarshal([]byte(tasks), &s.Tasks); err != nil {
+			return nil, fmt.Errorf("Error decoding task status: %v", err)
+		}
+		available = true
+	}
+	if p := resp.GetProgressUpdateResponse(); p != nil {
+		s.Progress = p.GetProgressedPercentage()
+		s.ProgressHeaders = p.GetHeaderNames()
+		s.ProgressRows = p.GetRows()
+		s.Footer = p.GetFooterSummary()
+		available = true
+	}
+
+	if !available {
+		return nil, ErrSummaryNotAvailable
+	}
+	return s, nil
+}
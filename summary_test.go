@@ -0,0 +1,63 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestSummary(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	conn := connectFake(t, svc, testOptions())
+
+	rs, err := conn.Query("SELECT count(*) FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	if _, err := rs.Summary(ctx); !errors.Is(err, ErrSummaryNotAvailable) {
+		t.Errorf("expected ErrSummaryNotAvailable from a bare status, got %v", err)
+	}
+
+	var progressRequested bool
+	svc.onStatus = func(req *inf.TGetOperationStatusReq) (*inf.TGetOperationStatusResp, error) {
+		progressRequested = req.GetGetProgressUpdate()
+		state := inf.TOperationState_FINISHED_STATE
+		return &inf.TGetOperationStatusResp{
+			Status:             okStatus(),
+			OperationState:     &state,
+			OperationStarted:   thrift.Int64Ptr(1700000000000),
+			OperationCompleted: thrift.Int64Ptr(1700000004000),
+			TaskStatus: thrift.StringPtr(`[{"taskId":"Stage-1","taskType":"MAPRED","status":"FINISHED",` +
+				`"externalHandle":"application_1_0001","beginTime":1700000000500,"endTime":1700000003500,"returnValue":0}]`),
+			ProgressUpdateResponse: &inf.TProgressUpdateResp{
+				HeaderNames:          []string{"VERTICES", "STATUS", "TOTAL", "COMPLETED"},
+				Rows:                 [][]string{{"Map 1", "SUCCEEDED", "4", "4"}},
+				ProgressedPercentage: 1,
+				FooterSummary:        "VERTICES: 01/01",
+			},
+		}, nil
+	}
+
+	s, err := rs.Summary(ctx)
+	if err != nil {
+		t.Fatalf("Summary error: %v", err)
+	}
+	if !progressRequested {
+		t.Error("expected Summary to request a progress update")
+	}
+	if d := s.Completed.Sub(s.Started); d != 4*time.Second {
+		t.Errorf("expected a 4s run, got %v", d)
+	}
+	if len(s.Tasks) != 1 || s.Tasks[0].ExternalHandle != "application_1_0001" || s.Tasks[0].Status != "FINISHED" {
+		t.Errorf("unexpected tasks %+v", s.Tasks)
+	}
+	if s.Progress != 1 || len(s.ProgressRows) != 1 || s.Footer != "VERTICES: 01/01" {
+		t.Errorf("unexpected progress %+v", s)
+	}
+}
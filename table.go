@@ -0,0 +1,148 @@
+package hive
+
+import (
+	"context"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+)
+
+// TableOptions control how WriteTable renders a result.
+type TableOptions struct {
+	// MaxColumnWidth, if positive, caps columns at that many characters;
+	// longer values are cut and end in "…".
+	MaxColumnWidth int
+	// Null is written for NULL values. It defaults to "NULL".
+	Null string
+	// MaxRows, if positive, stops the table after that many rows. The
+	// rest of the result is left unread.
+	MaxRows int64
+	// BufferRows is how many rows are held back to size the columns. It
+	// defaults to 1000. Later rows are fitted to the widths found, and
+	// cut like values over MaxColumnWidth if longer.
+	BufferRows int
+}
+
+// tableCellCleaner keeps values on one line of their cell.
+var tableCellCleaner = strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+
+// WriteTable writes the remaining rows to w as a table for reading in a
+// terminal: a header with the column names, a rule, and the rows, with
+// columns aligned by text/tabwriter. Column widths are measured in
+// characters, so wide characters such as CJK may misalign.
+//
+// The first BufferRows rows are written together once they have been
+// fetched, and the rest a batch at a time. If a write to w fails, the
+// operation is aborted and the write's error returned.
+func (r *rowSet) WriteTable(ctx context.Context, w io.Writer, opts TableOptions) error {
+	if err := r.waitForSuccess(ctx); err != nil {
+		return err
+	}
+	if opts.Null == "" {
+		opts.Null = "NULL"
+	}
+	if opts.BufferRows <= 0 {
+		opts.BufferRows = 1000
+	}
+
+	names := make([]string, len(r.columns))
+	for i, col := range r.columns {
+		names[i] = opts.cell(col.GetColumnName())
+	}
+	buffered := [][]string{names}
+	var rows int64
+	for (opts.MaxRows <= 0 || rows < opts.MaxRows) && len(buffered) <= opts.BufferRows && r.next(ctx) {
+		buffered = append(buffered, opts.row(r.nextRow))
+		rows++
+	}
+
+	widths := make([]int, len(names))
+	for _, row := range buffered {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+	rule := make([]string, len(widths))
+	for i, width := range widths {
+		rule[i] = strings.Repeat("-", width)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	writeTableRow(tw, buffered[0])
+	writeTableRow(tw, rule)
+	for _, row := range buffered[1:] {
+		writeTableRow(tw, row)
+	}
+	if err := tw.Flush(); err != nil {
+		return r.writeFailed(ctx, err)
+	}
+
+	// Cells padded to the widths keep tabwriter's columns the same from
+	// one flush to the next.
+	for (opts.MaxRows <= 0 || rows < opts.MaxRows) && r.next(ctx) {
+		row := opts.row(r.nextRow)
+		for i, cell := range row {
+			row[i] = truncateCell(cell, widths[i])
+			if i < len(row)-1 {
+				row[i] += strings.Repeat(" ", widths[i]-utf8.RuneCountInString(row[i]))
+			}
+		}
+		writeTableRow(tw, row)
+		rows++
+		if r.offset < r.batchLength() {
+			continue
+		}
+		if err := tw.Flush(); err != nil {
+			return r.writeFailed(ctx, err)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return r.writeFailed(ctx, err)
+	}
+	return r.Err()
+}
+
+// row renders the cells of a row.
+func (opts TableOptions) row(values []interface{}) []string {
+	row := make([]string, len(values))
+	for i, v := range values {
+		if v == nil {
+			row[i] = opts.cell(opts.Null)
+			continue
+		}
+		row[i] = opts.cell(formatField(v))
+	}
+	return row
+}
+
+// cell cleans s for a cell and cuts it to MaxColumnWidth.
+func (opts TableOptions) cell(s string) string {
+	s = tableCellCleaner.Replace(s)
+	if opts.MaxColumnWidth > 0 {
+		s = truncateCell(s, opts.MaxColumnWidth)
+	}
+	return s
+}
+
+// truncateCell cuts s to width characters, ending it in "…" if anything
+// was cut.
+func truncateCell(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	if width <= 1 {
+		return string([]rune(s)[:width])
+	}
+	return string([]rune(s)[:width-1]) + "…"
+}
+
+func writeTableRow(tw *tabwriter.Writer, cells []string) {
+	for i, cell := range cells {
+		if i > 0 {
+			io.WriteString(tw, "\t")
+		}
+		io.WriteString(tw, cell)
+	}
+	io.WriteString(tw, "\n")
+}
@@ -0,0 +1,72 @@
+package hive
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestWriteTable(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{
+		columnDesc("id", inf.TTypeId_INT_TYPE, 1),
+		columnDesc("name", inf.TTypeId_STRING_TYPE, 2),
+		columnDesc("note", inf.TTypeId_STRING_TYPE, 3),
+	}
+	svc.batches = []*inf.TRowSet{
+		{Columns: []*inf.TColumn{
+			{I32Val: &inf.TI32Column{Values: []int32{1, 22}, Nulls: []byte{}}},
+			{StringVal: &inf.TStringColumn{Values: []string{"ann", ""}, Nulls: []byte{0x02}}},
+			{StringVal: &inf.TStringColumn{Values: []string{"a\tb", "x"}, Nulls: []byte{}}},
+		}},
+		{Columns: []*inf.TColumn{
+			{I32Val: &inf.TI32Column{Values: []int32{333, 4}, Nulls: []byte{}}},
+			{StringVal: &inf.TStringColumn{Values: []string{"bartholomew", "dee"}, Nulls: []byte{}}},
+			{StringVal: &inf.TStringColumn{Values: []string{"y", "z"}, Nulls: []byte{}}},
+		}},
+	}
+
+	cases := []struct {
+		name string
+		opts TableOptions
+		want string
+	}{
+		{"all buffered", TableOptions{MaxColumnWidth: 8, Null: "-"}, "" +
+			"id   name      note\n" +
+			"---  --------  ----\n" +
+			"1    ann       a b\n" +
+			"22   -         x\n" +
+			"333  barthol…  y\n" +
+			"4    dee       z\n"},
+		// Past the buffered rows, cells are fitted to the widths found.
+		{"fixed widths", TableOptions{BufferRows: 2}, "" +
+			"id  name  note\n" +
+			"--  ----  ----\n" +
+			"1   ann   a b\n" +
+			"22  NULL  x\n" +
+			"3…  bar…  y\n" +
+			"4   dee   z\n"},
+		{"row limit", TableOptions{MaxRows: 1}, "" +
+			"id  name  note\n" +
+			"--  ----  ----\n" +
+			"1   ann   a b\n"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			conn := connectFake(t, svc, testOptions())
+			rs, err := conn.Query("SELECT id, name, note FROM t")
+			if err != nil {
+				t.Fatalf("Query error: %v", err)
+			}
+			var b strings.Builder
+			if err := rs.WriteTable(context.Background(), &b, tc.opts); err != nil {
+				t.Fatalf("WriteTable error: %v", err)
+			}
+			if b.String() != tc.want {
+				t.Errorf("expected\n%s\ngot\n%s", tc.want, b.String())
+			}
+		})
+	}
+}
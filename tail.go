@@ -0,0 +1,121 @@
+package hive
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"io"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// Tail returns the last n rows of the result, oldest first, and uses up
+// the RowSet: Next returns false afterwards. There are two strategies.
+//
+// Where the server supports reverse fetches, Tail asks for the last n
+// rows with FETCH_LAST, and for any the server held back with
+// FETCH_PRIOR, so only those rows cross the wire whatever the size of
+// the result.
+//
+// HiveServer2 itself only fetches forwards, and there Tail reads the
+// rest of the result with NextValues, keeping the last n rows in a ring
+// buffer. That costs a full scan of the remaining rows but no more than
+// n rows of memory. Rows already read before Tail are not included.
+func (r *rowSet) Tail(ctx context.Context, n int64) ([][]driver.Value, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	if err := r.waitForSuccess(ctx); err != nil {
+		return nil, err
+	}
+
+	if r.spool == nil {
+		rows, ok, err := r.tailReverse(ctx, n)
+		if err != nil || ok {
+			return rows, err
+		}
+	}
+	return r.tailRing(ctx, n)
+}
+
+// tailReverse reads the last n rows with reverse fetches. It returns
+// false if the server rejects FETCH_LAST.
+func (r *rowSet) tailReverse(ctx context.Context, n int64) ([][]driver.Value, bool, error) {
+	var rows [][]driver.Value
+	orientation := inf.TFetchOrientation_FETCH_LAST
+	for int64(len(rows)) < n {
+		fetchReq := r.fetchRequest(orientation, n-int64(len(rows)), FetchQueryOutput)
+		resp, err := r.fetchResults(ctx, fetchReq)
+		if err != nil {
+			return nil, false, fmt.Errorf("Error in FetchResults: %v", err)
+		}
+		if !isSuccessStatus(resp.Status) {
+			if orientation == inf.TFetchOrientation_FETCH_LAST {
+				return nil, false, nil
+			}
+			return nil, false, operationStatusError("FetchResults failed", resp.Status)
+		}
+
+		batch, err := r.batchValues(resp.GetResults())
+		if err != nil {
+			return nil, false, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		rows = append(batch, rows...)
+		orientation = inf.TFetchOrientation_FETCH_PRIOR
+	}
+
+	// The cursor is no longer where Next expects it.
+	r.resultSet = nil
+	r.hasMore = false
+	return rows, true, nil
+}
+
+// batchValues decodes results into rows of driver values.
+func (r *rowSet) batchValues(results *inf.TRowSet) ([][]driver.Value, error) {
+	if err := r.decodeBatch(results); err != nil {
+		return nil, err
+	}
+	rows := make([][]driver.Value, r.batchLength())
+	for i := range rows {
+		row := make([]interface{}, len(r.resultSet))
+		for j, col := range r.resultSet {
+			row[j] = col[i]
+		}
+		values, err := r.rowValues(row)
+		if err != nil {
+			return nil, err
+		}
+		rows[i] = values
+	}
+	r.offset = len(rows)
+	return rows, nil
+}
+
+// tailRing reads the remaining rows, keeping the last n.
+func (r *rowSet) tailRing(ctx context.Context, n int64) ([][]driver.Value, error) {
+	var (
+		ring  [][]driver.Value
+		start int
+	)
+	for {
+		values, err := r.NextValues(ctx)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(ring)) < n {
+			ring = append(ring, values)
+			continue
+		}
+		ring[start] = values
+		start = (start + 1) % len(ring)
+	}
+	rows := make([][]driver.Value, 0, len(ring))
+	rows = append(rows, ring[start:]...)
+	return append(rows, ring[:start]...), nil
+}
@@ -0,0 +1,80 @@
+package hive
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestTail(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		reverseLimit int64
+		// fetches is the number of FetchResults calls Tail should make.
+		fetches int
+	}{
+		{"reverse", 10, 1},
+		{"reverse in pieces", 2, 2},
+		{"ring buffer", 0, 5},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := newFakeService()
+			svc.schema = []*inf.TColumnDesc{columnDesc("line", inf.TTypeId_STRING_TYPE, 1)}
+			svc.batches = []*inf.TRowSet{stringBatch("a", "b"), stringBatch("c", "d"), stringBatch("e")}
+			svc.reverseLimit = tc.reverseLimit
+			conn := connectFake(t, svc, testOptions())
+
+			rs, err := conn.Query("SELECT line FROM log")
+			if err != nil {
+				t.Fatalf("Query error: %v", err)
+			}
+			if _, err := rs.Wait(); err != nil {
+				t.Fatalf("Wait error: %v", err)
+			}
+			before := len(svc.fetches)
+
+			rows, err := rs.Tail(context.Background(), 3)
+			if err != nil {
+				t.Fatalf("Tail error: %v", err)
+			}
+			expected := [][]driver.Value{{"c"}, {"d"}, {"e"}}
+			if !reflect.DeepEqual(rows, expected) {
+				t.Errorf("expected %v, got %v", expected, rows)
+			}
+			// The ring buffer path also makes the rejected FETCH_LAST
+			// and the final empty fetch.
+			if got := len(svc.fetches) - before; got != tc.fetches {
+				t.Errorf("expected %d fetches, got %d", tc.fetches, got)
+			}
+			if rs.Next() {
+				t.Error("expected Next to return false after Tail")
+			}
+			if rs.Err() != nil {
+				t.Errorf("unexpected error after Tail: %v", rs.Err())
+			}
+		})
+	}
+}
+
+func TestTailShortResult(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("line", inf.TTypeId_STRING_TYPE, 1)}
+	svc.batches = []*inf.TRowSet{stringBatch("a", "b")}
+	conn := connectFake(t, svc, testOptions())
+
+	rs, err := conn.Query("SELECT line FROM log")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	rows, err := rs.Tail(context.Background(), 5)
+	if err != nil {
+		t.Fatalf("Tail error: %v", err)
+	}
+	expected := [][]driver.Value{{"a"}, {"b"}}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("expected %v, got %v", expected, rows)
+	}
+}
@@ -0,0 +1,42 @@
+package hive
+
+import "context"
+
+// A PreparedTemplate is a query with "?" placeholders whose positions
+// have been found once, by Connection.Prepare, so that running it with
+// new arguments only formats them and joins the pieces.
+//
+// It is not a server prepared statement: Hive has none. Every Query
+// sends the full statement text, with the arguments filled in as
+// literals as ExecBatch does, and the server parses and compiles it
+// again. What is saved is the client's scan of the query: for a short
+// query with three arguments, filling in the template takes about a
+// quarter of the time binding the text does, see
+// BenchmarkPreparedTemplate.
+type PreparedTemplate struct {
+	conn  *Connection
+	parts []string
+}
+
+// Prepare finds the placeholders of query, skipping those inside string
+// literals, quoted identifiers and comments, and returns a template to
+// run it with. The template can be used concurrently.
+func (c *Connection) Prepare(query string) (*PreparedTemplate, error) {
+	return &PreparedTemplate{conn: c, parts: splitPlaceholders(query)}, nil
+}
+
+// NumParams returns the number of placeholders, which Query needs as
+// many arguments for.
+func (t *PreparedTemplate) NumParams() int {
+	return len(t.parts) - 1
+}
+
+// Query fills the placeholders with the literal forms of args, see
+// EscapeLiteral, and runs the statement like Connection.QueryContext.
+func (t *PreparedTemplate) Query(ctx context.Context, args ...interface{}) (RowSet, error) {
+	stmt, err := fillPlaceholders(t.parts, args)
+	if err != nil {
+		return nil, err
+	}
+	return t.conn.QueryContext(ctx, stmt)
+}
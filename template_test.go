@@ -0,0 +1,93 @@
+package hive
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+const templateQuery = "SELECT * FROM events /* ? */ WHERE user_id = ? AND kind = '?' AND day >= ? -- ?\nLIMIT ?"
+
+func TestPreparedTemplate(t *testing.T) {
+	svc := newFakeService()
+	conn := connectFake(t, svc, testOptions())
+
+	tmpl, err := conn.Prepare(templateQuery)
+	if err != nil {
+		t.Fatalf("Prepare error: %v", err)
+	}
+	if n := tmpl.NumParams(); n != 3 {
+		t.Errorf("expected 3 placeholders, got %d", n)
+	}
+
+	ctx := context.Background()
+	for _, user := range []int64{7, 8} {
+		rs, err := tmpl.Query(ctx, user, "2024-01-01", 10)
+		if err != nil {
+			t.Fatalf("Query error: %v", err)
+		}
+		rs.Close(ctx)
+	}
+	want := []string{
+		"SELECT * FROM events /* ? */ WHERE user_id = 7 AND kind = '?' AND day >= '2024-01-01' -- ?\nLIMIT 10",
+		"SELECT * FROM events /* ? */ WHERE user_id = 8 AND kind = '?' AND day >= '2024-01-01' -- ?\nLIMIT 10",
+	}
+	if got := svc.executed(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	if _, err := tmpl.Query(ctx, 7); err == nil {
+		t.Error("expected an error for too few arguments")
+	}
+	if _, err := tmpl.Query(ctx, 7, "x", 1, 2); err == nil {
+		t.Error("expected an error for too many arguments")
+	}
+	if got := svc.executed(); len(got) != 2 {
+		t.Errorf("expected nothing sent for bad arguments, got %q", got[2:])
+	}
+}
+
+func TestPreparedTemplateDoubledBacktick(t *testing.T) {
+	svc := newFakeService()
+	conn := connectFake(t, svc, testOptions())
+
+	tmpl, err := conn.Prepare("SELECT `a``?b` FROM t WHERE x = ?")
+	if err != nil {
+		t.Fatalf("Prepare error: %v", err)
+	}
+	if n := tmpl.NumParams(); n != 1 {
+		t.Errorf("expected 1 placeholder, got %d", n)
+	}
+	ctx := context.Background()
+	rs, err := tmpl.Query(ctx, 5)
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	rs.Close(ctx)
+	if got, want := svc.executed(), []string{"SELECT `a``?b` FROM t WHERE x = 5"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+// BenchmarkPreparedTemplate compares filling in a template against
+// binding the query text on every call.
+func BenchmarkPreparedTemplate(b *testing.B) {
+	args := []interface{}{int64(7), "2024-01-01", 10}
+	b.Run("bindParams", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := bindParams(templateQuery, args); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("PreparedTemplate", func(b *testing.B) {
+		b.ReportAllocs()
+		parts := splitPlaceholders(templateQuery)
+		for i := 0; i < b.N; i++ {
+			if _, err := fillPlaceholders(parts, args); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
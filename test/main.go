@@ -1,29 +1,43 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log"
+
+	"github.com/jasonlabz/hive"
 )
 
 func main() {
-	//	conn, err := gohive.Connect("127.0.0.1:10000", gohive.DefaultOptions) // 无用户名、密码
-	conn, err := gohive.ConnectWithUser("127.0.0.1:10000", "username", "password", gohive.DefaultOptions) // 需要用户名、密码
+	ctx := context.Background()
+
+	//	conn, err := hive.ConnectContext(ctx, "127.0.0.1:10000", hive.DefaultOptions) // 无用户名、密码
+	conn, err := hive.ConnectWithUserContext(ctx, "127.0.0.1:10000", "username", "password", hive.DefaultOptions) // 需要用户名、密码
 	if err != nil {
-		fmt.Errorf("Connect error %v", err)
+		log.Fatalf("Connect error %v", err)
 	}
+	defer conn.Close()
 
-	_, err = conn.Exec("create table if not exists t(c1 int)")
-	_, err = conn.Exec(" insert into default.t values(1), (2)")
-	if err != nil {
-		fmt.Errorf("Connection.Exec error: %v", err)
+	if _, err = conn.Exec("create table if not exists t(c1 int)"); err != nil {
+		log.Fatalf("Connection.Exec error: %v", err)
+	}
+	if _, err = conn.Exec(" insert into default.t values(1), (2)"); err != nil {
+		log.Fatalf("Connection.Exec error: %v", err)
 	}
 	rs, err := conn.Query("select c1 from t limit 10")
 	if err != nil {
-		fmt.Errorf("Connection.Query error: %v", err)
+		log.Fatalf("Connection.Query error: %v", err)
 	}
+	defer rs.Close(ctx)
+
 	var c1 int
 	for rs.Next() {
-		rs.Scan(&c1)
+		if err := rs.Scan(&c1); err != nil {
+			log.Fatalf("RowSet.Scan error: %v", err)
+		}
 		fmt.Println(c1)
 	}
-	conn.Close()
+	if err := rs.Err(); err != nil {
+		log.Fatalf("RowSet.Next error: %v", err)
+	}
 }
@@ -0,0 +1,228 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// ErrFetchTimeout is returned when a fetch isn't answered within
+// Options.FetchTimeout.
+var ErrFetchTimeout = errors.New("hive: fetch timed out")
+
+// Mechanisms reported by TimeoutError.
+const (
+	// TimeoutServer means the server enforced TExecuteStatementReq.QueryTimeout.
+	TimeoutServer = "server"
+	// TimeoutClient means the client-side backstop cancelled the operation
+	// because the server didn't stop it in time.
+	TimeoutClient = "client"
+)
+
+// timeoutBackstopGrace is how long after the query timeout the client
+// waits for the server to enforce it before cancelling the operation
+// itself.
+const timeoutBackstopGrace = time.Second
+
+// A TimeoutError reports that QueryWithTimeout stopped a query, and which
+// mechanism did it.
+type TimeoutError struct {
+	Timeout   time.Duration
+	Mechanism string
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("Query exceeded its %v timeout (stopped by the %s)", e.Timeout, e.Mechanism)
+}
+
+// QueryWithTimeout runs query with a server-enforced QueryTimeout and
+// waits for it to complete. Because not every Hive version honours
+// QueryTimeout, the client cancels the operation itself if it is still
+// running shortly after the timeout. Either way the error is a
+// *TimeoutError naming the mechanism that fired.
+//
+// The timeout covers execution only; once QueryWithTimeout returns the
+// results can be fetched at leisure. QueryTimeout has a granularity of
+// seconds, so timeout is rounded up to a whole second.
+func (c *Connection) QueryWithTimeout(ctx context.Context, query string, timeout time.Duration) (RowSet, error) {
+	if timeout <= 0 {
+		return nil, fmt.Errorf("Invalid query timeout %v", timeout)
+	}
+	seconds := int64((timeout + time.Second - 1) / time.Second)
+
+	executeReq := inf.NewTExecuteStatementReq()
+	executeReq.Statement = query
+	executeReq.RunAsync = true
+	executeReq.QueryTimeout = seconds
+
+	rs, err := c.submit(ctx, executeReq)
+	if err != nil {
+		return nil, err
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, time.Duration(seconds)*time.Second+timeoutBackstopGrace)
+	defer cancel()
+
+	status, err := rs.wait(waitCtx)
+	if err == nil {
+		return rs, nil
+	}
+
+	switch {
+	case status != nil && status.IsTimedOut():
+		err = &TimeoutError{Timeout: timeout, Mechanism: TimeoutServer}
+	case errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil:
+		// wait has already cancelled the operation.
+		var cancelErr *cancelFailedError
+		if errors.As(err, &cancelErr) {
+			err = fmt.Errorf("%w; cancelling the operation failed: %v", &TimeoutError{Timeout: timeout, Mechanism: TimeoutClient}, cancelErr.err)
+		} else {
+			err = &TimeoutError{Timeout: timeout, Mechanism: TimeoutClient}
+		}
+	}
+	rs.Close(context.Background())
+	return nil, err
+}
+
+// cancel asks the server to stop the operation.
+func (r *rowSet) cancel(ctx context.Context) error {
+	req := inf.NewTCancelOperationReq()
+	req.OperationHandle = r.operation
+	resp, err := r.thrift.CancelOperation(ctx, req)
+	if err != nil {
+		return fmt.Errorf("Error in CancelOperation: %v", err)
+	}
+	if !isSuccessStatus(resp.Status) {
+		return fmt.Errorf("CancelOperation failed: %s", resp.Status.String())
+	}
+	r.cancelled = true
+	return nil
+}
+
+// completed reports whether the operation has been seen to finish,
+// successfully or not.
+func (r *rowSet) completed() bool {
+	if r.ready {
+		return true
+	}
+	r.statusMu.Lock()
+	defer r.statusMu.Unlock()
+	return r.lastStatus != nil && r.lastStatus.IsComplete()
+}
+
+// abandon cancels the operation after ctx has ended, so the server
+// doesn't keep running a query nobody is waiting for. It returns ctx's
+// error, along with the cancel's if that failed.
+func (r *rowSet) abandon(ctx context.Context) error {
+	if err := r.cancel(context.Background()); err != nil {
+		return &cancelFailedError{ctxErr: ctx.Err(), err: err}
+	}
+	return ctx.Err()
+}
+
+// cancelFailedError is a context error whose operation couldn't be
+// cancelled.
+type cancelFailedError struct {
+	ctxErr error
+	err    error
+}
+
+func (e *cancelFailedError) Error() string {
+	return fmt.Sprintf("%v; cancelling the operation failed: %v", e.ctxErr, e.err)
+}
+
+func (e *cancelFailedError) Unwrap() error {
+	return e.ctxErr
+}
+
+// drainTimeout bounds the wait for the reply to a stalled fetch once its
+// operation has been cancelled.
+const drainTimeout = 5 * time.Second
+
+// fetchResults makes a FetchResults call bounded by Options.FetchTimeout.
+// The socket's read timeout is lowered for the call, since thrift only
+// checks ctx when a read times out.
+func (r *rowSet) fetchResults(ctx context.Context, req *inf.TFetchResultsReq) (*inf.TFetchResultsResp, error) {
+	if err := r.checkIdle(ctx); err != nil {
+		return nil, err
+	}
+	defer r.touch()
+	timeout := r.options.FetchTimeout
+	if timeout <= 0 || r.conn == nil || r.conn.socket == nil {
+		return r.thrift.FetchResults(ctx, req)
+	}
+
+	socket := r.conn.socket
+	fetchCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if r.options.SocketTimeout == 0 || timeout < r.options.SocketTimeout {
+		socket.SetSocketTimeout(timeout)
+	}
+	resp, err := r.thrift.FetchResults(fetchCtx, req)
+	socket.SetSocketTimeout(r.options.SocketTimeout)
+	if err == nil || fetchCtx.Err() == nil || ctx.Err() != nil {
+		return resp, err
+	}
+
+	err = fmt.Errorf("%w after %v", ErrFetchTimeout, timeout)
+	if cancelErr := r.cancelStalledFetch(ctx); cancelErr != nil {
+		return nil, fmt.Errorf("%w; cancelling the operation failed: %v", err, cancelErr)
+	}
+	return nil, err
+}
+
+// cancelStalledFetch cancels the operation of a fetch that timed out
+// over a connection of its own, the session's being busy waiting for
+// the reply, and then reads and discards that reply. If it doesn't
+// arrive, the transport is closed, as its next reply would be taken for
+// the answer to a later call.
+func (r *rowSet) cancelStalledFetch(ctx context.Context) error {
+	c := r.conn
+	ctx, done := context.WithTimeout(context.WithoutCancel(ctx), drainTimeout)
+	defer done()
+
+	cancelErr := func() error {
+		d, err := c.dial(ctx)
+		if err != nil {
+			return err
+		}
+		defer d.transport.Close()
+		defer d.stop()
+		req := inf.NewTCancelOperationReq()
+		req.OperationHandle = r.operation
+		resp, err := d.client.CancelOperation(ctx, req)
+		if err != nil {
+			return fmt.Errorf("Error in CancelOperation: %v", err)
+		}
+		if !isSuccessStatus(resp.Status) {
+			return fmt.Errorf("CancelOperation failed: %s", resp.Status.String())
+		}
+		r.cancelled = true
+		return nil
+	}()
+
+	c.socket.SetSocketTimeout(drainTimeout)
+	defer c.socket.SetSocketTimeout(c.options.SocketTimeout)
+	in := c.protocolFactory.GetProtocol(c.transport)
+	_, _, _, err := in.ReadMessageBegin(ctx)
+	if err == nil {
+		err = in.Skip(ctx, thrift.STRUCT)
+	}
+	if err == nil {
+		err = in.ReadMessageEnd(ctx)
+	}
+	if err != nil {
+		c.transport.Close()
+		err = fmt.Errorf("Error reading the stalled FetchResults reply, closed the connection: %v", err)
+		if cancelErr != nil {
+			return fmt.Errorf("%v; %v", cancelErr, err)
+		}
+		return err
+	}
+	return cancelErr
+}
@@ -0,0 +1,125 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestQueryWithTimeoutServer(t *testing.T) {
+	svc := newFakeService()
+	svc.states = []inf.TOperationState{inf.TOperationState_TIMEDOUT_STATE}
+	conn := connectFake(t, svc, testOptions())
+
+	_, err := conn.QueryWithTimeout(context.Background(), "SELECT slow()", 1500*time.Millisecond)
+	var timeoutErr *TimeoutError
+	if !errors.As(err, &timeoutErr) || timeoutErr.Mechanism != TimeoutServer {
+		t.Fatalf("expected a server-side TimeoutError, got %v", err)
+	}
+	if got := svc.executes[0].QueryTimeout; got != 2 {
+		t.Errorf("expected QueryTimeout rounded up to 2s, got %d", got)
+	}
+	if len(svc.cancels) != 0 {
+		t.Errorf("expected no client-side cancel, got %d", len(svc.cancels))
+	}
+}
+
+func TestQueryWithTimeoutClientBackstop(t *testing.T) {
+	svc := newFakeService()
+	// A server that ignores QueryTimeout keeps running.
+	svc.states = []inf.TOperationState{inf.TOperationState_RUNNING_STATE}
+	options := testOptions()
+	options.PollIntervalSeconds = 1
+	conn := connectFake(t, svc, options)
+
+	_, err := conn.QueryWithTimeout(context.Background(), "SELECT slow()", time.Second)
+	var timeoutErr *TimeoutError
+	if !errors.As(err, &timeoutErr) || timeoutErr.Mechanism != TimeoutClient {
+		t.Fatalf("expected a client-side TimeoutError, got %v", err)
+	}
+	if len(svc.cancels) != 1 {
+		t.Errorf("expected the operation to be cancelled, got %d cancels", len(svc.cancels))
+	}
+	if conn.InFlight() != 0 {
+		t.Error("expected the timed out operation to be closed")
+	}
+}
+
+func TestFetchTimeout(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("s", inf.TTypeId_STRING_TYPE, 1)}
+	released := make(chan struct{})
+	svc.onCancel = func(req *inf.TCancelOperationReq) (*inf.TCancelOperationResp, error) {
+		close(released)
+		return &inf.TCancelOperationResp{Status: okStatus()}, nil
+	}
+	svc.onFetch = func(req *inf.TFetchResultsReq) (*inf.TFetchResultsResp, error) {
+		// Stalls until the operation is cancelled.
+		<-released
+		return &inf.TFetchResultsResp{Status: errorStatus("Operation cancelled")}, nil
+	}
+	options := testOptions()
+	options.FetchTimeout = 100 * time.Millisecond
+	conn := connectFake(t, svc, options)
+
+	rs, err := conn.Query("SELECT s FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	start := time.Now()
+	if rs.Next() {
+		t.Fatal("expected the stalled fetch to fail")
+	}
+	if err := rs.Err(); !errors.Is(err, ErrFetchTimeout) {
+		t.Fatalf("expected ErrFetchTimeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected the fetch to fail promptly, took %v", elapsed)
+	}
+	svc.mu.Lock()
+	cancels, sessions := len(svc.cancels), len(svc.sessions)
+	svc.mu.Unlock()
+	if cancels != 1 || sessions != 1 {
+		t.Errorf("expected one cancel and no extra session, got %d cancels and %d sessions", cancels, sessions)
+	}
+
+	// The stalled reply has been drained, so the connection still works.
+	svc.onFetch = nil
+	if _, err := conn.Exec("SELECT 2"); err != nil {
+		t.Fatalf("expected the connection to stay usable, got %v", err)
+	}
+}
+
+func TestFetchTimeoutSlowFetch(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("s", inf.TTypeId_STRING_TYPE, 1)}
+	svc.batches = []*inf.TRowSet{stringBatch("a", "b")}
+	svc.onFetch = func(req *inf.TFetchResultsReq) (*inf.TFetchResultsResp, error) {
+		time.Sleep(50 * time.Millisecond)
+		svc.mu.Lock()
+		defer svc.mu.Unlock()
+		resp := &inf.TFetchResultsResp{Status: okStatus(), Results: &inf.TRowSet{}}
+		if len(svc.batches) > 0 {
+			resp.Results, svc.batches = svc.batches[0], svc.batches[1:]
+		}
+		return resp, nil
+	}
+	options := testOptions()
+	options.FetchTimeout = time.Second
+	conn := connectFake(t, svc, options)
+
+	rs, err := conn.Query("SELECT s FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	var n int
+	for rs.Next() {
+		n++
+	}
+	if err := rs.Err(); err != nil || n != 2 {
+		t.Errorf("expected 2 rows, got %d (error %v)", n, err)
+	}
+}
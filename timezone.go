@@ -0,0 +1,100 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// timeZoneKey is the Hive 3 setting for the zone TIMESTAMP values are
+// rendered in.
+const timeZoneKey = "hive.local.time.zone"
+
+// ServerTimeZone reads the session's hive.local.time.zone and returns it
+// as a location. The result is cached, and from then on NextValues
+// decodes TIMESTAMP and DATE values of this connection in that zone
+// unless Options.Location is set. Servers that leave the setting at
+// LOCAL, i.e. the JVM default zone, or predate it, return an error;
+// set Options.Location for those.
+func (c *Connection) ServerTimeZone(ctx context.Context) (*time.Location, error) {
+	c.mu.Lock()
+	loc := c.location
+	c.mu.Unlock()
+	if loc != nil {
+		return loc, nil
+	}
+
+	value, defined, err := c.readConf(ctx, timeZoneKey)
+	if err != nil {
+		return nil, err
+	}
+	if !defined {
+		return nil, fmt.Errorf("Server doesn't report %s", timeZoneKey)
+	}
+	if strings.EqualFold(value, "LOCAL") {
+		return nil, errors.New("Server uses its JVM default time zone, which it doesn't report")
+	}
+	loc, err = time.LoadLocation(value)
+	if err != nil {
+		return nil, fmt.Errorf("Unknown server time zone %q: %v", value, err)
+	}
+
+	c.mu.Lock()
+	c.location = loc
+	c.mu.Unlock()
+	return loc, nil
+}
+
+// A TimestampMode picks the zone NextValues attaches to TIMESTAMP and
+// DATE values, see Options.TimestampMode. Hive timestamps are
+// zone-naive: the server sends the wall clock, such as
+// "2024-03-01 12:30:00", and the mode only decides the Location of the
+// time.Time built from it, never the wall clock read off it. The same
+// value decoded in two modes has the same fields, and so denotes two
+// different instants.
+type TimestampMode struct {
+	kind timestampKind
+	loc  *time.Location
+}
+
+type timestampKind int
+
+const (
+	timestampUnset timestampKind = iota
+	timestampLocation
+	timestampServer
+)
+
+var (
+	// AssumeUTC decodes values as UTC.
+	AssumeUTC = TimestampMode{kind: timestampLocation, loc: time.UTC}
+	// AssumeServerTZ decodes values in the zone Connection.ServerTimeZone
+	// reports, looked up each time a session opens; Connect fails for
+	// servers that don't report it.
+	AssumeServerTZ = TimestampMode{kind: timestampServer}
+)
+
+// AssumeLocation decodes values in loc.
+func AssumeLocation(loc *time.Location) TimestampMode {
+	return TimestampMode{kind: timestampLocation, loc: loc}
+}
+
+// location is the zone NextValues decodes TIMESTAMP and DATE values in.
+func (r *rowSet) location() *time.Location {
+	if mode := r.options.TimestampMode; mode.kind == timestampLocation {
+		return mode.loc
+	}
+	if r.options.Location != nil {
+		return r.options.Location
+	}
+	if r.conn != nil {
+		r.conn.mu.Lock()
+		defer r.conn.mu.Unlock()
+		if r.conn.location != nil {
+			return r.conn.location
+		}
+	}
+	return time.UTC
+}
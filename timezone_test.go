@@ -0,0 +1,118 @@
+package hive
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func timestampService(zone string) *fakeService {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("ts", inf.TTypeId_TIMESTAMP_TYPE, 1)}
+	svc.batches = []*inf.TRowSet{stringBatch("2024-03-01 12:30:00")}
+	svc.results = map[string][]*inf.TRowSet{
+		"SET hive.local.time.zone": {stringBatch("hive.local.time.zone=" + zone)},
+	}
+	return svc
+}
+
+func firstTimestamp(t *testing.T, conn *Connection) time.Time {
+	t.Helper()
+	rs, err := conn.Query("SELECT ts FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	values, err := rs.NextValues(context.Background())
+	if err != nil {
+		t.Fatalf("NextValues error: %v", err)
+	}
+	return values[0].(time.Time)
+}
+
+func TestServerTimeZone(t *testing.T) {
+	ctx := context.Background()
+	conn := connectFake(t, timestampService("America/New_York"), testOptions())
+
+	if got := firstTimestamp(t, conn); got.Location() != time.UTC {
+		t.Errorf("expected UTC before the lookup, got %v", got.Location())
+	}
+
+	loc, err := conn.ServerTimeZone(ctx)
+	if err != nil {
+		t.Fatalf("ServerTimeZone error: %v", err)
+	}
+	if loc.String() != "America/New_York" {
+		t.Fatalf("expected America/New_York, got %v", loc)
+	}
+
+	want := time.Date(2024, 3, 1, 12, 30, 0, 0, loc)
+	if got := firstTimestamp(t, conn); !got.Equal(want) || got.Location() != loc {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestLocationOverridesServerTimeZone(t *testing.T) {
+	ctx := context.Background()
+	options := testOptions()
+	options.Location = time.FixedZone("UTC+8", 8*3600)
+	conn := connectFake(t, timestampService("America/New_York"), options)
+
+	if _, err := conn.ServerTimeZone(ctx); err != nil {
+		t.Fatalf("ServerTimeZone error: %v", err)
+	}
+	if got := firstTimestamp(t, conn); got.Location() != options.Location {
+		t.Errorf("expected Options.Location, got %v", got.Location())
+	}
+}
+
+func TestServerTimeZoneLocal(t *testing.T) {
+	conn := connectFake(t, timestampService("LOCAL"), testOptions())
+	if _, err := conn.ServerTimeZone(context.Background()); err == nil {
+		t.Error("expected an error for the JVM default zone")
+	}
+}
+
+func TestTimestampMode(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("no zone database: %v", err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("no zone database: %v", err)
+	}
+
+	for name, tc := range map[string]struct {
+		mode TimestampMode
+		want *time.Location
+	}{
+		"utc":      {AssumeUTC, time.UTC},
+		"server":   {AssumeServerTZ, newYork},
+		"location": {AssumeLocation(tokyo), tokyo},
+	} {
+		t.Run(name, func(t *testing.T) {
+			options := testOptions()
+			options.TimestampMode = tc.mode
+			conn := connectFake(t, timestampService("America/New_York"), options)
+
+			got := firstTimestamp(t, conn)
+			if got.Location().String() != tc.want.String() {
+				t.Errorf("expected %v, got %v", tc.want, got.Location())
+			}
+			// The wall clock is kept as sent; only the zone differs.
+			if want := time.Date(2024, 3, 1, 12, 30, 0, 0, tc.want); !got.Equal(want) {
+				t.Errorf("expected %v, got %v", want, got)
+			}
+		})
+	}
+}
+
+func TestAssumeServerTZNeedsZone(t *testing.T) {
+	options := testOptions()
+	options.TimestampMode = AssumeServerTZ
+	if _, err := ConnectContext(context.Background(), startFakeServer(t, timestampService("LOCAL")), options); err == nil {
+		t.Error("expected Connect to fail when the server doesn't report its zone")
+	}
+}
@@ -0,0 +1,75 @@
+package hive
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// parsePin decodes a TLSPinnedCertSHA256 entry: 64 hex digits, optionally
+// separated by colons.
+func parsePin(pin string) ([]byte, error) {
+	sum, err := hex.DecodeString(strings.ReplaceAll(pin, ":", ""))
+	if err != nil || len(sum) != sha256.Size {
+		return nil, fmt.Errorf("Invalid TLSPinnedCertSHA256 entry %q: want a hex SHA-256 digest", pin)
+	}
+	return sum, nil
+}
+
+// tlsConfig returns the TLS configuration to dial with, or nil for a
+// plain socket. Unless TLSConfig brings a ClientSessionCache of its own
+// or disables session tickets, it is a copy using sessions, so that
+// later handshakes to the server can resume an earlier session. With
+// pins set its VerifyConnection also checks the leaf certificate against
+// them; unlike VerifyPeerCertificate, that runs for resumed sessions
+// too.
+func (o Options) tlsConfig(sessions tls.ClientSessionCache) (*tls.Config, error) {
+	if len(o.TLSPinnedCertSHA256) == 0 {
+		if o.TLSConfig == nil || o.TLSConfig.ClientSessionCache != nil || o.TLSConfig.SessionTicketsDisabled || sessions == nil {
+			return o.TLSConfig, nil
+		}
+		config := o.TLSConfig.Clone()
+		config.ClientSessionCache = sessions
+		return config, nil
+	}
+
+	pins := make([][]byte, len(o.TLSPinnedCertSHA256))
+	for i, pin := range o.TLSPinnedCertSHA256 {
+		sum, err := parsePin(pin)
+		if err != nil {
+			return nil, err
+		}
+		pins[i] = sum
+	}
+
+	config := &tls.Config{}
+	if o.TLSConfig != nil {
+		config = o.TLSConfig.Clone()
+	}
+	if config.ClientSessionCache == nil && !config.SessionTicketsDisabled {
+		config.ClientSessionCache = sessions
+	}
+	verify := config.VerifyConnection
+	config.VerifyConnection = func(state tls.ConnectionState) error {
+		if verify != nil {
+			if err := verify(state); err != nil {
+				return err
+			}
+		}
+		if len(state.PeerCertificates) == 0 {
+			return errors.New("Server presented no certificate")
+		}
+		sum := sha256.Sum256(state.PeerCertificates[0].Raw)
+		for _, pin := range pins {
+			if bytes.Equal(sum[:], pin) {
+				return nil
+			}
+		}
+		return fmt.Errorf("Server certificate SHA-256 %x is not pinned", sum)
+	}
+	return config, nil
+}
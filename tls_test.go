@@ -0,0 +1,212 @@
+package hive
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// selfSignedCert returns a certificate for 127.0.0.1 and its parsed leaf.
+func selfSignedCert(t *testing.T) (tls.Certificate, *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey error: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "hiveserver2"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate error: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("ParseCertificate error: %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, leaf
+}
+
+func TestTLSPinnedCert(t *testing.T) {
+	cert, leaf := selfSignedCert(t)
+	svc := newFakeService()
+	svc.tlsConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+	addr := startFakeServer(t, svc)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(leaf)
+	sum := sha256.Sum256(leaf.Raw)
+	pin := fmt.Sprintf("%X", sum)
+	var colons []string
+	for _, b := range sum {
+		colons = append(colons, fmt.Sprintf("%02X", b))
+	}
+
+	for _, tc := range []struct {
+		name string
+		pins []string
+		ok   bool
+	}{
+		{"match", []string{pin}, true},
+		{"match with colons", []string{strings.Repeat("00", 32), strings.Join(colons, ":")}, true},
+		{"mismatch", []string{strings.Repeat("ab", 32)}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			options := testOptions()
+			options.TLSConfig = &tls.Config{RootCAs: roots}
+			options.TLSPinnedCertSHA256 = tc.pins
+			conn, err := ConnectContext(context.Background(), addr, options)
+			if tc.ok {
+				if err != nil {
+					t.Fatalf("expected the pinned certificate to be accepted, got %v", err)
+				}
+				conn.Close()
+				return
+			}
+			if err == nil {
+				conn.Close()
+				t.Fatal("expected an unpinned certificate to be rejected")
+			}
+			if !strings.Contains(err.Error(), "not pinned") {
+				t.Errorf("expected a pinning error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestTLSPinnedCertValidate(t *testing.T) {
+	options := DefaultOptions
+	options.TLSPinnedCertSHA256 = []string{"not-a-digest"}
+	if err := options.Validate(); err == nil {
+		t.Error("expected an invalid pin to be rejected")
+	}
+}
+
+// countHandshakes makes the server count its full and resumed TLS
+// handshakes.
+func countHandshakes(config *tls.Config) (full, resumed *atomic.Int32) {
+	full, resumed = new(atomic.Int32), new(atomic.Int32)
+	config.VerifyConnection = func(state tls.ConnectionState) error {
+		if state.DidResume {
+			resumed.Add(1)
+		} else {
+			full.Add(1)
+		}
+		return nil
+	}
+	return full, resumed
+}
+
+func TestTLSSessionResumption(t *testing.T) {
+	cert, leaf := selfSignedCert(t)
+	svc := newFakeService()
+	svc.tlsConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+	full, resumed := countHandshakes(svc.tlsConfig)
+	addr := startFakeServer(t, svc)
+	roots := x509.NewCertPool()
+	roots.AddCert(leaf)
+
+	t.Run("shared cache", func(t *testing.T) {
+		full.Store(0)
+		resumed.Store(0)
+		options := testOptions()
+		options.TLSConfig = &tls.Config{RootCAs: roots, ClientSessionCache: tls.NewLRUClientSessionCache(0)}
+		for i := 0; i < 5; i++ {
+			conn, err := ConnectContext(context.Background(), addr, options)
+			if err != nil {
+				t.Fatalf("ConnectContext error: %v", err)
+			}
+			conn.Close()
+		}
+		if full.Load() != 1 || resumed.Load() != 4 {
+			t.Errorf("expected 1 full and 4 resumed handshakes for 5 connections, got %d and %d", full.Load(), resumed.Load())
+		}
+	})
+
+	t.Run("same connection", func(t *testing.T) {
+		full.Store(0)
+		resumed.Store(0)
+		options := testOptions()
+		options.TLSConfig = &tls.Config{RootCAs: roots}
+		for i := 0; i < 3; i++ {
+			conn, err := ConnectContext(context.Background(), addr, options)
+			if err != nil {
+				t.Fatalf("ConnectContext error: %v", err)
+			}
+			d, err := conn.dial(context.Background())
+			if err != nil {
+				t.Fatalf("dial error: %v", err)
+			}
+			d.stop()
+			d.transport.Close()
+			conn.Close()
+		}
+		if full.Load() != 3 || resumed.Load() != 3 {
+			t.Errorf("expected each connection's second dial to resume its first session, got %d full and %d resumed handshakes", full.Load(), resumed.Load())
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		full.Store(0)
+		resumed.Store(0)
+		options := testOptions()
+		options.TLSConfig = &tls.Config{RootCAs: roots, SessionTicketsDisabled: true}
+		conn, err := ConnectContext(context.Background(), addr, options)
+		if err != nil {
+			t.Fatalf("ConnectContext error: %v", err)
+		}
+		defer conn.Close()
+		d, err := conn.dial(context.Background())
+		if err != nil {
+			t.Fatalf("dial error: %v", err)
+		}
+		d.stop()
+		d.transport.Close()
+		if resumed.Load() != 0 {
+			t.Errorf("expected no resumption with session tickets disabled, got %d", resumed.Load())
+		}
+	})
+
+	t.Run("pins checked on resumption", func(t *testing.T) {
+		cache := tls.NewLRUClientSessionCache(0)
+		options := testOptions()
+		options.TLSConfig = &tls.Config{RootCAs: roots, ClientSessionCache: cache}
+		conn, err := ConnectContext(context.Background(), addr, options)
+		if err != nil {
+			t.Fatalf("ConnectContext error: %v", err)
+		}
+		conn.Close()
+
+		resumed.Store(0)
+		options.TLSPinnedCertSHA256 = []string{strings.Repeat("ab", 32)}
+		conn, err = ConnectContext(context.Background(), addr, options)
+		if err == nil {
+			conn.Close()
+			t.Fatal("expected an unpinned certificate to be rejected on a resumed session")
+		}
+		if !strings.Contains(err.Error(), "not pinned") {
+			t.Errorf("expected a pinning error, got %v", err)
+		}
+		if resumed.Load() != 1 {
+			t.Errorf("expected the session to be resumed, got %d resumed handshakes", resumed.Load())
+		}
+	})
+}
@@ -0,0 +1,29 @@
+package hive
+
+import "context"
+
+// Transform reads the remaining rows, passes each through fn and hands
+// the result to sink, for map-style processing without holding the
+// result in memory. It stops at the first error from fn, sink or the
+// fetches, and closes the RowSet in any case; the error, if any, is
+// returned. fn gets a row of its own, which it may return modified.
+//
+// fn and sink run on the calling goroutine, between fetches: a slow sink
+// holds up the next fetch rather than letting rows queue up.
+func (r *rowSet) Transform(ctx context.Context, fn func(row []interface{}) ([]interface{}, error), sink func([]interface{}) error) (err error) {
+	defer func() {
+		if closeErr := r.Close(ctx); err == nil {
+			err = closeErr
+		}
+	}()
+	for r.next(ctx) {
+		out, err := fn(r.nextRow)
+		if err != nil {
+			return err
+		}
+		if err := sink(out); err != nil {
+			return err
+		}
+	}
+	return r.Err()
+}
@@ -0,0 +1,61 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestTransform(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("name", inf.TTypeId_STRING_TYPE, 1)}
+	svc.batches = []*inf.TRowSet{stringBatch("a", "b"), stringBatch("c")}
+	conn := connectFake(t, svc, testOptions())
+	ctx := context.Background()
+
+	rs, err := conn.Query("SELECT name FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	var out [][]interface{}
+	err = rs.Transform(ctx, func(row []interface{}) ([]interface{}, error) {
+		return []interface{}{row[0], fmt.Sprint(row[0], row[0])}, nil
+	}, func(row []interface{}) error {
+		out = append(out, row)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Transform error: %v", err)
+	}
+	expected := [][]interface{}{{"a", "aa"}, {"b", "bb"}, {"c", "cc"}}
+	if !reflect.DeepEqual(out, expected) {
+		t.Errorf("expected %v, got %v", expected, out)
+	}
+
+	// The first error stops the rows and the operation is closed.
+	rs, err = conn.Query("SELECT name FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	full := errors.New("sink full")
+	var sunk int
+	err = rs.Transform(ctx, func(row []interface{}) ([]interface{}, error) {
+		return row, nil
+	}, func([]interface{}) error {
+		sunk++
+		return full
+	})
+	if err != full {
+		t.Errorf("expected the sink's error, got %v", err)
+	}
+	if sunk != 1 {
+		t.Errorf("expected one row sunk, got %d", sunk)
+	}
+	if n := conn.InFlight(); n != 0 {
+		t.Errorf("expected the operations to be closed, %d in flight", n)
+	}
+}
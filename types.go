@@ -0,0 +1,194 @@
+package hive
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// A TypeMapper converts the cell values decoded from a fetched batch
+// into the Go values a RowSet hands out. colType and qualifiers come
+// from the column's result set metadata; qualifiers may be nil. NULL
+// cells are left as nil and never passed to Decode.
+type TypeMapper interface {
+	Decode(colType inf.TTypeId, qualifiers *inf.TTypeQualifiers, raw interface{}) (interface{}, error)
+}
+
+// DefaultTypeMapper returns values in their wire representation, which
+// is what a RowSet produces when Options.TypeMapper is nil:
+//   - BOOLEAN: bool
+//   - TINYINT: int8
+//   - SMALLINT: int16
+//   - INT: int32
+//   - BIGINT: int64
+//   - FLOAT, DOUBLE: float64
+//   - BINARY: []byte
+//   - everything else (STRING, VARCHAR, CHAR, DECIMAL, DATE, TIMESTAMP,
+//     INTERVAL_* and the complex types): string, as rendered by the server
+//
+// Custom mappers can embed it and override only the types they care
+// about.
+type DefaultTypeMapper struct{}
+
+// Decode returns raw unchanged.
+func (DefaultTypeMapper) Decode(colType inf.TTypeId, qualifiers *inf.TTypeQualifiers, raw interface{}) (interface{}, error) {
+	return raw, nil
+}
+
+// columnQualifiers returns the type qualifiers of a primitive column,
+// such as the precision and scale of a DECIMAL.
+func columnQualifiers(desc *inf.TColumnDesc) *inf.TTypeQualifiers {
+	types := desc.GetTypeDesc().GetTypes()
+	if len(types) == 0 || types[0] == nil || !types[0].IsSetPrimitiveEntry() {
+		return nil
+	}
+	return types[0].GetPrimitiveEntry().GetTypeQualifiers()
+}
+
+// qualifierInt returns an integer type qualifier, or 0 if it isn't set.
+func qualifierInt(qualifiers *inf.TTypeQualifiers, name string) int {
+	if qualifiers == nil {
+		return 0
+	}
+	v, ok := qualifiers.GetQualifiers()[name]
+	if !ok {
+		return 0
+	}
+	return int(v.GetI32Value())
+}
+
+// HiveTypeMapper decodes the types DefaultTypeMapper leaves as strings
+// where Hive's semantics need more than a string:
+//   - INTERVAL_YEAR_MONTH, INTERVAL_DAY_TIME: Interval
+//   - CHAR(n): padded with spaces to n characters, or with the padding
+//     removed when TrimChar is set
+//
+// All other types are decoded as by DefaultTypeMapper.
+type HiveTypeMapper struct {
+	DefaultTypeMapper
+	TrimChar bool
+}
+
+// Decode implements TypeMapper.
+func (m HiveTypeMapper) Decode(colType inf.TTypeId, qualifiers *inf.TTypeQualifiers, raw interface{}) (interface{}, error) {
+	s, ok := raw.(string)
+	if !ok {
+		return m.DefaultTypeMapper.Decode(colType, qualifiers, raw)
+	}
+
+	switch colType {
+	case inf.TTypeId_INTERVAL_YEAR_MONTH_TYPE:
+		return ParseYearMonthInterval(s)
+	case inf.TTypeId_INTERVAL_DAY_TIME_TYPE:
+		return ParseDayTimeInterval(s)
+	case inf.TTypeId_CHAR_TYPE:
+		if m.TrimChar {
+			return strings.TrimRight(s, " "), nil
+		}
+		if n := qualifierInt(qualifiers, inf.CHARACTER_MAXIMUM_LENGTH) - len([]rune(s)); n > 0 {
+			return s + strings.Repeat(" ", n), nil
+		}
+		return s, nil
+	}
+	return m.DefaultTypeMapper.Decode(colType, qualifiers, raw)
+}
+
+// An Interval is a decoded Hive interval. INTERVAL_YEAR_MONTH values
+// only set Months and INTERVAL_DAY_TIME values only set Duration.
+type Interval struct {
+	Months   int64
+	Duration time.Duration
+}
+
+// String formats the interval the way Hive does, e.g. "1-2" or
+// "3 04:05:06.000000000".
+func (i Interval) String() string {
+	if i.Months != 0 || i.Duration == 0 {
+		sign, m := "", i.Months
+		if m < 0 {
+			sign, m = "-", -m
+		}
+		return fmt.Sprintf("%s%d-%d", sign, m/12, m%12)
+	}
+
+	sign, d := "", i.Duration
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+	return fmt.Sprintf("%s%d %02d:%02d:%02d.%09d", sign, days,
+		d/time.Hour, d%time.Hour/time.Minute, d%time.Minute/time.Second, d%time.Second)
+}
+
+// ParseYearMonthInterval parses Hive's "[-]years-months" rendering of
+// an INTERVAL_YEAR_MONTH value.
+func ParseYearMonthInterval(s string) (Interval, error) {
+	body, negative := strings.CutPrefix(strings.TrimSpace(s), "-")
+	years, months, ok := strings.Cut(body, "-")
+	if !ok {
+		return Interval{}, fmt.Errorf("Invalid year-month interval %q", s)
+	}
+	y, err := strconv.ParseInt(years, 10, 64)
+	if err != nil {
+		return Interval{}, fmt.Errorf("Invalid year-month interval %q", s)
+	}
+	m, err := strconv.ParseInt(months, 10, 64)
+	if err != nil || m < 0 || m > 11 {
+		return Interval{}, fmt.Errorf("Invalid year-month interval %q", s)
+	}
+
+	total := y*12 + m
+	if negative {
+		total = -total
+	}
+	return Interval{Months: total}, nil
+}
+
+// ParseDayTimeInterval parses Hive's "[-]days hh:mm:ss[.fffffffff]"
+// rendering of an INTERVAL_DAY_TIME value.
+func ParseDayTimeInterval(s string) (Interval, error) {
+	body, negative := strings.CutPrefix(strings.TrimSpace(s), "-")
+	days, clock, ok := strings.Cut(body, " ")
+	if !ok {
+		return Interval{}, fmt.Errorf("Invalid day-time interval %q", s)
+	}
+	d, err := strconv.ParseInt(days, 10, 64)
+	if err != nil {
+		return Interval{}, fmt.Errorf("Invalid day-time interval %q", s)
+	}
+
+	parts := strings.Split(clock, ":")
+	if len(parts) != 3 {
+		return Interval{}, fmt.Errorf("Invalid day-time interval %q", s)
+	}
+	h, err1 := strconv.ParseInt(parts[0], 10, 64)
+	m, err2 := strconv.ParseInt(parts[1], 10, 64)
+	secs, frac, _ := strings.Cut(parts[2], ".")
+	sec, err3 := strconv.ParseInt(secs, 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return Interval{}, fmt.Errorf("Invalid day-time interval %q", s)
+	}
+
+	var nanos int64
+	if frac != "" {
+		if len(frac) > 9 {
+			frac = frac[:9]
+		}
+		n, err := strconv.ParseInt(frac+strings.Repeat("0", 9-len(frac)), 10, 64)
+		if err != nil {
+			return Interval{}, fmt.Errorf("Invalid day-time interval %q", s)
+		}
+		nanos = n
+	}
+
+	total := time.Duration(d)*24*time.Hour + time.Duration(h)*time.Hour +
+		time.Duration(m)*time.Minute + time.Duration(sec)*time.Second + time.Duration(nanos)
+	if negative {
+		total = -total
+	}
+	return Interval{Duration: total}, nil
+}
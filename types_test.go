@@ -0,0 +1,217 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// qualifiedDesc builds a primitive column with integer type qualifiers,
+// as HiveServer2 describes CHAR, VARCHAR and DECIMAL columns.
+func qualifiedDesc(name string, typ inf.TTypeId, position int32, qualifiers map[string]int32) *inf.TColumnDesc {
+	desc := columnDesc(name, typ, position)
+	q := &inf.TTypeQualifiers{Qualifiers: map[string]*inf.TTypeQualifierValue{}}
+	for k, v := range qualifiers {
+		v := v
+		q.Qualifiers[k] = &inf.TTypeQualifierValue{I32Value: &v}
+	}
+	desc.TypeDesc.Types[0].PrimitiveEntry.TypeQualifiers = q
+	return desc
+}
+
+func TestSchemaTypeParameters(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{
+		qualifiedDesc("code", inf.TTypeId_CHAR_TYPE, 1, map[string]int32{inf.CHARACTER_MAXIMUM_LENGTH: 3}),
+		qualifiedDesc("name", inf.TTypeId_VARCHAR_TYPE, 2, map[string]int32{inf.CHARACTER_MAXIMUM_LENGTH: 255}),
+		qualifiedDesc("price", inf.TTypeId_DECIMAL_TYPE, 3, map[string]int32{inf.PRECISION: 10, inf.SCALE: 2}),
+		columnDesc("span", inf.TTypeId_INTERVAL_DAY_TIME_TYPE, 4),
+	}
+	conn := connectFake(t, svc, testOptions())
+
+	rs, err := conn.Query("SELECT * FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	schema, err := rs.Schema(context.Background())
+	if err != nil {
+		t.Fatalf("Schema error: %v", err)
+	}
+
+	expected := []string{"CHAR(3)", "VARCHAR(255)", "DECIMAL(10,2)", "INTERVAL_DAY_TIME"}
+	for i, col := range schema {
+		if got := col.DatabaseTypeName(); got != expected[i] {
+			t.Errorf("column %s: expected %s, got %s", col.Name, expected[i], got)
+		}
+	}
+}
+
+func TestSchemaColumnMetadata(t *testing.T) {
+	svc := newFakeService()
+	amount := qualifiedDesc("amount", inf.TTypeId_DECIMAL_TYPE, 1, map[string]int32{inf.PRECISION: 18, inf.SCALE: 4})
+	comment := "Settled amount in EUR"
+	amount.Comment = &comment
+	svc.schema = []*inf.TColumnDesc{
+		amount,
+		qualifiedDesc("label", inf.TTypeId_VARCHAR_TYPE, 2, map[string]int32{inf.CHARACTER_MAXIMUM_LENGTH: 100}),
+	}
+	conn := connectFake(t, svc, testOptions())
+
+	rs, err := conn.Query("SELECT amount, label FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	schema, err := rs.Schema(context.Background())
+	if err != nil {
+		t.Fatalf("Schema error: %v", err)
+	}
+
+	expected := []Column{
+		{Name: "amount", Type: "DECIMAL", TypeID: inf.TTypeId_DECIMAL_TYPE, Precision: 18, Scale: 4, Comment: comment},
+		{Name: "label", Type: "VARCHAR", TypeID: inf.TTypeId_VARCHAR_TYPE, Length: 100},
+	}
+	for i, want := range expected {
+		if schema[i] != want {
+			t.Errorf("column %d: expected %+v, got %+v", i, want, schema[i])
+		}
+	}
+}
+
+// recordingMapper parses DECIMAL cells with the scale of their column,
+// records the types it is called for and fails on "bad".
+type recordingMapper struct {
+	DefaultTypeMapper
+	calls []inf.TTypeId
+}
+
+func (m *recordingMapper) Decode(colType inf.TTypeId, qualifiers *inf.TTypeQualifiers, raw interface{}) (interface{}, error) {
+	m.calls = append(m.calls, colType)
+	if raw == "bad" {
+		return nil, errors.New("unparseable cell")
+	}
+	if colType == inf.TTypeId_DECIMAL_TYPE {
+		scale := qualifiers.GetQualifiers()[inf.SCALE].GetI32Value()
+		return fmt.Sprintf("%s (scale %d)", raw, scale), nil
+	}
+	return m.DefaultTypeMapper.Decode(colType, qualifiers, raw)
+}
+
+func TestTypeMapper(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{
+		qualifiedDesc("amount", inf.TTypeId_DECIMAL_TYPE, 1, map[string]int32{inf.PRECISION: 10, inf.SCALE: 2}),
+		columnDesc("data", inf.TTypeId_BINARY_TYPE, 2),
+	}
+	svc.batches = []*inf.TRowSet{{Columns: []*inf.TColumn{
+		{StringVal: &inf.TStringColumn{Values: []string{"1.50", ""}, Nulls: []byte{0x02}}},
+		{BinaryVal: &inf.TBinaryColumn{Values: [][]byte{{0x00, 'a'}, []byte("b")}, Nulls: []byte{}}},
+	}}}
+	mapper := &recordingMapper{}
+	options := testOptions()
+	options.TypeMapper = mapper
+	conn := connectFake(t, svc, options)
+
+	rs, err := conn.Query("SELECT amount, data FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	var amounts []interface{}
+	var data []string
+	for rs.Next() {
+		var amount interface{}
+		var raw []byte
+		var s string
+		if err := rs.Scan(&amount, &raw); err != nil {
+			t.Fatalf("Scan error: %v", err)
+		}
+		if err := rs.Scan(new(interface{}), &s); err != nil {
+			t.Fatalf("Scan error: %v", err)
+		}
+		if string(raw) != s {
+			t.Errorf("expected the same BINARY cell in []byte and string, got %q and %q", raw, s)
+		}
+		amounts = append(amounts, amount)
+		data = append(data, s)
+	}
+	if err := rs.Err(); err != nil {
+		t.Fatalf("Next error: %v", err)
+	}
+
+	if !reflect.DeepEqual(amounts, []interface{}{"1.50 (scale 2)", nil}) {
+		t.Errorf("expected the mapped DECIMAL and a NULL left alone, got %#v", amounts)
+	}
+	if !reflect.DeepEqual(data, []string{"\x00a", "b"}) {
+		t.Errorf("expected the BINARY cells, got %q", data)
+	}
+	want := []inf.TTypeId{inf.TTypeId_DECIMAL_TYPE, inf.TTypeId_BINARY_TYPE, inf.TTypeId_BINARY_TYPE}
+	if !reflect.DeepEqual(mapper.calls, want) {
+		t.Errorf("expected calls for %v, got %v", want, mapper.calls)
+	}
+}
+
+func TestTypeMapperError(t *testing.T) {
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{columnDesc("s", inf.TTypeId_STRING_TYPE, 1)}
+	svc.batches = []*inf.TRowSet{stringBatch("ok", "bad")}
+	options := testOptions()
+	options.TypeMapper = &recordingMapper{}
+	conn := connectFake(t, svc, options)
+
+	rs, err := conn.Query("SELECT s FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	if rs.Next() {
+		t.Error("expected no rows from a batch the mapper rejects")
+	}
+	if err := rs.Err(); err == nil || !strings.Contains(err.Error(), "unparseable cell") {
+		t.Errorf("expected the mapper's error, got %v", err)
+	}
+}
+
+func TestHiveTypeMapper(t *testing.T) {
+	length := int32(5)
+	charQualifiers := &inf.TTypeQualifiers{Qualifiers: map[string]*inf.TTypeQualifierValue{
+		inf.CHARACTER_MAXIMUM_LENGTH: {I32Value: &length},
+	}}
+
+	cases := []struct {
+		mapper  HiveTypeMapper
+		colType inf.TTypeId
+		raw     interface{}
+		want    interface{}
+	}{
+		{HiveTypeMapper{}, inf.TTypeId_INTERVAL_YEAR_MONTH_TYPE, "1-2", Interval{Months: 14}},
+		{HiveTypeMapper{}, inf.TTypeId_INTERVAL_YEAR_MONTH_TYPE, "-0-3", Interval{Months: -3}},
+		{HiveTypeMapper{}, inf.TTypeId_INTERVAL_DAY_TIME_TYPE, "1 02:03:04.500000000",
+			Interval{Duration: 26*time.Hour + 3*time.Minute + 4*time.Second + 500*time.Millisecond}},
+		{HiveTypeMapper{}, inf.TTypeId_INTERVAL_DAY_TIME_TYPE, "-0 00:00:01.000000000", Interval{Duration: -time.Second}},
+		{HiveTypeMapper{}, inf.TTypeId_CHAR_TYPE, "ab", "ab   "},
+		{HiveTypeMapper{TrimChar: true}, inf.TTypeId_CHAR_TYPE, "ab   ", "ab"},
+		{HiveTypeMapper{}, inf.TTypeId_INT_TYPE, int32(7), int32(7)},
+	}
+
+	for _, c := range cases {
+		got, err := c.mapper.Decode(c.colType, charQualifiers, c.raw)
+		if err != nil {
+			t.Errorf("Decode(%v, %v) error: %v", c.colType, c.raw, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("Decode(%v, %v): expected %v, got %v", c.colType, c.raw, c.want, got)
+		}
+	}
+
+	if _, err := (HiveTypeMapper{}).Decode(inf.TTypeId_INTERVAL_DAY_TIME_TYPE, nil, "bogus"); err == nil {
+		t.Error("expected an error for a malformed interval")
+	}
+	if s := (Interval{Duration: 26*time.Hour + time.Second}).String(); s != "1 02:00:01.000000000" {
+		t.Errorf("unexpected interval rendering %q", s)
+	}
+}
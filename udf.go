@@ -0,0 +1,79 @@
+package hive
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// AddJar adds the jar at path to the session with ADD JAR, e.g. for the
+// classes of temporary functions. The path is resolved by the server, so
+// it must be readable there: a local path on the HiveServer2 host or a
+// URI such as hdfs://, not a file on the client. The jar is added again
+// whenever the connection opens a new session.
+func (c *Connection) AddJar(ctx context.Context, path string) error {
+	if path == "" || strings.ContainsAny(path, " \t\r\n;'\"`") {
+		return fmt.Errorf("Invalid jar path %q", path)
+	}
+	return c.execSetup(ctx, "ADD JAR "+path)
+}
+
+// CreateTemporaryFunction registers the UDF className, typically from a
+// jar added with AddJar, as name for the rest of the session. The
+// function is registered again whenever the connection opens a new
+// session.
+func (c *Connection) CreateTemporaryFunction(ctx context.Context, name, className string) error {
+	if !isIdentifier(name) {
+		return fmt.Errorf("Invalid function name %q", name)
+	}
+	for _, part := range strings.Split(className, ".") {
+		if !isIdentifier(strings.ReplaceAll(part, "$", "_")) {
+			return fmt.Errorf("Invalid class name %q", className)
+		}
+	}
+	return c.execSetup(ctx, "CREATE TEMPORARY FUNCTION "+name+" AS "+quoteString(className))
+}
+
+// execSetup runs a session setup statement and, once it succeeds, keeps
+// it to be run again in a new session.
+func (c *Connection) execSetup(ctx context.Context, stmt string) error {
+	if _, err := c.execContext(ctx, stmt); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.setup = append(c.setup, stmt)
+	c.mu.Unlock()
+	return nil
+}
+
+// replaySetup runs the statements kept by execSetup in a newly opened
+// session.
+func (c *Connection) replaySetup(ctx context.Context) error {
+	c.mu.Lock()
+	setup := append([]string(nil), c.setup...)
+	c.mu.Unlock()
+	for _, stmt := range setup {
+		executeReq := inf.NewTExecuteStatementReq()
+		executeReq.Statement = stmt
+		if _, err := c.executeStatement(WithoutTableQualification(ctx), executeReq); err != nil {
+			return fmt.Errorf("Error restoring session setup %q: %v", stmt, err)
+		}
+	}
+	return nil
+}
+
+// isIdentifier reports whether s is a plain identifier: a letter or
+// underscore followed by letters, digits and underscores.
+func isIdentifier(s string) bool {
+	if s == "" || s[0] >= '0' && s[0] <= '9' {
+		return false
+	}
+	for i := range s {
+		if isWordBoundary(s, i) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,47 @@
+package hive
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestSessionFunctions(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	conn := connectFake(t, svc, testOptions())
+
+	if err := conn.AddJar(ctx, "hdfs:///udfs/my udfs.jar"); err == nil {
+		t.Error("expected a path with a space to be rejected")
+	}
+	if err := conn.CreateTemporaryFunction(ctx, "bad-name", "com.example.Upper"); err == nil {
+		t.Error("expected an invalid function name to be rejected")
+	}
+	if err := conn.CreateTemporaryFunction(ctx, "upper2", "com.example.Upper'; DROP"); err == nil {
+		t.Error("expected an invalid class name to be rejected")
+	}
+
+	sent := len(svc.executed())
+	if err := conn.AddJar(ctx, "hdfs:///udfs/udfs.jar"); err != nil {
+		t.Fatalf("AddJar error: %v", err)
+	}
+	if err := conn.CreateTemporaryFunction(ctx, "upper2", "com.example.Udfs$Upper"); err != nil {
+		t.Fatalf("CreateTemporaryFunction error: %v", err)
+	}
+	want := []string{
+		"ADD JAR hdfs:///udfs/udfs.jar",
+		"CREATE TEMPORARY FUNCTION upper2 AS 'com.example.Udfs$Upper'",
+	}
+	if got := svc.executed()[sent:]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+
+	// A new session gets the same setup.
+	sent = len(svc.executed())
+	if err := conn.open(ctx); err != nil {
+		t.Fatalf("open error: %v", err)
+	}
+	if got := svc.executed()[sent:]; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected the setup replayed as %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,49 @@
+package hive
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// A UTF8Policy says what RowSets do with string cells that aren't valid
+// UTF-8, as bad source data can leave in STRING columns, see
+// Options.InvalidUTF8.
+type UTF8Policy int
+
+const (
+	// UTF8Keep passes the bytes on as sent.
+	UTF8Keep UTF8Policy = iota
+	// UTF8Replace replaces each run of invalid bytes with U+FFFD.
+	UTF8Replace
+	// UTF8Error fails the fetch with a *DecodeError wrapping
+	// ErrInvalidUTF8.
+	UTF8Error
+)
+
+// ErrInvalidUTF8 is wrapped by the DecodeError for a string cell that
+// isn't valid UTF-8 under UTF8Error.
+var ErrInvalidUTF8 = errors.New("hive: invalid UTF-8")
+
+// checkUTF8 applies Options.InvalidUTF8 to the string cells of the
+// current batch. BINARY cells are left alone.
+func (r *rowSet) checkUTF8() error {
+	policy := r.options.InvalidUTF8
+	if policy == UTF8Keep {
+		return nil
+	}
+	for i, col := range r.resultSet {
+		for j, raw := range col {
+			s, ok := raw.(string)
+			if !ok || utf8.ValidString(s) {
+				continue
+			}
+			if policy == UTF8Replace {
+				col[j] = strings.ToValidUTF8(s, "\uFFFD")
+				continue
+			}
+			return r.cellError(j, i, s, ErrInvalidUTF8)
+		}
+	}
+	return nil
+}
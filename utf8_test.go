@@ -0,0 +1,57 @@
+package hive
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestInvalidUTF8(t *testing.T) {
+	bad := "caf\xe9 \xff\xfe"
+	for name, tc := range map[string]struct {
+		policy UTF8Policy
+		want   string
+	}{
+		"keep":    {UTF8Keep, bad},
+		"replace": {UTF8Replace, "caf\uFFFD \uFFFD"},
+		"error":   {policy: UTF8Error},
+	} {
+		t.Run(name, func(t *testing.T) {
+			svc := newFakeService()
+			svc.schema = []*inf.TColumnDesc{columnDesc("name", inf.TTypeId_STRING_TYPE, 1)}
+			svc.batches = []*inf.TRowSet{stringBatch("ok", bad)}
+			options := testOptions()
+			options.InvalidUTF8 = tc.policy
+			conn := connectFake(t, svc, options)
+
+			rs, err := conn.Query("SELECT name FROM t")
+			if err != nil {
+				t.Fatalf("Query error: %v", err)
+			}
+			var got []string
+			for {
+				values, err := rs.NextValues(context.Background())
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					var decodeErr *DecodeError
+					if !errors.Is(err, ErrInvalidUTF8) || !errors.As(err, &decodeErr) || decodeErr.Row != 1 || decodeErr.Column != "name" {
+						t.Errorf("expected a DecodeError for row 1 of name, got %v", err)
+					}
+					return
+				}
+				got = append(got, values[0].(string))
+			}
+			if tc.policy == UTF8Error {
+				t.Fatal("expected the invalid cell to fail the fetch")
+			}
+			if len(got) != 2 || got[0] != "ok" || got[1] != tc.want {
+				t.Errorf("expected [ok %q], got %q", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package hive
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+// NextValues advances to the next row and returns it as driver.Value
+// cells, or io.EOF once the rows are exhausted. Cells are converted by
+// column type:
+//   - TINYINT, SMALLINT, INT, BIGINT: int64
+//   - FLOAT, DOUBLE: float64
+//   - BOOLEAN: bool
+//   - BINARY: []byte
+//   - TIMESTAMP, DATE: time.Time, in the zone described by
+//     Options.TimestampMode and Options.Location
+//   - everything else: string
+//   - NULL: nil
+//
+// Values a TypeMapper already turned into a valid driver.Value are
+// passed through unchanged.
+func (r *rowSet) NextValues(ctx context.Context) ([]driver.Value, error) {
+	if !r.next(ctx) {
+		if r.err != nil {
+			return nil, r.err
+		}
+		return nil, io.EOF
+	}
+	return r.rowValues(r.nextRow)
+}
+
+// NextInto advances to the next row and stores its cells in dest, which
+// must have one element per column, or returns io.EOF once the rows are
+// exhausted. The cells are those Scan hands to *interface{} targets,
+// without NextValues' conversion, so a loop reusing dest doesn't
+// allocate per row; see BenchmarkNextInto. dest is also the row Scan
+// then reads, so it must not be changed before a Scan.
+func (r *rowSet) NextInto(ctx context.Context, dest []interface{}) error {
+	if err := r.waitForSuccess(ctx); err != nil {
+		return err
+	}
+	if len(dest) != len(r.columns) {
+		return fmt.Errorf("Can't read a row of %d columns into %d values", len(r.columns), len(dest))
+	}
+	if !r.nextInto(ctx, dest) {
+		if r.err != nil {
+			return r.err
+		}
+		return io.EOF
+	}
+	return nil
+}
+
+// rowValues converts a fetched row to driver values.
+func (r *rowSet) rowValues(row []interface{}) ([]driver.Value, error) {
+	loc := r.location()
+	values := make([]driver.Value, len(row))
+	for i, v := range row {
+		colType := inf.TTypeId_STRING_TYPE
+		if i < len(r.columns) {
+			colType = columnTypeID(r.columns[i])
+		}
+		dv, err := driverValue(colType, v, loc)
+		if err != nil {
+			return nil, fmt.Errorf("Column %d: %v", i, err)
+		}
+		values[i] = dv
+	}
+	return values, nil
+}
+
+func driverValue(colType inf.TTypeId, v interface{}, loc *time.Location) (driver.Value, error) {
+	switch t := v.(type) {
+	case nil:
+		return nil, nil
+	case int8:
+		return int64(t), nil
+	case int16:
+		return int64(t), nil
+	case int32:
+		return int64(t), nil
+	case string:
+		switch colType {
+		case inf.TTypeId_TIMESTAMP_TYPE:
+			return time.ParseInLocation("2006-01-02 15:04:05.999999999", t, loc)
+		case inf.TTypeId_DATE_TYPE:
+			return time.ParseInLocation("2006-01-02", t, loc)
+		case inf.TTypeId_TINYINT_TYPE, inf.TTypeId_SMALLINT_TYPE, inf.TTypeId_INT_TYPE, inf.TTypeId_BIGINT_TYPE:
+			return strconv.ParseInt(t, 10, 64)
+		case inf.TTypeId_FLOAT_TYPE, inf.TTypeId_DOUBLE_TYPE:
+			return strconv.ParseFloat(t, 64)
+		case inf.TTypeId_BOOLEAN_TYPE:
+			return strconv.ParseBool(t)
+		}
+		return t, nil
+	case map[string]interface{}, []MapEntry:
+		// Decoded MAP values, see Options.DecodeMaps.
+		return t, nil
+	}
+
+	if driver.IsValue(v) {
+		return v, nil
+	}
+	return fmt.Sprint(v), nil
+}
@@ -0,0 +1,150 @@
+package hive
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestNextValues(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{
+		columnDesc("id", inf.TTypeId_INT_TYPE, 1),
+		columnDesc("ts", inf.TTypeId_TIMESTAMP_TYPE, 2),
+		columnDesc("name", inf.TTypeId_STRING_TYPE, 3),
+	}
+	svc.batches = []*inf.TRowSet{{Columns: []*inf.TColumn{
+		{I32Val: &inf.TI32Column{Values: []int32{1, 2}, Nulls: []byte{}}},
+		{StringVal: &inf.TStringColumn{Values: []string{"2024-03-01 12:30:00.5", ""}, Nulls: []byte{0x02}}},
+		{StringVal: &inf.TStringColumn{Values: []string{"a", "b"}, Nulls: []byte{}}},
+	}}}
+	conn := connectFake(t, svc, testOptions())
+
+	rs, err := conn.Query("SELECT id, ts, name FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+
+	expected := [][]driver.Value{
+		{int64(1), time.Date(2024, 3, 1, 12, 30, 0, 500000000, time.UTC), "a"},
+		{int64(2), nil, "b"},
+	}
+	for i, want := range expected {
+		got, err := rs.NextValues(ctx)
+		if err != nil {
+			t.Fatalf("row %d: NextValues error: %v", i, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("row %d: expected %v, got %v", i, want, got)
+		}
+	}
+
+	if _, err := rs.NextValues(ctx); err != io.EOF {
+		t.Errorf("expected io.EOF after the last row, got %v", err)
+	}
+}
+
+func TestNextInto(t *testing.T) {
+	ctx := context.Background()
+	svc := newFakeService()
+	svc.schema = []*inf.TColumnDesc{
+		columnDesc("id", inf.TTypeId_INT_TYPE, 1),
+		columnDesc("name", inf.TTypeId_STRING_TYPE, 2),
+	}
+	svc.batches = []*inf.TRowSet{
+		{Columns: []*inf.TColumn{
+			{I32Val: &inf.TI32Column{Values: []int32{1, 2}, Nulls: []byte{}}},
+			{StringVal: &inf.TStringColumn{Values: []string{"a", ""}, Nulls: []byte{0x02}}},
+		}},
+		{Columns: []*inf.TColumn{
+			{I32Val: &inf.TI32Column{Values: []int32{3}, Nulls: []byte{}}},
+			{StringVal: &inf.TStringColumn{Values: []string{"c"}, Nulls: []byte{}}},
+		}},
+	}
+	conn := connectFake(t, svc, testOptions())
+
+	rs, err := conn.Query("SELECT id, name FROM t")
+	if err != nil {
+		t.Fatalf("Query error: %v", err)
+	}
+	if err := rs.NextInto(ctx, make([]interface{}, 3)); err == nil {
+		t.Error("expected an error for a destination of the wrong length")
+	}
+
+	dest := make([]interface{}, 2)
+	var rows [][]interface{}
+	for {
+		err := rs.NextInto(ctx, dest)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("NextInto error: %v", err)
+		}
+		rows = append(rows, append([]interface{}(nil), dest...))
+	}
+	expected := [][]interface{}{{int32(1), "a"}, {int32(2), nil}, {int32(3), "c"}}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("expected %v, got %v", expected, rows)
+	}
+}
+
+// BenchmarkNextInto compares the allocations of reading rows with
+// NextValues and with NextInto, on an already decoded batch.
+func BenchmarkNextInto(b *testing.B) {
+	const rows = 100000
+	ids := make([]int64, rows)
+	for i := range ids {
+		ids[i] = int64(i)
+	}
+	batch := &inf.TRowSet{Columns: []*inf.TColumn{
+		{I64Val: &inf.TI64Column{Values: ids, Nulls: []byte{}}},
+		{StringVal: &inf.TStringColumn{Values: make([]string, rows), Nulls: []byte{}}},
+	}}
+	schema := []*inf.TColumnDesc{
+		columnDesc("id", inf.TTypeId_BIGINT_TYPE, 1),
+		columnDesc("name", inf.TTypeId_STRING_TYPE, 2),
+	}
+	r := &rowSet{operation: &inf.TOperationHandle{OperationId: &inf.THandleIdentifier{}}, options: testOptions(), columns: schema, ready: true}
+	if err := r.decodeBatch(batch); err != nil {
+		b.Fatalf("decodeBatch error: %v", err)
+	}
+	ctx := context.Background()
+
+	b.Run("NextValues", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			r.offset = 0
+			count := 0
+			for {
+				if _, err := r.NextValues(ctx); err != nil {
+					break
+				}
+				count++
+			}
+			if count != rows {
+				b.Fatalf("expected %d rows, got %d", rows, count)
+			}
+		}
+	})
+	b.Run("NextInto", func(b *testing.B) {
+		b.ReportAllocs()
+		dest := make([]interface{}, 2)
+		for n := 0; n < b.N; n++ {
+			r.offset = 0
+			count := 0
+			for r.NextInto(ctx, dest) == nil {
+				count++
+			}
+			if count != rows {
+				b.Fatalf("expected %d rows, got %d", rows, count)
+			}
+		}
+	})
+}
@@ -0,0 +1,43 @@
+package hive
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jasonlabz/hive/inf"
+)
+
+func TestOpenSessionWarnings(t *testing.T) {
+	svc := newFakeService()
+	messages := []string{
+		"*org.apache.hive.service.cli.HiveSQLException:hive.mapred.mode is deprecated:0:0",
+		"Use hive.strict.checks.* instead",
+	}
+	svc.onOpenSession = func(req *inf.TOpenSessionReq) (*inf.TOpenSessionResp, error) {
+		return &inf.TOpenSessionResp{
+			Status: &inf.TStatus{
+				StatusCode:   inf.TStatusCode_SUCCESS_WITH_INFO_STATUS,
+				InfoMessages: messages,
+			},
+			ServerProtocolVersion: inf.TProtocolVersion_HIVE_CLI_SERVICE_PROTOCOL_V6,
+			SessionHandle: &inf.TSessionHandle{SessionId: &inf.THandleIdentifier{
+				GUID: make([]byte, 16), Secret: make([]byte, 16),
+			}},
+		}, nil
+	}
+	conn := connectFake(t, svc, testOptions())
+
+	if got := conn.Warnings(); !reflect.DeepEqual(got, messages) {
+		t.Errorf("expected warnings %q, got %q", messages, got)
+	}
+	if _, err := conn.Exec("SELECT 1"); err != nil {
+		t.Errorf("expected a usable connection despite warnings, got %v", err)
+	}
+}
+
+func TestOpenSessionNoWarnings(t *testing.T) {
+	conn := connectFake(t, newFakeService(), testOptions())
+	if got := conn.Warnings(); got != nil {
+		t.Errorf("expected no warnings, got %q", got)
+	}
+}